@@ -22,7 +22,12 @@ func main() {
 	
 	defer pool.Close()
 
-	r:= router.NewRouter(pool)
+	paymentGateway, err := router.NewPaymentGateway(cfg)
+	if err != nil {
+		logger.Fatal("Failed to set up payment gateway: %v", err)
+	}
+
+	r:= router.NewRouter(ctx, cfg, pool, paymentGateway)
 
 	logger.Info("Server started on port %s", cfg.ServerPort)
 	
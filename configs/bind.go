@@ -37,6 +37,26 @@ func BindAllKeys() {
 		// Stripe
 		StripeAPIKey,
 		StripeWebhookSecret,
+
+		// Payment gateway
+		PaymentGateway,
+		FakeGatewayOutcome,
+		FakeGatewayDelayMs,
+
+		// Payment capture
+		PaymentCaptureMethod,
+		PaymentAuthorizationTTLHours,
+		// Payment reconciliation
+		PaymentReconcileIntervalMinutes,
+		PaymentReconcileAfterMinutes,
+
+		// Bank transfers
+		BankTransferDetails,
+		BankTransferTTLHours,
+
+		// Stock reservations
+		StockReservationTTLMinutes,
+		InventoryAllocationStrategy,
     }
 
     for _, key := range keys {
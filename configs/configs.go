@@ -26,6 +26,15 @@ func Load() *Config {
 	BindAllKeys()
 
 	viper.SetDefault("SERVER_PORT", ":8080")
+	viper.SetDefault("PAYMENT_GATEWAY", "stripe")
+	viper.SetDefault("PAYMENT_CAPTURE_METHOD", "automatic")
+	// Card authorizations usually lapse after 7 days; cancel a day earlier
+	viper.SetDefault("PAYMENT_AUTHORIZATION_TTL_HOURS", 144)
+	viper.SetDefault("PAYMENT_RECONCILE_INTERVAL_MINUTES", 15)
+	viper.SetDefault("PAYMENT_RECONCILE_AFTER_MINUTES", 30)
+	viper.SetDefault("BANK_TRANSFER_TTL_HOURS", 72)
+	viper.SetDefault("STOCK_RESERVATION_TTL_MINUTES", 60)
+	viper.SetDefault("INVENTORY_ALLOCATION_STRATEGY", "priority")
 
 	var c Config
 	if err := viper.Unmarshal(&c); err != nil {
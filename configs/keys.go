@@ -28,4 +28,25 @@ const (
     // Stripe
     StripeAPIKey       = "STRIPE_API_KEY"
     StripeWebhookSecret = "STRIPE_WEBHOOK_SECRET"
+
+    // Payment gateway
+    PaymentGateway     = "PAYMENT_GATEWAY"
+    FakeGatewayOutcome = "FAKE_GATEWAY_OUTCOME"
+    FakeGatewayDelayMs = "FAKE_GATEWAY_DELAY_MS"
+
+    // Payment capture
+    PaymentCaptureMethod         = "PAYMENT_CAPTURE_METHOD"
+    PaymentAuthorizationTTLHours = "PAYMENT_AUTHORIZATION_TTL_HOURS"
+
+    // Payment reconciliation
+    PaymentReconcileIntervalMinutes = "PAYMENT_RECONCILE_INTERVAL_MINUTES"
+    PaymentReconcileAfterMinutes    = "PAYMENT_RECONCILE_AFTER_MINUTES"
+
+    // Bank transfers
+    BankTransferDetails  = "BANK_TRANSFER_DETAILS"
+    BankTransferTTLHours = "BANK_TRANSFER_TTL_HOURS"
+
+    // Stock reservations
+    StockReservationTTLMinutes  = "STOCK_RESERVATION_TTL_MINUTES"
+    InventoryAllocationStrategy = "INVENTORY_ALLOCATION_STRATEGY"
 )
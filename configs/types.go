@@ -28,4 +28,37 @@ type Config struct {
 	// Stripe
 	StripeAPIKey      string `mapstructure:"STRIPE_API_KEY"`      
 	StripeWebhookSecret string `mapstructure:"STRIPE_WEBHOOK_SECRET"`
+
+	// Payment gateway: "stripe" (default) or "fake"
+	PaymentGateway     string `mapstructure:"PAYMENT_GATEWAY"`
+	FakeGatewayOutcome string `mapstructure:"FAKE_GATEWAY_OUTCOME"`
+	FakeGatewayDelayMs int    `mapstructure:"FAKE_GATEWAY_DELAY_MS"`
+
+	// Payment capture: "automatic" (default) charges at checkout, "manual"
+	// authorizes at checkout and captures when the order ships. Unshipped
+	// authorizations are cancelled after PaymentAuthorizationTTLHours.
+	PaymentCaptureMethod         string `mapstructure:"PAYMENT_CAPTURE_METHOD"`
+	PaymentAuthorizationTTLHours int    `mapstructure:"PAYMENT_AUTHORIZATION_TTL_HOURS"`
+
+	// Payment reconciliation checks payments that have waited on the
+	// provider for PaymentReconcileAfterMinutes, every
+	// PaymentReconcileIntervalMinutes; 0 disables the job.
+	PaymentReconcileIntervalMinutes int `mapstructure:"PAYMENT_RECONCILE_INTERVAL_MINUTES"`
+	PaymentReconcileAfterMinutes    int `mapstructure:"PAYMENT_RECONCILE_AFTER_MINUTES"`
+
+	// Bank transfers: BankTransferDetails is shown to the customer with the
+	// payment reference; transfers not received within BankTransferTTLHours
+	// are cancelled, 0 keeps them open until an admin expires them.
+	BankTransferDetails  string `mapstructure:"BANK_TRANSFER_DETAILS"`
+	BankTransferTTLHours int    `mapstructure:"BANK_TRANSFER_TTL_HOURS"`
+
+	// Stock reserved at checkout for an order paid by card is released if
+	// the order is not paid within StockReservationTTLMinutes; 0 holds it
+	// until the order is paid or cancelled.
+	StockReservationTTLMinutes int `mapstructure:"STOCK_RESERVATION_TTL_MINUTES"`
+
+	// Order lines are reserved at the warehouses InventoryAllocationStrategy
+	// picks: "priority" tries them in priority order, "closest" tries those
+	// nearest the destination first.
+	InventoryAllocationStrategy string `mapstructure:"INVENTORY_ALLOCATION_STRATEGY"`
 }
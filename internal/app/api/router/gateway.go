@@ -0,0 +1,37 @@
+package router
+
+import (
+	"ecommerce-app/configs"
+	"ecommerce-app/internal/domain/gateway"
+	"ecommerce-app/internal/domain/stripe"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// NewPaymentGateway builds the payment gateway selected by PAYMENT_GATEWAY,
+// capturing payments as PAYMENT_CAPTURE_METHOD says
+func NewPaymentGateway(cfg *configs.Config) (gateway.PaymentGateway, error) {
+	var captureMethod string
+	switch strings.ToLower(cfg.PaymentCaptureMethod) {
+	case "", "automatic":
+		captureMethod = gateway.CaptureAutomatic
+	case "manual":
+		captureMethod = gateway.CaptureManual
+	default:
+		return nil, fmt.Errorf("unknown payment capture method %q", cfg.PaymentCaptureMethod)
+	}
+
+	switch strings.ToLower(cfg.PaymentGateway) {
+	case "", "stripe":
+		return stripe.NewStripeProvider(cfg.StripeAPIKey, cfg.StripeWebhookSecret, captureMethod), nil
+	case "fake":
+		return gateway.NewFakeGateway(gateway.FakeConfig{
+			Outcome:       cfg.FakeGatewayOutcome,
+			Delay:         time.Duration(cfg.FakeGatewayDelayMs) * time.Millisecond,
+			CaptureMethod: captureMethod,
+		}), nil
+	default:
+		return nil, fmt.Errorf("unknown payment gateway %q", cfg.PaymentGateway)
+	}
+}
@@ -0,0 +1,24 @@
+package router
+
+import (
+	"context"
+	"time"
+)
+
+// runEvery calls job every interval until ctx is done. A slow run delays
+// the next one rather than overlapping it.
+func runEvery(ctx context.Context, interval time.Duration, job func(ctx context.Context)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				job(ctx)
+			}
+		}
+	}()
+}
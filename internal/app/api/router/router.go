@@ -1,20 +1,34 @@
 package router
 
 import (
+	"context"
+	"net/http"
+	"time"
+
+	"ecommerce-app/configs"
 	"ecommerce-app/internal/domain/address"
 	"ecommerce-app/internal/domain/auth"
 	"ecommerce-app/internal/domain/cart"
 	"ecommerce-app/internal/domain/cartitem"
 	"ecommerce-app/internal/domain/category"
 	"ecommerce-app/internal/domain/coupon"
+	"ecommerce-app/internal/domain/gateway"
+	"ecommerce-app/internal/domain/giftcard"
 	"ecommerce-app/internal/domain/inventory"
 	"ecommerce-app/internal/domain/order"
 	"ecommerce-app/internal/domain/payment"
 	"ecommerce-app/internal/domain/product"
+	"ecommerce-app/internal/domain/returns"
 	"ecommerce-app/internal/domain/review"
 	"ecommerce-app/internal/domain/shipment"
+	"ecommerce-app/internal/domain/shipping"
+	"ecommerce-app/internal/domain/tax"
 	"ecommerce-app/internal/domain/user"
+	"ecommerce-app/internal/domain/wallet"
+	"ecommerce-app/internal/domain/warehouse"
 	"ecommerce-app/internal/infra/db"
+	"ecommerce-app/internal/pkg/logger"
+	"ecommerce-app/internal/pkg/middleware"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/cors"
@@ -22,7 +36,9 @@ import (
 )
 
 
-func NewRouter(pool *pgxpool.Pool) chi.Router {
+// NewRouter wires the domains and mounts their routes. Background jobs run
+// until ctx is done.
+func NewRouter(ctx context.Context, cfg *configs.Config, pool *pgxpool.Pool, paymentGateway gateway.PaymentGateway) chi.Router {
 	q:= db.NewQueries(pool)
 	r := chi.NewRouter()
 
@@ -30,11 +46,15 @@ func NewRouter(pool *pgxpool.Pool) chi.Router {
     r.Use(cors.Handler(cors.Options{
     AllowedOrigins:   []string{"*"},
     AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-    AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type"},
+    AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", middleware.IdempotencyKeyHeader},
+    ExposedHeaders:   []string{middleware.IdempotentReplayedHeader},
     AllowCredentials: false,
 	}))
 
 
+	// Opt-in Idempotency-Key handling for retry-prone POST endpoints
+	idempotent := middleware.Idempotency(db.NewIdempotencyStore(q))
+
 	// Product domain setup
 	productRepo := product.NewRepository(q)
 	productSvc := product.NewService(productRepo)
@@ -68,38 +88,166 @@ func NewRouter(pool *pgxpool.Pool) chi.Router {
 	// CartItem domain setup
 	cartItemRepo := cartitem.NewRepository(q)
 	cartItemSvc := cartitem.NewService(cartItemRepo, cartSvc)
-	cartItemRoutes := cartitem.Routes(cartItemSvc)
+	cartItemRoutes := cartitem.Routes(cartItemSvc, idempotent)
 
 	// Address domain setup
 	addressRepo := address.NewRepository(q)
 	addressSvc := address.NewService(addressRepo)
 	addressRoutes := address.Routes(addressSvc)
 
+	// Tax domain setup
+	taxRepo := tax.NewRepository(q)
+	taxSvc := tax.NewService(taxRepo)
+	taxRoutes := tax.Routes(taxSvc)
+
+	// Shipping domain setup
+	shippingRepo := shipping.NewRepository(q, pool)
+	shippingSvc := shipping.NewService(shippingRepo, productSvc, cartSvc, cartItemSvc)
+	shippingRoutes := shipping.Routes(shippingSvc)
+
+	// Wallet domain setup
+	walletRepo := wallet.NewRepository(q, pool)
+	walletSvc := wallet.NewService(walletRepo)
+	walletRoutes := wallet.Routes(walletSvc, idempotent)
+
+	// Lapse store credit past its expiry
+	runEvery(ctx, time.Hour, func(ctx context.Context) {
+		expired, appErr := walletSvc.ExpireCredits(ctx)
+		if appErr != nil {
+			logger.Error("Wallet credit expiry sweep failed: %s", appErr.Message)
+			return
+		}
+		if expired > 0 {
+			logger.Info("Expired %d wallet credits", expired)
+		}
+	})
+
+	// Gift card domain setup
+	giftCardRepo := giftcard.NewRepository(q, pool)
+	giftCardSvc := giftcard.NewService(giftCardRepo)
+	giftCardRoutes := giftcard.Routes(giftCardSvc, idempotent)
+
+	// Warehouse domain setup
+	warehouseRepo := warehouse.NewRepository(q)
+	warehouseSvc := warehouse.NewService(warehouseRepo)
+	warehouseRoutes := warehouse.Routes(warehouseSvc)
+
+	// Inventory domain setup
+	allocationStrategy, err := inventory.ParseAllocationStrategy(cfg.InventoryAllocationStrategy)
+	if err != nil {
+		logger.Fatal("Failed to set up inventory: %v", err)
+	}
+	inventoryRepo := inventory.NewRepository(q, pool)
+	inventorySvc := inventory.NewService(inventoryRepo, warehouseSvc, inventory.NewLogNotifier(), allocationStrategy)
+	inventoryRoutes := inventory.Routes(inventorySvc)
+
+	// Give back stock held by orders that were never paid
+	if cfg.StockReservationTTLMinutes > 0 {
+		runEvery(ctx, time.Minute, func(ctx context.Context) {
+			released, appErr := inventorySvc.ReleaseExpiredReservations(ctx)
+			if appErr != nil {
+				logger.Error("Stock reservation expiry sweep failed: %s", appErr.Message)
+				return
+			}
+			if released > 0 {
+				logger.Info("Released expired stock reservations of %d orders", released)
+			}
+		})
+	}
+
+	// Tell the admins about products that ran low on stock
+	runEvery(ctx, time.Minute, func(ctx context.Context) {
+		delivered, appErr := inventorySvc.DeliverLowStockAlerts(ctx)
+		if appErr != nil {
+			logger.Error("Low-stock alert delivery failed: %s", appErr.Message)
+			return
+		}
+		if delivered > 0 {
+			logger.Info("Delivered %d low-stock alerts", delivered)
+		}
+	})
+
 	// Order domain setup
-	orderRepo := order.NewRepository(q)
-	orderSvc := order.NewService(orderRepo, productSvc)
-	orderRoutes := order.Routes(orderSvc)
+	orderRepo := order.NewRepository(q, pool)
+	orderSvc := order.NewService(orderRepo, productSvc, cartSvc, cartItemSvc, couponSvc, taxSvc, shippingSvc, paymentGateway, walletSvc, giftCardSvc, order.OfflinePaymentConfig{
+		BankTransferDetails: cfg.BankTransferDetails,
+		BankTransferTTL:     time.Duration(cfg.BankTransferTTLHours) * time.Hour,
+	}, inventorySvc, time.Duration(cfg.StockReservationTTLMinutes)*time.Minute)
+	orderRoutes := order.Routes(orderSvc, idempotent)
+
+	// Cancel orders whose bank transfer never arrived
+	if cfg.BankTransferTTLHours > 0 {
+		runEvery(ctx, time.Hour, func(ctx context.Context) {
+			expired, appErr := orderSvc.ExpireOfflinePayments(ctx)
+			if appErr != nil {
+				logger.Error("Offline payment expiry sweep failed: %s", appErr.Message)
+				return
+			}
+			if expired > 0 {
+				logger.Info("Expired %d offline payments", expired)
+			}
+		})
+	}
 
 	// Payment domain setup
-	paymentRepo := payment.NewPaymentRepository(q)
-	paymentSvc := payment.NewPaymentService(paymentRepo, orderSvc)
-	paymentRoutes := payment.Routes(paymentSvc)
+	paymentRepo := payment.NewPaymentRepository(q, pool)
+	paymentSvc := payment.NewPaymentService(paymentRepo, orderSvc, paymentGateway)
+	paymentRoutes := payment.Routes(paymentSvc, idempotent)
+
+	// Cancel authorizations that were never captured before they lapse
+	if cfg.PaymentAuthorizationTTLHours > 0 {
+		authorizationTTL := time.Duration(cfg.PaymentAuthorizationTTLHours) * time.Hour
+		runEvery(ctx, time.Hour, func(ctx context.Context) {
+			cancelled, appErr := orderSvc.CancelStaleAuthorizations(ctx, authorizationTTL)
+			if appErr != nil {
+				logger.Error("Stale authorization sweep failed: %s", appErr.Message)
+				return
+			}
+			if cancelled > 0 {
+				logger.Info("Cancelled %d stale payment authorizations", cancelled)
+			}
+		})
+	}
+
+	// Catch up on payments whose webhooks never arrived
+	if cfg.PaymentReconcileIntervalMinutes > 0 {
+		reconcileAfter := time.Duration(cfg.PaymentReconcileAfterMinutes) * time.Minute
+		runEvery(ctx, time.Duration(cfg.PaymentReconcileIntervalMinutes)*time.Minute, func(ctx context.Context) {
+			report, appErr := paymentSvc.Reconcile(ctx, reconcileAfter)
+			if appErr != nil {
+				logger.Error("Payment reconciliation failed: %s", appErr.Message)
+				return
+			}
+			logger.Info("Payment reconciliation checked %d payments, applied %d, %d mismatches, %d errors",
+				report.Checked, report.Applied, len(report.Mismatches), report.Errors)
+			for _, m := range report.Mismatches {
+				logger.Warn("Reconciliation %s: payment=%s intent=%s: %s", m.Kind, m.PaymentID, m.ProviderTxnID, m.Detail)
+			}
+		})
+	}
+
+	// The fake gateway delivers its webhooks in-process
+	if fake, ok := paymentGateway.(*gateway.FakeGateway); ok {
+		fake.SetWebhookSink(func(ctx context.Context, payload []byte, header http.Header) error {
+			return paymentSvc.HandleWebhook(ctx, fake.Name(), payload, header)
+		})
+	}
 
 	// Auth domain setup
 	authRepo := auth.NewRepository(q)
 	authSvc := auth.NewService(authRepo)
 	authRoutes := auth.Routes(authSvc)
 
-	// Inventory domain setup
-	inventoryRepo := inventory.NewRepository(q)
-	inventorySvc := inventory.NewService(inventoryRepo)
-	inventoryRoutes := inventory.Routes(inventorySvc)
-
 	// Shipment domain setup
-	shipmentRepo := shipment.NewRepository(q)
-	shipmentSvc := shipment.NewService(shipmentRepo)
+	shipmentRepo := shipment.NewRepository(q, pool)
+	shipmentSvc := shipment.NewService(shipmentRepo, orderSvc, inventorySvc)
 	shipmentRoutes := shipment.Routes(shipmentSvc)
 
+	// Returns domain setup
+	returnsRepo := returns.NewRepository(q, pool)
+	returnsSvc := returns.NewService(returnsRepo, orderSvc, productSvc, shipmentSvc, inventorySvc)
+	returnsRoutes := returns.Routes(returnsSvc)
+
 	// Mount domain routes
 	r.Mount("/users", userRoutes)
 	r.Mount("/products", productRoutes)
@@ -111,9 +259,15 @@ func NewRouter(pool *pgxpool.Pool) chi.Router {
 	r.Mount("/addresses", addressRoutes)
 	r.Mount("/orders", orderRoutes)
 	r.Mount("/payments", paymentRoutes)
+	r.Mount("/wallet", walletRoutes)
+	r.Mount("/gift-cards", giftCardRoutes)
 	r.Mount("/auth", authRoutes)
+	r.Mount("/warehouses", warehouseRoutes)
 	r.Mount("/inventories", inventoryRoutes)
 	r.Mount("/shipments", shipmentRoutes)
+	r.Mount("/returns", returnsRoutes)
+	r.Mount("/tax", taxRoutes)
+	r.Mount("/shipping", shippingRoutes)
 
 	return r
 }
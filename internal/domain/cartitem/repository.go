@@ -3,6 +3,7 @@ package cartitem
 import (
 	"context"
 	"ecommerce-app/internal/domain/cart"
+	"ecommerce-app/internal/pkg/database"
 	"ecommerce-app/internal/pkg/database/sqlc"
 	"ecommerce-app/internal/pkg/logger"
 	"fmt"
@@ -21,6 +22,7 @@ type Repository interface {
 	UpdateQuantity(ctx context.Context, id string, quantity int32) (CartItem, error)
 	Delete(ctx context.Context, id string) error
 	DeleteByCart(ctx context.Context, cartID string) error
+	DeleteOrdered(ctx context.Context, items []CartItem) error
 	GetCartByUserID(ctx context.Context, userID string) (cart.Cart, error)
 }
 
@@ -32,6 +34,11 @@ func NewRepository(q *sqlc.Queries) Repository {
 	return &repository{q: q}
 }
 
+// queries joins the caller's transaction when one is carried on ctx.
+func (r *repository) queries(ctx context.Context) *sqlc.Queries {
+	return database.Queries(ctx, r.q)
+}
+
 // Add or Upsert Item
 func (r *repository) Add(ctx context.Context, item CartItem) (CartItem, error) {
 	var cartUUID, productUUID pgtype.UUID
@@ -47,7 +54,7 @@ func (r *repository) Add(ctx context.Context, item CartItem) (CartItem, error) {
 		Quantity:  item.Quantity,
 	}
 
-	row, err := r.q.AddCartItem(ctx, params)
+	row, err := r.queries(ctx).AddCartItem(ctx, params)
 	if err != nil {
 		logger.Error("Error adding cart item: %v", err)
 		return CartItem{}, err
@@ -98,7 +105,7 @@ func (r *repository) AddItems(ctx context.Context, req AddItemsRequest) ([]CartI
 	}
 
 	// Execute query
-	rows, err := r.q.AddCartItems(ctx, params)
+	rows, err := r.queries(ctx).AddCartItems(ctx, params)
 	if err != nil {
 		logger.Error("Error adding or updating cart items: %v", err)
 		return nil, fmt.Errorf("failed to add cart items: %w", err)
@@ -119,7 +126,7 @@ func (r *repository) GetByID(ctx context.Context, id string) (CartItem, error) {
 		return CartItem{}, err
 	}
 
-	row, err := r.q.GetCartItem(ctx, uuidID)
+	row, err := r.queries(ctx).GetCartItem(ctx, uuidID)
 	if err != nil {
 		logger.Error("Error getting cart item by ID: %v", err)
 		return CartItem{}, err
@@ -134,7 +141,7 @@ func (r *repository) GetByUserID(ctx context.Context, userID string) ([]CartItem
 		return nil, err
 	}
 
-	rows, err := r.q.GetCartItemsByUserID(ctx, uuidUser)
+	rows, err := r.queries(ctx).GetCartItemsByUserID(ctx, uuidUser)
 	if err != nil {
 		logger.Error("Error getting cart items by user ID: %v", err)
 		return nil, err
@@ -157,7 +164,7 @@ func (r *repository) GetByCartAndProduct(ctx context.Context, cartID, productID
 		return CartItem{}, err
 	}
 
-	row, err := r.q.GetCartItemByProduct(ctx, sqlc.GetCartItemByProductParams{
+	row, err := r.queries(ctx).GetCartItemByProduct(ctx, sqlc.GetCartItemByProductParams{
 		CartID:    cartUUID,
 		ProductID: productUUID,
 	})
@@ -175,7 +182,7 @@ func (r *repository) ListByCart(ctx context.Context, cartID string) ([]CartItem,
 		return nil, err
 	}
 
-	rows, err := r.q.ListCartItems(ctx, uuidCart)
+	rows, err := r.queries(ctx).ListCartItems(ctx, uuidCart)
 	if err != nil {
 		logger.Error("Error listing cart items: %v", err)
 		return nil, err
@@ -200,7 +207,7 @@ func (r *repository) UpdateQuantity(ctx context.Context, id string, quantity int
 		Quantity: quantity,
 	}
 
-	row, err := r.q.UpdateCartItemQuantity(ctx, params)
+	row, err := r.queries(ctx).UpdateCartItemQuantity(ctx, params)
 	if err != nil {
 		logger.Error("Error updating cart item quantity: %v", err)
 		return CartItem{}, err
@@ -216,7 +223,7 @@ func (r *repository) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	if err := r.q.DeleteCartItem(ctx, uuidID); err != nil {
+	if err := r.queries(ctx).DeleteCartItem(ctx, uuidID); err != nil {
 		logger.Error("Error deleting cart item: %v", err)
 		return err
 	}
@@ -230,20 +237,40 @@ func (r *repository) DeleteByCart(ctx context.Context, cartID string) error {
 		return err
 	}
 
-	if err := r.q.DeleteCartItemsByCart(ctx, uuidCart); err != nil {
+	if err := r.queries(ctx).DeleteCartItemsByCart(ctx, uuidCart); err != nil {
 		logger.Error("Error deleting cart items by cart: %v", err)
 		return err
 	}
 	return nil
 }
 
+// Delete the items a checkout ordered, as long as they are unchanged
+func (r *repository) DeleteOrdered(ctx context.Context, items []CartItem) error {
+	ids := make([]pgtype.UUID, len(items))
+	quantities := make([]int32, len(items))
+	for i, item := range items {
+		ids[i] = pgtype.UUID{Bytes: item.ID, Valid: true}
+		quantities[i] = item.Quantity
+	}
+
+	err := r.queries(ctx).DeleteOrderedCartItems(ctx, sqlc.DeleteOrderedCartItemsParams{
+		Ids:        ids,
+		Quantities: quantities,
+	})
+	if err != nil {
+		logger.Error("Error deleting ordered cart items: %v", err)
+		return err
+	}
+	return nil
+}
+
 func (r *repository) GetCartByUserID(ctx context.Context, userID string) (cart.Cart, error) {
 	var uuidUser pgtype.UUID
 	if err := uuidUser.Scan(userID); err != nil {
 		return cart.Cart{}, err
 	}
 
-	row, err := r.q.GetCartByUserID(ctx, uuidUser)
+	row, err := r.queries(ctx).GetCartByUserID(ctx, uuidUser)
 	if err != nil {
 		logger.Error("Error getting cart by user ID: %v", err)
 		return cart.Cart{}, err
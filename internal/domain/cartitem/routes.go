@@ -1,18 +1,23 @@
 package cartitem
 
 import (
+	"net/http"
+
+	"ecommerce-app/internal/pkg/middleware"
 	"ecommerce-app/internal/pkg/validator"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func Routes(svc Service) chi.Router {
+// Routes mounts the cart item endpoints. idempotent guards batch adds
+// against client retries.
+func Routes(svc Service, idempotent func(http.Handler) http.Handler) chi.Router {
 	h := NewHandler(svc)
 	r := chi.NewRouter()
 
 	r.With(validator.Validate[AddItemRequest]()).Post("/", h.AddItem)
 
-	r.With(validator.Validate[[]AddItemRequest]()).Post("/batch", h.AddItems)
+	r.With(middleware.RoleMiddleware("customer")).With(idempotent).With(validator.Validate[[]AddItemRequest]()).Post("/batch", h.AddItems)
 
 	r.Get("/user/items", h.GetUserCartItems)
 
@@ -21,6 +21,7 @@ type Service interface {
 	UpdateItemQuantity(ctx context.Context, id string, req UpdateQuantityRequest) (CartItem, *errs.AppError)
 	DeleteItem(ctx context.Context, id string) *errs.AppError
 	ClearCartItems(ctx context.Context, cartID string) *errs.AppError
+	RemoveOrderedItems(ctx context.Context, items []CartItem) *errs.AppError
 }
 
 type service struct {
@@ -175,3 +176,16 @@ func (s *service) ClearCartItems(ctx context.Context, cartID string) *errs.AppEr
 	}
 	return nil
 }
+
+// --- Remove the items a checkout ordered ---
+// Items added to the cart or changed since the checkout read them stay.
+func (s *service) RemoveOrderedItems(ctx context.Context, items []CartItem) *errs.AppError {
+	if len(items) == 0 {
+		return nil
+	}
+	err := s.repo.DeleteOrdered(ctx, items)
+	if err != nil {
+		return errs.ErrInternal.WithMessage("Failed to remove ordered cart items")
+	}
+	return nil
+}
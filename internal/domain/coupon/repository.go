@@ -2,8 +2,12 @@ package coupon
 
 import (
 	"context"
+	"database/sql"
+	"ecommerce-app/internal/pkg/database"
 	"ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
 	"ecommerce-app/internal/pkg/logger"
+	"errors"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -18,6 +22,8 @@ type Repository interface{
 	Delete(ctx context.Context, id string) error
 	Count(ctx context.Context) (int32, error)
 	IncrementUsage(ctx context.Context, code string) (Coupon, error)
+	Redeem(ctx context.Context, code string) (Coupon, error)
+	Release(ctx context.Context, code string) (Coupon, error)
 }
 
 type repository struct {
@@ -28,6 +34,10 @@ func NewRepository(q *sqlc.Queries) Repository {
 	return &repository{q}
 }
 
+func (r *repository) queries(ctx context.Context) *sqlc.Queries {
+	return database.Queries(ctx, r.q)
+}
+
 func (r *repository) Create(ctx context.Context, c Coupon) (Coupon, error) {
 	validFrom := pgtype.Timestamptz{Time: c.ValidFrom, Valid: true}
 	validUntil := pgtype.Timestamptz{Time: c.ValidUntil, Valid: true}
@@ -69,6 +79,9 @@ func (r *repository) Delete(ctx context.Context, id string) error {
 func (r *repository) GetByCode(ctx context.Context, code string) (Coupon, error) {
 	row, err := r.q.GetCouponByCode(ctx, code)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Coupon{}, errs.ErrNotFound
+		}
 		logger.Error("Error getting coupon by code: %v", err)
 		return Coupon{}, err
 	}
@@ -278,6 +291,36 @@ func (r *repository) IncrementUsage(ctx context.Context, code string) (Coupon, e
 	return mapCoupon(row), nil
 }
 
+// Redeem counts one use of the coupon. It runs on the transaction carried
+// by ctx and returns errs.ErrConflict once the coupon can no longer be used.
+func (r *repository) Redeem(ctx context.Context, code string) (Coupon, error) {
+	row, err := r.queries(ctx).RedeemCoupon(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Coupon{}, errs.ErrConflict
+		}
+		logger.Error("Error redeeming coupon: %v", err)
+		return Coupon{}, err
+	}
+
+	return mapCoupon(row), nil
+}
+
+// Release gives back one use of the coupon. It runs on the transaction
+// carried by ctx and returns errs.ErrNotFound when no use is counted.
+func (r *repository) Release(ctx context.Context, code string) (Coupon, error) {
+	row, err := r.queries(ctx).ReleaseCoupon(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Coupon{}, errs.ErrNotFound
+		}
+		logger.Error("Error releasing coupon: %v", err)
+		return Coupon{}, err
+	}
+
+	return mapCoupon(row), nil
+}
+
 func mapCoupon(row sqlc.Coupon) Coupon {
 
 	return Coupon{
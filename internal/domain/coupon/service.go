@@ -5,6 +5,7 @@ import (
 	"ecommerce-app/internal/pkg/errs"
 	"ecommerce-app/internal/pkg/response"
 	"ecommerce-app/pkg/pagination"
+	"errors"
 
 	"github.com/google/uuid"
 )
@@ -15,6 +16,8 @@ type Service interface {
 	GetCouponByCode(ctx context.Context, code string) (Coupon, *errs.AppError)
 	GetCoupons(ctx context.Context, page, perPage int) (CouponsWithMeta, *errs.AppError)
 	IncrementCouponUsage(ctx context.Context, code string) (Coupon, *errs.AppError)
+	RedeemCoupon(ctx context.Context, code string) (Coupon, *errs.AppError)
+	ReleaseCoupon(ctx context.Context, code string) *errs.AppError
 	UpdateCoupon(ctx context.Context, id string, req UpdateCouponRequest) (Coupon, *errs.AppError)
 	DeleteCoupon(ctx context.Context, id string) *errs.AppError
 }
@@ -93,6 +96,9 @@ func (s *service) GetCoupons(ctx context.Context, page, perPage int) (CouponsWit
 func (s *service) GetCouponByCode(ctx context.Context, code string) (Coupon, *errs.AppError) {
 	coupon, err := s.repo.GetByCode(ctx, code)
 	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Coupon{}, errs.ErrNotFound.WithMessage("Coupon not found")
+		}
 		return Coupon{}, errs.ErrInternal.WithMessage("Failed to get coupon by code")
 	}
 	return coupon, nil
@@ -107,6 +113,29 @@ func (s *service) IncrementCouponUsage(ctx context.Context, code string) (Coupon
 	return updatedCoupon, nil
 }
 
+// RedeemCoupon counts one use of the coupon at order time. Run it inside the
+// order transaction so the use is rolled back with a failed order.
+func (s *service) RedeemCoupon(ctx context.Context, code string) (Coupon, *errs.AppError) {
+	redeemed, err := s.repo.Redeem(ctx, code)
+	if err != nil {
+		if errors.Is(err, errs.ErrConflict) {
+			return Coupon{}, errs.ErrConflict.WithMessage("Coupon " + code + " is no longer valid")
+		}
+		return Coupon{}, errs.ErrInternal.WithMessage("Failed to redeem coupon")
+	}
+	return redeemed, nil
+}
+
+// ReleaseCoupon gives back the use an order counted with RedeemCoupon, once
+// the order is cancelled. A coupon with no use counted, or that has since
+// been deleted, is left alone.
+func (s *service) ReleaseCoupon(ctx context.Context, code string) *errs.AppError {
+	if _, err := s.repo.Release(ctx, code); err != nil && !errors.Is(err, errs.ErrNotFound) {
+		return errs.ErrInternal.WithMessage("Failed to release coupon")
+	}
+	return nil
+}
+
 func (s *service) UpdateCoupon(ctx context.Context, id string, req UpdateCouponRequest) (Coupon, *errs.AppError) {
 	updatedCoupon, err := s.repo.Update(ctx, id, req)
 	if err != nil {
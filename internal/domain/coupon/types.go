@@ -22,6 +22,17 @@ type Coupon struct {
 	UpdatedAt       time.Time       `json:"updated_at"`
 }
 
+// Redeemable reports whether the coupon can be applied to an order at t.
+func (c Coupon) Redeemable(t time.Time) bool {
+	if !c.IsActive || c.IsDeleted {
+		return false
+	}
+	if t.Before(c.ValidFrom) || !t.Before(c.ValidUntil) {
+		return false
+	}
+	return c.MaxUses <= 0 || c.UsedCount < c.MaxUses
+}
+
 type CouponsWithMeta struct {
 	Coupons []Coupon     `json:"coupons"`
 	Meta    response.Meta `json:"meta"`
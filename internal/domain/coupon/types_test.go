@@ -0,0 +1,39 @@
+package coupon
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRedeemable(t *testing.T) {
+	now := time.Now()
+	valid := Coupon{IsActive: true, ValidFrom: now.Add(-time.Hour), ValidUntil: now.Add(time.Hour)}
+	with := func(change func(c *Coupon)) Coupon {
+		c := valid
+		change(&c)
+		return c
+	}
+
+	tests := []struct {
+		name   string
+		coupon Coupon
+		want   bool
+	}{
+		{name: "active within its window", coupon: valid, want: true},
+		{name: "uses left", coupon: with(func(c *Coupon) { c.MaxUses, c.UsedCount = 2, 1 }), want: true},
+		{name: "used up", coupon: with(func(c *Coupon) { c.MaxUses, c.UsedCount = 2, 2 }), want: false},
+		{name: "no limit is unlimited", coupon: with(func(c *Coupon) { c.MaxUses, c.UsedCount = 0, 500 }), want: true},
+		{name: "inactive", coupon: with(func(c *Coupon) { c.IsActive = false }), want: false},
+		{name: "deleted", coupon: with(func(c *Coupon) { c.IsDeleted = true }), want: false},
+		{name: "not started", coupon: with(func(c *Coupon) { c.ValidFrom = now.Add(time.Minute) }), want: false},
+		{name: "ended", coupon: with(func(c *Coupon) { c.ValidUntil = now }), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.coupon.Redeemable(now); got != tt.want {
+				t.Errorf("Redeemable = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
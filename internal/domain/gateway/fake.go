@@ -0,0 +1,556 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"ecommerce-app/internal/pkg/logger"
+)
+
+const FakeProviderName = "FAKE"
+
+// Outcomes the fake gateway simulates for a new intent. The outcome comes
+// from FakeConfig and can be overridden per intent with the
+// FakeOutcomeMetadataKey metadata entry.
+const (
+	FakeOutcomeSuccess = "success"
+	FakeOutcomeFailure = "failure"
+	FakeOutcomeDelayed = "delayed"
+	// FakeOutcomeLost succeeds at the gateway but never sends the webhook,
+	// leaving the payment for reconciliation to pick up.
+	FakeOutcomeLost = "lost"
+)
+
+const FakeOutcomeMetadataKey = "fake_outcome"
+
+// fakeDeliveryAttempts and fakeRetryBackoff mimic a provider retrying a
+// webhook the shop did not acknowledge, e.g. because the order transaction
+// had not committed yet.
+const (
+	fakeDeliveryAttempts = 5
+	fakeRetryBackoff     = 250 * time.Millisecond
+	fakeDefaultDelay     = 5 * time.Second
+)
+
+// FakeConfig selects how the fake gateway settles new intents.
+type FakeConfig struct {
+	Outcome string
+	// Delay is how long a delayed outcome waits before its webhook is sent.
+	Delay time.Duration
+	// CaptureMethod is CaptureAutomatic (default) or CaptureManual
+	CaptureMethod string
+}
+
+// WebhookSink receives the webhooks the fake gateway sends, the way the
+// webhook endpoint would.
+type WebhookSink func(ctx context.Context, payload []byte, header http.Header) error
+
+// FakeGateway is an in-process PaymentGateway for development and offline
+// testing. IDs are sequential, so runs are reproducible. Each intent settles
+// through a webhook sent to the sink; ParseWebhook only accepts events this
+// gateway issued, so the fake cannot be driven from outside.
+type FakeGateway struct {
+	mu            sync.Mutex
+	outcome       string
+	delay         time.Duration
+	captureMethod string
+	seq           int
+	intents       map[string]*fakeIntent
+	sessions      map[string]*fakeSession
+	events        map[string][]byte
+	sink          WebhookSink
+}
+
+type fakeIntent struct {
+	intent        Intent
+	orderID       string
+	capturedCents int64
+	refundedCents int64
+	sessionID     string
+}
+
+type fakeSession struct {
+	session CheckoutSession
+	status  string
+}
+
+// fakeEvent is the webhook payload the fake gateway sends.
+type fakeEvent struct {
+	ID                  string `json:"id"`
+	Type                string `json:"type"`
+	Created             int64  `json:"created"`
+	IntentID            string `json:"intent_id"`
+	SessionID           string `json:"session_id,omitempty"`
+	OrderID             string `json:"order_id"`
+	AmountCents         int64  `json:"amount_cents"`
+	FailureReason       string `json:"failure_reason,omitempty"`
+	RefundID            string `json:"refund_id,omitempty"`
+	RefundReference     string `json:"refund_reference,omitempty"`
+	AmountRefundedCents int64  `json:"amount_refunded_cents,omitempty"`
+	PaymentStatus       string `json:"payment_status,omitempty"`
+}
+
+// fakeEventStatuses maps fake event types to payment statuses
+var fakeEventStatuses = map[string]string{
+	"payment_intent.amount_capturable_updated": StatusAuthorized,
+	"payment_intent.succeeded":                 StatusSucceeded,
+	"payment_intent.payment_failed":            StatusFailed,
+	"payment_intent.canceled":                  StatusCancelled,
+	"charge.refunded":                          StatusRefunded,
+	"refund.updated":                           StatusRefunded,
+	"checkout.session.expired":                 StatusCancelled,
+}
+
+// fakeCheckoutURL is where the fake pretends to host its checkout pages
+const fakeCheckoutURL = "https://fake-gateway.local/checkout/"
+
+// fakeSessionTTL matches how long a provider keeps a checkout page open
+const fakeSessionTTL = 24 * time.Hour
+
+func NewFakeGateway(cfg FakeConfig) *FakeGateway {
+	outcome := cfg.Outcome
+	if outcome == "" {
+		outcome = FakeOutcomeSuccess
+	}
+	delay := cfg.Delay
+	if delay <= 0 {
+		delay = fakeDefaultDelay
+	}
+	captureMethod := cfg.CaptureMethod
+	if captureMethod == "" {
+		captureMethod = CaptureAutomatic
+	}
+
+	return &FakeGateway{
+		outcome:       outcome,
+		delay:         delay,
+		captureMethod: captureMethod,
+		intents:       map[string]*fakeIntent{},
+		sessions:      map[string]*fakeSession{},
+		events:        map[string][]byte{},
+	}
+}
+
+// SetWebhookSink registers where webhooks are delivered. Until a sink is
+// set, events are recorded but not sent.
+func (g *FakeGateway) SetWebhookSink(sink WebhookSink) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sink = sink
+}
+
+func (g *FakeGateway) Name() string {
+	return FakeProviderName
+}
+
+// CreateIntent records the intent and schedules the webhook for its
+// outcome: success and failure are sent right away, delayed success after
+// the configured delay. With manual capture a successful intent is only
+// authorized until CaptureIntent is called.
+func (g *FakeGateway) CreateIntent(ctx context.Context, req IntentRequest) (Intent, error) {
+	return g.createIntent(req, "")
+}
+
+// createIntent creates an intent, on behalf of the checkout session
+// sessionID when it is set, and settles it per the outcome. A session that
+// is paid reports checkout.session.completed instead of the intent's own
+// success event.
+func (g *FakeGateway) createIntent(req IntentRequest, sessionID string) (Intent, error) {
+	if req.AmountCents < 0 {
+		return Intent{}, errors.New("fake gateway: amount must not be negative")
+	}
+
+	outcome := g.outcome
+	if o, ok := req.Metadata[FakeOutcomeMetadataKey]; ok {
+		outcome = o
+	}
+
+	g.mu.Lock()
+	id := g.nextID("fake_pi")
+	fi := &fakeIntent{
+		intent: Intent{
+			ID:            id,
+			ClientSecret:  id + "_secret",
+			AmountCents:   req.AmountCents,
+			Currency:      req.Currency,
+			Status:        "requires_payment_method",
+			PaymentStatus: StatusInitiated,
+			CaptureMethod: g.captureMethod,
+			OrderID:       req.Metadata["order_id"],
+			CreatedAt:     time.Now(),
+		},
+		orderID:   req.Metadata["order_id"],
+		sessionID: sessionID,
+	}
+	g.intents[id] = fi
+	intent := fi.intent
+	g.mu.Unlock()
+
+	successEvent := "payment_intent.succeeded"
+	if intent.CaptureMethod == CaptureManual {
+		successEvent = "payment_intent.amount_capturable_updated"
+	}
+
+	succeed := func() fakeEvent {
+		ev := g.settle(id, successEvent, "")
+		if sessionID != "" {
+			ev.Type = "checkout.session.completed"
+			ev.PaymentStatus = fakeEventStatuses[successEvent]
+			g.completeSession(sessionID)
+		}
+		return ev
+	}
+
+	switch outcome {
+	case FakeOutcomeSuccess:
+		g.send(succeed(), 0)
+	case FakeOutcomeFailure:
+		g.send(g.settle(id, "payment_intent.payment_failed", "Your card was declined."), 0)
+	case FakeOutcomeDelayed:
+		g.send(succeed(), g.delay)
+	case FakeOutcomeLost:
+		succeed()
+	default:
+		return Intent{}, fmt.Errorf("fake gateway: unknown outcome %q", outcome)
+	}
+
+	return intent, nil
+}
+
+// CaptureIntent captures an authorized intent and sends the
+// payment_intent.succeeded webhook for the captured amount.
+func (g *FakeGateway) CaptureIntent(ctx context.Context, intentID string, amountCents int64) (Intent, error) {
+	g.mu.Lock()
+	fi, ok := g.intents[intentID]
+	if !ok {
+		g.mu.Unlock()
+		return Intent{}, fmt.Errorf("fake gateway: no such intent %s", intentID)
+	}
+	if fi.intent.Status != "requires_capture" {
+		g.mu.Unlock()
+		return Intent{}, fmt.Errorf("fake gateway: intent %s is %s, not awaiting capture", intentID, fi.intent.Status)
+	}
+	if amountCents <= 0 || amountCents > fi.intent.AmountCents {
+		amountCents = fi.intent.AmountCents
+	}
+
+	fi.capturedCents = amountCents
+	fi.intent.Status = "succeeded"
+	fi.intent.PaymentStatus = StatusSucceeded
+	intent := fi.intent
+
+	ev := fakeEvent{
+		ID:          g.nextID("evt_fake"),
+		Type:        "payment_intent.succeeded",
+		Created:     time.Now().Unix(),
+		IntentID:    intentID,
+		OrderID:     fi.orderID,
+		AmountCents: amountCents,
+	}
+	g.mu.Unlock()
+
+	g.send(ev, 0)
+	return intent, nil
+}
+
+func (g *FakeGateway) CancelIntent(ctx context.Context, intentID string) (Intent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	fi, ok := g.intents[intentID]
+	if !ok {
+		return Intent{}, fmt.Errorf("fake gateway: no such intent %s", intentID)
+	}
+	if fi.intent.Status == "succeeded" {
+		return Intent{}, fmt.Errorf("fake gateway: intent %s is already captured", intentID)
+	}
+
+	fi.intent.Status = "canceled"
+	fi.intent.PaymentStatus = StatusCancelled
+	return fi.intent, nil
+}
+
+func (g *FakeGateway) GetIntent(ctx context.Context, intentID string) (Intent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	fi, ok := g.intents[intentID]
+	if !ok {
+		return Intent{}, ErrIntentNotFound
+	}
+
+	return fi.intent, nil
+}
+
+// ListIntents returns the intents created in [from, to), oldest first.
+func (g *FakeGateway) ListIntents(ctx context.Context, from, to time.Time) ([]Intent, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	intents := []Intent{}
+	for _, fi := range g.intents {
+		created := fi.intent.CreatedAt
+		if !created.Before(from) && created.Before(to) {
+			intents = append(intents, fi.intent)
+		}
+	}
+	sort.Slice(intents, func(i, j int) bool { return intents[i].ID < intents[j].ID })
+
+	return intents, nil
+}
+
+// Refund refunds the intent right away and sends a refund.updated webhook
+// for it, the way a provider confirms a card refund.
+func (g *FakeGateway) Refund(ctx context.Context, req RefundRequest) (Refund, error) {
+	g.mu.Lock()
+	fi, ok := g.intents[req.IntentID]
+	if !ok {
+		g.mu.Unlock()
+		return Refund{}, fmt.Errorf("fake gateway: no such intent %s", req.IntentID)
+	}
+	if fi.intent.Status != "succeeded" {
+		g.mu.Unlock()
+		return Refund{}, fmt.Errorf("fake gateway: intent %s has not been captured", req.IntentID)
+	}
+
+	remaining := fi.capturedCents - fi.refundedCents
+	amount := req.AmountCents
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount <= 0 || amount > remaining {
+		g.mu.Unlock()
+		return Refund{}, fmt.Errorf("fake gateway: refund of %d exceeds the %d remaining on %s", amount, remaining, req.IntentID)
+	}
+	fi.refundedCents += amount
+
+	refund := Refund{
+		ID:          g.nextID("fake_re"),
+		IntentID:    req.IntentID,
+		AmountCents: amount,
+		Status:      RefundSucceeded,
+		Metadata:    req.Metadata,
+	}
+
+	ev := fakeEvent{
+		ID:                  g.nextID("evt_fake"),
+		Type:                "refund.updated",
+		Created:             time.Now().Unix(),
+		IntentID:            req.IntentID,
+		OrderID:             fi.orderID,
+		AmountCents:         amount,
+		RefundID:            refund.ID,
+		RefundReference:     req.Metadata[RefundReferenceKey],
+		AmountRefundedCents: fi.refundedCents,
+	}
+	g.mu.Unlock()
+
+	g.send(ev, 0)
+	return refund, nil
+}
+
+// CreateCheckoutSession records a hosted checkout page and settles its
+// intent per the configured outcome, as if the customer paid right away.
+func (g *FakeGateway) CreateCheckoutSession(ctx context.Context, req CheckoutSessionRequest) (CheckoutSession, error) {
+	var total int64
+	for _, line := range req.Lines {
+		total += line.Quantity * line.UnitAmountCents
+	}
+	if total != req.AmountCents {
+		return CheckoutSession{}, fmt.Errorf("fake gateway: lines add up to %d, session is for %d", total, req.AmountCents)
+	}
+
+	g.mu.Lock()
+	id := g.nextID("fake_cs")
+	session := CheckoutSession{
+		ID:            id,
+		URL:           fakeCheckoutURL + id,
+		CaptureMethod: g.captureMethod,
+		ExpiresAt:     time.Now().Add(fakeSessionTTL),
+	}
+	g.sessions[id] = &fakeSession{session: session, status: "open"}
+	g.mu.Unlock()
+
+	intent, err := g.createIntent(IntentRequest{
+		AmountCents: req.AmountCents,
+		Currency:    req.Currency,
+		Metadata:    req.Metadata,
+	}, id)
+	if err != nil {
+		return CheckoutSession{}, err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.sessions[id].session.IntentID = intent.ID
+
+	return g.sessions[id].session, nil
+}
+
+// ExpireCheckoutSession expires an open session, cancels its intent and
+// sends checkout.session.expired.
+func (g *FakeGateway) ExpireCheckoutSession(ctx context.Context, sessionID string) error {
+	g.mu.Lock()
+	fs, ok := g.sessions[sessionID]
+	if !ok {
+		g.mu.Unlock()
+		return fmt.Errorf("fake gateway: no such checkout session %s", sessionID)
+	}
+	if fs.status != "open" {
+		g.mu.Unlock()
+		return fmt.Errorf("fake gateway: checkout session %s is %s", sessionID, fs.status)
+	}
+	fs.status = "expired"
+
+	ev := fakeEvent{
+		ID:        g.nextID("evt_fake"),
+		Type:      "checkout.session.expired",
+		Created:   time.Now().Unix(),
+		IntentID:  fs.session.IntentID,
+		SessionID: sessionID,
+	}
+	if fi, ok := g.intents[fs.session.IntentID]; ok {
+		fi.intent.Status = "canceled"
+		fi.intent.PaymentStatus = StatusCancelled
+		ev.OrderID = fi.orderID
+		ev.AmountCents = fi.intent.AmountCents
+	}
+	g.mu.Unlock()
+
+	g.send(ev, 0)
+	return nil
+}
+
+// ParseWebhook decodes an event previously sent by this gateway. Payloads it
+// did not issue are rejected, standing in for signature verification.
+func (g *FakeGateway) ParseWebhook(ctx context.Context, payload []byte, header http.Header) (*WebhookEvent, error) {
+	var ev fakeEvent
+	if err := json.Unmarshal(payload, &ev); err != nil {
+		return nil, fmt.Errorf("fake gateway: invalid payload: %w", err)
+	}
+
+	g.mu.Lock()
+	issued, ok := g.events[ev.ID]
+	g.mu.Unlock()
+	if !ok || !bytes.Equal(issued, payload) {
+		return nil, fmt.Errorf("fake gateway: event %s was not issued by this gateway", ev.ID)
+	}
+
+	status, ok := fakeEventStatuses[ev.Type]
+	if ev.PaymentStatus != "" {
+		status, ok = ev.PaymentStatus, true
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	var refund *Refund
+	if ev.RefundID != "" {
+		refund = &Refund{
+			ID:          ev.RefundID,
+			IntentID:    ev.IntentID,
+			AmountCents: ev.AmountCents,
+			Status:      RefundSucceeded,
+		}
+		if ev.RefundReference != "" {
+			refund.Metadata = map[string]string{RefundReferenceKey: ev.RefundReference}
+		}
+	}
+
+	return &WebhookEvent{
+		EventID:             ev.ID,
+		Type:                ev.Type,
+		Provider:            FakeProviderName,
+		ProviderTxnID:       ev.IntentID,
+		CheckoutSessionID:   ev.SessionID,
+		OrderID:             ev.OrderID,
+		Status:              status,
+		FailureReason:       ev.FailureReason,
+		OccurredAt:          time.Unix(ev.Created, 0),
+		Refund:              refund,
+		AmountRefundedCents: ev.AmountRefundedCents,
+		RawEvent:            ev,
+	}, nil
+}
+
+// settle moves the intent to its final state and returns the webhook that
+// reports it.
+func (g *FakeGateway) settle(intentID, eventType, failureReason string) fakeEvent {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	fi := g.intents[intentID]
+	switch eventType {
+	case "payment_intent.succeeded":
+		fi.intent.Status = "succeeded"
+		fi.capturedCents = fi.intent.AmountCents
+	case "payment_intent.amount_capturable_updated":
+		fi.intent.Status = "requires_capture"
+	default:
+		fi.intent.Status = "requires_payment_method"
+	}
+	fi.intent.PaymentStatus = fakeEventStatuses[eventType]
+	fi.intent.FailureReason = failureReason
+
+	return fakeEvent{
+		ID:            g.nextID("evt_fake"),
+		Type:          eventType,
+		Created:       time.Now().Unix(),
+		IntentID:      intentID,
+		SessionID:     fi.sessionID,
+		OrderID:       fi.orderID,
+		AmountCents:   fi.intent.AmountCents,
+		FailureReason: failureReason,
+	}
+}
+
+// completeSession marks a checkout session paid
+func (g *FakeGateway) completeSession(sessionID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if fs, ok := g.sessions[sessionID]; ok {
+		fs.status = "complete"
+	}
+}
+
+// send records ev as issued and delivers it to the sink after delay,
+// retrying while the sink reports an error.
+func (g *FakeGateway) send(ev fakeEvent, delay time.Duration) {
+	payload, _ := json.Marshal(ev)
+
+	g.mu.Lock()
+	g.events[ev.ID] = payload
+	sink := g.sink
+	g.mu.Unlock()
+
+	if sink == nil {
+		return
+	}
+
+	go func() {
+		time.Sleep(delay)
+		for attempt := 1; attempt <= fakeDeliveryAttempts; attempt++ {
+			err := sink(context.Background(), payload, http.Header{})
+			if err == nil {
+				return
+			}
+			logger.Warn("Fake gateway webhook %s attempt %d failed: %v", ev.ID, attempt, err)
+			time.Sleep(time.Duration(attempt) * fakeRetryBackoff)
+		}
+		logger.Error("Fake gateway gave up delivering webhook %s", ev.ID)
+	}()
+}
+
+// nextID returns the next sequential ID with prefix. Callers hold g.mu.
+func (g *FakeGateway) nextID(prefix string) string {
+	g.seq++
+	return fmt.Sprintf("%s_%06d", prefix, g.seq)
+}
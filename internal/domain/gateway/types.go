@@ -0,0 +1,156 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// ErrIntentNotFound is returned when the provider has no intent with the
+// requested ID.
+var ErrIntentNotFound = errors.New("payment intent not found")
+
+// Payment statuses a gateway reports, matching the payments.status CHECK
+// constraint.
+const (
+	StatusInitiated  = "INITIATED"
+	StatusAuthorized = "AUTHORIZED"
+	StatusSucceeded  = "SUCCESS"
+	StatusFailed     = "FAILED"
+	StatusCancelled  = "CANCELLED"
+	StatusRefunded   = "REFUNDED"
+)
+
+// Capture methods, matching the payments.capture_method CHECK constraint.
+// Manual intents are only authorized when the customer pays and must be
+// captured (or cancelled) later.
+const (
+	CaptureAutomatic = "AUTOMATIC"
+	CaptureManual    = "MANUAL"
+)
+
+// Refund statuses a gateway reports, matching the refunds.status CHECK
+// constraint.
+const (
+	RefundPending   = "PENDING"
+	RefundSucceeded = "SUCCEEDED"
+	RefundFailed    = "FAILED"
+	RefundCancelled = "CANCELLED"
+)
+
+// RefundReferenceKey is the refund metadata entry carrying the shop's own
+// refund ID, so webhooks can be matched to refunds started here.
+const RefundReferenceKey = "refund_id"
+
+// PaymentGateway is a payment provider the shop takes payments through.
+type PaymentGateway interface {
+	// Name is stored as payments.provider and is the {provider} segment of
+	// the webhook URL.
+	Name() string
+	CreateIntent(ctx context.Context, req IntentRequest) (Intent, error)
+	// CaptureIntent captures an authorized intent. An amountCents of 0
+	// captures the full authorized amount.
+	CaptureIntent(ctx context.Context, intentID string, amountCents int64) (Intent, error)
+	CancelIntent(ctx context.Context, intentID string) (Intent, error)
+	// GetIntent fetches the provider's current view of an intent.
+	GetIntent(ctx context.Context, intentID string) (Intent, error)
+	// ListIntents returns the intents created in [from, to).
+	ListIntents(ctx context.Context, from, to time.Time) ([]Intent, error)
+	Refund(ctx context.Context, req RefundRequest) (Refund, error)
+	// CreateCheckoutSession starts a provider-hosted checkout page. Its
+	// intent may only exist once the customer pays, so webhooks about the
+	// session carry the session ID alongside the intent ID.
+	CreateCheckoutSession(ctx context.Context, req CheckoutSessionRequest) (CheckoutSession, error)
+	// ExpireCheckoutSession closes a session the customer has not paid.
+	ExpireCheckoutSession(ctx context.Context, sessionID string) error
+	// ParseWebhook verifies and decodes a webhook delivery. Events the shop
+	// does not act on yield a nil event and no error.
+	ParseWebhook(ctx context.Context, payload []byte, header http.Header) (*WebhookEvent, error)
+}
+
+type IntentRequest struct {
+	AmountCents int64
+	Currency    string
+	Metadata    map[string]string
+}
+
+// CheckoutSessionRequest describes a hosted checkout page. Lines must add
+// up to AmountCents; the page shows them to the customer.
+type CheckoutSessionRequest struct {
+	AmountCents int64
+	Currency    string
+	Lines       []CheckoutLine
+	SuccessURL  string
+	CancelURL   string
+	Metadata    map[string]string
+}
+
+type CheckoutLine struct {
+	Name            string
+	Quantity        int64
+	UnitAmountCents int64
+}
+
+// CheckoutSession is a provider-hosted checkout page the customer is sent
+// to. IntentID is set once the provider has created the session's intent.
+type CheckoutSession struct {
+	ID            string
+	URL           string
+	IntentID      string
+	CaptureMethod string
+	ExpiresAt     time.Time
+}
+
+// Intent is a provider-side payment the customer completes with its client
+// secret. Status is the provider's own status; PaymentStatus translates it
+// to the shop's payment statuses.
+type Intent struct {
+	ID            string
+	ClientSecret  string
+	AmountCents   int64
+	Currency      string
+	Status        string
+	PaymentStatus string
+	FailureReason string
+	CaptureMethod string
+	OrderID       string
+	CreatedAt     time.Time
+}
+
+// RefundRequest refunds AmountCents of an intent; 0 refunds everything
+// captured.
+type RefundRequest struct {
+	IntentID    string
+	AmountCents int64
+	Metadata    map[string]string
+}
+
+type Refund struct {
+	ID            string
+	IntentID      string
+	AmountCents   int64
+	Status        string
+	FailureReason string
+	Metadata      map[string]string
+}
+
+// WebhookEvent is a provider webhook translated to the shop's payment
+// statuses. Refund-related events report StatusRefunded: events about a
+// single refund carry it in Refund, charge-level events carry the
+// provider's running refunded total in AmountRefundedCents. Events about a
+// checkout session carry its ID in CheckoutSessionID.
+type WebhookEvent struct {
+	EventID             string
+	Type                string
+	Provider            string
+	ProviderTxnID       string
+	CheckoutSessionID   string
+	OrderID             string
+	Status              string
+	FailureReason       string
+	OccurredAt          time.Time
+	Refund              *Refund
+	AmountRefundedCents int64
+	RawEvent            interface{}
+}
@@ -0,0 +1,37 @@
+package giftcard
+
+import "time"
+
+// IssueGiftCardRequest is an admin issuing a card outside of an order
+type IssueGiftCardRequest struct {
+	AmountCents int64      `json:"amount_cents" validate:"required,gt=0"`
+	Currency    string     `json:"currency,omitempty" validate:"omitempty,len=3"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// IssueForOrderInput lists the gift-card lines of a paid order. Each unit
+// of a line becomes a card worth AmountCents.
+type IssueForOrderInput struct {
+	OrderID  string
+	UserID   string
+	Currency string
+	Lines    []IssueLine
+}
+
+type IssueLine struct {
+	OrderItemID string
+	Qty         int
+	AmountCents int64
+}
+
+// --- DB (Repository) DTOs ---
+type CreateGiftCardInput struct {
+	Code         string
+	InitialCents int64
+	Currency     string
+	OrderID      string
+	OrderItemID  string
+	PurchasedBy  string
+	IssuedBy     string
+	ExpiresAt    *time.Time
+}
@@ -0,0 +1,58 @@
+package giftcard
+
+import (
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/internal/pkg/validator"
+	"ecommerce-app/pkg/pagination"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) GetBalance(w http.ResponseWriter, r *http.Request) {
+	code := chi.URLParam(r, "code")
+
+	balance, appErr := h.svc.CheckBalance(r.Context(), code)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, balance, "Gift card balance fetched successfully")
+}
+
+// ListMyGiftCards pages through the gift cards the signed-in user bought
+func (h *Handler) ListMyGiftCards(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	page, perPage := pagination.GetPaginationParams(r)
+
+	result, appErr := h.svc.ListPurchased(r.Context(), userID, page, perPage)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OkWithMeta(w, result.GiftCards, result.Meta)
+}
+
+func (h *Handler) IssueGiftCard(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	req := validator.GetValidatedBody[IssueGiftCardRequest](r)
+
+	card, appErr := h.svc.IssueGiftCard(r.Context(), adminID, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, card, "Gift card issued")
+}
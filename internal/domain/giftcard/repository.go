@@ -0,0 +1,325 @@
+package giftcard
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ecommerce-app/internal/pkg/database"
+	"ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Repository interface {
+	Create(ctx context.Context, in CreateGiftCardInput) (GiftCard, error)
+	GetByCode(ctx context.Context, code string) (GiftCard, error)
+	Lock(ctx context.Context, id string) (GiftCard, error)
+	UpdateBalance(ctx context.Context, id string, balanceCents int64) (GiftCard, error)
+	ListByPurchaser(ctx context.Context, userID string, limit, offset int32) ([]GiftCard, error)
+	CountByPurchaser(ctx context.Context, userID string) (int64, error)
+	LockOrderGiftCards(ctx context.Context, orderID string) ([]GiftCard, error)
+	VoidOrderGiftCards(ctx context.Context, orderID string) ([]GiftCard, error)
+	CreateRedemption(ctx context.Context, giftCardID, orderID string, amountCents int64) (Redemption, error)
+	ListAppliedRedemptions(ctx context.Context, orderID string) ([]Redemption, error)
+	RestoreRedemption(ctx context.Context, id string, amountCents int64) (Redemption, error)
+	ReverseRedemption(ctx context.Context, id string) (Redemption, error)
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// repository implements Repository
+type repository struct {
+	q  *sqlc.Queries
+	db database.Transactor
+}
+
+func NewRepository(q *sqlc.Queries, db database.Transactor) Repository {
+	return &repository{q: q, db: db}
+}
+
+// WithTx runs fn in a single transaction; every repository call made with
+// the ctx passed to fn takes part in it.
+func (r *repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithTx(ctx, r.db, fn)
+}
+
+func (r *repository) queries(ctx context.Context) *sqlc.Queries {
+	return database.Queries(ctx, r.q)
+}
+
+func (r *repository) Create(ctx context.Context, in CreateGiftCardInput) (GiftCard, error) {
+	params := sqlc.CreateGiftCardParams{
+		Code:         in.Code,
+		InitialCents: in.InitialCents,
+		Currency:     in.Currency,
+	}
+	if in.OrderID != "" {
+		if err := params.OrderID.Scan(in.OrderID); err != nil {
+			return GiftCard{}, err
+		}
+	}
+	if in.OrderItemID != "" {
+		if err := params.OrderItemID.Scan(in.OrderItemID); err != nil {
+			return GiftCard{}, err
+		}
+	}
+	if in.PurchasedBy != "" {
+		if err := params.PurchasedBy.Scan(in.PurchasedBy); err != nil {
+			return GiftCard{}, err
+		}
+	}
+	if in.IssuedBy != "" {
+		if err := params.IssuedBy.Scan(in.IssuedBy); err != nil {
+			return GiftCard{}, err
+		}
+	}
+	if in.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *in.ExpiresAt, Valid: true}
+	}
+
+	row, err := r.queries(ctx).CreateGiftCard(ctx, params)
+	if err != nil {
+		return GiftCard{}, err
+	}
+
+	return mapGiftCard(row), nil
+}
+
+func (r *repository) GetByCode(ctx context.Context, code string) (GiftCard, error) {
+	row, err := r.queries(ctx).GetGiftCardByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return GiftCard{}, errs.ErrNotFound
+		}
+		return GiftCard{}, err
+	}
+
+	return mapGiftCard(row), nil
+}
+
+// Lock reads the gift card and locks it until the transaction ends
+func (r *repository) Lock(ctx context.Context, id string) (GiftCard, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return GiftCard{}, err
+	}
+
+	row, err := r.queries(ctx).LockGiftCard(ctx, uuidID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return GiftCard{}, errs.ErrNotFound
+		}
+		return GiftCard{}, err
+	}
+
+	return mapGiftCard(row), nil
+}
+
+func (r *repository) UpdateBalance(ctx context.Context, id string, balanceCents int64) (GiftCard, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return GiftCard{}, err
+	}
+
+	row, err := r.queries(ctx).UpdateGiftCardBalance(ctx, sqlc.UpdateGiftCardBalanceParams{
+		ID:           uuidID,
+		BalanceCents: balanceCents,
+	})
+	if err != nil {
+		return GiftCard{}, err
+	}
+
+	return mapGiftCard(row), nil
+}
+
+func (r *repository) ListByPurchaser(ctx context.Context, userID string, limit, offset int32) ([]GiftCard, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListGiftCardsByPurchaser(ctx, sqlc.ListGiftCardsByPurchaserParams{
+		PurchasedBy: userUUID,
+		Limit:       limit,
+		Offset:      offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapGiftCards(rows), nil
+}
+
+func (r *repository) CountByPurchaser(ctx context.Context, userID string) (int64, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return 0, err
+	}
+
+	return r.queries(ctx).CountGiftCardsByPurchaser(ctx, userUUID)
+}
+
+// LockOrderGiftCards returns the gift cards bought in an order and locks
+// them until the transaction ends
+func (r *repository) LockOrderGiftCards(ctx context.Context, orderID string) ([]GiftCard, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).LockOrderGiftCards(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapGiftCards(rows), nil
+}
+
+func (r *repository) VoidOrderGiftCards(ctx context.Context, orderID string) ([]GiftCard, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).VoidOrderGiftCards(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapGiftCards(rows), nil
+}
+
+func (r *repository) CreateRedemption(ctx context.Context, giftCardID, orderID string, amountCents int64) (Redemption, error) {
+	params := sqlc.CreateGiftCardRedemptionParams{AmountCents: amountCents}
+	if err := params.GiftCardID.Scan(giftCardID); err != nil {
+		return Redemption{}, err
+	}
+	if err := params.OrderID.Scan(orderID); err != nil {
+		return Redemption{}, err
+	}
+
+	row, err := r.queries(ctx).CreateGiftCardRedemption(ctx, params)
+	if err != nil {
+		return Redemption{}, err
+	}
+
+	return mapRedemption(row), nil
+}
+
+// ListAppliedRedemptions returns the redemptions an order has not reversed,
+// ordered by gift card so their cards are locked in a consistent order
+func (r *repository) ListAppliedRedemptions(ctx context.Context, orderID string) ([]Redemption, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListAppliedGiftCardRedemptions(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	redemptions := make([]Redemption, len(rows))
+	for i, row := range rows {
+		redemptions[i] = mapRedemption(row)
+	}
+
+	return redemptions, nil
+}
+
+// RestoreRedemption records amountCents of an applied redemption as put
+// back on its card, returning errs.ErrConflict if the redemption was
+// reversed or does not have that much left
+func (r *repository) RestoreRedemption(ctx context.Context, id string, amountCents int64) (Redemption, error) {
+	params := sqlc.RestoreGiftCardRedemptionParams{RestoredCents: amountCents}
+	if err := params.ID.Scan(id); err != nil {
+		return Redemption{}, err
+	}
+
+	row, err := r.queries(ctx).RestoreGiftCardRedemption(ctx, params)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Redemption{}, errs.ErrConflict
+		}
+		return Redemption{}, err
+	}
+
+	return mapRedemption(row), nil
+}
+
+// ReverseRedemption marks an applied redemption reversed, returning
+// errs.ErrConflict if it already was
+func (r *repository) ReverseRedemption(ctx context.Context, id string) (Redemption, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Redemption{}, err
+	}
+
+	row, err := r.queries(ctx).ReverseGiftCardRedemption(ctx, uuidID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Redemption{}, errs.ErrConflict
+		}
+		return Redemption{}, err
+	}
+
+	return mapRedemption(row), nil
+}
+
+func mapGiftCard(row sqlc.GiftCard) GiftCard {
+	return GiftCard{
+		ID:           uuid.UUID(row.ID.Bytes),
+		Code:         row.Code,
+		InitialCents: row.InitialCents,
+		BalanceCents: row.BalanceCents,
+		Currency:     row.Currency,
+		Status:       row.Status,
+		OrderID:      uuidPtr(row.OrderID),
+		OrderItemID:  uuidPtr(row.OrderItemID),
+		PurchasedBy:  uuidPtr(row.PurchasedBy),
+		IssuedBy:     uuidPtr(row.IssuedBy),
+		ExpiresAt:    timePtr(row.ExpiresAt),
+		CreatedAt:    row.CreatedAt.Time,
+		UpdatedAt:    row.UpdatedAt.Time,
+	}
+}
+
+func mapGiftCards(rows []sqlc.GiftCard) []GiftCard {
+	cards := make([]GiftCard, len(rows))
+	for i, row := range rows {
+		cards[i] = mapGiftCard(row)
+	}
+	return cards
+}
+
+func mapRedemption(row sqlc.GiftCardRedemption) Redemption {
+	return Redemption{
+		ID:            uuid.UUID(row.ID.Bytes),
+		GiftCardID:    uuid.UUID(row.GiftCardID.Bytes),
+		OrderID:       uuid.UUID(row.OrderID.Bytes),
+		AmountCents:   row.AmountCents,
+		Status:        row.Status,
+		CreatedAt:     row.CreatedAt.Time,
+		ReversedAt:    timePtr(row.ReversedAt),
+		RestoredCents: row.RestoredCents,
+	}
+}
+
+func uuidPtr(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	u := uuid.UUID(id.Bytes)
+	return &u
+}
+
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
@@ -0,0 +1,24 @@
+package giftcard
+
+import (
+	"net/http"
+
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes mounts the gift card endpoints. idempotent guards issuing against
+// client retries.
+func Routes(svc Service, idempotent func(http.Handler) http.Handler) chi.Router {
+	h := NewHandler(svc)
+	r := chi.NewRouter()
+
+	r.With(middleware.RoleMiddleware("customer", "admin")).Get("/", h.ListMyGiftCards)
+	r.With(middleware.RoleMiddleware("admin")).With(idempotent).With(validator.Validate[IssueGiftCardRequest]()).Post("/", h.IssueGiftCard)
+
+	r.With(middleware.RoleMiddleware("customer", "admin", "support")).Get("/{code}/balance", h.GetBalance)
+
+	return r
+}
@@ -0,0 +1,462 @@
+package giftcard
+
+import (
+	"context"
+	"crypto/rand"
+	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/logger"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/pkg/pagination"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	CheckBalance(ctx context.Context, code string) (Balance, *errs.AppError)
+	ListPurchased(ctx context.Context, userID string, page, perPage int) (GiftCardsWithMeta, *errs.AppError)
+	IssueGiftCard(ctx context.Context, adminID string, req IssueGiftCardRequest) (GiftCard, *errs.AppError)
+	IssueForOrder(ctx context.Context, in IssueForOrderInput) ([]GiftCard, *errs.AppError)
+	CheckOrderGiftCardsUnused(ctx context.Context, orderID string) *errs.AppError
+	VoidOrderGiftCards(ctx context.Context, orderID string) (int, *errs.AppError)
+	PreviewRedemption(ctx context.Context, codes []string, maxCents int64, currency string) (int64, *errs.AppError)
+	RedeemForOrder(ctx context.Context, orderID string, codes []string, maxCents int64, currency string) (int64, *errs.AppError)
+	RestoreOrderRedemptions(ctx context.Context, orderID string, maxCents int64) (int64, *errs.AppError)
+	ReverseOrderRedemptions(ctx context.Context, orderID string) (int64, *errs.AppError)
+}
+
+// codeAlphabet leaves out characters that are easily misread (0/O, 1/I).
+// It has 32 characters, so every random byte maps onto it evenly.
+const codeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// codeLength is how many characters a code has, without its dashes. At 5
+// bits each, codes cannot be guessed and do not collide in practice.
+const codeLength = 16
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// CheckBalance reports what is left on the card with code
+func (s *service) CheckBalance(ctx context.Context, code string) (Balance, *errs.AppError) {
+	card, appErr := s.getByCode(ctx, code)
+	if appErr != nil {
+		return Balance{}, appErr
+	}
+
+	return Balance{
+		Code:         card.Code,
+		BalanceCents: card.BalanceCents,
+		Currency:     card.Currency,
+		Status:       card.Status,
+		ExpiresAt:    card.ExpiresAt,
+	}, nil
+}
+
+// ListPurchased pages through the gift cards a user has bought, newest first
+func (s *service) ListPurchased(ctx context.Context, userID string, page, perPage int) (GiftCardsWithMeta, *errs.AppError) {
+	p := pagination.New(page, perPage)
+
+	cards, err := s.repo.ListByPurchaser(ctx, userID, int32(p.PerPage), int32(p.Offset()))
+	if err != nil {
+		logger.Error("Failed to list gift cards of user %s: %v", userID, err)
+		return GiftCardsWithMeta{}, errs.ErrInternal.WithMessage("Failed to list gift cards")
+	}
+
+	total, err := s.repo.CountByPurchaser(ctx, userID)
+	if err != nil {
+		return GiftCardsWithMeta{}, errs.ErrInternal.WithMessage("Failed to count gift cards")
+	}
+
+	return GiftCardsWithMeta{
+		GiftCards: cards,
+		Meta: response.Meta{
+			Page:    p.Page,
+			PerPage: p.PerPage,
+			Total:   int(total),
+		},
+	}, nil
+}
+
+// IssueGiftCard lets an admin issue a card that was not bought in an order
+func (s *service) IssueGiftCard(ctx context.Context, adminID string, req IssueGiftCardRequest) (GiftCard, *errs.AppError) {
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return GiftCard{}, errs.ErrBadRequest.WithMessage("Gift card must expire in the future")
+	}
+
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	card, err := s.repo.Create(ctx, CreateGiftCardInput{
+		Code:         generateCode(),
+		InitialCents: req.AmountCents,
+		Currency:     currency,
+		IssuedBy:     adminID,
+		ExpiresAt:    req.ExpiresAt,
+	})
+	if err != nil {
+		logger.Error("Failed to issue gift card: %v", err)
+		return GiftCard{}, errs.ErrInternal.WithMessage("Failed to issue gift card")
+	}
+
+	return card, nil
+}
+
+// IssueForOrder issues a card for every gift-card unit of a paid order. An
+// order that already has its cards gets them back unchanged.
+func (s *service) IssueForOrder(ctx context.Context, in IssueForOrderInput) ([]GiftCard, *errs.AppError) {
+	var cards []GiftCard
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		existing, err := s.repo.LockOrderGiftCards(ctx, in.OrderID)
+		if err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			cards = existing
+			return nil
+		}
+
+		for _, line := range in.Lines {
+			for range line.Qty {
+				card, err := s.repo.Create(ctx, CreateGiftCardInput{
+					Code:         generateCode(),
+					InitialCents: line.AmountCents,
+					Currency:     in.Currency,
+					OrderID:      in.OrderID,
+					OrderItemID:  line.OrderItemID,
+					PurchasedBy:  in.UserID,
+				})
+				if err != nil {
+					return err
+				}
+				cards = append(cards, card)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to issue gift cards for order %s: %v", in.OrderID, err)
+		return nil, errs.ErrInternal.WithMessage("Failed to issue gift cards")
+	}
+
+	return cards, nil
+}
+
+// CheckOrderGiftCardsUnused fails with a conflict if any gift card bought
+// in the order has been spent from. The cards stay locked until the
+// caller's transaction ends, so none can be spent in the meantime.
+func (s *service) CheckOrderGiftCardsUnused(ctx context.Context, orderID string) *errs.AppError {
+	cards, err := s.repo.LockOrderGiftCards(ctx, orderID)
+	if err != nil {
+		logger.Error("Failed to get gift cards of order %s: %v", orderID, err)
+		return errs.ErrInternal.WithMessage("Failed to get order gift cards")
+	}
+
+	for _, card := range cards {
+		if card.BalanceCents < card.InitialCents {
+			return errs.ErrConflict.WithMessage(fmt.Sprintf("Gift card %s bought in this order has already been used", maskCode(card.Code)))
+		}
+	}
+
+	return nil
+}
+
+// VoidOrderGiftCards voids the gift cards bought in an order that is being
+// cancelled or refunded, returning how many it voided
+func (s *service) VoidOrderGiftCards(ctx context.Context, orderID string) (int, *errs.AppError) {
+	voided, err := s.repo.VoidOrderGiftCards(ctx, orderID)
+	if err != nil {
+		logger.Error("Failed to void gift cards of order %s: %v", orderID, err)
+		return 0, errs.ErrInternal.WithMessage("Failed to void order gift cards")
+	}
+
+	for _, card := range voided {
+		if card.BalanceCents < card.InitialCents {
+			logger.Warn("Voided gift card %s of order %s after %d of %d cents were spent",
+				card.ID.String(), orderID, card.InitialCents-card.BalanceCents, card.InitialCents)
+		}
+	}
+
+	return len(voided), nil
+}
+
+// PreviewRedemption returns how much of maxCents the cards would pay, in
+// the order given, without touching them.
+func (s *service) PreviewRedemption(ctx context.Context, codes []string, maxCents int64, currency string) (int64, *errs.AppError) {
+	var total int64
+	now := time.Now()
+
+	for _, code := range normalizeCodes(codes) {
+		card, appErr := s.getRedeemable(ctx, code)
+		if appErr != nil {
+			return 0, appErr
+		}
+		if total >= maxCents {
+			break
+		}
+		if appErr := checkRedeemable(card, currency, now); appErr != nil {
+			return 0, appErr
+		}
+		total += min(card.BalanceCents, maxCents-total)
+	}
+
+	return total, nil
+}
+
+// RedeemForOrder takes up to maxCents from the cards, in the order given,
+// to pay for an order and returns how much they paid. It runs in the
+// caller's transaction. The cards are locked in ID order first, so
+// checkouts sharing cards wait for each other instead of deadlocking or
+// spending the same balance twice.
+func (s *service) RedeemForOrder(ctx context.Context, orderID string, codes []string, maxCents int64, currency string) (int64, *errs.AppError) {
+	var total int64
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		codes := normalizeCodes(codes)
+		cards := make([]GiftCard, len(codes))
+		for i, code := range codes {
+			card, appErr := s.getRedeemable(ctx, code)
+			if appErr != nil {
+				return appErr
+			}
+			cards[i] = card
+		}
+
+		lockOrder := make([]GiftCard, len(cards))
+		copy(lockOrder, cards)
+		sort.Slice(lockOrder, func(i, j int) bool {
+			return lockOrder[i].ID.String() < lockOrder[j].ID.String()
+		})
+		locked := make(map[uuid.UUID]GiftCard, len(cards))
+		for _, card := range lockOrder {
+			card, err := s.repo.Lock(ctx, card.ID.String())
+			if err != nil {
+				return errs.ErrInternal.WithMessage("Failed to get gift card")
+			}
+			locked[card.ID] = card
+		}
+
+		now := time.Now()
+		for _, card := range cards {
+			if total >= maxCents {
+				break
+			}
+
+			card = locked[card.ID]
+			if appErr := checkRedeemable(card, currency, now); appErr != nil {
+				return appErr
+			}
+
+			amount := min(card.BalanceCents, maxCents-total)
+
+			if _, err := s.repo.UpdateBalance(ctx, card.ID.String(), card.BalanceCents-amount); err != nil {
+				return errs.ErrInternal.WithMessage("Failed to redeem gift card")
+			}
+			if _, err := s.repo.CreateRedemption(ctx, card.ID.String(), orderID, amount); err != nil {
+				return errs.ErrInternal.WithMessage("Failed to record gift card redemption")
+			}
+			total += amount
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to redeem gift cards for order %s: %v", orderID, err)
+		return 0, errs.EnsureAppError(err)
+	}
+
+	return total, nil
+}
+
+// RestoreOrderRedemptions puts up to maxCents of what an order took from
+// gift cards back on them, card by card, returning the amount restored. The
+// redemptions stay applied; reversing them later gives back only the rest.
+func (s *service) RestoreOrderRedemptions(ctx context.Context, orderID string, maxCents int64) (int64, *errs.AppError) {
+	var restored int64
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		redemptions, err := s.repo.ListAppliedRedemptions(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		for _, redemption := range redemptions {
+			amount := min(redemption.AmountCents-redemption.RestoredCents, maxCents-restored)
+			if amount <= 0 {
+				continue
+			}
+
+			card, err := s.repo.Lock(ctx, redemption.GiftCardID.String())
+			if err != nil {
+				return err
+			}
+			if _, err := s.repo.RestoreRedemption(ctx, redemption.ID.String(), amount); err != nil {
+				// Reversed or restored by a concurrent call while the card
+				// was locked
+				if errors.Is(err, errs.ErrConflict) {
+					continue
+				}
+				return err
+			}
+			if _, err := s.repo.UpdateBalance(ctx, card.ID.String(), card.BalanceCents+amount); err != nil {
+				return err
+			}
+			restored += amount
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to restore gift card redemptions of order %s: %v", orderID, err)
+		return 0, errs.ErrInternal.WithMessage("Failed to restore gift card redemptions")
+	}
+
+	return restored, nil
+}
+
+// ReverseOrderRedemptions puts what an order took from gift cards, and has
+// not been restored yet, back on them, returning the amount restored.
+// Calling it again restores nothing.
+func (s *service) ReverseOrderRedemptions(ctx context.Context, orderID string) (int64, *errs.AppError) {
+	var restored int64
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		redemptions, err := s.repo.ListAppliedRedemptions(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		for _, redemption := range redemptions {
+			card, err := s.repo.Lock(ctx, redemption.GiftCardID.String())
+			if err != nil {
+				return err
+			}
+			if _, err := s.repo.ReverseRedemption(ctx, redemption.ID.String()); err != nil {
+				// Reversed by a concurrent call while the card was locked
+				if errors.Is(err, errs.ErrConflict) {
+					continue
+				}
+				return err
+			}
+			amount := redemption.AmountCents - redemption.RestoredCents
+			if _, err := s.repo.UpdateBalance(ctx, card.ID.String(), card.BalanceCents+amount); err != nil {
+				return err
+			}
+			restored += amount
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to reverse gift card redemptions of order %s: %v", orderID, err)
+		return 0, errs.ErrInternal.WithMessage("Failed to reverse gift card redemptions")
+	}
+
+	return restored, nil
+}
+
+func (s *service) getByCode(ctx context.Context, code string) (GiftCard, *errs.AppError) {
+	card, err := s.repo.GetByCode(ctx, normalizeCode(code))
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return GiftCard{}, errs.ErrNotFound.WithMessage("Gift card not found")
+		}
+		return GiftCard{}, errs.ErrInternal.WithMessage("Failed to get gift card")
+	}
+
+	return card, nil
+}
+
+// getRedeemable looks a card up for checkout, where an unknown code is a
+// mistake in the request rather than a missing resource
+func (s *service) getRedeemable(ctx context.Context, code string) (GiftCard, *errs.AppError) {
+	card, appErr := s.getByCode(ctx, code)
+	if appErr != nil && appErr.Code == http.StatusNotFound {
+		return GiftCard{}, errs.ErrBadRequest.WithMessage(fmt.Sprintf("Gift card %s was not found", maskCode(normalizeCode(code))))
+	}
+	return card, appErr
+}
+
+// checkRedeemable tells the customer why a card cannot pay for an order
+func checkRedeemable(card GiftCard, currency string, now time.Time) *errs.AppError {
+	masked := maskCode(card.Code)
+
+	if card.Status != StatusActive {
+		return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Gift card %s is no longer valid", masked))
+	}
+	if card.ExpiresAt != nil && !now.Before(*card.ExpiresAt) {
+		return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Gift card %s has expired", masked))
+	}
+	if !strings.EqualFold(card.Currency, currency) {
+		return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Gift card %s is in %s and cannot pay for an order in %s", masked, card.Currency, currency))
+	}
+	if card.BalanceCents == 0 {
+		return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Gift card %s has no balance left", masked))
+	}
+
+	return nil
+}
+
+// generateCode returns a random code such as ABCD-EFGH-JKMN-PQRS
+func generateCode() string {
+	buf := make([]byte, codeLength)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+
+	var b strings.Builder
+	for i, c := range buf {
+		if i > 0 && i%4 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteByte(codeAlphabet[int(c)%len(codeAlphabet)])
+	}
+	return b.String()
+}
+
+// normalizeCode accepts a code typed in any case, with or without its
+// dashes
+func normalizeCode(code string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(code) {
+		if r == '-' || r == ' ' {
+			continue
+		}
+		if b.Len() > 0 && (b.Len()+1)%5 == 0 {
+			b.WriteByte('-')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// normalizeCodes normalizes codes and drops repeats, keeping their order
+func normalizeCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	out := make([]string, 0, len(codes))
+	for _, code := range codes {
+		code = normalizeCode(code)
+		if !seen[code] {
+			seen[code] = true
+			out = append(out, code)
+		}
+	}
+	return out
+}
+
+// maskCode shows only the last group of a code
+func maskCode(code string) string {
+	if len(code) <= 4 {
+		return code
+	}
+	return "ending in " + code[len(code)-4:]
+}
@@ -0,0 +1,90 @@
+package giftcard
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestGenerateCode(t *testing.T) {
+	format := regexp.MustCompile(`^[` + codeAlphabet + `]{4}(-[` + codeAlphabet + `]{4}){3}$`)
+
+	seen := map[string]bool{}
+	for range 1000 {
+		code := generateCode()
+		if !format.MatchString(code) {
+			t.Fatalf("generateCode() = %q, want four dashed groups of four from %s", code, codeAlphabet)
+		}
+		if normalizeCode(code) != code {
+			t.Errorf("normalizeCode(%q) = %q, want it unchanged", code, normalizeCode(code))
+		}
+		if seen[code] {
+			t.Errorf("generateCode() repeated %q", code)
+		}
+		seen[code] = true
+	}
+}
+
+func TestNormalizeCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code string
+		want string
+	}{
+		{name: "already normal", code: "ABCD-EFGH-JKMN-PQRS", want: "ABCD-EFGH-JKMN-PQRS"},
+		{name: "lower case", code: "abcd-efgh-jkmn-pqrs", want: "ABCD-EFGH-JKMN-PQRS"},
+		{name: "without dashes", code: "ABCDEFGHJKMNPQRS", want: "ABCD-EFGH-JKMN-PQRS"},
+		{name: "spaces instead of dashes", code: "abcd efgh jkmn pqrs", want: "ABCD-EFGH-JKMN-PQRS"},
+		{name: "dashes in the wrong places", code: "AB-CDEF-GHJ-KMNPQRS-", want: "ABCD-EFGH-JKMN-PQRS"},
+		{name: "short code", code: "abcde", want: "ABCD-E"},
+		{name: "empty", code: " - ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeCode(tt.code); got != tt.want {
+				t.Errorf("normalizeCode(%q) = %q, want %q", tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeCodes(t *testing.T) {
+	tests := []struct {
+		name  string
+		codes []string
+		want  []string
+	}{
+		{
+			name:  "repeats dropped in order",
+			codes: []string{"wxyz-2345-6789-abcd", "ABCDEFGHJKMNPQRS", "WXYZ23456789ABCD", "abcd-efgh-jkmn-pqrs"},
+			want:  []string{"WXYZ-2345-6789-ABCD", "ABCD-EFGH-JKMN-PQRS"},
+		},
+		{name: "none", codes: nil, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeCodes(tt.codes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("normalizeCodes(%v) = %v, want %v", tt.codes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskCode(t *testing.T) {
+	tests := []struct {
+		code string
+		want string
+	}{
+		{code: "ABCD-EFGH-JKMN-PQRS", want: "ending in PQRS"},
+		{code: "ABCD", want: "ABCD"},
+		{code: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := maskCode(tt.code); got != tt.want {
+			t.Errorf("maskCode(%q) = %q, want %q", tt.code, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package giftcard
+
+import (
+	"ecommerce-app/internal/pkg/response"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCurrency is the currency gift cards are issued in
+const DefaultCurrency = "USD"
+
+// Gift card statuses, matching the gift_cards.status CHECK constraint
+const (
+	StatusActive = "ACTIVE"
+	StatusVoided = "VOIDED"
+)
+
+// Redemption statuses, matching the gift_card_redemptions.status CHECK
+// constraint
+const (
+	RedemptionApplied  = "APPLIED"
+	RedemptionReversed = "REVERSED"
+)
+
+// GiftCard is a code holding a balance that pays for orders
+type GiftCard struct {
+	ID           uuid.UUID  `json:"id"`
+	Code         string     `json:"code"`
+	InitialCents int64      `json:"initial_cents"`
+	BalanceCents int64      `json:"balance_cents"`
+	Currency     string     `json:"currency"`
+	Status       string     `json:"status"`
+	OrderID      *uuid.UUID `json:"order_id,omitempty"`
+	OrderItemID  *uuid.UUID `json:"order_item_id,omitempty"`
+	PurchasedBy  *uuid.UUID `json:"purchased_by,omitempty"`
+	IssuedBy     *uuid.UUID `json:"issued_by,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Balance is what anyone holding a code may see of its card
+type Balance struct {
+	Code         string     `json:"code"`
+	BalanceCents int64      `json:"balance_cents"`
+	Currency     string     `json:"currency"`
+	Status       string     `json:"status"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// Redemption is what one gift card paid towards an order. RestoredCents is
+// the part already put back on the card by returns.
+type Redemption struct {
+	ID            uuid.UUID  `json:"id"`
+	GiftCardID    uuid.UUID  `json:"gift_card_id"`
+	OrderID       uuid.UUID  `json:"order_id"`
+	AmountCents   int64      `json:"amount_cents"`
+	RestoredCents int64      `json:"restored_cents"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReversedAt    *time.Time `json:"reversed_at,omitempty"`
+}
+
+type GiftCardsWithMeta struct {
+	GiftCards []GiftCard    `json:"gift_cards"`
+	Meta      response.Meta `json:"meta"`
+}
@@ -0,0 +1,146 @@
+package inventory
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxImportRows caps the rows one stock import file may have
+const maxImportRows = 10000
+
+// exportHeader is the header of the stock export. The export reads back as
+// an import that sets every product's stock to what it is now.
+var exportHeader = []string{"sku", "warehouse", "stock", "reserved", "available", "name"}
+
+// parseImportCSV reads a stock import file. The header names the columns:
+// sku and one of stock or delta are required; reason, note and warehouse
+// are optional and other columns are ignored. Rows that cannot be read are
+// returned with their Error set; a file that cannot be read at all is an
+// error.
+func parseImportCSV(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("file is empty")
+		}
+		return nil, fmt.Errorf("invalid header: %w", err)
+	}
+
+	// Spreadsheets often start the file with a byte order mark
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := columns["sku"]; !ok {
+		return nil, errors.New("header has no sku column")
+	}
+	_, hasStock := columns["stock"]
+	_, hasDelta := columns["delta"]
+	if !hasStock && !hasDelta {
+		return nil, errors.New("header needs a stock or delta column")
+	}
+
+	var rows []ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			rows = append(rows, ImportRow{Line: parseErr.Line, Error: parseErr.Err.Error()})
+			continue
+		}
+		if len(rows) == maxImportRows {
+			return nil, fmt.Errorf("file has more than %d rows", maxImportRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, parseImportRecord(line, record, columns))
+	}
+
+	return rows, nil
+}
+
+func parseImportRecord(line int, record []string, columns map[string]int) ImportRow {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	row := ImportRow{
+		Line:      line,
+		SKU:       field("sku"),
+		Warehouse: strings.ToUpper(field("warehouse")),
+		Reason:    strings.ToUpper(field("reason")),
+		Note:      field("note"),
+	}
+	if row.SKU == "" {
+		row.Error = "sku is required"
+		return row
+	}
+
+	stock, delta := field("stock"), field("delta")
+	switch {
+	case stock != "" && delta != "":
+		row.Error = "give either stock or delta, not both"
+	case stock != "":
+		n, err := strconv.ParseInt(stock, 10, 32)
+		if err != nil || n < 0 {
+			row.Error = fmt.Sprintf("invalid stock %q", stock)
+			break
+		}
+		v := int32(n)
+		row.Stock = &v
+	case delta != "":
+		n, err := strconv.ParseInt(delta, 10, 32)
+		if err != nil {
+			row.Error = fmt.Sprintf("invalid delta %q", delta)
+			break
+		}
+		v := int32(n)
+		row.Delta = &v
+	default:
+		row.Error = "stock or delta is required"
+	}
+
+	return row
+}
+
+// writeStockCSV writes the stock export
+func writeStockCSV(w io.Writer, levels []StockLevel) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(exportHeader); err != nil {
+		return err
+	}
+
+	for _, l := range levels {
+		record := []string{
+			l.SKU,
+			l.WarehouseCode,
+			strconv.Itoa(int(l.Stock)),
+			strconv.Itoa(int(l.Reserved)),
+			strconv.Itoa(int(l.Stock - l.Reserved)),
+			l.Name,
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
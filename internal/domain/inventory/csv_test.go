@@ -0,0 +1,110 @@
+package inventory
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func int32Ptr(v int32) *int32 {
+	return &v
+}
+
+func TestParseImportCSV(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		want    []ImportRow
+		wantErr string
+	}{
+		{
+			name: "stock and delta rows",
+			file: "sku,stock,delta,reason,note,warehouse\n" +
+				"A-1,10,,,counted,main\n" +
+				"B-2,,-3,damaged,,\n",
+			want: []ImportRow{
+				{Line: 2, SKU: "A-1", Stock: int32Ptr(10), Note: "counted", Warehouse: "MAIN"},
+				{Line: 3, SKU: "B-2", Delta: int32Ptr(-3), Reason: "DAMAGED"},
+			},
+		},
+		{
+			name: "header read case-insensitively after a byte order mark",
+			file: "\ufeffSKU, Name ,Stock\nA-1,Widget,4\n",
+			want: []ImportRow{{Line: 2, SKU: "A-1", Stock: int32Ptr(4)}},
+		},
+		{
+			name: "columns in any order with short rows",
+			file: "delta,sku,note\n5,A-1\n",
+			want: []ImportRow{{Line: 2, SKU: "A-1", Delta: int32Ptr(5)}},
+		},
+		{
+			name: "unreadable row reported and the rest read",
+			file: "sku,stock\nA\"1,3\nB-2,7\n",
+			want: []ImportRow{
+				{Line: 2, Error: `bare " in non-quoted-field`},
+				{Line: 3, SKU: "B-2", Stock: int32Ptr(7)},
+			},
+		},
+		{
+			name: "header only",
+			file: "sku,stock\n",
+			want: nil,
+		},
+		{name: "empty file", file: "", wantErr: "file is empty"},
+		{name: "no sku column", file: "code,stock\nA-1,3\n", wantErr: "header has no sku column"},
+		{name: "no stock or delta column", file: "sku,qty\nA-1,3\n", wantErr: "header needs a stock or delta column"},
+		{
+			name:    "too many rows",
+			file:    "sku,stock\n" + strings.Repeat("A-1,1\n", maxImportRows+1),
+			wantErr: fmt.Sprintf("file has more than %d rows", maxImportRows),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseImportCSV(strings.NewReader(tt.file))
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("parseImportCSV error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseImportCSV: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseImportCSV = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseImportRecord(t *testing.T) {
+	columns := map[string]int{"sku": 0, "stock": 1, "delta": 2}
+
+	tests := []struct {
+		name   string
+		record []string
+		want   ImportRow
+	}{
+		{name: "stock", record: []string{" A-1 ", " 12 ", ""}, want: ImportRow{Line: 7, SKU: "A-1", Stock: int32Ptr(12)}},
+		{name: "zero stock", record: []string{"A-1", "0", ""}, want: ImportRow{Line: 7, SKU: "A-1", Stock: int32Ptr(0)}},
+		{name: "negative delta", record: []string{"A-1", "", "-4"}, want: ImportRow{Line: 7, SKU: "A-1", Delta: int32Ptr(-4)}},
+		{name: "missing sku", record: []string{" ", "3", ""}, want: ImportRow{Line: 7, Error: "sku is required"}},
+		{name: "both stock and delta", record: []string{"A-1", "3", "1"}, want: ImportRow{Line: 7, SKU: "A-1", Error: "give either stock or delta, not both"}},
+		{name: "neither stock nor delta", record: []string{"A-1", "", ""}, want: ImportRow{Line: 7, SKU: "A-1", Error: "stock or delta is required"}},
+		{name: "negative stock", record: []string{"A-1", "-1", ""}, want: ImportRow{Line: 7, SKU: "A-1", Error: `invalid stock "-1"`}},
+		{name: "fractional stock", record: []string{"A-1", "1.5", ""}, want: ImportRow{Line: 7, SKU: "A-1", Error: `invalid stock "1.5"`}},
+		{name: "delta out of range", record: []string{"A-1", "", "3000000000"}, want: ImportRow{Line: 7, SKU: "A-1", Error: `invalid delta "3000000000"`}},
+		{name: "record shorter than the header", record: []string{"A-1", "5"}, want: ImportRow{Line: 7, SKU: "A-1", Stock: int32Ptr(5)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseImportRecord(7, tt.record, columns); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseImportRecord = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,128 @@
 package inventory
 
+import "time"
+
 // --- Request Dto ---
+// CreateInventoryRequest starts tracking a product. Its stock is booked at
+// WarehouseID, or at the default warehouse when that is empty; the same
+// goes for the other stock requests.
 type CreateInventoryRequest struct {
-	ProductID string `json:"product_id" validate:"required,uuid4"`
-	Stock     int32  `json:"stock" validate:"required,min=0"`
-	Reserved  int32  `json:"reserved" validate:"min=0"`
+	ProductID   string `json:"product_id" validate:"required,uuid4"`
+	WarehouseID string `json:"warehouse_id,omitempty" validate:"omitempty,uuid4"`
+	Stock       int32  `json:"stock" validate:"required,min=0"`
+	Reserved    int32  `json:"reserved" validate:"min=0"`
 }
 
 type UpdateInventoryRequest struct {
-	Stock    *int32 `json:"stock,omitempty" validate:"min=0"`
+	WarehouseID string `json:"warehouse_id,omitempty" validate:"omitempty,uuid4"`
+	Stock       *int32 `json:"stock,omitempty" validate:"min=0"`
 	// Reserved *int32 `json:"reserved,omitempty" validate:"min=0"`
-}
\ No newline at end of file
+}
+
+// AdjustInventoryRequest moves stock by a signed delta. Sales, reservations
+// and returns are recorded by their own flows, so only the reasons a person
+// books by hand are accepted.
+type AdjustInventoryRequest struct {
+	WarehouseID string `json:"warehouse_id,omitempty" validate:"omitempty,uuid4"`
+	Delta       int32  `json:"delta" validate:"required"`
+	Reason      string `json:"reason" validate:"required,oneof=RECEIVED ADJUSTMENT DAMAGED"`
+	Note        string `json:"note" validate:"max=500"`
+}
+
+// SetThresholdRequest sets a product's low-stock threshold and reorder
+// quantity. A null clears the product's own setting, so its category's
+// applies.
+type SetThresholdRequest struct {
+	LowStockThreshold *int32 `json:"low_stock_threshold" validate:"omitempty,min=0"`
+	ReorderQty        *int32 `json:"reorder_qty" validate:"omitempty,min=1"`
+}
+
+// SetCategoryThresholdRequest sets the defaults of the products in a
+// category
+type SetCategoryThresholdRequest struct {
+	LowStockThreshold *int32 `json:"low_stock_threshold" validate:"required,min=0"`
+	ReorderQty        *int32 `json:"reorder_qty" validate:"omitempty,min=1"`
+}
+
+// --- DB (Repository) DTOs ---
+
+// ReservationLine is an order line to reserve stock for. Name is only used
+// to tell the customer what is out of stock.
+type ReservationLine struct {
+	OrderItemID string
+	ProductID   string
+	Name        string
+	Qty         int32
+}
+
+type CreateReservationInput struct {
+	OrderID     string
+	OrderItemID string
+	ProductID   string
+	WarehouseID string
+	Qty         int32
+	ExpiresAt   *time.Time
+}
+
+// ImportRow is one row of a stock import file. It either sets the stock
+// to a counted figure or moves it by a delta; Warehouse is a warehouse code
+// and defaults to the default warehouse. Error is set when the row could
+// not be read.
+type ImportRow struct {
+	Line      int
+	SKU       string
+	Warehouse string
+	Stock     *int32
+	Delta     *int32
+	Reason    string
+	Note      string
+	Error     string
+}
+
+// SKUProduct is the product a SKU names; Tracked tells whether its stock
+// is tracked
+type SKUProduct struct {
+	ProductID string
+	SKU       string
+	Tracked   bool
+}
+
+// LocationCandidate is a warehouse an order line could be allocated from.
+// SameZone tells whether its country shares a shipping zone with the
+// order's destination.
+type LocationCandidate struct {
+	Location
+	Country  string
+	Priority int32
+	IsActive bool
+	SameZone bool
+}
+
+// AdjustStockInput moves a product's stock by Delta and records why.
+// Everything after Reason is optional; without a WarehouseID the stock is
+// booked where the order's line was allocated from, or else at the default
+// warehouse.
+type AdjustStockInput struct {
+	ProductID   string
+	Delta       int32
+	Reason      string
+	WarehouseID string
+	OrderID     string
+	ReturnID    string
+	ActorID     string
+	Note        string
+}
+
+type CreateMovementInput struct {
+	ProductID     string
+	WarehouseID   string
+	Delta         int32
+	ReservedDelta int32
+	StockAfter    int32
+	ReservedAfter int32
+	Reason        string
+	OrderID       string
+	ReturnID      string
+	CreatedBy     string
+	Note          string
+}
@@ -1,9 +1,18 @@
 package inventory
 
 import (
+	"ecommerce-app/internal/pkg/httputil"
+	"ecommerce-app/internal/pkg/logger"
+	"ecommerce-app/internal/pkg/middleware"
 	"ecommerce-app/internal/pkg/response"
 	"ecommerce-app/internal/pkg/validator"
+	"ecommerce-app/pkg/pagination"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -18,8 +27,9 @@ func NewHandler(svc Service) *Handler {
 
 func (h *Handler) CreateInventory(w http.ResponseWriter, r *http.Request) {
 	req := validator.GetValidatedBody[CreateInventoryRequest](r)
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
 
-	inv, appErr := h.svc.CreateInventory(r.Context(), req)
+	inv, appErr := h.svc.CreateInventory(r.Context(), adminID, req)
 	if appErr != nil {
 		response.Error(w, appErr.Code, appErr.Message)
 		return
@@ -43,8 +53,9 @@ func (h *Handler) GetInventoryByProductID(w http.ResponseWriter, r *http.Request
 func (h *Handler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	req := validator.GetValidatedBody[UpdateInventoryRequest](r)
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
 
-	inv, appErr := h.svc.UpdateInventory(r.Context(), id, *req.Stock)
+	inv, appErr := h.svc.UpdateInventory(r.Context(), adminID, id, req.WarehouseID, *req.Stock)
 	if appErr != nil {
 		response.Error(w, appErr.Code, appErr.Message)
 		return
@@ -55,12 +66,209 @@ func (h *Handler) UpdateInventory(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) DeleteInventory(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
 
-	appErr := h.svc.DeleteInventory(r.Context(), id)
+	appErr := h.svc.DeleteInventory(r.Context(), adminID, id)
 	if appErr != nil {
 		response.Error(w, appErr.Code, appErr.Message)
 		return
 	}
 
 	response.NoContent(w)
+}
+
+// AdjustInventory moves a product's stock by a delta, e.g. to book in a
+// delivery or write off damaged goods
+func (h *Handler) AdjustInventory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	req := validator.GetValidatedBody[AdjustInventoryRequest](r)
+
+	inv, appErr := h.svc.AdjustStock(r.Context(), AdjustStockInput{
+		ProductID:   id,
+		WarehouseID: req.WarehouseID,
+		Delta:       req.Delta,
+		Reason:      req.Reason,
+		ActorID:     adminID,
+		Note:        req.Note,
+	})
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, inv, "Inventory adjusted successfully")
+}
+
+func (h *Handler) ListMovements(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	page, perPage := pagination.GetPaginationParams(r)
+
+	result, appErr := h.svc.ListMovements(r.Context(), id, page, perPage)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OkWithMeta(w, result.Movements, result.Meta)
+}
+
+// CheckLedger reports products whose stock does not match their movements
+func (h *Handler) CheckLedger(w http.ResponseWriter, r *http.Request) {
+	report, appErr := h.svc.CheckLedger(r.Context())
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, report, "Stock ledger checked")
+}
+
+// ListOversold lists reservations of paid orders that found no stock left
+func (h *Handler) ListOversold(w http.ResponseWriter, r *http.Request) {
+	reservations, appErr := h.svc.ListOversoldReservations(r.Context())
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, reservations, "Oversold reservations retrieved")
+}
+
+// defaultSalesWindowDays is how many days of sales the low-stock report
+// averages, unless ?days says otherwise
+const defaultSalesWindowDays = 30
+
+// LowStockReport lists the products at or below their low-stock threshold
+func (h *Handler) LowStockReport(w http.ResponseWriter, r *http.Request) {
+	days := defaultSalesWindowDays
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid days")
+			return
+		}
+		days = parsed
+	}
+
+	report, appErr := h.svc.LowStockReport(r.Context(), days)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, report, "Low-stock report generated")
+}
+
+func (h *Handler) SetThreshold(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[SetThresholdRequest](r)
+
+	inv, appErr := h.svc.SetThreshold(r.Context(), id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, inv, "Low-stock threshold updated successfully")
+}
+
+func (h *Handler) GetCategoryThreshold(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryID")
+
+	threshold, appErr := h.svc.GetCategoryThreshold(r.Context(), categoryID)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, threshold, "Low-stock threshold fetched successfully")
+}
+
+func (h *Handler) SetCategoryThreshold(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryID")
+	req := validator.GetValidatedBody[SetCategoryThresholdRequest](r)
+
+	threshold, appErr := h.svc.SetCategoryThreshold(r.Context(), categoryID, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, threshold, "Low-stock threshold updated successfully")
+}
+
+func (h *Handler) DeleteCategoryThreshold(w http.ResponseWriter, r *http.Request) {
+	categoryID := chi.URLParam(r, "categoryID")
+
+	if appErr := h.svc.DeleteCategoryThreshold(r.Context(), categoryID); appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+// maxImportSize caps the size of a stock import file
+const maxImportSize = 5 << 20
+
+// ImportStock takes a CSV file, either as the request body or as the file
+// field of a multipart form, and dry-runs it; ?confirm=true applies it
+func (h *Handler) ImportStock(w http.ResponseWriter, r *http.Request) {
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+
+	var file io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid or missing file")
+			return
+		}
+		defer f.Close()
+		file = f
+	}
+
+	rows, err := parseImportCSV(file)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid CSV: "+err.Error())
+		return
+	}
+
+	confirm := httputil.GetBoolQuery(r, "confirm", false)
+	report, appErr := h.svc.ImportStock(r.Context(), adminID, rows, confirm)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	if confirm && !report.Applied {
+		response.JSON(w, http.StatusUnprocessableEntity, response.GenericResponse[ImportReport]{
+			Status:  "error",
+			Message: "Stock import has errors; nothing was applied",
+			Data:    &report,
+		})
+		return
+	}
+	if !confirm {
+		response.OK(w, report, "Stock import checked; confirm to apply it")
+		return
+	}
+
+	response.OK(w, report, "Stock import applied")
+}
+
+// ExportStock downloads every product's stock per warehouse as CSV
+func (h *Handler) ExportStock(w http.ResponseWriter, r *http.Request) {
+	levels, appErr := h.svc.ExportStockLevels(r.Context())
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="inventory-%s.csv"`, time.Now().UTC().Format("20060102")))
+	if err := writeStockCSV(w, levels); err != nil {
+		logger.Error("Failed to write stock export: %v", err)
+	}
 }
\ No newline at end of file
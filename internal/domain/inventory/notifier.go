@@ -0,0 +1,25 @@
+package inventory
+
+import (
+	"context"
+	"ecommerce-app/internal/pkg/logger"
+	"fmt"
+)
+
+type logNotifier struct{}
+
+// NewLogNotifier returns an AlertNotifier that writes low-stock alerts to
+// the log, where the admins' log alerting picks them up
+func NewLogNotifier() AlertNotifier {
+	return logNotifier{}
+}
+
+func (logNotifier) NotifyLowStock(ctx context.Context, alert LowStockAlert) error {
+	reorder := "no reorder quantity set"
+	if alert.ReorderQty != nil {
+		reorder = fmt.Sprintf("reorder %d", *alert.ReorderQty)
+	}
+
+	logger.Warn("Low stock: %s (%s) has %d available, threshold %d; %s", alert.Name, alert.SKU, alert.Available, alert.LowStockThreshold, reorder)
+	return nil
+}
@@ -2,8 +2,11 @@ package inventory
 
 import (
 	"context"
+	"ecommerce-app/internal/pkg/database"
 	"ecommerce-app/internal/pkg/database/sqlc"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -11,18 +14,60 @@ import (
 type Repository interface {
 	CreateInventory(ctx context.Context, productID string, stock int32, reserved int32) (Inventory, error)
 	GetInventoryByProductID(ctx context.Context, productID string) (Inventory, error)
-	UpdateInventoryStock(ctx context.Context, productID string, stock int32) (Inventory, error)
+	CountInventory(ctx context.Context) (int64, error)
+	MoveInventory(ctx context.Context, productID string, delta, reservedDelta int32) (Inventory, error)
 	DeleteInventory(ctx context.Context, productID string) error
+	EnsureLocation(ctx context.Context, warehouseID, productID string) error
+	LockLocation(ctx context.Context, warehouseID, productID string) (Location, error)
+	LockLocations(ctx context.Context, productID, country string) ([]LocationCandidate, error)
+	ListLocations(ctx context.Context, productID string) ([]Location, error)
+	MoveLocation(ctx context.Context, warehouseID, productID string, delta, reservedDelta int32) (Location, error)
+	DeleteLocations(ctx context.Context, productID string) error
+	CreateReservation(ctx context.Context, in CreateReservationInput) (Reservation, error)
+	LockOrderReservations(ctx context.Context, orderID string) ([]Reservation, error)
+	UpdateReservationStatus(ctx context.Context, id, status string) (Reservation, error)
+	ListOrdersWithExpiredReservations(ctx context.Context, before time.Time, limit int32) ([]string, error)
+	ListOversoldReservations(ctx context.Context) ([]Reservation, error)
+	ListOrderAllocations(ctx context.Context, orderID string) ([]Allocation, error)
+	CreateMovement(ctx context.Context, in CreateMovementInput) (Movement, error)
+	ListMovements(ctx context.Context, productID string, limit, offset int32) ([]Movement, error)
+	CountMovements(ctx context.Context, productID string) (int64, error)
+	ListLedgerMismatches(ctx context.Context) ([]LedgerMismatch, error)
+	ListTotalMismatches(ctx context.Context) ([]TotalMismatch, error)
+	SetThresholds(ctx context.Context, productID string, threshold, reorderQty *int32) (Inventory, error)
+	GetEffectiveThreshold(ctx context.Context, productID string) (StockThreshold, error)
+	ListLowStock(ctx context.Context, soldSince time.Time) ([]LowStockItem, error)
+	UpsertCategoryThreshold(ctx context.Context, categoryID string, threshold int32, reorderQty *int32) (CategoryThreshold, error)
+	GetCategoryThreshold(ctx context.Context, categoryID string) (CategoryThreshold, error)
+	DeleteCategoryThreshold(ctx context.Context, categoryID string) (int64, error)
+	CreateLowStockAlert(ctx context.Context, productID string, available, threshold int32, reorderQty *int32) error
+	ListUndeliveredAlerts(ctx context.Context, limit int32) ([]LowStockAlert, error)
+	MarkAlertDelivered(ctx context.Context, id string) error
+	ListProductsBySku(ctx context.Context, skus []string) ([]SKUProduct, error)
+	ListStockLevels(ctx context.Context) ([]StockLevel, error)
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 
 type repository struct {
-	queries *sqlc.Queries
+	q  *sqlc.Queries
+	db database.Transactor
 }
 
 
-func NewRepository(queries *sqlc.Queries) Repository {
-	return &repository{queries: queries}
+func NewRepository(q *sqlc.Queries, db database.Transactor) Repository {
+	return &repository{q: q, db: db}
+}
+
+// WithTx runs fn in a single transaction; every repository call made with
+// the ctx passed to fn takes part in it.
+func (r *repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithTx(ctx, r.db, fn)
+}
+
+// queries joins the transaction carried by ctx, if any
+func (r *repository) queries(ctx context.Context) *sqlc.Queries {
+	return database.Queries(ctx, r.q)
 }
 
 func (r *repository) CreateInventory(ctx context.Context, productID string, stock int32, reserved int32) (Inventory, error) {
@@ -37,7 +82,7 @@ func (r *repository) CreateInventory(ctx context.Context, productID string, stoc
 		Reserved:  reserved,
 	}
 
-	row, err := r.queries.CreateInventory(ctx, params)
+	row, err := r.queries(ctx).CreateInventory(ctx, params)
 	if err != nil {
 		return Inventory{}, err
 	}
@@ -51,7 +96,7 @@ func (r *repository) GetInventoryByProductID(ctx context.Context, productID stri
 		return Inventory{}, err
 	}
 
-	row, err := r.queries.GetInventoryByProductID(ctx, productUUID)
+	row, err := r.queries(ctx).GetInventoryByProductID(ctx, productUUID)
 	if err != nil {
 		return Inventory{}, err
 	}
@@ -59,18 +104,417 @@ func (r *repository) GetInventoryByProductID(ctx context.Context, productID stri
 	return mapInventory(row), nil
 }
 
-func (r *repository) UpdateInventoryStock(ctx context.Context, productID string, stock int32) (Inventory, error) {
+func (r *repository) CountInventory(ctx context.Context) (int64, error) {
+	return r.queries(ctx).CountInventory(ctx)
+}
+
+func (r *repository) DeleteInventory(ctx context.Context, productID string) error {
+	var productUUID pgtype.UUID
+	if err := productUUID.Scan(productID); err != nil {
+		return err
+	}
+
+	return r.queries(ctx).DeleteInventory(ctx, productUUID)
+}
+
+// MoveInventory moves the product's totals by a warehouse movement's deltas
+func (r *repository) MoveInventory(ctx context.Context, productID string, delta, reservedDelta int32) (Inventory, error) {
 	var productUUID pgtype.UUID
 	if err := productUUID.Scan(productID); err != nil {
 		return Inventory{}, err
 	}
 
-	params := sqlc.UpdateInventoryStockParams{
+	row, err := r.queries(ctx).MoveInventory(ctx, sqlc.MoveInventoryParams{
+		Delta:         delta,
+		ReservedDelta: reservedDelta,
+		ProductID:     productUUID,
+	})
+	if err != nil {
+		return Inventory{}, err
+	}
+
+	return mapInventory(row), nil
+}
+
+// EnsureLocation starts tracking the product at the warehouse, with nothing
+// in stock, unless it already is
+func (r *repository) EnsureLocation(ctx context.Context, warehouseID, productID string) error {
+	var params sqlc.EnsureWarehouseStockParams
+	if err := params.WarehouseID.Scan(warehouseID); err != nil {
+		return err
+	}
+	if err := params.ProductID.Scan(productID); err != nil {
+		return err
+	}
+
+	return r.queries(ctx).EnsureWarehouseStock(ctx, params)
+}
+
+// LockLocation reads the product's stock at the warehouse and locks it
+// until the transaction ends
+func (r *repository) LockLocation(ctx context.Context, warehouseID, productID string) (Location, error) {
+	var params sqlc.LockWarehouseStockParams
+	if err := params.WarehouseID.Scan(warehouseID); err != nil {
+		return Location{}, err
+	}
+	if err := params.ProductID.Scan(productID); err != nil {
+		return Location{}, err
+	}
+
+	row, err := r.queries(ctx).LockWarehouseStock(ctx, params)
+	if err != nil {
+		return Location{}, err
+	}
+
+	return mapLocation(row), nil
+}
+
+// LockLocations returns the product's stock at every warehouse, with what
+// allocation needs to rank them for an order shipped to country, and locks
+// it until the transaction ends
+func (r *repository) LockLocations(ctx context.Context, productID, country string) ([]LocationCandidate, error) {
+	params := sqlc.LockProductWarehouseStockParams{Country: country}
+	if err := params.ProductID.Scan(productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).LockProductWarehouseStock(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]LocationCandidate, len(rows))
+	for i, row := range rows {
+		candidates[i] = LocationCandidate{
+			Location: Location{
+				WarehouseID:   uuid.UUID(row.WarehouseID.Bytes).String(),
+				WarehouseCode: row.WarehouseCode,
+				WarehouseName: row.WarehouseName,
+				ProductID:     uuid.UUID(row.ProductID.Bytes).String(),
+				Stock:         row.Stock,
+				Reserved:      row.Reserved,
+				UpdatedAt:     row.UpdatedAt.Time,
+			},
+			Country:  row.Country,
+			Priority: row.Priority,
+			IsActive: row.IsActive,
+			SameZone: row.SameZone,
+		}
+	}
+
+	return candidates, nil
+}
+
+// ListLocations returns the product's stock at each warehouse, in
+// allocation priority order
+func (r *repository) ListLocations(ctx context.Context, productID string) ([]Location, error) {
+	var productUUID pgtype.UUID
+	if err := productUUID.Scan(productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListProductWarehouseStock(ctx, productUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	locations := make([]Location, len(rows))
+	for i, row := range rows {
+		locations[i] = Location{
+			WarehouseID:   uuid.UUID(row.WarehouseID.Bytes).String(),
+			WarehouseCode: row.WarehouseCode,
+			WarehouseName: row.WarehouseName,
+			ProductID:     uuid.UUID(row.ProductID.Bytes).String(),
+			Stock:         row.Stock,
+			Reserved:      row.Reserved,
+			UpdatedAt:     row.UpdatedAt.Time,
+		}
+	}
+
+	return locations, nil
+}
+
+// MoveLocation moves the product's stock and reserved units at the
+// warehouse. It returns sql.ErrNoRows when the warehouse does not track the
+// product.
+func (r *repository) MoveLocation(ctx context.Context, warehouseID, productID string, delta, reservedDelta int32) (Location, error) {
+	params := sqlc.MoveWarehouseStockParams{
+		Delta:         delta,
+		ReservedDelta: reservedDelta,
+	}
+	if err := params.WarehouseID.Scan(warehouseID); err != nil {
+		return Location{}, err
+	}
+	if err := params.ProductID.Scan(productID); err != nil {
+		return Location{}, err
+	}
+
+	row, err := r.queries(ctx).MoveWarehouseStock(ctx, params)
+	if err != nil {
+		return Location{}, err
+	}
+
+	return mapLocation(row), nil
+}
+
+func (r *repository) DeleteLocations(ctx context.Context, productID string) error {
+	var productUUID pgtype.UUID
+	if err := productUUID.Scan(productID); err != nil {
+		return err
+	}
+
+	return r.queries(ctx).DeleteProductWarehouseStock(ctx, productUUID)
+}
+
+func (r *repository) CreateReservation(ctx context.Context, in CreateReservationInput) (Reservation, error) {
+	params := sqlc.CreateInventoryReservationParams{Qty: in.Qty}
+	if err := params.OrderID.Scan(in.OrderID); err != nil {
+		return Reservation{}, err
+	}
+	if err := params.OrderItemID.Scan(in.OrderItemID); err != nil {
+		return Reservation{}, err
+	}
+	if err := params.ProductID.Scan(in.ProductID); err != nil {
+		return Reservation{}, err
+	}
+	if err := params.WarehouseID.Scan(in.WarehouseID); err != nil {
+		return Reservation{}, err
+	}
+	if in.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *in.ExpiresAt, Valid: true}
+	}
+
+	row, err := r.queries(ctx).CreateInventoryReservation(ctx, params)
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	return mapReservation(row), nil
+}
+
+// LockOrderReservations returns the order's reservations and locks them
+// until the transaction ends
+func (r *repository) LockOrderReservations(ctx context.Context, orderID string) ([]Reservation, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).LockOrderInventoryReservations(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := make([]Reservation, len(rows))
+	for i, row := range rows {
+		reservations[i] = mapReservation(row)
+	}
+
+	return reservations, nil
+}
+
+func (r *repository) UpdateReservationStatus(ctx context.Context, id, status string) (Reservation, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Reservation{}, err
+	}
+
+	row, err := r.queries(ctx).UpdateInventoryReservationStatus(ctx, sqlc.UpdateInventoryReservationStatusParams{
+		ID:     uuidID,
+		Status: status,
+	})
+	if err != nil {
+		return Reservation{}, err
+	}
+
+	return mapReservation(row), nil
+}
+
+func (r *repository) ListOversoldReservations(ctx context.Context) ([]Reservation, error) {
+	rows, err := r.queries(ctx).ListOversoldReservations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	reservations := make([]Reservation, len(rows))
+	for i, row := range rows {
+		reservations[i] = mapReservation(row)
+	}
+
+	return reservations, nil
+}
+
+func (r *repository) ListOrdersWithExpiredReservations(ctx context.Context, before time.Time, limit int32) ([]string, error) {
+	rows, err := r.queries(ctx).ListOrdersWithExpiredReservations(ctx, sqlc.ListOrdersWithExpiredReservationsParams{
+		ExpiredBefore: pgtype.Timestamptz{Time: before, Valid: true},
+		RowLimit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	orderIDs := make([]string, len(rows))
+	for i, row := range rows {
+		orderIDs[i] = uuid.UUID(row.Bytes).String()
+	}
+
+	return orderIDs, nil
+}
+
+// ListOrderAllocations returns the warehouses the order's lines are served
+// from, grouped by warehouse in priority order
+func (r *repository) ListOrderAllocations(ctx context.Context, orderID string) ([]Allocation, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListOrderInventoryAllocations(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	allocations := make([]Allocation, len(rows))
+	for i, row := range rows {
+		allocations[i] = Allocation{
+			OrderItemID:   uuid.UUID(row.OrderItemID.Bytes).String(),
+			ProductID:     uuid.UUID(row.ProductID.Bytes).String(),
+			WarehouseID:   uuid.UUID(row.WarehouseID.Bytes).String(),
+			WarehouseCode: row.WarehouseCode,
+			Qty:           row.Qty,
+			Status:        row.Status,
+		}
+	}
+
+	return allocations, nil
+}
+
+func (r *repository) CreateMovement(ctx context.Context, in CreateMovementInput) (Movement, error) {
+	params := sqlc.CreateStockMovementParams{
+		Delta:         in.Delta,
+		ReservedDelta: in.ReservedDelta,
+		StockAfter:    in.StockAfter,
+		ReservedAfter: in.ReservedAfter,
+		Reason:        in.Reason,
+		Note:          pgtype.Text{String: in.Note, Valid: in.Note != ""},
+	}
+	if err := params.ProductID.Scan(in.ProductID); err != nil {
+		return Movement{}, err
+	}
+	if err := params.WarehouseID.Scan(in.WarehouseID); err != nil {
+		return Movement{}, err
+	}
+	if in.OrderID != "" {
+		if err := params.OrderID.Scan(in.OrderID); err != nil {
+			return Movement{}, err
+		}
+	}
+	if in.ReturnID != "" {
+		if err := params.ReturnID.Scan(in.ReturnID); err != nil {
+			return Movement{}, err
+		}
+	}
+	if in.CreatedBy != "" {
+		if err := params.CreatedBy.Scan(in.CreatedBy); err != nil {
+			return Movement{}, err
+		}
+	}
+
+	row, err := r.queries(ctx).CreateStockMovement(ctx, params)
+	if err != nil {
+		return Movement{}, err
+	}
+
+	return mapMovement(row), nil
+}
+
+// ListMovements returns a page of the product's ledger, newest first
+func (r *repository) ListMovements(ctx context.Context, productID string, limit, offset int32) ([]Movement, error) {
+	var productUUID pgtype.UUID
+	if err := productUUID.Scan(productID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListStockMovements(ctx, sqlc.ListStockMovementsParams{
 		ProductID: productUUID,
-		Stock:     stock,
+		Limit:     limit,
+		Offset:    offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	movements := make([]Movement, len(rows))
+	for i, row := range rows {
+		movements[i] = mapMovement(row)
 	}
 
-	row, err := r.queries.UpdateInventoryStock(ctx, params)
+	return movements, nil
+}
+
+func (r *repository) CountMovements(ctx context.Context, productID string) (int64, error) {
+	var productUUID pgtype.UUID
+	if err := productUUID.Scan(productID); err != nil {
+		return 0, err
+	}
+
+	return r.queries(ctx).CountStockMovements(ctx, productUUID)
+}
+
+func (r *repository) ListLedgerMismatches(ctx context.Context) ([]LedgerMismatch, error) {
+	rows, err := r.queries(ctx).ListStockLedgerMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := make([]LedgerMismatch, len(rows))
+	for i, row := range rows {
+		mismatches[i] = LedgerMismatch{
+			ProductID:      uuid.UUID(row.ProductID.Bytes).String(),
+			WarehouseID:    uuid.UUID(row.WarehouseID.Bytes).String(),
+			Stock:          row.Stock,
+			Reserved:       row.Reserved,
+			LedgerStock:    row.LedgerStock,
+			LedgerReserved: row.LedgerReserved,
+		}
+	}
+
+	return mismatches, nil
+}
+
+func (r *repository) ListTotalMismatches(ctx context.Context) ([]TotalMismatch, error) {
+	rows, err := r.queries(ctx).ListInventoryTotalMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := make([]TotalMismatch, len(rows))
+	for i, row := range rows {
+		mismatches[i] = TotalMismatch{
+			ProductID:         uuid.UUID(row.ProductID.Bytes).String(),
+			Stock:             row.Stock,
+			Reserved:          row.Reserved,
+			WarehouseStock:    row.WarehouseStock,
+			WarehouseReserved: row.WarehouseReserved,
+		}
+	}
+
+	return mismatches, nil
+}
+
+// SetThresholds sets the product's own low-stock threshold and reorder
+// quantity; nil clears them
+func (r *repository) SetThresholds(ctx context.Context, productID string, threshold, reorderQty *int32) (Inventory, error) {
+	var productUUID pgtype.UUID
+	if err := productUUID.Scan(productID); err != nil {
+		return Inventory{}, err
+	}
+
+	row, err := r.queries(ctx).SetInventoryThresholds(ctx, sqlc.SetInventoryThresholdsParams{
+		LowStockThreshold: toPGInt4(threshold),
+		ReorderQty:        toPGInt4(reorderQty),
+		ProductID:         productUUID,
+	})
 	if err != nil {
 		return Inventory{}, err
 	}
@@ -78,23 +522,267 @@ func (r *repository) UpdateInventoryStock(ctx context.Context, productID string,
 	return mapInventory(row), nil
 }
 
-func (r *repository) DeleteInventory(ctx context.Context, productID string) error {
+// GetEffectiveThreshold returns the threshold and reorder quantity that
+// apply to the product: its own, else its category's
+func (r *repository) GetEffectiveThreshold(ctx context.Context, productID string) (StockThreshold, error) {
+	var productUUID pgtype.UUID
+	if err := productUUID.Scan(productID); err != nil {
+		return StockThreshold{}, err
+	}
+
+	row, err := r.queries(ctx).GetEffectiveStockThreshold(ctx, productUUID)
+	if err != nil {
+		return StockThreshold{}, err
+	}
+
+	return StockThreshold{
+		LowStockThreshold: fromPGInt4(row.LowStockThreshold),
+		ReorderQty:        fromPGInt4(row.ReorderQty),
+	}, nil
+}
+
+// ListLowStock returns the products at or below their threshold with the
+// units sold since soldSince; the sales rates are left to the caller
+func (r *repository) ListLowStock(ctx context.Context, soldSince time.Time) ([]LowStockItem, error) {
+	rows, err := r.queries(ctx).ListLowStockInventory(ctx, pgtype.Timestamptz{Time: soldSince, Valid: true})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]LowStockItem, len(rows))
+	for i, row := range rows {
+		items[i] = LowStockItem{
+			ProductID:         uuid.UUID(row.ProductID.Bytes).String(),
+			SKU:               row.Sku,
+			Name:              row.Name,
+			Stock:             row.Stock,
+			Reserved:          row.Reserved,
+			Available:         row.Stock - row.Reserved,
+			LowStockThreshold: row.LowStockThreshold.Int32,
+			ReorderQty:        fromPGInt4(row.ReorderQty),
+			UnitsSold:         row.UnitsSold,
+		}
+	}
+
+	return items, nil
+}
+
+func (r *repository) UpsertCategoryThreshold(ctx context.Context, categoryID string, threshold int32, reorderQty *int32) (CategoryThreshold, error) {
+	var categoryUUID pgtype.UUID
+	if err := categoryUUID.Scan(categoryID); err != nil {
+		return CategoryThreshold{}, err
+	}
+
+	row, err := r.queries(ctx).UpsertCategoryStockThreshold(ctx, sqlc.UpsertCategoryStockThresholdParams{
+		CategoryID:        categoryUUID,
+		LowStockThreshold: threshold,
+		ReorderQty:        toPGInt4(reorderQty),
+	})
+	if err != nil {
+		return CategoryThreshold{}, err
+	}
+
+	return mapCategoryThreshold(row), nil
+}
+
+func (r *repository) GetCategoryThreshold(ctx context.Context, categoryID string) (CategoryThreshold, error) {
+	var categoryUUID pgtype.UUID
+	if err := categoryUUID.Scan(categoryID); err != nil {
+		return CategoryThreshold{}, err
+	}
+
+	row, err := r.queries(ctx).GetCategoryStockThreshold(ctx, categoryUUID)
+	if err != nil {
+		return CategoryThreshold{}, err
+	}
+
+	return mapCategoryThreshold(row), nil
+}
+
+// DeleteCategoryThreshold returns how many thresholds it deleted
+func (r *repository) DeleteCategoryThreshold(ctx context.Context, categoryID string) (int64, error) {
+	var categoryUUID pgtype.UUID
+	if err := categoryUUID.Scan(categoryID); err != nil {
+		return 0, err
+	}
+
+	return r.queries(ctx).DeleteCategoryStockThreshold(ctx, categoryUUID)
+}
+
+func (r *repository) CreateLowStockAlert(ctx context.Context, productID string, available, threshold int32, reorderQty *int32) error {
 	var productUUID pgtype.UUID
 	if err := productUUID.Scan(productID); err != nil {
 		return err
 	}
 
-	return r.queries.DeleteInventory(ctx, productUUID)
+	_, err := r.queries(ctx).CreateLowStockAlert(ctx, sqlc.CreateLowStockAlertParams{
+		ProductID:         productUUID,
+		Available:         available,
+		LowStockThreshold: threshold,
+		ReorderQty:        toPGInt4(reorderQty),
+	})
+	return err
+}
+
+// ListUndeliveredAlerts locks up to limit alerts not yet delivered, oldest
+// first; alerts locked by another delivery are skipped
+func (r *repository) ListUndeliveredAlerts(ctx context.Context, limit int32) ([]LowStockAlert, error) {
+	rows, err := r.queries(ctx).ListUndeliveredLowStockAlerts(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	alerts := make([]LowStockAlert, len(rows))
+	for i, row := range rows {
+		alerts[i] = LowStockAlert{
+			ID:                uuid.UUID(row.ID.Bytes).String(),
+			ProductID:         uuid.UUID(row.ProductID.Bytes).String(),
+			SKU:               row.Sku,
+			Name:              row.Name,
+			Available:         row.Available,
+			LowStockThreshold: row.LowStockThreshold,
+			ReorderQty:        fromPGInt4(row.ReorderQty),
+			CreatedAt:         row.CreatedAt.Time,
+		}
+	}
+
+	return alerts, nil
+}
+
+func (r *repository) MarkAlertDelivered(ctx context.Context, id string) error {
+	var alertUUID pgtype.UUID
+	if err := alertUUID.Scan(id); err != nil {
+		return err
+	}
+
+	return r.queries(ctx).MarkLowStockAlertDelivered(ctx, alertUUID)
+}
+
+func (r *repository) ListProductsBySku(ctx context.Context, skus []string) ([]SKUProduct, error) {
+	rows, err := r.queries(ctx).ListProductsBySku(ctx, skus)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]SKUProduct, len(rows))
+	for i, row := range rows {
+		products[i] = SKUProduct{
+			ProductID: uuid.UUID(row.ID.Bytes).String(),
+			SKU:       row.Sku,
+			Tracked:   row.Tracked,
+		}
+	}
+
+	return products, nil
+}
+
+func (r *repository) ListStockLevels(ctx context.Context) ([]StockLevel, error) {
+	rows, err := r.queries(ctx).ListStockLevels(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	levels := make([]StockLevel, len(rows))
+	for i, row := range rows {
+		levels[i] = StockLevel{
+			SKU:           row.Sku,
+			Name:          row.Name,
+			WarehouseCode: row.WarehouseCode,
+			Stock:         row.Stock,
+			Reserved:      row.Reserved,
+		}
+	}
+
+	return levels, nil
+}
+
+func toPGInt4(v *int32) pgtype.Int4 {
+	if v == nil {
+		return pgtype.Int4{Valid: false}
+	}
+	return pgtype.Int4{Int32: *v, Valid: true}
+}
+
+func fromPGInt4(v pgtype.Int4) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int32
 }
 
 func mapInventory(row sqlc.Inventory) Inventory {
 	return Inventory{
-		ProductID: row.ProductID.String(),
-		Stock:     row.Stock,
-		Reserved:  row.Reserved,
-		CreatedAt: row.CreatedAt.Time,
-		UpdatedAt: row.UpdatedAt.Time,
+		ProductID:         row.ProductID.String(),
+		Stock:             row.Stock,
+		Reserved:          row.Reserved,
+		LowStockThreshold: fromPGInt4(row.LowStockThreshold),
+		ReorderQty:        fromPGInt4(row.ReorderQty),
+		CreatedAt:         row.CreatedAt.Time,
+		UpdatedAt:         row.UpdatedAt.Time,
 	}
 }
 
+func mapCategoryThreshold(row sqlc.CategoryStockThreshold) CategoryThreshold {
+	return CategoryThreshold{
+		CategoryID:        uuid.UUID(row.CategoryID.Bytes).String(),
+		LowStockThreshold: row.LowStockThreshold,
+		ReorderQty:        fromPGInt4(row.ReorderQty),
+		UpdatedAt:         row.UpdatedAt.Time,
+	}
+}
+
+func mapLocation(row sqlc.WarehouseStock) Location {
+	return Location{
+		WarehouseID: uuid.UUID(row.WarehouseID.Bytes).String(),
+		ProductID:   uuid.UUID(row.ProductID.Bytes).String(),
+		Stock:       row.Stock,
+		Reserved:    row.Reserved,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}
+}
 
+func mapReservation(row sqlc.InventoryReservation) Reservation {
+	reservation := Reservation{
+		ID:          uuid.UUID(row.ID.Bytes).String(),
+		OrderID:     uuid.UUID(row.OrderID.Bytes).String(),
+		OrderItemID: uuid.UUID(row.OrderItemID.Bytes).String(),
+		ProductID:   uuid.UUID(row.ProductID.Bytes).String(),
+		WarehouseID: uuid.UUID(row.WarehouseID.Bytes).String(),
+		Qty:         row.Qty,
+		Status:      row.Status,
+		CreatedAt:   row.CreatedAt.Time,
+		UpdatedAt:   row.UpdatedAt.Time,
+	}
+	if row.ExpiresAt.Valid {
+		t := row.ExpiresAt.Time
+		reservation.ExpiresAt = &t
+	}
+
+	return reservation
+}
+
+func mapMovement(row sqlc.StockMovement) Movement {
+	movement := Movement{
+		ID:            uuid.UUID(row.ID.Bytes).String(),
+		ProductID:     uuid.UUID(row.ProductID.Bytes).String(),
+		WarehouseID:   uuid.UUID(row.WarehouseID.Bytes).String(),
+		Delta:         row.Delta,
+		ReservedDelta: row.ReservedDelta,
+		StockAfter:    row.StockAfter,
+		ReservedAfter: row.ReservedAfter,
+		Reason:        row.Reason,
+		Note:          row.Note.String,
+		CreatedAt:     row.CreatedAt.Time,
+	}
+	if row.OrderID.Valid {
+		movement.OrderID = uuid.UUID(row.OrderID.Bytes).String()
+	}
+	if row.ReturnID.Valid {
+		movement.ReturnID = uuid.UUID(row.ReturnID.Bytes).String()
+	}
+	if row.CreatedBy.Valid {
+		movement.CreatedBy = uuid.UUID(row.CreatedBy.Bytes).String()
+	}
+
+	return movement
+}
@@ -13,10 +13,32 @@ func Routes(svc Service) chi.Router {
 
 	r.With(validator.Validate[CreateInventoryRequest]()).With(middleware.RoleMiddleware("admin")).Post("/", h.CreateInventory)
 
+	r.With(middleware.RoleMiddleware("admin")).Get("/ledger-check", h.CheckLedger)
+
+	r.With(middleware.RoleMiddleware("admin")).Get("/low-stock", h.LowStockReport)
+
+	r.With(middleware.RoleMiddleware("admin")).Get("/oversold", h.ListOversold)
+
+	r.With(middleware.RoleMiddleware("admin")).Post("/import", h.ImportStock)
+
+	r.With(middleware.RoleMiddleware("admin")).Get("/export", h.ExportStock)
+
+	r.With(middleware.RoleMiddleware("admin")).Get("/categories/{categoryID}/threshold", h.GetCategoryThreshold)
+
+	r.With(validator.Validate[SetCategoryThresholdRequest]()).With(middleware.RoleMiddleware("admin")).Put("/categories/{categoryID}/threshold", h.SetCategoryThreshold)
+
+	r.With(middleware.RoleMiddleware("admin")).Delete("/categories/{categoryID}/threshold", h.DeleteCategoryThreshold)
+
 	r.With(middleware.RoleMiddleware("admin")).Get("/{id}", h.GetInventoryByProductID)
 
+	r.With(middleware.RoleMiddleware("admin")).Get("/{id}/movements", h.ListMovements)
+
+	r.With(validator.Validate[AdjustInventoryRequest]()).With(middleware.RoleMiddleware("admin")).Post("/{id}/adjust", h.AdjustInventory)
+
 	r.With(validator.Validate[UpdateInventoryRequest]()).With(middleware.RoleMiddleware("admin")).Put("/{id}", h.UpdateInventory)
 
+	r.With(validator.Validate[SetThresholdRequest]()).With(middleware.RoleMiddleware("admin")).Put("/{id}/threshold", h.SetThreshold)
+
 	r.With(middleware.RoleMiddleware("admin")).Delete("/{id}", h.DeleteInventory)
 
 	return r
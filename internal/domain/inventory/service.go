@@ -2,36 +2,139 @@ package inventory
 
 import (
 	"context"
+	"database/sql"
+	"ecommerce-app/internal/domain/warehouse"
+	"ecommerce-app/internal/pkg/database"
 	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/logger"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/pkg/pagination"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
+// reservationExpiryBatch is how many orders one expiry run releases at most
+const reservationExpiryBatch = 100
+
+// alertDeliveryBatch is how many low-stock alerts one delivery run sends at
+// most
+const alertDeliveryBatch = 100
+
+// maxSalesWindowDays caps how far back the low-stock report counts sales
+const maxSalesWindowDays = 365
+
 type Service interface {
-	CreateInventory(ctx context.Context, req CreateInventoryRequest) (Inventory, *errs.AppError)
+	CreateInventory(ctx context.Context, actorID string, req CreateInventoryRequest) (Inventory, *errs.AppError)
 	GetInventoryByProductID(ctx context.Context, id string) (Inventory, *errs.AppError)
-	UpdateInventory(ctx context.Context, id string, stock int32) (Inventory, *errs.AppError)
-	DeleteInventory(ctx context.Context, id string) *errs.AppError
+	UpdateInventory(ctx context.Context, actorID, id, warehouseID string, stock int32) (Inventory, *errs.AppError)
+	AdjustStock(ctx context.Context, in AdjustStockInput) (Inventory, *errs.AppError)
+	DeleteInventory(ctx context.Context, actorID, id string) *errs.AppError
+	ListMovements(ctx context.Context, productID string, page, perPage int) (MovementsWithMeta, *errs.AppError)
+	CheckLedger(ctx context.Context) (LedgerReport, *errs.AppError)
+	ReserveStock(ctx context.Context, orderID, country string, lines []ReservationLine, expiresAt *time.Time) *errs.AppError
+	CommitReservations(ctx context.Context, orderID string) *errs.AppError
+	ReleaseReservations(ctx context.Context, orderID string) *errs.AppError
+	CancelReservations(ctx context.Context, orderID string) *errs.AppError
+	ReleaseExpiredReservations(ctx context.Context) (int, *errs.AppError)
+	ListOversoldReservations(ctx context.Context) ([]Reservation, *errs.AppError)
+	ListOrderAllocations(ctx context.Context, orderID string) ([]Allocation, *errs.AppError)
+	SetThreshold(ctx context.Context, productID string, req SetThresholdRequest) (Inventory, *errs.AppError)
+	GetCategoryThreshold(ctx context.Context, categoryID string) (CategoryThreshold, *errs.AppError)
+	SetCategoryThreshold(ctx context.Context, categoryID string, req SetCategoryThresholdRequest) (CategoryThreshold, *errs.AppError)
+	DeleteCategoryThreshold(ctx context.Context, categoryID string) *errs.AppError
+	LowStockReport(ctx context.Context, salesWindowDays int) (LowStockReport, *errs.AppError)
+	DeliverLowStockAlerts(ctx context.Context) (int, *errs.AppError)
+	ImportStock(ctx context.Context, actorID string, rows []ImportRow, confirm bool) (ImportReport, *errs.AppError)
+	ExportStockLevels(ctx context.Context) ([]StockLevel, *errs.AppError)
 }
 
 type service struct {
-	repo Repository
+	repo       Repository
+	warehouses WarehouseProvider
+	notifier   AlertNotifier
+	strategy   string
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo}
+func NewService(repo Repository, warehouses WarehouseProvider, notifier AlertNotifier, strategy string) Service {
+	return &service{repo: repo, warehouses: warehouses, notifier: notifier, strategy: strategy}
 }
 
-func (s *service) CreateInventory(ctx context.Context, req CreateInventoryRequest) (Inventory, *errs.AppError) {
+// ParseAllocationStrategy reads INVENTORY_ALLOCATION_STRATEGY; empty means
+// AllocationPriority
+func ParseAllocationStrategy(strategy string) (string, error) {
+	switch strings.ToLower(strategy) {
+	case "", AllocationPriority:
+		return AllocationPriority, nil
+	case AllocationClosest:
+		return AllocationClosest, nil
+	default:
+		return "", fmt.Errorf("unknown inventory allocation strategy %q", strategy)
+	}
+}
+
+// CreateInventory starts tracking a product's stock at a warehouse; the
+// opening stock is recorded as RECEIVED.
+func (s *service) CreateInventory(ctx context.Context, actorID string, req CreateInventoryRequest) (Inventory, *errs.AppError) {
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		warehouseID, appErr := s.resolveWarehouse(ctx, req.WarehouseID)
+		if appErr != nil {
+			return appErr
+		}
+
+		if _, err := s.repo.CreateInventory(ctx, req.ProductID, 0, 0); err != nil {
+			if database.IsUniqueViolation(err) {
+				return errs.ErrConflict.WithMessage("inventory already exists for product")
+			}
+			return errs.ErrInternal.WithMessage("failed to create inventory")
+		}
+		if err := s.repo.EnsureLocation(ctx, warehouseID, req.ProductID); err != nil {
+			return errs.ErrInternal.WithMessage("failed to create inventory")
+		}
 
-	inv, err := s.repo.CreateInventory(ctx, req.ProductID, req.Stock, req.Reserved)
+		if req.Stock == 0 && req.Reserved == 0 {
+			return nil
+		}
+		_, err := s.move(ctx, CreateMovementInput{
+			ProductID:     req.ProductID,
+			WarehouseID:   warehouseID,
+			Delta:         req.Stock,
+			ReservedDelta: req.Reserved,
+			Reason:        MovementReceived,
+			CreatedBy:     actorID,
+		})
+		if err != nil {
+			if database.IsCheckViolation(err) {
+				return errs.ErrBadRequest.WithMessage("reserved cannot be more than stock")
+			}
+			return errs.ErrInternal.WithMessage("failed to create inventory")
+		}
+		return nil
+	})
 	if err != nil {
-		return Inventory{}, errs.ErrInternal.WithMessage("failed to create inventory")
+		return Inventory{}, errs.EnsureAppError(err)
 	}
 
-	return inv, nil
+	return s.GetInventoryByProductID(ctx, req.ProductID)
 }
 
+// GetInventoryByProductID returns a product's totals with their breakdown
+// per warehouse
 func (s *service) GetInventoryByProductID(ctx context.Context, id string) (Inventory, *errs.AppError) {
-	res,err:= s.repo.GetInventoryByProductID(ctx, id)
+	res, err := s.repo.GetInventoryByProductID(ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Inventory{}, errs.ErrNotFound.WithMessage("inventory not found for product")
+		}
+		return Inventory{}, errs.ErrInternal.WithMessage("failed to get inventory")
+	}
+
+	res.Locations, err = s.repo.ListLocations(ctx, id)
 	if err != nil {
 		return Inventory{}, errs.ErrInternal.WithMessage("failed to get inventory")
 	}
@@ -39,20 +142,964 @@ func (s *service) GetInventoryByProductID(ctx context.Context, id string) (Inven
 	return res, nil
 }
 
-func (s *service) UpdateInventory(ctx context.Context, productId string, stock int32) (Inventory, *errs.AppError) {
-   res, err:= s.repo.UpdateInventoryStock(ctx, productId, stock)
-   if err != nil {
-	   return Inventory{}, errs.ErrInternal.WithMessage("failed to update inventory")
-   }
+// UpdateInventory sets a product's stock at a warehouse to a counted
+// figure; the difference is recorded as an ADJUSTMENT.
+func (s *service) UpdateInventory(ctx context.Context, actorID, productId, warehouseID string, stock int32) (Inventory, *errs.AppError) {
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		warehouseID, appErr := s.locate(ctx, productId, warehouseID)
+		if appErr != nil {
+			return appErr
+		}
+
+		current, err := s.repo.LockLocation(ctx, warehouseID, productId)
+		if err != nil {
+			return errs.ErrInternal.WithMessage("failed to update inventory")
+		}
+		if current.Stock == stock {
+			return nil
+		}
+
+		_, err = s.move(ctx, CreateMovementInput{
+			ProductID:   productId,
+			WarehouseID: warehouseID,
+			Delta:       stock - current.Stock,
+			Reason:      MovementAdjustment,
+			CreatedBy:   actorID,
+		})
+		if err != nil {
+			if database.IsCheckViolation(err) {
+				return errs.ErrConflict.WithMessage("stock cannot be less than reserved")
+			}
+			return errs.ErrInternal.WithMessage("failed to update inventory")
+		}
+		return nil
+	})
+	if err != nil {
+		return Inventory{}, errs.EnsureAppError(err)
+	}
+
+	return s.GetInventoryByProductID(ctx, productId)
+}
+
+// AdjustStock adds in.Delta to a product's stock at a warehouse, e.g. when
+// a delivery is booked in or returned goods are put back on the shelf, and
+// records the movement. Negative deltas take stock out.
+func (s *service) AdjustStock(ctx context.Context, in AdjustStockInput) (Inventory, *errs.AppError) {
+	if appErr := validateAdjustment(in.Reason, in.Delta); appErr != nil {
+		return Inventory{}, appErr
+	}
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		warehouseID := in.WarehouseID
+		if warehouseID == "" && in.OrderID != "" {
+			var appErr *errs.AppError
+			if warehouseID, appErr = s.allocatedWarehouse(ctx, in.OrderID, in.ProductID); appErr != nil {
+				return appErr
+			}
+		}
+
+		warehouseID, appErr := s.locate(ctx, in.ProductID, warehouseID)
+		if appErr != nil {
+			return appErr
+		}
+
+		_, err := s.move(ctx, CreateMovementInput{
+			ProductID:   in.ProductID,
+			WarehouseID: warehouseID,
+			Delta:       in.Delta,
+			Reason:      in.Reason,
+			OrderID:     in.OrderID,
+			ReturnID:    in.ReturnID,
+			CreatedBy:   in.ActorID,
+			Note:        in.Note,
+		})
+		if err != nil {
+			if database.IsCheckViolation(err) {
+				return errs.ErrConflict.WithMessage("insufficient stock")
+			}
+			return errs.ErrInternal.WithMessage("failed to adjust inventory")
+		}
+		return nil
+	})
+	if err != nil {
+		return Inventory{}, errs.EnsureAppError(err)
+	}
+
+	return s.GetInventoryByProductID(ctx, in.ProductID)
+}
+
+// DeleteInventory stops tracking a product's stock. The ledger at each
+// warehouse is closed with a movement back to zero, so tracking the
+// product again starts from a balanced ledger.
+func (s *service) DeleteInventory(ctx context.Context, actorID, id string) *errs.AppError {
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.GetInventoryByProductID(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return errs.ErrNotFound.WithMessage("inventory not found for product")
+			}
+			return errs.ErrInternal.WithMessage("failed to delete inventory")
+		}
+
+		locations, err := s.repo.LockLocations(ctx, id, "")
+		if err != nil {
+			return errs.ErrInternal.WithMessage("failed to delete inventory")
+		}
+
+		for _, loc := range locations {
+			if loc.Stock == 0 && loc.Reserved == 0 {
+				continue
+			}
+			_, err := s.repo.CreateMovement(ctx, CreateMovementInput{
+				ProductID:     id,
+				WarehouseID:   loc.WarehouseID,
+				Delta:         -loc.Stock,
+				ReservedDelta: -loc.Reserved,
+				Reason:        MovementAdjustment,
+				CreatedBy:     actorID,
+				Note:          "Inventory deleted",
+			})
+			if err != nil {
+				logger.Error("Failed to close stock ledger of product %s at %s: %v", id, loc.WarehouseCode, err)
+				return errs.ErrInternal.WithMessage("failed to delete inventory")
+			}
+		}
+
+		if err := s.repo.DeleteLocations(ctx, id); err != nil {
+			return errs.ErrInternal.WithMessage("failed to delete inventory")
+		}
+		if err := s.repo.DeleteInventory(ctx, id); err != nil {
+			return errs.ErrInternal.WithMessage("failed to delete inventory")
+		}
+		return nil
+	})
+	if err != nil {
+		return errs.EnsureAppError(err)
+	}
+
+	return nil
+}
+
+// ListMovements returns a page of a product's stock ledger, newest first
+func (s *service) ListMovements(ctx context.Context, productID string, page, perPage int) (MovementsWithMeta, *errs.AppError) {
+	if _, err := uuid.Parse(productID); err != nil {
+		return MovementsWithMeta{}, errs.ErrBadRequest.WithMessage("Invalid product ID")
+	}
+
+	p := pagination.New(page, perPage)
+
+	movements, err := s.repo.ListMovements(ctx, productID, int32(p.PerPage), int32(p.Offset()))
+	if err != nil {
+		logger.Error("Failed to list stock movements for product %s: %v", productID, err)
+		return MovementsWithMeta{}, errs.ErrInternal.WithMessage("failed to list stock movements")
+	}
+
+	total, err := s.repo.CountMovements(ctx, productID)
+	if err != nil {
+		return MovementsWithMeta{}, errs.ErrInternal.WithMessage("failed to count stock movements")
+	}
+
+	return MovementsWithMeta{
+		Movements: movements,
+		Meta: response.Meta{
+			Page:    p.Page,
+			PerPage: p.PerPage,
+			Total:   int(total),
+		},
+	}, nil
+}
+
+// CheckLedger verifies that every product's stock and reserved counts at
+// each warehouse equal the sum of its movements there, and that its totals
+// equal the sum over its warehouses, and lists the ones that do not.
+func (s *service) CheckLedger(ctx context.Context) (LedgerReport, *errs.AppError) {
+	checked, err := s.repo.CountInventory(ctx)
+	if err != nil {
+		logger.Error("Failed to count inventory: %v", err)
+		return LedgerReport{}, errs.ErrInternal.WithMessage("failed to check stock ledger")
+	}
+
+	mismatches, err := s.repo.ListLedgerMismatches(ctx)
+	if err != nil {
+		logger.Error("Failed to compare warehouse stock with stock movements: %v", err)
+		return LedgerReport{}, errs.ErrInternal.WithMessage("failed to check stock ledger")
+	}
+	for _, m := range mismatches {
+		logger.Error("Stock ledger mismatch for product %s at warehouse %s: stock %d/%d, reserved %d/%d", m.ProductID, m.WarehouseID, m.Stock, m.LedgerStock, m.Reserved, m.LedgerReserved)
+	}
+
+	totals, err := s.repo.ListTotalMismatches(ctx)
+	if err != nil {
+		logger.Error("Failed to compare inventory with warehouse stock: %v", err)
+		return LedgerReport{}, errs.ErrInternal.WithMessage("failed to check stock ledger")
+	}
+	for _, m := range totals {
+		logger.Error("Inventory totals mismatch for product %s: stock %d/%d, reserved %d/%d", m.ProductID, m.Stock, m.WarehouseStock, m.Reserved, m.WarehouseReserved)
+	}
+
+	return LedgerReport{Checked: checked, Mismatches: mismatches, TotalMismatches: totals}, nil
+}
+
+// ReserveStock allocates and reserves every line of an order or none of
+// them. Each line's warehouses are locked in warehouse order and lines are
+// taken in product order, so concurrent orders for the last units cannot
+// both get them or deadlock. Products no warehouse tracks are not
+// stock-tracked and are skipped. When lines cannot be reserved the error
+// lists all of them.
+func (s *service) ReserveStock(ctx context.Context, orderID, country string, lines []ReservationLine, expiresAt *time.Time) *errs.AppError {
+	sorted := make([]ReservationLine, len(lines))
+	copy(sorted, lines)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ProductID < sorted[j].ProductID })
+	country = strings.ToUpper(country)
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		var unavailable []string
+		for _, line := range sorted {
+			candidates, err := s.repo.LockLocations(ctx, line.ProductID, country)
+			if err != nil {
+				logger.Error("Failed to lock stock of %s for order %s: %v", line.ProductID, orderID, err)
+				return errs.ErrInternal.WithMessage("failed to reserve stock")
+			}
+			if len(candidates) == 0 {
+				continue
+			}
+
+			allocations := s.allocate(candidates, country, line.Qty)
+			if allocations == nil {
+				unavailable = append(unavailable, fmt.Sprintf("%s (%s)", line.Name, line.ProductID))
+				continue
+			}
+
+			for _, a := range allocations {
+				_, err := s.move(ctx, CreateMovementInput{
+					ProductID:     line.ProductID,
+					WarehouseID:   a.WarehouseID,
+					ReservedDelta: a.Qty,
+					Reason:        MovementReserved,
+					OrderID:       orderID,
+				})
+				if err == nil {
+					_, err = s.repo.CreateReservation(ctx, CreateReservationInput{
+						OrderID:     orderID,
+						OrderItemID: line.OrderItemID,
+						ProductID:   line.ProductID,
+						WarehouseID: a.WarehouseID,
+						Qty:         a.Qty,
+						ExpiresAt:   expiresAt,
+					})
+				}
+				if err != nil {
+					logger.Error("Failed to reserve %s at %s for order %s: %v", line.ProductID, a.WarehouseCode, orderID, err)
+					return errs.ErrInternal.WithMessage("failed to reserve stock")
+				}
+			}
+		}
+
+		if len(unavailable) > 0 {
+			return errs.ErrConflict.WithMessage("Not enough stock for: " + strings.Join(unavailable, ", "))
+		}
+		return nil
+	})
+	if err != nil {
+		return errs.EnsureAppError(err)
+	}
+
+	return nil
+}
+
+// allocate picks the warehouses an order line is served from: the first
+// active warehouse, in strategy order, that has all of it, so the line
+// ships in one parcel, or else as much as each has in that order. It
+// returns nil when the active warehouses together are short.
+func (s *service) allocate(candidates []LocationCandidate, country string, qty int32) []Allocation {
+	active := make([]LocationCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if c.IsActive && c.Stock > c.Reserved {
+			active = append(active, c)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		if s.strategy == AllocationClosest {
+			if ri, rj := distanceRank(active[i], country), distanceRank(active[j], country); ri != rj {
+				return ri < rj
+			}
+		}
+		if active[i].Priority != active[j].Priority {
+			return active[i].Priority < active[j].Priority
+		}
+		return active[i].WarehouseCode < active[j].WarehouseCode
+	})
+
+	for _, c := range active {
+		if c.Stock-c.Reserved >= qty {
+			return []Allocation{allocationAt(c, qty)}
+		}
+	}
+
+	var allocations []Allocation
+	for _, c := range active {
+		a := allocationAt(c, min(c.Stock-c.Reserved, qty))
+		allocations = append(allocations, a)
+		if qty -= a.Qty; qty == 0 {
+			return allocations
+		}
+	}
+
+	return nil
+}
+
+func allocationAt(c LocationCandidate, qty int32) Allocation {
+	return Allocation{
+		ProductID:     c.ProductID,
+		WarehouseID:   c.WarehouseID,
+		WarehouseCode: c.WarehouseCode,
+		Qty:           qty,
+	}
+}
+
+// distanceRank orders warehouses by how close they are to the destination
+// country: in it, sharing a shipping zone with it, or anywhere else.
+func distanceRank(c LocationCandidate, country string) int {
+	switch {
+	case c.Country == country:
+		return 0
+	case c.SameZone:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// CommitReservations takes a paid order's units out of stock. Units whose
+// reservation was released while the order waited for payment are taken
+// again if their warehouse still has them; if not the payment still stands
+// and the reservation is marked OVERSOLD, so admins find it through
+// ListOversoldReservations and restock or refund the order.
+func (s *service) CommitReservations(ctx context.Context, orderID string) *errs.AppError {
+	return s.updateReservations(ctx, orderID, func(ctx context.Context, res Reservation) (string, error) {
+		switch res.Status {
+		case ReservationHeld:
+			_, err := s.move(ctx, CreateMovementInput{
+				ProductID:     res.ProductID,
+				WarehouseID:   res.WarehouseID,
+				Delta:         -res.Qty,
+				ReservedDelta: -res.Qty,
+				Reason:        MovementSold,
+				OrderID:       orderID,
+			})
+			return ReservationCommitted, err
+		case ReservationReleased:
+			loc, err := s.repo.LockLocation(ctx, res.WarehouseID, res.ProductID)
+			if err == nil && loc.Stock-loc.Reserved < res.Qty {
+				err = sql.ErrNoRows
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				logger.Warn("Order %s was paid but %d of %s are no longer in stock at warehouse %s; marked oversold", orderID, res.Qty, res.ProductID, res.WarehouseID)
+				return ReservationOversold, nil
+			}
+			if err != nil {
+				return "", err
+			}
+			_, err = s.move(ctx, CreateMovementInput{
+				ProductID:   res.ProductID,
+				WarehouseID: res.WarehouseID,
+				Delta:       -res.Qty,
+				Reason:      MovementSold,
+				OrderID:     orderID,
+			})
+			return ReservationCommitted, err
+		}
+		return "", nil
+	})
+}
+
+// ReleaseReservations frees the units an unpaid order holds, e.g. when its
+// payment fails. The order can still be paid later.
+func (s *service) ReleaseReservations(ctx context.Context, orderID string) *errs.AppError {
+	return s.updateReservations(ctx, orderID, s.releaseHeld(time.Time{}))
+}
+
+// CancelReservations gives back everything a cancelled order took: held
+// units are freed and units taken from stock by its payment are put back
+// at the warehouse they came from. Oversold units never left stock, so
+// they are only released.
+func (s *service) CancelReservations(ctx context.Context, orderID string) *errs.AppError {
+	release := s.releaseHeld(time.Time{})
+	return s.updateReservations(ctx, orderID, func(ctx context.Context, res Reservation) (string, error) {
+		switch res.Status {
+		case ReservationOversold:
+			return ReservationReleased, nil
+		case ReservationCommitted:
+			_, err := s.move(ctx, CreateMovementInput{
+				ProductID:   res.ProductID,
+				WarehouseID: res.WarehouseID,
+				Delta:       res.Qty,
+				Reason:      MovementCancelled,
+				OrderID:     orderID,
+			})
+			return ReservationReleased, err
+		}
+		return release(ctx, res)
+	})
+}
+
+// ListOversoldReservations lists the reservations of paid orders whose units
+// were gone by the time they were paid, oldest first
+func (s *service) ListOversoldReservations(ctx context.Context) ([]Reservation, *errs.AppError) {
+	reservations, err := s.repo.ListOversoldReservations(ctx)
+	if err != nil {
+		logger.Error("Failed to list oversold reservations: %v", err)
+		return nil, errs.ErrInternal.WithMessage("failed to list oversold reservations")
+	}
+
+	return reservations, nil
+}
+
+// ReleaseExpiredReservations frees the units held by orders that were not
+// paid before their reservations expired. It returns how many orders it
+// released; an order that fails is logged and left for the next run.
+func (s *service) ReleaseExpiredReservations(ctx context.Context) (int, *errs.AppError) {
+	now := time.Now()
+	orderIDs, err := s.repo.ListOrdersWithExpiredReservations(ctx, now, reservationExpiryBatch)
+	if err != nil {
+		logger.Error("Failed to list expired reservations: %v", err)
+		return 0, errs.ErrInternal.WithMessage("failed to list expired reservations")
+	}
+
+	released := 0
+	for _, orderID := range orderIDs {
+		if appErr := s.updateReservations(ctx, orderID, s.releaseHeld(now)); appErr != nil {
+			logger.Error("Failed to release expired reservations of order %s: %s", orderID, appErr.Message)
+			continue
+		}
+		released++
+	}
+
+	return released, nil
+}
+
+// ListOrderAllocations returns the warehouses an order's lines are served
+// from, grouped by warehouse, so each can ship its part
+func (s *service) ListOrderAllocations(ctx context.Context, orderID string) ([]Allocation, *errs.AppError) {
+	if _, err := uuid.Parse(orderID); err != nil {
+		return nil, errs.ErrBadRequest.WithMessage("Invalid order ID")
+	}
+
+	allocations, err := s.repo.ListOrderAllocations(ctx, orderID)
+	if err != nil {
+		logger.Error("Failed to list stock allocations of order %s: %v", orderID, err)
+		return nil, errs.ErrInternal.WithMessage("failed to list stock allocations")
+	}
+
+	return allocations, nil
+}
+
+// SetThreshold sets a product's own low-stock threshold and reorder
+// quantity; nulls fall back to its category's
+func (s *service) SetThreshold(ctx context.Context, productID string, req SetThresholdRequest) (Inventory, *errs.AppError) {
+	if _, err := uuid.Parse(productID); err != nil {
+		return Inventory{}, errs.ErrBadRequest.WithMessage("Invalid product ID")
+	}
+
+	if _, err := s.repo.SetThresholds(ctx, productID, req.LowStockThreshold, req.ReorderQty); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Inventory{}, errs.ErrNotFound.WithMessage("inventory not found for product")
+		}
+		logger.Error("Failed to set low-stock threshold of product %s: %v", productID, err)
+		return Inventory{}, errs.ErrInternal.WithMessage("failed to set low-stock threshold")
+	}
+
+	return s.GetInventoryByProductID(ctx, productID)
+}
+
+func (s *service) GetCategoryThreshold(ctx context.Context, categoryID string) (CategoryThreshold, *errs.AppError) {
+	if _, err := uuid.Parse(categoryID); err != nil {
+		return CategoryThreshold{}, errs.ErrBadRequest.WithMessage("Invalid category ID")
+	}
+
+	threshold, err := s.repo.GetCategoryThreshold(ctx, categoryID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return CategoryThreshold{}, errs.ErrNotFound.WithMessage("category has no low-stock threshold")
+		}
+		return CategoryThreshold{}, errs.ErrInternal.WithMessage("failed to get low-stock threshold")
+	}
+
+	return threshold, nil
+}
+
+// SetCategoryThreshold sets the low-stock threshold and reorder quantity of
+// the category's products that have none of their own
+func (s *service) SetCategoryThreshold(ctx context.Context, categoryID string, req SetCategoryThresholdRequest) (CategoryThreshold, *errs.AppError) {
+	if _, err := uuid.Parse(categoryID); err != nil {
+		return CategoryThreshold{}, errs.ErrBadRequest.WithMessage("Invalid category ID")
+	}
 
-   return res, nil
+	threshold, err := s.repo.UpsertCategoryThreshold(ctx, categoryID, *req.LowStockThreshold, req.ReorderQty)
+	if err != nil {
+		if database.IsForeignKeyViolation(err) {
+			return CategoryThreshold{}, errs.ErrNotFound.WithMessage("category not found")
+		}
+		logger.Error("Failed to set low-stock threshold of category %s: %v", categoryID, err)
+		return CategoryThreshold{}, errs.ErrInternal.WithMessage("failed to set low-stock threshold")
+	}
+
+	return threshold, nil
 }
 
-func (s *service) DeleteInventory(ctx context.Context, id string) *errs.AppError {
-	err:= s.repo.DeleteInventory(ctx, id)
+func (s *service) DeleteCategoryThreshold(ctx context.Context, categoryID string) *errs.AppError {
+	if _, err := uuid.Parse(categoryID); err != nil {
+		return errs.ErrBadRequest.WithMessage("Invalid category ID")
+	}
+
+	deleted, err := s.repo.DeleteCategoryThreshold(ctx, categoryID)
 	if err != nil {
-		return errs.ErrInternal.WithMessage("failed to delete inventory")
+		return errs.ErrInternal.WithMessage("failed to delete low-stock threshold")
+	}
+	if deleted == 0 {
+		return errs.ErrNotFound.WithMessage("category has no low-stock threshold")
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// LowStockReport lists the products at or below their low-stock threshold,
+// with how fast they sold over the last salesWindowDays and how many days
+// their available stock lasts at that rate
+func (s *service) LowStockReport(ctx context.Context, salesWindowDays int) (LowStockReport, *errs.AppError) {
+	if salesWindowDays < 1 || salesWindowDays > maxSalesWindowDays {
+		return LowStockReport{}, errs.ErrBadRequest.WithMessage(fmt.Sprintf("days must be between 1 and %d", maxSalesWindowDays))
+	}
+
+	soldSince := time.Now().AddDate(0, 0, -salesWindowDays)
+	items, err := s.repo.ListLowStock(ctx, soldSince)
+	if err != nil {
+		logger.Error("Failed to list low-stock products: %v", err)
+		return LowStockReport{}, errs.ErrInternal.WithMessage("failed to build low-stock report")
+	}
+
+	for i := range items {
+		daily := float64(items[i].UnitsSold) / float64(salesWindowDays)
+		items[i].DailySales = math.Round(daily*100) / 100
+		if daily > 0 {
+			cover := math.Round(float64(max(items[i].Available, 0))/daily*10) / 10
+			items[i].DaysOfCover = &cover
+		}
+	}
+
+	return LowStockReport{SalesWindowDays: salesWindowDays, Items: items}, nil
+}
+
+// DeliverLowStockAlerts hands the alerts not yet delivered to the notifier.
+// It returns how many it delivered; an alert that fails is logged and left
+// for the next run.
+func (s *service) DeliverLowStockAlerts(ctx context.Context) (int, *errs.AppError) {
+	delivered := 0
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		alerts, err := s.repo.ListUndeliveredAlerts(ctx, alertDeliveryBatch)
+		if err != nil {
+			logger.Error("Failed to list low-stock alerts: %v", err)
+			return errs.ErrInternal.WithMessage("failed to deliver low-stock alerts")
+		}
+
+		for _, alert := range alerts {
+			if err := s.notifier.NotifyLowStock(ctx, alert); err != nil {
+				logger.Error("Failed to deliver low-stock alert %s for product %s: %v", alert.ID, alert.ProductID, err)
+				continue
+			}
+			if err := s.repo.MarkAlertDelivered(ctx, alert.ID); err != nil {
+				logger.Error("Failed to mark low-stock alert %s delivered: %v", alert.ID, err)
+				return errs.ErrInternal.WithMessage("failed to deliver low-stock alerts")
+			}
+			delivered++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errs.EnsureAppError(err)
+	}
+
+	return delivered, nil
+}
+
+// ImportStock checks every row of a stock import against the current stock
+// and reports what it would do. With confirm set and no row in error, the
+// whole file is applied in one transaction, each change recorded as a
+// movement; otherwise nothing changes. Rows are applied in file order, so
+// later rows for the same product and warehouse see the earlier ones.
+func (s *service) ImportStock(ctx context.Context, actorID string, rows []ImportRow, confirm bool) (ImportReport, *errs.AppError) {
+	report := ImportReport{DryRun: !confirm, Rows: len(rows), Lines: make([]ImportLine, len(rows))}
+	if len(rows) == 0 {
+		return ImportReport{}, errs.ErrBadRequest.WithMessage("file has no rows")
+	}
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		products, warehouses, appErr := s.importLookups(ctx, rows)
+		if appErr != nil {
+			return appErr
+		}
+
+		current := make(map[[2]string]Location)
+		warehouseIDs := make([]string, len(rows))
+		for i, row := range rows {
+			line := &report.Lines[i]
+			*line = ImportLine{Line: row.Line, SKU: row.SKU, Warehouse: row.Warehouse, Reason: row.Reason, Error: row.Error}
+			if line.Error != "" {
+				continue
+			}
+
+			product, ok := products[row.SKU]
+			if !ok {
+				line.Error = "unknown SKU"
+				continue
+			}
+			line.ProductID = product.ProductID
+			if !product.Tracked {
+				line.Error = "product is not stock-tracked"
+				continue
+			}
+
+			if line.Warehouse == "" {
+				line.Warehouse = warehouses[""].Code
+			}
+			w, ok := warehouses[line.Warehouse]
+			if !ok {
+				line.Error = "unknown warehouse"
+				continue
+			}
+			warehouseIDs[i] = w.ID.String()
+
+			key := [2]string{warehouseIDs[i], product.ProductID}
+			loc, ok := current[key]
+			if !ok {
+				var err error
+				loc, err = s.repo.LockLocation(ctx, warehouseIDs[i], product.ProductID)
+				if err != nil && !errors.Is(err, sql.ErrNoRows) {
+					logger.Error("Failed to lock stock of %s at %s: %v", product.ProductID, line.Warehouse, err)
+					return errs.ErrInternal.WithMessage("failed to import stock")
+				}
+			}
+
+			if line.Reason == "" {
+				line.Reason = MovementAdjustment
+			}
+			line.StockBefore = loc.Stock
+			line.Reserved = loc.Reserved
+			if row.Stock != nil {
+				line.Delta = *row.Stock - loc.Stock
+				if line.Reason != MovementAdjustment {
+					line.Error = "stock counts must use reason ADJUSTMENT"
+				}
+			} else {
+				line.Delta = *row.Delta
+				if line.Reason == MovementReturned {
+					// Returns are booked by the returns flow
+					line.Error = "invalid adjustment reason"
+				} else if appErr := validateAdjustment(line.Reason, line.Delta); appErr != nil {
+					line.Error = appErr.Message
+				}
+			}
+			line.StockAfter = loc.Stock + line.Delta
+
+			switch {
+			case line.Error != "":
+			case line.StockAfter < 0:
+				line.Error = "stock would be negative"
+			case line.StockAfter < loc.Reserved:
+				line.Error = fmt.Sprintf("stock would fall below the %d units reserved", loc.Reserved)
+			default:
+				loc.Stock = line.StockAfter
+				current[key] = loc
+			}
+		}
+
+		for _, line := range report.Lines {
+			if line.Error != "" {
+				report.Errors++
+			} else if line.Delta != 0 {
+				report.Changed++
+			}
+		}
+		if !confirm || report.Errors > 0 {
+			return nil
+		}
+
+		for i, line := range report.Lines {
+			if line.Delta == 0 {
+				continue
+			}
+			note := rows[i].Note
+			if note == "" {
+				note = fmt.Sprintf("Stock import, line %d", line.Line)
+			}
+
+			err := s.repo.EnsureLocation(ctx, warehouseIDs[i], line.ProductID)
+			if err == nil {
+				_, err = s.move(ctx, CreateMovementInput{
+					ProductID:   line.ProductID,
+					WarehouseID: warehouseIDs[i],
+					Delta:       line.Delta,
+					Reason:      line.Reason,
+					CreatedBy:   actorID,
+					Note:        note,
+				})
+			}
+			if err != nil {
+				logger.Error("Failed to import stock of %s at %s from line %d: %v", line.SKU, line.Warehouse, line.Line, err)
+				return errs.ErrInternal.WithMessage("failed to import stock")
+			}
+		}
+		report.Applied = true
+		return nil
+	})
+	if err != nil {
+		return ImportReport{}, errs.EnsureAppError(err)
+	}
+
+	return report, nil
+}
+
+// importLookups finds the products the import's SKUs name and the
+// warehouses by code; "" maps to the default warehouse when a row needs it
+func (s *service) importLookups(ctx context.Context, rows []ImportRow) (map[string]SKUProduct, map[string]warehouse.Warehouse, *errs.AppError) {
+	skus := make([]string, 0, len(rows))
+	needsDefault := false
+	for _, row := range rows {
+		if row.Error == "" {
+			skus = append(skus, row.SKU)
+			needsDefault = needsDefault || row.Warehouse == ""
+		}
+	}
+
+	found, err := s.repo.ListProductsBySku(ctx, skus)
+	if err != nil {
+		logger.Error("Failed to look up products for stock import: %v", err)
+		return nil, nil, errs.ErrInternal.WithMessage("failed to import stock")
+	}
+	products := make(map[string]SKUProduct, len(found))
+	for _, p := range found {
+		products[p.SKU] = p
+	}
+
+	list, appErr := s.warehouses.ListWarehouses(ctx)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+	warehouses := make(map[string]warehouse.Warehouse, len(list)+1)
+	for _, w := range list {
+		warehouses[w.Code] = w
+	}
+	if needsDefault {
+		if warehouses[""], appErr = s.warehouses.GetDefaultWarehouse(ctx); appErr != nil {
+			return nil, nil, appErr
+		}
+	}
+
+	return products, warehouses, nil
+}
+
+// ExportStockLevels returns every product's stock at every warehouse, by
+// SKU
+func (s *service) ExportStockLevels(ctx context.Context) ([]StockLevel, *errs.AppError) {
+	levels, err := s.repo.ListStockLevels(ctx)
+	if err != nil {
+		logger.Error("Failed to list stock levels: %v", err)
+		return nil, errs.ErrInternal.WithMessage("failed to export stock levels")
+	}
+
+	return levels, nil
+}
+
+// releaseHeld returns a reservation update that frees held units. With a
+// non-zero expiredBefore only reservations that expired by then are freed.
+func (s *service) releaseHeld(expiredBefore time.Time) func(ctx context.Context, res Reservation) (string, error) {
+	return func(ctx context.Context, res Reservation) (string, error) {
+		if res.Status != ReservationHeld {
+			return "", nil
+		}
+		if !expiredBefore.IsZero() && (res.ExpiresAt == nil || !res.ExpiresAt.Before(expiredBefore)) {
+			return "", nil
+		}
+		_, err := s.move(ctx, CreateMovementInput{
+			ProductID:     res.ProductID,
+			WarehouseID:   res.WarehouseID,
+			ReservedDelta: -res.Qty,
+			Reason:        MovementReleased,
+			OrderID:       res.OrderID,
+		})
+		return ReservationReleased, err
+	}
+}
+
+// updateReservations locks an order's reservations and applies fn to each;
+// fn moves the stock and returns the reservation's new status, or "" to
+// leave it alone. Stock removed since the reservation was made no longer
+// needs updating, so the reservation just changes status.
+func (s *service) updateReservations(ctx context.Context, orderID string, fn func(ctx context.Context, res Reservation) (string, error)) *errs.AppError {
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		reservations, err := s.repo.LockOrderReservations(ctx, orderID)
+		if err != nil {
+			logger.Error("Failed to lock reservations of order %s: %v", orderID, err)
+			return errs.ErrInternal.WithMessage("failed to update stock reservations")
+		}
+
+		for _, res := range reservations {
+			status, err := fn(ctx, res)
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				logger.Error("Failed to update %s reservation %s of order %s: %v", res.Status, res.ID, orderID, err)
+				return errs.ErrInternal.WithMessage("failed to update stock reservations")
+			}
+			if status == "" {
+				continue
+			}
+			if _, err := s.repo.UpdateReservationStatus(ctx, res.ID, status); err != nil {
+				logger.Error("Failed to update reservation %s of order %s: %v", res.ID, orderID, err)
+				return errs.ErrInternal.WithMessage("failed to update stock reservations")
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errs.EnsureAppError(err)
+	}
+
+	return nil
+}
+
+// move applies a movement at one warehouse: the product's stock there and
+// its totals move by the movement's deltas, and the movement is appended
+// to the ledger. It returns sql.ErrNoRows when the warehouse does not
+// track the product, and the CHECK violation when the move would leave
+// stock below zero or below what is reserved.
+func (s *service) move(ctx context.Context, in CreateMovementInput) (Location, error) {
+	loc, err := s.repo.MoveLocation(ctx, in.WarehouseID, in.ProductID, in.Delta, in.ReservedDelta)
+	if err != nil {
+		return Location{}, err
+	}
+	inv, err := s.repo.MoveInventory(ctx, in.ProductID, in.Delta, in.ReservedDelta)
+	if err != nil {
+		return Location{}, err
+	}
+	if change := in.Delta - in.ReservedDelta; change < 0 {
+		if err := s.checkLowStock(ctx, inv, change); err != nil {
+			return Location{}, err
+		}
+	}
+
+	in.StockAfter = loc.Stock
+	in.ReservedAfter = loc.Reserved
+	if _, err := s.repo.CreateMovement(ctx, in); err != nil {
+		logger.Error("Failed to record %s stock movement for product %s: %v", in.Reason, in.ProductID, err)
+		return Location{}, err
+	}
+
+	return loc, nil
+}
+
+// checkLowStock records a low-stock alert when a move that changed the
+// product's available stock by change took it from above its threshold to
+// at or below it
+func (s *service) checkLowStock(ctx context.Context, inv Inventory, change int32) error {
+	threshold, err := s.repo.GetEffectiveThreshold(ctx, inv.ProductID)
+	if err != nil {
+		return err
+	}
+	if threshold.LowStockThreshold == nil {
+		return nil
+	}
+
+	limit := *threshold.LowStockThreshold
+	after := inv.Stock - inv.Reserved
+	if before := after - change; before <= limit || after > limit {
+		return nil
+	}
+
+	if err := s.repo.CreateLowStockAlert(ctx, inv.ProductID, after, limit, threshold.ReorderQty); err != nil {
+		logger.Error("Failed to record low-stock alert for product %s: %v", inv.ProductID, err)
+		return err
+	}
+	return nil
+}
+
+// resolveWarehouse returns the ID of the named warehouse, or of the
+// default warehouse when none is named
+func (s *service) resolveWarehouse(ctx context.Context, warehouseID string) (string, *errs.AppError) {
+	if warehouseID != "" {
+		w, appErr := s.warehouses.GetWarehouse(ctx, warehouseID)
+		if appErr != nil {
+			return "", appErr
+		}
+		return w.ID.String(), nil
+	}
+
+	w, appErr := s.warehouses.GetDefaultWarehouse(ctx)
+	if appErr != nil {
+		return "", appErr
+	}
+	return w.ID.String(), nil
+}
+
+// locate resolves the warehouse stock of a tracked product is booked at and
+// makes sure the warehouse tracks the product
+func (s *service) locate(ctx context.Context, productID, warehouseID string) (string, *errs.AppError) {
+	if _, err := s.repo.GetInventoryByProductID(ctx, productID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", errs.ErrNotFound.WithMessage("inventory not found for product")
+		}
+		return "", errs.ErrInternal.WithMessage("failed to get inventory")
+	}
+
+	warehouseID, appErr := s.resolveWarehouse(ctx, warehouseID)
+	if appErr != nil {
+		return "", appErr
+	}
+
+	if err := s.repo.EnsureLocation(ctx, warehouseID, productID); err != nil {
+		return "", errs.ErrInternal.WithMessage("failed to get inventory")
+	}
+
+	return warehouseID, nil
+}
+
+// allocatedWarehouse returns the warehouse an order's line for the product
+// was served from, or "" when it was not allocated
+func (s *service) allocatedWarehouse(ctx context.Context, orderID, productID string) (string, *errs.AppError) {
+	allocations, err := s.repo.ListOrderAllocations(ctx, orderID)
+	if err != nil {
+		logger.Error("Failed to list stock allocations of order %s: %v", orderID, err)
+		return "", errs.ErrInternal.WithMessage("failed to adjust inventory")
+	}
+
+	for _, a := range allocations {
+		if a.ProductID == productID {
+			return a.WarehouseID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// validateAdjustment checks that a stock adjustment moves stock the way its
+// reason says: goods received or returned come in, damaged goods go out.
+// Reservations and sales are recorded by their own flows.
+func validateAdjustment(reason string, delta int32) *errs.AppError {
+	if delta == 0 {
+		return errs.ErrBadRequest.WithMessage("delta must not be zero")
+	}
+
+	switch reason {
+	case MovementReceived, MovementReturned:
+		if delta < 0 {
+			return errs.ErrBadRequest.WithMessage(reason + " adjustments must add stock")
+		}
+	case MovementDamaged:
+		if delta > 0 {
+			return errs.ErrBadRequest.WithMessage("DAMAGED adjustments must remove stock")
+		}
+	case MovementAdjustment:
+	default:
+		return errs.ErrBadRequest.WithMessage("invalid adjustment reason")
+	}
+
+	return nil
+}
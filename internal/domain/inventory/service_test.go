@@ -0,0 +1,433 @@
+package inventory
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"ecommerce-app/internal/pkg/errs"
+)
+
+const (
+	testProduct   = "product-1"
+	testWarehouse = "warehouse-1"
+)
+
+type stockTxKey struct{}
+
+// stockTx is the row locks one fake transaction holds until it ends
+type stockTx struct {
+	held map[string]bool
+	keys []string
+}
+
+// stockRepo keeps locations and reservations in memory and emulates the
+// row locks of SELECT ... FOR UPDATE: a locked row stays locked until the
+// WithTx that locked it returns. Only the methods reservations reach are
+// implemented; the embedded interface panics on anything else.
+type stockRepo struct {
+	Repository
+	mu           sync.Mutex // guards the fields below
+	rowLocks     map[string]*sync.Mutex
+	locations    map[string]*Location
+	reservations []Reservation
+}
+
+func newStockRepo(stock int32) *stockRepo {
+	return &stockRepo{
+		rowLocks: map[string]*sync.Mutex{},
+		locations: map[string]*Location{
+			testWarehouse: {WarehouseID: testWarehouse, ProductID: testProduct, Stock: stock},
+		},
+	}
+}
+
+func (r *stockRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(stockTxKey{}).(*stockTx); ok {
+		return fn(ctx)
+	}
+
+	tx := &stockTx{held: map[string]bool{}}
+	defer func() {
+		for _, key := range tx.keys {
+			r.rowLock(key).Unlock()
+		}
+	}()
+	return fn(context.WithValue(ctx, stockTxKey{}, tx))
+}
+
+func (r *stockRepo) rowLock(key string) *sync.Mutex {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.rowLocks[key] == nil {
+		r.rowLocks[key] = &sync.Mutex{}
+	}
+	return r.rowLocks[key]
+}
+
+func (r *stockRepo) lock(ctx context.Context, key string) {
+	tx := ctx.Value(stockTxKey{}).(*stockTx)
+	if tx.held[key] {
+		return
+	}
+	r.rowLock(key).Lock()
+	tx.held[key] = true
+	tx.keys = append(tx.keys, key)
+}
+
+func (r *stockRepo) LockLocations(ctx context.Context, productID, country string) ([]LocationCandidate, error) {
+	r.mu.Lock()
+	warehouses := make([]string, 0, len(r.locations))
+	for id := range r.locations {
+		warehouses = append(warehouses, id)
+	}
+	r.mu.Unlock()
+	sort.Strings(warehouses)
+
+	candidates := make([]LocationCandidate, 0, len(warehouses))
+	for _, id := range warehouses {
+		loc, err := r.LockLocation(ctx, id, productID)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, LocationCandidate{Location: loc, Country: country, IsActive: true})
+	}
+	return candidates, nil
+}
+
+func (r *stockRepo) LockLocation(ctx context.Context, warehouseID, productID string) (Location, error) {
+	r.lock(ctx, "location/"+warehouseID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	loc, ok := r.locations[warehouseID]
+	if !ok {
+		return Location{}, sql.ErrNoRows
+	}
+	return *loc, nil
+}
+
+// MoveLocation refuses moves that leave the location short, as the
+// inventory_locations CHECK constraints do
+func (r *stockRepo) MoveLocation(ctx context.Context, warehouseID, productID string, delta, reservedDelta int32) (Location, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	loc := r.locations[warehouseID]
+	stock, reserved := loc.Stock+delta, loc.Reserved+reservedDelta
+	if stock < 0 || reserved < 0 || reserved > stock {
+		return Location{}, fmt.Errorf("location %s would hold %d with %d reserved", warehouseID, stock, reserved)
+	}
+	loc.Stock, loc.Reserved = stock, reserved
+	return *loc, nil
+}
+
+func (r *stockRepo) MoveInventory(ctx context.Context, productID string, delta, reservedDelta int32) (Inventory, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	inv := Inventory{ProductID: productID}
+	for _, loc := range r.locations {
+		inv.Stock += loc.Stock
+		inv.Reserved += loc.Reserved
+	}
+	return inv, nil
+}
+
+func (r *stockRepo) GetEffectiveThreshold(ctx context.Context, productID string) (StockThreshold, error) {
+	return StockThreshold{}, nil
+}
+
+func (r *stockRepo) CreateMovement(ctx context.Context, in CreateMovementInput) (Movement, error) {
+	return Movement{ProductID: in.ProductID, WarehouseID: in.WarehouseID, Reason: in.Reason}, nil
+}
+
+func (r *stockRepo) CreateReservation(ctx context.Context, in CreateReservationInput) (Reservation, error) {
+	return r.addReservation(in.OrderID, in.Qty, ReservationHeld), nil
+}
+
+func (r *stockRepo) addReservation(orderID string, qty int32, status string) Reservation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	res := Reservation{
+		ID:          fmt.Sprintf("reservation-%d", len(r.reservations)+1),
+		OrderID:     orderID,
+		ProductID:   testProduct,
+		WarehouseID: testWarehouse,
+		Qty:         qty,
+		Status:      status,
+	}
+	r.reservations = append(r.reservations, res)
+	return res
+}
+
+func (r *stockRepo) LockOrderReservations(ctx context.Context, orderID string) ([]Reservation, error) {
+	r.lock(ctx, "order/"+orderID)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var reservations []Reservation
+	for _, res := range r.reservations {
+		if res.OrderID == orderID {
+			reservations = append(reservations, res)
+		}
+	}
+	return reservations, nil
+}
+
+func (r *stockRepo) UpdateReservationStatus(ctx context.Context, id, status string) (Reservation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, res := range r.reservations {
+		if res.ID == id {
+			r.reservations[i].Status = status
+			return r.reservations[i], nil
+		}
+	}
+	return Reservation{}, sql.ErrNoRows
+}
+
+func (r *stockRepo) statuses() map[string]int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	counts := map[string]int{}
+	for _, res := range r.reservations {
+		counts[res.Status]++
+	}
+	return counts
+}
+
+func TestReserveLastUnitConcurrently(t *testing.T) {
+	repo := newStockRepo(1)
+	svc := NewService(repo, nil, nil, AllocationPriority)
+
+	const orders = 8
+	results := make([]*errs.AppError, orders)
+	var wg sync.WaitGroup
+	for i := range orders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			line := ReservationLine{OrderItemID: fmt.Sprintf("item-%d", i), ProductID: testProduct, Name: "Last one", Qty: 1}
+			results[i] = svc.ReserveStock(context.Background(), fmt.Sprintf("order-%d", i), "US", []ReservationLine{line}, nil)
+		}()
+	}
+	wg.Wait()
+
+	reserved := 0
+	for i, appErr := range results {
+		switch {
+		case appErr == nil:
+			reserved++
+		case appErr.Code != http.StatusConflict:
+			t.Errorf("order-%d: got %d %q, want 409", i, appErr.Code, appErr.Message)
+		}
+	}
+	if reserved != 1 {
+		t.Errorf("%d orders reserved the last unit, want 1", reserved)
+	}
+	if loc := repo.locations[testWarehouse]; loc.Stock != 1 || loc.Reserved != 1 {
+		t.Errorf("location holds %d with %d reserved, want 1 with 1", loc.Stock, loc.Reserved)
+	}
+}
+
+func TestCommitReleasedLastUnitConcurrently(t *testing.T) {
+	repo := newStockRepo(1)
+	svc := NewService(repo, nil, nil, AllocationPriority)
+
+	const orders = 8
+	for i := range orders {
+		repo.addReservation(fmt.Sprintf("order-%d", i), 1, ReservationReleased)
+	}
+
+	var wg sync.WaitGroup
+	for i := range orders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if appErr := svc.CommitReservations(context.Background(), fmt.Sprintf("order-%d", i)); appErr != nil {
+				t.Errorf("order-%d: %s", i, appErr.Message)
+			}
+		}()
+	}
+	wg.Wait()
+
+	statuses := repo.statuses()
+	if statuses[ReservationCommitted] != 1 || statuses[ReservationOversold] != orders-1 {
+		t.Errorf("got %v, want 1 %s and %d %s", statuses, ReservationCommitted, orders-1, ReservationOversold)
+	}
+	if loc := repo.locations[testWarehouse]; loc.Stock != 0 || loc.Reserved != 0 {
+		t.Errorf("location holds %d with %d reserved, want 0 with 0", loc.Stock, loc.Reserved)
+	}
+}
+
+func TestOversoldReservations(t *testing.T) {
+	tests := []struct {
+		name         string
+		cancel       bool
+		wantStatus   string
+		wantStock    int32
+		wantReserved int32
+	}{
+		{name: "paid after another order held the last unit", wantStatus: ReservationOversold, wantStock: 1, wantReserved: 1},
+		{name: "oversold order cancelled", cancel: true, wantStatus: ReservationReleased, wantStock: 1, wantReserved: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newStockRepo(1)
+			svc := NewService(repo, nil, nil, AllocationPriority)
+			late := repo.addReservation("late", 1, ReservationReleased)
+			if appErr := svc.ReserveStock(context.Background(), "early", "US", []ReservationLine{{OrderItemID: "item", ProductID: testProduct, Qty: 1}}, nil); appErr != nil {
+				t.Fatalf("reserve: %s", appErr.Message)
+			}
+
+			if appErr := svc.CommitReservations(context.Background(), "late"); appErr != nil {
+				t.Fatalf("commit: %s", appErr.Message)
+			}
+			if tt.cancel {
+				if appErr := svc.CancelReservations(context.Background(), "late"); appErr != nil {
+					t.Fatalf("cancel: %s", appErr.Message)
+				}
+			}
+
+			for _, res := range repo.reservations {
+				if res.ID == late.ID && res.Status != tt.wantStatus {
+					t.Errorf("late reservation is %s, want %s", res.Status, tt.wantStatus)
+				}
+				if res.OrderID == "early" && res.Status != ReservationHeld {
+					t.Errorf("early reservation is %s, want %s", res.Status, ReservationHeld)
+				}
+			}
+			if loc := repo.locations[testWarehouse]; loc.Stock != tt.wantStock || loc.Reserved != tt.wantReserved {
+				t.Errorf("location holds %d with %d reserved, want %d with %d", loc.Stock, loc.Reserved, tt.wantStock, tt.wantReserved)
+			}
+		})
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	warehouse := func(code, country string, priority, stock, reserved int32, sameZone bool) LocationCandidate {
+		return LocationCandidate{
+			Location: Location{WarehouseID: code, WarehouseCode: code, ProductID: testProduct, Stock: stock, Reserved: reserved},
+			Country:  country,
+			Priority: priority,
+			IsActive: true,
+			SameZone: sameZone,
+		}
+	}
+	inactive := warehouse("OLD", "US", 0, 100, 0, false)
+	inactive.IsActive = false
+
+	tests := []struct {
+		name       string
+		strategy   string
+		candidates []LocationCandidate
+		country    string
+		qty        int32
+		want       []string
+	}{
+		{
+			name:       "highest priority warehouse with the whole line",
+			strategy:   AllocationPriority,
+			candidates: []LocationCandidate{warehouse("B", "US", 2, 10, 0, false), warehouse("A", "US", 1, 10, 0, false)},
+			qty:        5,
+			want:       []string{"A:5"},
+		},
+		{
+			name:       "one parcel preferred over priority",
+			strategy:   AllocationPriority,
+			candidates: []LocationCandidate{warehouse("A", "US", 1, 3, 0, false), warehouse("B", "US", 2, 10, 0, false)},
+			qty:        5,
+			want:       []string{"B:5"},
+		},
+		{
+			name:       "split in priority order when no warehouse has it all",
+			strategy:   AllocationPriority,
+			candidates: []LocationCandidate{warehouse("C", "US", 3, 4, 0, false), warehouse("A", "US", 1, 3, 1, false), warehouse("B", "US", 2, 2, 0, false)},
+			qty:        6,
+			want:       []string{"A:2", "B:2", "C:2"},
+		},
+		{
+			name:       "code breaks priority ties",
+			strategy:   AllocationPriority,
+			candidates: []LocationCandidate{warehouse("B", "US", 1, 10, 0, false), warehouse("A", "US", 1, 10, 0, false)},
+			qty:        1,
+			want:       []string{"A:1"},
+		},
+		{
+			name:       "inactive and fully reserved warehouses skipped",
+			strategy:   AllocationPriority,
+			candidates: []LocationCandidate{inactive, warehouse("A", "US", 1, 5, 5, false), warehouse("B", "US", 2, 5, 0, false)},
+			qty:        5,
+			want:       []string{"B:5"},
+		},
+		{
+			name:       "short across all warehouses",
+			strategy:   AllocationPriority,
+			candidates: []LocationCandidate{warehouse("A", "US", 1, 2, 0, false), warehouse("B", "US", 2, 2, 1, false), inactive},
+			qty:        4,
+			want:       nil,
+		},
+		{
+			name:       "closest prefers the destination country",
+			strategy:   AllocationClosest,
+			candidates: []LocationCandidate{warehouse("US", "US", 1, 10, 0, false), warehouse("ZONE", "FR", 2, 10, 0, true), warehouse("DE", "DE", 3, 10, 0, true)},
+			country:    "DE",
+			qty:        5,
+			want:       []string{"DE:5"},
+		},
+		{
+			name:       "closest falls back to the shipping zone",
+			strategy:   AllocationClosest,
+			candidates: []LocationCandidate{warehouse("US", "US", 1, 10, 0, false), warehouse("FR", "FR", 2, 10, 0, true)},
+			country:    "DE",
+			qty:        5,
+			want:       []string{"FR:5"},
+		},
+		{
+			name:       "closest splits nearest first",
+			strategy:   AllocationClosest,
+			candidates: []LocationCandidate{warehouse("US", "US", 1, 4, 0, false), warehouse("FR", "FR", 2, 3, 0, true), warehouse("DE", "DE", 3, 2, 0, false)},
+			country:    "DE",
+			qty:        7,
+			want:       []string{"DE:2", "FR:3", "US:2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &service{strategy: tt.strategy}
+			var got []string
+			for _, a := range svc.allocate(tt.candidates, tt.country, tt.qty) {
+				got = append(got, fmt.Sprintf("%s:%d", a.WarehouseCode, a.Qty))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allocate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistanceRank(t *testing.T) {
+	tests := []struct {
+		name      string
+		candidate LocationCandidate
+		want      int
+	}{
+		{name: "in the country", candidate: LocationCandidate{Country: "DE", SameZone: true}, want: 0},
+		{name: "in the shipping zone", candidate: LocationCandidate{Country: "FR", SameZone: true}, want: 1},
+		{name: "anywhere else", candidate: LocationCandidate{Country: "US"}, want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := distanceRank(tt.candidate, "DE"); got != tt.want {
+				t.Errorf("distanceRank = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
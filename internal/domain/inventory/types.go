@@ -1,12 +1,251 @@
 package inventory
 
-import "time"
+import (
+	"context"
+	"ecommerce-app/internal/domain/warehouse"
+	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/response"
+	"time"
+)
 
+// Inventory is a product's stock across all warehouses; Locations breaks
+// it down per warehouse when requested. LowStockThreshold and ReorderQty
+// are the product's own settings; unset, its category's apply.
 type Inventory struct {
-	ProductID string `json:"product_id"`
-	Stock     int32  `json:"stock"`
-	Reserved  int32  `json:"reserved"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ProductID         string     `json:"product_id"`
+	Stock             int32      `json:"stock"`
+	Reserved          int32      `json:"reserved"`
+	LowStockThreshold *int32     `json:"low_stock_threshold,omitempty"`
+	ReorderQty        *int32     `json:"reorder_qty,omitempty"`
+	Locations         []Location `json:"locations,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
 }
 
+// Location is a product's stock at one warehouse
+type Location struct {
+	WarehouseID   string    `json:"warehouse_id"`
+	WarehouseCode string    `json:"warehouse_code,omitempty"`
+	WarehouseName string    `json:"warehouse_name,omitempty"`
+	ProductID     string    `json:"product_id"`
+	Stock         int32     `json:"stock"`
+	Reserved      int32     `json:"reserved"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Allocation strategies, chosen by INVENTORY_ALLOCATION_STRATEGY. Priority
+// allocates from warehouses by their priority; closest prefers warehouses
+// in the destination country, then ones sharing a shipping zone with it,
+// and uses priority to break ties.
+const (
+	AllocationPriority = "priority"
+	AllocationClosest  = "closest"
+)
+
+// Reservation statuses, matching the inventory_reservations.status CHECK
+// constraint. HELD units count towards Inventory.Reserved; COMMITTED ones
+// have left stock with a paid order. An OVERSOLD reservation belongs to an
+// order paid after its units were released and sold to someone else; it
+// took nothing from stock and waits for an admin to restock or refund it.
+const (
+	ReservationHeld      = "HELD"
+	ReservationCommitted = "COMMITTED"
+	ReservationReleased  = "RELEASED"
+	ReservationOversold  = "OVERSOLD"
+)
+
+// Reservation is the stock one order line holds at one warehouse. A line
+// served from several warehouses has one reservation for each.
+type Reservation struct {
+	ID          string     `json:"id"`
+	OrderID     string     `json:"order_id"`
+	OrderItemID string     `json:"order_item_id"`
+	ProductID   string     `json:"product_id"`
+	WarehouseID string     `json:"warehouse_id"`
+	Qty         int32      `json:"qty"`
+	Status      string     `json:"status"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+
+// Movement reasons, matching the stock_movements.reason CHECK constraint.
+// RESERVED and RELEASED only move Inventory.Reserved; SOLD takes units out
+// of both stock and reserved.
+const (
+	MovementReceived   = "RECEIVED"
+	MovementSold       = "SOLD"
+	MovementReturned   = "RETURNED"
+	MovementAdjustment = "ADJUSTMENT"
+	MovementDamaged    = "DAMAGED"
+	MovementReserved   = "RESERVED"
+	MovementReleased   = "RELEASED"
+	MovementCancelled  = "CANCELLED"
+)
+
+// Movement is one entry in a product's stock ledger at a warehouse. Delta
+// and ReservedDelta are signed; StockAfter and ReservedAfter are the
+// warehouse's stock once it was applied.
+type Movement struct {
+	ID            string    `json:"id"`
+	ProductID     string    `json:"product_id"`
+	WarehouseID   string    `json:"warehouse_id"`
+	Delta         int32     `json:"delta"`
+	ReservedDelta int32     `json:"reserved_delta"`
+	StockAfter    int32     `json:"stock_after"`
+	ReservedAfter int32     `json:"reserved_after"`
+	Reason        string    `json:"reason"`
+	OrderID       string    `json:"order_id,omitempty"`
+	ReturnID      string    `json:"return_id,omitempty"`
+	CreatedBy     string    `json:"created_by,omitempty"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type MovementsWithMeta struct {
+	Movements []Movement    `json:"movements"`
+	Meta      response.Meta `json:"meta"`
+}
+
+// LedgerMismatch is a product's stock at a warehouse that differs from the
+// sum of its movements there
+type LedgerMismatch struct {
+	ProductID      string `json:"product_id"`
+	WarehouseID    string `json:"warehouse_id"`
+	Stock          int32  `json:"stock"`
+	Reserved       int32  `json:"reserved"`
+	LedgerStock    int64  `json:"ledger_stock"`
+	LedgerReserved int64  `json:"ledger_reserved"`
+}
+
+// TotalMismatch is a product whose inventory totals differ from the sum of
+// its stock at each warehouse
+type TotalMismatch struct {
+	ProductID         string `json:"product_id"`
+	Stock             int32  `json:"stock"`
+	Reserved          int32  `json:"reserved"`
+	WarehouseStock    int64  `json:"warehouse_stock"`
+	WarehouseReserved int64  `json:"warehouse_reserved"`
+}
+
+// LedgerReport is the result of checking every product's stock against its
+// movements and its totals against its warehouses
+type LedgerReport struct {
+	Checked         int64            `json:"checked"`
+	Mismatches      []LedgerMismatch `json:"mismatches"`
+	TotalMismatches []TotalMismatch  `json:"total_mismatches"`
+}
+
+// Allocation is the part of an order line a warehouse serves
+type Allocation struct {
+	OrderItemID   string `json:"order_item_id"`
+	ProductID     string `json:"product_id"`
+	WarehouseID   string `json:"warehouse_id"`
+	WarehouseCode string `json:"warehouse_code"`
+	Qty           int32  `json:"qty"`
+	Status        string `json:"status"`
+}
+
+// CategoryThreshold is the low-stock threshold and reorder quantity of the
+// products in a category that have none of their own
+type CategoryThreshold struct {
+	CategoryID        string    `json:"category_id"`
+	LowStockThreshold int32     `json:"low_stock_threshold"`
+	ReorderQty        *int32    `json:"reorder_qty,omitempty"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// StockThreshold is the low-stock threshold and reorder quantity that apply
+// to a product; a nil LowStockThreshold means it is not watched
+type StockThreshold struct {
+	LowStockThreshold *int32
+	ReorderQty        *int32
+}
+
+// LowStockItem is a product at or below its low-stock threshold. DailySales
+// averages the units sold over the report's window; DaysOfCover is how long
+// the available stock lasts at that rate, nil when nothing sold.
+type LowStockItem struct {
+	ProductID         string   `json:"product_id"`
+	SKU               string   `json:"sku"`
+	Name              string   `json:"name"`
+	Stock             int32    `json:"stock"`
+	Reserved          int32    `json:"reserved"`
+	Available         int32    `json:"available"`
+	LowStockThreshold int32    `json:"low_stock_threshold"`
+	ReorderQty        *int32   `json:"reorder_qty,omitempty"`
+	UnitsSold         int64    `json:"units_sold"`
+	DailySales        float64  `json:"daily_sales"`
+	DaysOfCover       *float64 `json:"days_of_cover,omitempty"`
+}
+
+// LowStockReport lists the products at or below their low-stock threshold
+type LowStockReport struct {
+	SalesWindowDays int            `json:"sales_window_days"`
+	Items           []LowStockItem `json:"items"`
+}
+
+// LowStockAlert records a product's available stock dropping to its
+// low-stock threshold, waiting to be delivered to the admins
+type LowStockAlert struct {
+	ID                string    `json:"id"`
+	ProductID         string    `json:"product_id"`
+	SKU               string    `json:"sku"`
+	Name              string    `json:"name"`
+	Available         int32     `json:"available"`
+	LowStockThreshold int32     `json:"low_stock_threshold"`
+	ReorderQty        *int32    `json:"reorder_qty,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ImportLine is what a stock import does, or would do, with one CSV row.
+// Error explains why the row cannot be applied; a file with any such row
+// is not applied at all.
+type ImportLine struct {
+	Line        int    `json:"line"`
+	SKU         string `json:"sku"`
+	ProductID   string `json:"product_id,omitempty"`
+	Warehouse   string `json:"warehouse,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+	StockBefore int32  `json:"stock_before"`
+	Delta       int32  `json:"delta"`
+	StockAfter  int32  `json:"stock_after"`
+	Reserved    int32  `json:"reserved"`
+	Error       string `json:"error,omitempty"`
+}
+
+// ImportReport is the outcome of a stock import. A dry run reports what
+// confirming the same file would do without changing anything.
+type ImportReport struct {
+	DryRun  bool         `json:"dry_run"`
+	Applied bool         `json:"applied"`
+	Rows    int          `json:"rows"`
+	Changed int          `json:"changed"`
+	Errors  int          `json:"errors"`
+	Lines   []ImportLine `json:"lines"`
+}
+
+// StockLevel is a product's stock at one warehouse, as exported
+type StockLevel struct {
+	SKU           string
+	Name          string
+	WarehouseCode string
+	Stock         int32
+	Reserved      int32
+}
+
+// Dependency Injection Interfaces
+
+// WarehouseProvider resolves the warehouse stock is booked at
+type WarehouseProvider interface {
+	GetWarehouse(ctx context.Context, id string) (warehouse.Warehouse, *errs.AppError)
+	GetDefaultWarehouse(ctx context.Context) (warehouse.Warehouse, *errs.AppError)
+	ListWarehouses(ctx context.Context) ([]warehouse.Warehouse, *errs.AppError)
+}
+
+// AlertNotifier delivers low-stock alerts to the admins. An alert whose
+// delivery fails is retried on the next run.
+type AlertNotifier interface {
+	NotifyLowStock(ctx context.Context, alert LowStockAlert) error
+}
@@ -1,9 +1,19 @@
 package order
 
+import "time"
+
 // --- DTOs ---
 type CreateOrderRequest struct {
 	Items        []CreateOrderItem `json:"items" validate:"required,min=1,dive"`
 	ShippingInfo interface{}       `json:"shipping_info" validate:"required"`
+	ShippingMethodID string        `json:"shipping_method_id,omitempty" validate:"omitempty,uuid4"`
+	CouponCode   string            `json:"coupon_code,omitempty" validate:"omitempty,alphanum,max=20"`
+	UseWallet    bool              `json:"use_wallet,omitempty"`
+	GiftCardCodes []string         `json:"gift_card_codes,omitempty" validate:"omitempty,max=5,dive,required,max=32"`
+	PaymentMethod string           `json:"payment_method,omitempty" validate:"omitempty,oneof=CREDIT_CARD BANK_TRANSFER CASH_ON_DELIVERY"`
+	CheckoutMode string            `json:"checkout_mode,omitempty" validate:"omitempty,oneof=payment_intent checkout_session"`
+	SuccessURL   string            `json:"success_url,omitempty" validate:"omitempty,url,max=2048"`
+	CancelURL    string            `json:"cancel_url,omitempty" validate:"omitempty,url,max=2048"`
 	Notes        string            `json:"notes,omitempty"`
 }
 
@@ -12,8 +22,48 @@ type CreateOrderItem struct {
 	Quantity  int     `json:"quantity" validate:"required,min=1"`
 }
 
+type CheckoutRequest struct {
+	ShippingInfo interface{} `json:"shipping_info" validate:"required"`
+	ShippingMethodID string  `json:"shipping_method_id" validate:"required,uuid4"`
+	CouponCode   string      `json:"coupon_code,omitempty" validate:"omitempty,alphanum,max=20"`
+	UseWallet    bool        `json:"use_wallet,omitempty"`
+	GiftCardCodes []string   `json:"gift_card_codes,omitempty" validate:"omitempty,max=5,dive,required,max=32"`
+	PaymentMethod string     `json:"payment_method,omitempty" validate:"omitempty,oneof=CREDIT_CARD BANK_TRANSFER CASH_ON_DELIVERY"`
+	CheckoutMode string      `json:"checkout_mode,omitempty" validate:"omitempty,oneof=payment_intent checkout_session"`
+	SuccessURL   string      `json:"success_url,omitempty" validate:"omitempty,url,max=2048"`
+	CancelURL    string      `json:"cancel_url,omitempty" validate:"omitempty,url,max=2048"`
+	Notes        string      `json:"notes,omitempty"`
+}
+
+type CheckoutSummaryRequest struct {
+	ShippingInfo interface{} `json:"shipping_info" validate:"required"`
+	ShippingMethodID string  `json:"shipping_method_id,omitempty" validate:"omitempty,uuid4"`
+	CouponCode   string      `json:"coupon_code,omitempty" validate:"omitempty,alphanum,max=20"`
+	UseWallet    bool        `json:"use_wallet,omitempty"`
+	GiftCardCodes []string   `json:"gift_card_codes,omitempty" validate:"omitempty,max=5,dive,required,max=32"`
+}
+
+// OrderFilter narrows the admin order listing. Zero values are ignored;
+// the *To bounds are exclusive.
+type OrderFilter struct {
+	Statuses          []string
+	UserID            string
+	Email             string
+	OrderNumberPrefix string
+	CreatedFrom       *time.Time
+	CreatedTo         *time.Time
+	PaidFrom          *time.Time
+	PaidTo            *time.Time
+	MinTotalCents     *int64
+	MaxTotalCents     *int64
+}
+
+// UpdateOrderStatusRequest is an admin's manual status change. Orders are
+// cancelled and refunded through their own endpoints, which also void or
+// refund the payment.
 type UpdateOrderStatusRequest struct {
-	Status string `json:"status" validate:"required,oneof=PENDING PAID SHIPPED CANCELLED"`
+	Status string `json:"status" validate:"required,oneof=PENDING PAID PROCESSING SHIPPED"`
+	Reason string `json:"reason,omitempty" validate:"omitempty,max=500"`
 }
 
 
@@ -38,8 +88,53 @@ type CreateOrderRequestInput struct {
 	Currency      string                 `json:"currency" validate:"required,len=3"`
 	Status        string                 `json:"status" validate:"required,oneof=CREATED PAID SHIPPED CANCELLED"`
 	ShippingInfo interface{}             `json:"shipping_info" validate:"required"`
+	ShippingMethodID   string            `json:"shipping_method_id,omitempty"`
+	ShippingMethodName string            `json:"shipping_method_name,omitempty"`
 	Notes        string                  `json:"notes,omitempty"`
-	Items        []CreateOrderItem     `json:"items" validate:"required,min=1,dive"`
+	Items        []CreateOrderItemInput `json:"items" validate:"required,min=1,dive"`
+}
+
+type CreateTaxLineInput struct {
+	OrderItemID  string
+	TaxRateID    string
+	Jurisdiction string
+	TaxCategory  string
+	RateBps      int32
+	IsInclusive  bool
+	TaxableCents int64
+	TaxCents     int64
+}
+
+type CreateAdjustmentInput struct {
+	OrderItemID string
+	Kind        string
+	Code        string
+	Description string
+	AmountCents int64
+}
+
+// CreateRefundInput opens a PENDING refund in the ledger before the
+// provider is asked to refund it.
+type CreateRefundInput struct {
+	PaymentID   string
+	OrderID     string
+	Provider    string
+	AmountCents int64
+	Currency    string
+	Reason      string
+	CreatedBy   string
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
+type StatusHistoryInput struct {
+	OrderID    string `json:"order_id" validate:"required,uuid4"`
+	FromStatus string `json:"from_status" validate:"required"`
+	ToStatus   string `json:"to_status" validate:"required"`
+	ChangedBy  string `json:"changed_by,omitempty"`
+	Reason     string `json:"reason,omitempty"`
 }
 
 type CreateOrderPaymentInput struct {
@@ -48,6 +143,17 @@ type CreateOrderPaymentInput struct {
 	ProviderTxnID string `json:"provider_txn_id,omitempty"`
 	AmountCents   int64  `json:"amount_cents" validate:"required,min=0"`
 	Currency      string `json:"currency" validate:"required,len=3"`
-	PaymentMethod string `json:"payment_method" validate:"required,oneof=CREDIT_CARD PAYPAL BANK_TRANSFER STRIPE APPLE_PAY GOOGLE_PAY"`
+	PaymentMethod string `json:"payment_method" validate:"required,oneof=CREDIT_CARD PAYPAL BANK_TRANSFER STRIPE APPLE_PAY GOOGLE_PAY CASH_ON_DELIVERY"`
 	Status        string `json:"status" validate:"required,oneof=INITIATED COMPLETED FAILED"`
+	CaptureMethod string `json:"capture_method,omitempty"`
+	Reference     string `json:"reference,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	CheckoutSessionID string `json:"checkout_session_id,omitempty"`
+}
+
+// UnshippedItem is a quantity of an order line that will not ship, left out
+// when an authorized payment is captured.
+type UnshippedItem struct {
+	OrderItemID string `json:"order_item_id" validate:"required,uuid4"`
+	Qty         int32  `json:"qty" validate:"required,gt=0"`
 }
@@ -2,13 +2,19 @@
 package order
 
 import (
+	"ecommerce-app/internal/pkg/httputil"
 	"ecommerce-app/internal/pkg/middleware"
 	"ecommerce-app/internal/pkg/response"
 	"ecommerce-app/internal/pkg/validator"
 	"ecommerce-app/pkg/pagination"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
 )
 
 type Handler struct {
@@ -32,6 +38,32 @@ func (h *Handler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	response.Created(w, res, "Order created successfully")
 }
 
+func (h *Handler) Checkout(w http.ResponseWriter, r *http.Request) {
+	req := validator.GetValidatedBody[CheckoutRequest](r)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	res, appErr := h.svc.Checkout(r.Context(), userID, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, res, "Order created successfully")
+}
+
+func (h *Handler) CheckoutSummary(w http.ResponseWriter, r *http.Request) {
+	req := validator.GetValidatedBody[CheckoutSummaryRequest](r)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	summary, appErr := h.svc.CheckoutSummary(r.Context(), userID, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, summary, "Checkout summary calculated successfully")
+}
+
 func (h *Handler) GetOrdersByUser(w http.ResponseWriter, r *http.Request) {
 	page, perPage := pagination.GetPaginationParams(r)
 	userID := r.Context().Value(middleware.UserIDKey).(string)
@@ -59,8 +91,15 @@ func (h *Handler) GetOrderByID(w http.ResponseWriter, r *http.Request) {
 
 func (h *Handler) GetAllOrders(w http.ResponseWriter, r *http.Request) {
 	page, perPage := pagination.GetPaginationParams(r)
-	
-	result, appErr := h.svc.GetAllOrders(r.Context(), page, perPage)
+	sort := httputil.ParseSortParams(r)
+
+	filter, err := parseOrderFilter(r)
+	if err != nil {
+		response.BadRequest(w, err.Error())
+		return
+	}
+
+	result, appErr := h.svc.GetAllOrders(r.Context(), filter, sort, page, perPage)
 	if appErr != nil {
 		response.Error(w, appErr.Code, appErr.Message)
 		return
@@ -73,7 +112,9 @@ func (h *Handler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	req := validator.GetValidatedBody[UpdateOrderStatusRequest](r)
 
-	order, appErr := h.svc.UpdateOrderStatus(r.Context(), id, req.Status)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	order, appErr := h.svc.UpdateOrderStatus(r.Context(), id, req.Status, userID, req.Reason)
 	if appErr != nil {
 		response.Error(w, appErr.Code, appErr.Message)
 		return
@@ -82,6 +123,33 @@ func (h *Handler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, order, "Order status updated successfully")
 }
 
+func (h *Handler) CancelOrder(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[CancelOrderRequest](r)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	role := r.Context().Value(middleware.UserRoleKey).(string)
+
+	order, appErr := h.svc.CancelOrder(r.Context(), userID, role, id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, order, "Order cancelled successfully")
+}
+
+func (h *Handler) GetOrderStatusHistory(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	history, appErr := h.svc.GetOrderStatusHistory(r.Context(), id)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, history, "Order status history fetched successfully")
+}
+
 func (h *Handler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -93,3 +161,85 @@ func (h *Handler) DeleteOrder(w http.ResponseWriter, r *http.Request) {
 
 	response.NoContent(w)
 }
+
+// parseOrderFilter reads the admin order search filters from the query
+// string: status (comma separated), user_id, email, order_number (prefix),
+// created_from/created_to, paid_from/paid_to (RFC 3339 or YYYY-MM-DD, with
+// date-only upper bounds covering the whole day) and min_total/max_total in
+// cents.
+func parseOrderFilter(r *http.Request) (OrderFilter, error) {
+	q := r.URL.Query()
+	filter := OrderFilter{
+		Email:             strings.TrimSpace(q.Get("email")),
+		OrderNumberPrefix: strings.TrimSpace(q.Get("order_number")),
+	}
+
+	if statuses := q.Get("status"); statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			if status = strings.ToUpper(strings.TrimSpace(status)); status != "" {
+				filter.Statuses = append(filter.Statuses, status)
+			}
+		}
+	}
+
+	if userID := q.Get("user_id"); userID != "" {
+		if _, err := uuid.Parse(userID); err != nil {
+			return OrderFilter{}, fmt.Errorf("invalid user_id")
+		}
+		filter.UserID = userID
+	}
+
+	var err error
+	if filter.CreatedFrom, err = parseTimeQuery(q.Get("created_from"), false); err != nil {
+		return OrderFilter{}, fmt.Errorf("invalid created_from")
+	}
+	if filter.CreatedTo, err = parseTimeQuery(q.Get("created_to"), true); err != nil {
+		return OrderFilter{}, fmt.Errorf("invalid created_to")
+	}
+	if filter.PaidFrom, err = parseTimeQuery(q.Get("paid_from"), false); err != nil {
+		return OrderFilter{}, fmt.Errorf("invalid paid_from")
+	}
+	if filter.PaidTo, err = parseTimeQuery(q.Get("paid_to"), true); err != nil {
+		return OrderFilter{}, fmt.Errorf("invalid paid_to")
+	}
+
+	if filter.MinTotalCents, err = parseCentsQuery(q.Get("min_total")); err != nil {
+		return OrderFilter{}, fmt.Errorf("invalid min_total")
+	}
+	if filter.MaxTotalCents, err = parseCentsQuery(q.Get("max_total")); err != nil {
+		return OrderFilter{}, fmt.Errorf("invalid max_total")
+	}
+
+	return filter, nil
+}
+
+func parseTimeQuery(value string, upperBound bool) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	if upperBound {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+func parseCentsQuery(value string) (*int64, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	cents, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || cents < 0 {
+		return nil, fmt.Errorf("invalid amount %q", value)
+	}
+	return &cents, nil
+}
@@ -0,0 +1,433 @@
+package order
+
+import (
+	"context"
+	"ecommerce-app/internal/domain/coupon"
+	"ecommerce-app/internal/domain/shipping"
+	"ecommerce-app/internal/domain/tax"
+	"ecommerce-app/internal/pkg/errs"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Adjustment kinds, in the order the pricing pipeline applies them. They
+// match the order_adjustments.kind CHECK constraint.
+const (
+	AdjustmentProductDiscount = "PRODUCT_DISCOUNT"
+	AdjustmentCoupon          = "COUPON"
+	AdjustmentShipping        = "SHIPPING"
+	AdjustmentTax             = "TAX"
+)
+
+// pricingRequest is what every path that prices an order hands the
+// pipeline: the checkout summary, order creation and checkout. Orders have
+// no edit path: their lines are fixed once placed and only their status
+// changes, so changing what was bought means cancelling and ordering again.
+// An edit endpoint would have to price through priceItems like these.
+type pricingRequest struct {
+	UserID           string
+	Items            []CreateOrderItem
+	ShippingInfo     interface{}
+	ShippingMethodID string
+	CouponCode       string
+	UseWallet        bool
+	GiftCardCodes    []string
+	PaymentMethod    string
+	CheckoutMode     string
+	SuccessURL       string
+	CancelURL        string
+}
+
+// pricedOrder is a set of requested lines priced from the catalogue. Items
+// keep their list price; the discounts taken off each line are tracked
+// alongside so tax and refunds see what was actually charged.
+type pricedOrder struct {
+	items         []CreateOrderItemInput
+	lineDiscounts []int64
+	taxCategories []string
+	weightGrams   int64
+	subtotalCents int64
+	discountCents int64
+	coupon        *coupon.Coupon
+	tax           tax.Calculation
+	shipping      *shipping.Quote
+	adjustments   []PriceAdjustment
+}
+
+// lineCents is what line i costs after its discounts.
+func (p pricedOrder) lineCents(i int) int64 {
+	item := p.items[i]
+	return int64(item.Qty)*int64(item.PriceCents) - p.lineDiscounts[i]
+}
+
+func (p pricedOrder) shippingCents() int64 {
+	if p.shipping == nil {
+		return 0
+	}
+	return p.shipping.CostCents
+}
+
+// totalCents is the subtotal less discounts, plus shipping and the tax that
+// is not already included in the line prices.
+func (p pricedOrder) totalCents() int64 {
+	return p.subtotalCents - p.discountCents + p.shippingCents() + p.tax.ExclusiveTaxCents
+}
+
+func (p pricedOrder) couponCode() string {
+	if p.coupon == nil {
+		return ""
+	}
+	return p.coupon.Code
+}
+
+// priceItems runs the pricing pipeline. The steps always apply in the same
+// order: product sale prices, then the coupon on the discounted lines, then
+// shipping, then tax on what each line finally costs.
+func (s *service) priceItems(ctx context.Context, req pricingRequest) (pricedOrder, *errs.AppError) {
+	now := time.Now()
+
+	priced, appErr := s.applySalePrices(ctx, req.Items, now)
+	if appErr != nil {
+		return pricedOrder{}, appErr
+	}
+
+	if req.CouponCode != "" {
+		if appErr := s.applyCoupon(ctx, &priced, req.CouponCode, now); appErr != nil {
+			return pricedOrder{}, appErr
+		}
+	}
+
+	dest := parseDestination(req.ShippingInfo)
+
+	if req.ShippingMethodID != "" {
+		if appErr := s.applyShipping(ctx, &priced, req.ShippingMethodID, dest); appErr != nil {
+			return pricedOrder{}, appErr
+		}
+	}
+
+	if appErr := s.applyTax(ctx, &priced, req.UserID, dest); appErr != nil {
+		return pricedOrder{}, appErr
+	}
+
+	return priced, nil
+}
+
+// applySalePrices snapshots each requested line from its product and takes
+// off the product's own discount when it is still running.
+func (s *service) applySalePrices(ctx context.Context, reqItems []CreateOrderItem, now time.Time) (pricedOrder, *errs.AppError) {
+	priced := pricedOrder{
+		items:         make([]CreateOrderItemInput, 0, len(reqItems)),
+		lineDiscounts: make([]int64, 0, len(reqItems)),
+		taxCategories: make([]string, 0, len(reqItems)),
+	}
+
+	for _, item := range reqItems {
+		if item.Quantity <= 0 {
+			return pricedOrder{}, errs.ErrBadRequest.WithMessage("Quantity must be greater than zero")
+		}
+
+		// Fetch product price
+		prod, appErr := s.productSvc.GetProductByID(ctx, item.ProductID)
+		if appErr != nil {
+			return pricedOrder{}, appErr
+		}
+
+		if !prod.IsActive || prod.IsDeleted {
+			return pricedOrder{}, errs.ErrBadRequest.WithMessage("Product " + prod.Name + " is no longer available")
+		}
+
+		line := len(priced.items)
+		discount := int64(item.Quantity) * prod.SaleDiscountCents(now)
+
+		priced.items = append(priced.items, CreateOrderItemInput{
+			ProductID:  item.ProductID,
+			SKU:        prod.SKU,
+			Name:       prod.Name,
+			Qty:        item.Quantity,
+			PriceCents: int(prod.PriceCents),
+		})
+		priced.lineDiscounts = append(priced.lineDiscounts, discount)
+		priced.taxCategories = append(priced.taxCategories, prod.TaxCategory)
+		priced.weightGrams += int64(item.Quantity) * int64(prod.WeightGrams)
+		priced.subtotalCents += int64(item.Quantity) * int64(prod.PriceCents)
+
+		if discount > 0 {
+			priced.discountCents += discount
+			priced.adjustments = append(priced.adjustments, PriceAdjustment{
+				Kind:        AdjustmentProductDiscount,
+				ProductID:   item.ProductID,
+				Description: fmt.Sprintf("%d%% off %s", prod.DiscountPercent, prod.Name),
+				AmountCents: -discount,
+				line:        line,
+			})
+		}
+	}
+
+	return priced, nil
+}
+
+// applyCoupon takes the coupon's percentage off the discounted lines and
+// spreads it across them by value, so each line's tax and any later refund
+// reflect its share.
+func (s *service) applyCoupon(ctx context.Context, priced *pricedOrder, code string, now time.Time) *errs.AppError {
+	c, appErr := s.couponSvc.GetCouponByCode(ctx, code)
+	if appErr != nil {
+		if appErr.Code == http.StatusNotFound {
+			return errs.ErrBadRequest.WithMessage("Coupon " + code + " does not exist")
+		}
+		return appErr
+	}
+
+	if !c.Redeemable(now) {
+		return errs.ErrBadRequest.WithMessage("Coupon " + code + " is expired or no longer available")
+	}
+	priced.coupon = &c
+
+	weights := make([]int64, len(priced.items))
+	merchandise := int64(0)
+	for i := range priced.items {
+		weights[i] = priced.lineCents(i)
+		merchandise += weights[i]
+	}
+
+	total := (merchandise*int64(c.DiscountPercent) + 50) / 100
+	for i, share := range allocate(total, weights) {
+		if share == 0 {
+			continue
+		}
+		priced.lineDiscounts[i] += share
+		priced.discountCents += share
+		priced.adjustments = append(priced.adjustments, PriceAdjustment{
+			Kind:        AdjustmentCoupon,
+			ProductID:   priced.items[i].ProductID,
+			Code:        c.Code,
+			Description: fmt.Sprintf("Coupon %s: %d%% off", c.Code, c.DiscountPercent),
+			AmountCents: -share,
+			line:        i,
+		})
+	}
+
+	return nil
+}
+
+// applyShipping prices shipping the lot with the chosen method. Free
+// shipping thresholds are measured against the discounted merchandise.
+func (s *service) applyShipping(ctx context.Context, priced *pricedOrder, methodID string, dest destination) *errs.AppError {
+	addr := shipping.Address{
+		Country:    dest.Country,
+		State:      dest.State,
+		PostalCode: dest.PostalCode,
+	}
+	parcel := shipping.Parcel{
+		WeightGrams:   priced.weightGrams,
+		SubtotalCents: priced.subtotalCents - priced.discountCents,
+	}
+
+	quote, appErr := s.shippingSvc.QuoteMethod(ctx, methodID, addr, parcel)
+	if appErr != nil {
+		return appErr
+	}
+	priced.shipping = &quote
+
+	description := "Shipping via " + quote.Name
+	if quote.IsFree {
+		description = "Free shipping via " + quote.Name
+	}
+	priced.adjustments = append(priced.adjustments, PriceAdjustment{
+		Kind:        AdjustmentShipping,
+		Code:        quote.Name,
+		Description: description,
+		AmountCents: quote.CostCents,
+		line:        -1,
+	})
+
+	return nil
+}
+
+// applyTax computes the tax owed on what each line costs after discounts at
+// the shipping address. Only tax added on top of the price is itemized as an
+// adjustment; included tax is already part of the line amounts.
+func (s *service) applyTax(ctx context.Context, priced *pricedOrder, userID string, dest destination) *errs.AppError {
+	lines := make([]tax.Line, len(priced.items))
+	for i := range priced.items {
+		// Tax lines refer back to their order line by position
+		lines[i] = tax.Line{
+			Ref:         strconv.Itoa(i),
+			TaxCategory: priced.taxCategories[i],
+			AmountCents: priced.lineCents(i),
+		}
+	}
+
+	calc, appErr := s.taxSvc.Calculate(ctx, tax.CalculationRequest{
+		UserID: userID,
+		Address: tax.Address{
+			Country:    dest.Country,
+			State:      dest.State,
+			PostalCode: dest.PostalCode,
+		},
+		Lines: lines,
+	})
+	if appErr != nil {
+		return appErr
+	}
+	priced.tax = calc
+
+	for _, lt := range calc.Lines {
+		if lt.IsInclusive || lt.TaxCents == 0 {
+			continue
+		}
+		line, err := strconv.Atoi(lt.Ref)
+		if err != nil || line < 0 || line >= len(priced.items) {
+			continue
+		}
+		priced.adjustments = append(priced.adjustments, PriceAdjustment{
+			Kind:        AdjustmentTax,
+			ProductID:   priced.items[line].ProductID,
+			Code:        lt.Jurisdiction,
+			Description: fmt.Sprintf("%s tax at %s%%", lt.Jurisdiction, formatBps(lt.RateBps)),
+			AmountCents: lt.TaxCents,
+			line:        line,
+		})
+	}
+
+	return nil
+}
+
+// allocate splits total across weights in proportion, handing the cents
+// lost to rounding to the earliest lines that carry any weight.
+func allocate(total int64, weights []int64) []int64 {
+	shares := make([]int64, len(weights))
+
+	sum := int64(0)
+	for _, w := range weights {
+		sum += w
+	}
+	if sum <= 0 || total <= 0 {
+		return shares
+	}
+
+	given := int64(0)
+	for i, w := range weights {
+		shares[i] = total * w / sum
+		given += shares[i]
+	}
+	for i := 0; given < total; i = (i + 1) % len(weights) {
+		if weights[i] > 0 && shares[i] < weights[i] {
+			shares[i]++
+			given++
+		}
+	}
+
+	return shares
+}
+
+// formatBps renders basis points as a percentage, e.g. 825 as "8.25".
+func formatBps(bps int32) string {
+	return strconv.FormatFloat(float64(bps)/100, 'f', -1, 64)
+}
+
+// destination is the part of the free-form shipping info that decides the
+// tax jurisdiction and the shipping zone.
+type destination struct {
+	Country    string `json:"country"`
+	State      string `json:"state"`
+	PostalCode string `json:"postal_code"`
+}
+
+// parseDestination reads the country, state and postal code out of the
+// shipping info. Anything unreadable yields an empty destination.
+func parseDestination(shippingInfo interface{}) destination {
+	var dest destination
+
+	raw, err := json.Marshal(shippingInfo)
+	if err != nil {
+		return dest
+	}
+	_ = json.Unmarshal(raw, &dest)
+
+	return dest
+}
+
+// taxLineInputs ties each computed line tax to the order item it was
+// calculated for.
+func taxLineInputs(calc tax.Calculation, orderItems []OrderItem) []CreateTaxLineInput {
+	inputs := make([]CreateTaxLineInput, 0, len(calc.Lines))
+	for _, line := range calc.Lines {
+		input := CreateTaxLineInput{
+			Jurisdiction: line.Jurisdiction,
+			TaxCategory:  line.TaxCategory,
+			RateBps:      line.RateBps,
+			IsInclusive:  line.IsInclusive,
+			TaxableCents: line.TaxableCents,
+			TaxCents:     line.TaxCents,
+		}
+		if i, err := strconv.Atoi(line.Ref); err == nil && i >= 0 && i < len(orderItems) {
+			input.OrderItemID = orderItems[i].ID.String()
+		}
+		if line.TaxRateID != nil {
+			input.TaxRateID = line.TaxRateID.String()
+		}
+		inputs = append(inputs, input)
+	}
+
+	return inputs
+}
+
+// adjustmentInputs ties each item-level adjustment to the order item it was
+// priced for.
+func adjustmentInputs(adjustments []PriceAdjustment, orderItems []OrderItem) []CreateAdjustmentInput {
+	inputs := make([]CreateAdjustmentInput, len(adjustments))
+	for i, adj := range adjustments {
+		inputs[i] = CreateAdjustmentInput{
+			Kind:        adj.Kind,
+			Code:        adj.Code,
+			Description: adj.Description,
+			AmountCents: adj.AmountCents,
+		}
+		if adj.line >= 0 && adj.line < len(orderItems) {
+			inputs[i].OrderItemID = orderItems[adj.line].ID.String()
+		}
+	}
+
+	return inputs
+}
+
+// CouponCode is the coupon the order was priced with, read from its
+// adjustments; orders placed before adjustments were itemized have none.
+func (o Order) CouponCode() string {
+	for _, adj := range o.Adjustments {
+		if adj.Kind == AdjustmentCoupon {
+			return adj.Code
+		}
+	}
+	return ""
+}
+
+// LinePaidCents is the amount paid for qty units of a line: the line total
+// plus the discounts and exclusive tax itemized against the item, pro-rated
+// by quantity. Orders placed before adjustments were itemized fall back to
+// sharing the order-level discount by the line's share of the subtotal.
+func (o Order) LinePaidCents(item OrderItem, qty int32) int64 {
+	if len(o.Adjustments) == 0 {
+		line := item.UnitPriceCents * int64(qty)
+		if o.SubtotalCents <= 0 || o.DiscountCents <= 0 {
+			return line
+		}
+		return line - o.DiscountCents*line/o.SubtotalCents
+	}
+
+	paid := item.UnitPriceCents * int64(item.Qty)
+	for _, adj := range o.Adjustments {
+		if adj.OrderItemID != nil && *adj.OrderItemID == item.ID {
+			paid += adj.AmountCents
+		}
+	}
+	if item.Qty <= 0 || paid <= 0 {
+		return 0
+	}
+
+	return paid * int64(qty) / int64(item.Qty)
+}
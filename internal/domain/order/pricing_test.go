@@ -0,0 +1,205 @@
+package order
+
+import (
+	"context"
+	"ecommerce-app/internal/domain/coupon"
+	"ecommerce-app/internal/pkg/errs"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// couponsByCode serves coupons from memory. Only lookups are implemented;
+// the embedded interface panics on anything else.
+type couponsByCode struct {
+	CouponProvider
+	coupons map[string]coupon.Coupon
+}
+
+func (c couponsByCode) GetCouponByCode(ctx context.Context, code string) (coupon.Coupon, *errs.AppError) {
+	found, ok := c.coupons[code]
+	if !ok {
+		return coupon.Coupon{}, errs.ErrNotFound.WithMessage("Coupon not found")
+	}
+	return found, nil
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{name: "exact split", total: 400, weights: []int64{1000, 3000}, want: []int64{100, 300}},
+		{name: "rounding goes to the earliest lines", total: 2, weights: []int64{1, 1, 1}, want: []int64{1, 1, 0}},
+		{name: "rounding skips weightless lines", total: 10, weights: []int64{0, 4, 4, 4}, want: []int64{0, 4, 3, 3}},
+		{name: "share never exceeds its line", total: 3, weights: []int64{1, 2}, want: []int64{1, 2}},
+		{name: "nothing to allocate", total: 0, weights: []int64{100, 200}, want: []int64{0, 0}},
+		{name: "no weight", total: 50, weights: []int64{0, 0}, want: []int64{0, 0}},
+		{name: "no lines", total: 50, weights: []int64{}, want: []int64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := allocate(tt.total, tt.weights)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("allocate(%d, %v) = %v, want %v", tt.total, tt.weights, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyCoupon(t *testing.T) {
+	now := time.Now()
+	active := func(percent int32) coupon.Coupon {
+		return coupon.Coupon{
+			Code:            "SAVE",
+			DiscountPercent: percent,
+			ValidFrom:       now.Add(-time.Hour),
+			ValidUntil:      now.Add(time.Hour),
+			IsActive:        true,
+		}
+	}
+
+	tests := []struct {
+		name          string
+		coupon        coupon.Coupon
+		prices        []int
+		saleDiscounts []int64
+		wantDiscounts []int64
+		wantErr       bool
+	}{
+		{
+			name:          "spread by line value",
+			coupon:        active(10),
+			prices:        []int{1000, 3000},
+			saleDiscounts: []int64{0, 0},
+			wantDiscounts: []int64{100, 300},
+		},
+		{
+			name:          "measured after sale prices",
+			coupon:        active(10),
+			prices:        []int{1000, 1000},
+			saleDiscounts: []int64{500, 0},
+			wantDiscounts: []int64{550, 100},
+		},
+		{
+			name:          "rounded total split without losing a cent",
+			coupon:        active(15),
+			prices:        []int{333, 333, 333},
+			saleDiscounts: []int64{0, 0, 0},
+			wantDiscounts: []int64{50, 50, 50},
+		},
+		{
+			name:          "used up coupon rejected",
+			coupon:        func() coupon.Coupon { c := active(10); c.MaxUses, c.UsedCount = 1, 1; return c }(),
+			prices:        []int{1000},
+			saleDiscounts: []int64{0},
+			wantErr:       true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &service{couponSvc: couponsByCode{coupons: map[string]coupon.Coupon{"SAVE": tt.coupon}}}
+			priced := pricedOrder{lineDiscounts: append([]int64(nil), tt.saleDiscounts...)}
+			for i, price := range tt.prices {
+				priced.items = append(priced.items, CreateOrderItemInput{ProductID: uuid.NewString(), Qty: 1, PriceCents: price})
+				priced.subtotalCents += int64(price)
+				priced.discountCents += tt.saleDiscounts[i]
+			}
+			saleTotal := priced.discountCents
+
+			appErr := svc.applyCoupon(context.Background(), &priced, "SAVE", now)
+			if tt.wantErr {
+				if appErr == nil {
+					t.Fatal("applyCoupon succeeded, want an error")
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatalf("applyCoupon: %s", appErr.Message)
+			}
+
+			if !reflect.DeepEqual(priced.lineDiscounts, tt.wantDiscounts) {
+				t.Errorf("line discounts = %v, want %v", priced.lineDiscounts, tt.wantDiscounts)
+			}
+			couponTotal := int64(0)
+			for _, adj := range priced.adjustments {
+				if adj.Kind != AdjustmentCoupon {
+					t.Errorf("unexpected %s adjustment", adj.Kind)
+				}
+				couponTotal -= adj.AmountCents
+			}
+			if priced.discountCents != saleTotal+couponTotal {
+				t.Errorf("discount %d, want sale %d plus coupon adjustments %d", priced.discountCents, saleTotal, couponTotal)
+			}
+		})
+	}
+}
+
+func TestLinePaidCents(t *testing.T) {
+	item := OrderItem{ID: uuid.New(), Qty: 4, UnitPriceCents: 1000}
+	other := uuid.New()
+	adjustment := func(itemID uuid.UUID, kind string, cents int64) Adjustment {
+		return Adjustment{OrderItemID: &itemID, Kind: kind, AmountCents: cents}
+	}
+
+	tests := []struct {
+		name  string
+		order Order
+		qty   int32
+		want  int64
+	}{
+		{
+			name:  "no discounts",
+			order: Order{SubtotalCents: 4000},
+			qty:   2,
+			want:  2000,
+		},
+		{
+			name:  "legacy order shares the order discount",
+			order: Order{SubtotalCents: 8000, DiscountCents: 800},
+			qty:   2,
+			want:  1800,
+		},
+		{
+			name: "itemized discounts and tax on the line",
+			order: Order{Adjustments: []Adjustment{
+				adjustment(item.ID, AdjustmentProductDiscount, -400),
+				adjustment(item.ID, AdjustmentCoupon, -360),
+				adjustment(item.ID, AdjustmentTax, 292),
+			}},
+			qty:  1,
+			want: 883,
+		},
+		{
+			name: "other lines' adjustments ignored",
+			order: Order{Adjustments: []Adjustment{
+				adjustment(other, AdjustmentCoupon, -2000),
+				{Kind: AdjustmentShipping, AmountCents: 500},
+			}},
+			qty:  4,
+			want: 4000,
+		},
+		{
+			name: "fully discounted line",
+			order: Order{Adjustments: []Adjustment{
+				adjustment(item.ID, AdjustmentCoupon, -5000),
+			}},
+			qty:  1,
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.order.LinePaidCents(item, tt.qty); got != tt.want {
+				t.Errorf("LinePaidCents = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,9 +5,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"strings"
+	"time"
 
+	"ecommerce-app/internal/domain/gateway"
+	"ecommerce-app/internal/pkg/database"
 	"ecommerce-app/internal/pkg/database/sqlc"
 	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/httputil"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
@@ -17,21 +22,55 @@ type Repository interface {
 	Create(ctx context.Context,userID string, params CreateOrderRequestInput) (Order, error)
 	GetByID(ctx context.Context, id string) (Order, error)
 	GetByUserID(ctx context.Context, userID string, limit, offset int32) ([]Order, error)
-	GetAll(ctx context.Context, limit, offset int32) ([]Order, error)
-	CountAll(ctx context.Context) (int32, error)
+	GetAll(ctx context.Context, filter OrderFilter, sort httputil.SortParams, limit, offset int32) ([]Order, error)
+	CountAll(ctx context.Context, filter OrderFilter) (int32, error)
 	CountByUserID(ctx context.Context, userID string) (int32, error)
-	UpdateStatus(ctx context.Context, id, status string) (Order, error)
+	UpdateStatus(ctx context.Context, id, fromStatus, toStatus string) (Order, error)
+	AddRefundedCents(ctx context.Context, id string, amountCents int64) (Order, error)
+	SetFinalCents(ctx context.Context, id string, finalCents int64) error
+	SetDeliveredAt(ctx context.Context, id string, deliveredAt time.Time) error
+	CreateStatusHistory(ctx context.Context, entry StatusHistoryInput) (StatusHistory, error)
+	ListStatusHistory(ctx context.Context, orderID string) ([]StatusHistory, error)
 	Delete(ctx context.Context, id string) error
 	CreateOrderPayment(ctx context.Context, params CreateOrderPaymentInput) error
+	GetOrderPayment(ctx context.Context, orderID string) (OrderPayment, error)
+	UpdateOrderPaymentStatus(ctx context.Context, paymentID, status string) error
+	LockPayment(ctx context.Context, paymentID string) (OrderPayment, error)
+	CapturePayment(ctx context.Context, paymentID string, amountCents int64) (OrderPayment, error)
+	ListStaleAuthorizations(ctx context.Context, authorizedBefore time.Time, limit int32) ([]OrderPayment, error)
+	ConfirmOfflinePayment(ctx context.Context, paymentID, confirmedBy string) (OrderPayment, error)
+	ExpireOfflinePayment(ctx context.Context, paymentID, reason string) (OrderPayment, error)
+	ListExpiredOfflinePayments(ctx context.Context, expiredBefore time.Time, limit int32) ([]OrderPayment, error)
+	CreateRefund(ctx context.Context, params CreateRefundInput) (Refund, error)
+	GetRefund(ctx context.Context, id string) (Refund, error)
+	UpdateRefundResult(ctx context.Context, id, providerRefundID, status, failureReason string) (Refund, error)
+	GetRefundTotals(ctx context.Context, paymentID string) (succeededCents, committedCents int64, err error)
+	CreateItems(ctx context.Context, orderID string, items []CreateOrderItemInput) ([]OrderItem, error)
+	CreateTaxLines(ctx context.Context, orderID string, lines []CreateTaxLineInput) ([]TaxLine, error)
+	ListTaxLines(ctx context.Context, orderID string) ([]TaxLine, error)
+	CreateAdjustments(ctx context.Context, orderID string, adjustments []CreateAdjustmentInput) ([]Adjustment, error)
+	ListAdjustments(ctx context.Context, orderID string) ([]Adjustment, error)
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 // repository implements Repository
 type repository struct {
-	q *sqlc.Queries
+	q  *sqlc.Queries
+	db database.Transactor
 }
 
-func NewRepository(q *sqlc.Queries) Repository {
-	return &repository{q: q}
+func NewRepository(q *sqlc.Queries, db database.Transactor) Repository {
+	return &repository{q: q, db: db}
+}
+
+// WithTx runs fn in a single transaction; every repository call made with
+// the ctx passed to fn takes part in it.
+func (r *repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithTx(ctx, r.db, fn)
+}
+
+func (r *repository) queries(ctx context.Context) *sqlc.Queries {
+	return database.Queries(ctx, r.q)
 }
 
 func (r *repository) Create(ctx context.Context, userID string, req CreateOrderRequestInput) (Order, error) {
@@ -56,9 +95,16 @@ func (r *repository) Create(ctx context.Context, userID string, req CreateOrderR
 		FinalCents:   req.FinalCents,
 		ShippingInfo: shippingJSON,
 		Notes:        pgtype.Text{String: req.Notes, Valid: req.Notes != ""},
+		ShippingMethodName: pgtype.Text{String: req.ShippingMethodName, Valid: req.ShippingMethodName != ""},
+	}
+
+	if req.ShippingMethodID != "" {
+		if err := params.ShippingMethodID.Scan(req.ShippingMethodID); err != nil {
+			return Order{}, err
+		}
 	}
 
-	row, err := r.q.CreateOrder(ctx, params)
+	row, err := r.queries(ctx).CreateOrder(ctx, params)
 	if err != nil {
 		return Order{}, err
 	}
@@ -72,7 +118,7 @@ func (r *repository) GetByID(ctx context.Context, id string) (Order, error) {
 		return Order{}, err
 	}
 
-	row, err := r.q.GetOrderWithItemsByID(ctx, uuidID)
+	row, err := r.queries(ctx).GetOrderWithItemsByID(ctx, uuidID)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return Order{}, errs.ErrNotFound
@@ -90,6 +136,16 @@ func (r *repository) GetByID(ctx context.Context, id string) (Order, error) {
 		shipping = map[string]interface{}{}
 	}
 
+	taxLines, err := r.ListTaxLines(ctx, id)
+	if err != nil {
+		return Order{}, err
+	}
+
+	adjustments, err := r.ListAdjustments(ctx, id)
+	if err != nil {
+		return Order{}, err
+	}
+
 	return Order{
 		ID:            uuid.UUID(row.ID.Bytes),
 		UserID:        uuid.UUID(row.UserID.Bytes),
@@ -106,7 +162,17 @@ func (r *repository) GetByID(ctx context.Context, id string) (Order, error) {
 		Notes:         row.Notes.String,
 		CreatedAt:     row.CreatedAt.Time,
 		UpdatedAt:     row.UpdatedAt.Time,
+		PaidAt:        timePtr(row.PaidAt),
+		ShippedAt:     timePtr(row.ShippedAt),
+		DeliveredAt:   timePtr(row.DeliveredAt),
+		CancelledAt:   timePtr(row.CancelledAt),
+		RefundedAt:    timePtr(row.RefundedAt),
+		RefundedCents: row.RefundedCents,
+		ShippingMethodID:   uuidPtr(row.ShippingMethodID),
+		ShippingMethodName: row.ShippingMethodName.String,
 		Items:         items,
+		TaxLines:      taxLines,
+		Adjustments:   adjustments,
 	}, nil
 }
 
@@ -122,7 +188,7 @@ func (r *repository) GetByUserID(ctx context.Context, userID string, limit, offs
 		Offset: int32(offset),
 	}
 
-	rows, err := r.q.GetOrdersWithItemsByUserID(ctx, params)
+	rows, err := r.queries(ctx).GetOrdersWithItemsByUserID(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -150,6 +216,14 @@ func (r *repository) GetByUserID(ctx context.Context, userID string, limit, offs
 			Notes:         row.Notes.String,
 			CreatedAt:     row.CreatedAt.Time,
 			UpdatedAt:     row.UpdatedAt.Time,
+			PaidAt:        timePtr(row.PaidAt),
+			ShippedAt:     timePtr(row.ShippedAt),
+			DeliveredAt:   timePtr(row.DeliveredAt),
+			CancelledAt:   timePtr(row.CancelledAt),
+			RefundedAt:    timePtr(row.RefundedAt),
+			RefundedCents: row.RefundedCents,
+			ShippingMethodID:   uuidPtr(row.ShippingMethodID),
+			ShippingMethodName: row.ShippingMethodName.String,
 			Items:         items,
 		}
 	}
@@ -157,13 +231,30 @@ func (r *repository) GetByUserID(ctx context.Context, userID string, limit, offs
 	return orders, nil
 }
 
-func (r *repository) GetAll(ctx context.Context, limit, offset int32) ([]Order, error) {
+func (r *repository) GetAll(ctx context.Context, filter OrderFilter, sort httputil.SortParams, limit, offset int32) ([]Order, error) {
+	f, err := orderFilterParams(filter)
+	if err != nil {
+		return nil, err
+	}
+
 	params := sqlc.GetOrdersWithItemsParams{
-		Limit:  limit,
-		Offset: offset,
+		Statuses:          f.Statuses,
+		UserID:            f.UserID,
+		Email:             f.Email,
+		OrderNumberPrefix: f.OrderNumberPrefix,
+		CreatedFrom:       f.CreatedFrom,
+		CreatedTo:         f.CreatedTo,
+		PaidFrom:          f.PaidFrom,
+		PaidTo:            f.PaidTo,
+		MinTotalCents:     f.MinTotalCents,
+		MaxTotalCents:     f.MaxTotalCents,
+		SortBy:            sort.SortBy,
+		SortOrder:         sort.Order,
+		RowLimit:          limit,
+		RowOffset:         offset,
 	}
 
-	rows, err := r.q.GetOrdersWithItems(ctx, params)
+	rows, err := r.queries(ctx).GetOrdersWithItems(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -191,6 +282,14 @@ func (r *repository) GetAll(ctx context.Context, limit, offset int32) ([]Order,
 			Notes:         row.Notes.String,
 			CreatedAt:     row.CreatedAt.Time,
 			UpdatedAt:     row.UpdatedAt.Time,
+			PaidAt:        timePtr(row.PaidAt),
+			ShippedAt:     timePtr(row.ShippedAt),
+			DeliveredAt:   timePtr(row.DeliveredAt),
+			CancelledAt:   timePtr(row.CancelledAt),
+			RefundedAt:    timePtr(row.RefundedAt),
+			RefundedCents: row.RefundedCents,
+			ShippingMethodID:   uuidPtr(row.ShippingMethodID),
+			ShippingMethodName: row.ShippingMethodName.String,
 			Items:         items,
 		}
 	}
@@ -198,40 +297,94 @@ func (r *repository) GetAll(ctx context.Context, limit, offset int32) ([]Order,
 	return orders, nil
 }
 
-func (r *repository) CountAll(ctx context.Context) (int32, error) {
-	count, err := r.q.CountOrders(ctx)
+func (r *repository) CountAll(ctx context.Context, filter OrderFilter) (int32, error) {
+	params, err := orderFilterParams(filter)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := r.queries(ctx).CountOrders(ctx, params)
 	if err != nil {
 		return 0, err
 	}
 	return int32(count), nil
 }
 
+// orderFilterParams converts filter into the nullable query arguments shared
+// by GetOrdersWithItems and CountOrders.
+func orderFilterParams(filter OrderFilter) (sqlc.CountOrdersParams, error) {
+	params := sqlc.CountOrdersParams{
+		Statuses:      filter.Statuses,
+		Email:         pgtype.Text{String: filter.Email, Valid: filter.Email != ""},
+		CreatedFrom:   timestamptz(filter.CreatedFrom),
+		CreatedTo:     timestamptz(filter.CreatedTo),
+		PaidFrom:      timestamptz(filter.PaidFrom),
+		PaidTo:        timestamptz(filter.PaidTo),
+		MinTotalCents: int8Ptr(filter.MinTotalCents),
+		MaxTotalCents: int8Ptr(filter.MaxTotalCents),
+	}
+
+	if filter.UserID != "" {
+		if err := params.UserID.Scan(filter.UserID); err != nil {
+			return sqlc.CountOrdersParams{}, err
+		}
+	}
+
+	if filter.OrderNumberPrefix != "" {
+		// Match the prefix literally rather than as a LIKE pattern
+		prefix := likeEscaper.Replace(filter.OrderNumberPrefix)
+		params.OrderNumberPrefix = pgtype.Text{String: prefix, Valid: true}
+	}
+
+	return params, nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func timestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+func int8Ptr(v *int64) pgtype.Int8 {
+	if v == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *v, Valid: true}
+}
+
 func (r *repository) CountByUserID(ctx context.Context, userID string) (int32, error) {
 	var userUUID pgtype.UUID
 	if err := userUUID.Scan(userID); err != nil {
 		return 0, err
 	}
 
-	count, err := r.q.CountOrdersByUser(ctx, userUUID)
+	count, err := r.queries(ctx).CountOrdersByUser(ctx, userUUID)
 	if err != nil {
 		return 0, err
 	}
 	return int32(count), nil
 }
 
-func (r *repository) UpdateStatus(ctx context.Context, id, status string) (Order, error) {
+func (r *repository) UpdateStatus(ctx context.Context, id, fromStatus, toStatus string) (Order, error) {
 	var uuidID pgtype.UUID
 	if err := uuidID.Scan(id); err != nil {
 		return Order{}, err
 	}
 
 	params := sqlc.UpdateOrderStatusParams{
-		ID:     uuidID,
-		Status: status,
+		ID:         uuidID,
+		Status:     toStatus,
+		FromStatus: fromStatus,
 	}
 
-	row, err := r.q.UpdateOrderStatus(ctx, params)
+	row, err := r.queries(ctx).UpdateOrderStatus(ctx, params)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Order{}, errs.ErrConflict
+		}
 		return Order{}, err
 	}
 
@@ -256,17 +409,74 @@ func (r *repository) UpdateStatus(ctx context.Context, id, status string) (Order
 		Notes:         row.Notes.String,
 		CreatedAt:     row.CreatedAt.Time,
 		UpdatedAt:     row.UpdatedAt.Time,
+		PaidAt:        timePtr(row.PaidAt),
+		ShippedAt:     timePtr(row.ShippedAt),
+		DeliveredAt:   timePtr(row.DeliveredAt),
+		CancelledAt:   timePtr(row.CancelledAt),
+		RefundedAt:    timePtr(row.RefundedAt),
+		RefundedCents: row.RefundedCents,
+		ShippingMethodID:   uuidPtr(row.ShippingMethodID),
+		ShippingMethodName: row.ShippingMethodName.String,
 		Items:         items,
 	}, nil
 }
 
+// AddRefundedCents adds amountCents to the order's refunded total, returning
+// errs.ErrConflict if the total would exceed the amount charged.
+func (r *repository) AddRefundedCents(ctx context.Context, id string, amountCents int64) (Order, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Order{}, err
+	}
+
+	row, err := r.queries(ctx).AddOrderRefundedCents(ctx, sqlc.AddOrderRefundedCentsParams{
+		AmountCents: amountCents,
+		ID:          uuidID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Order{}, errs.ErrConflict
+		}
+		return Order{}, err
+	}
+
+	return mapOrder(row), nil
+}
+
+// SetFinalCents sets what is left to charge for the order once part of it
+// has been paid from the customer's wallet.
+func (r *repository) SetFinalCents(ctx context.Context, id string, finalCents int64) error {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return err
+	}
+
+	return r.queries(ctx).SetOrderFinalCents(ctx, sqlc.SetOrderFinalCentsParams{
+		ID:         uuidID,
+		FinalCents: finalCents,
+	})
+}
+
+// SetDeliveredAt records when the order's latest shipment was delivered
+func (r *repository) SetDeliveredAt(ctx context.Context, id string, deliveredAt time.Time) error {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return err
+	}
+
+	return r.queries(ctx).SetOrderDeliveredAt(ctx, sqlc.SetOrderDeliveredAtParams{
+		ID:          uuidID,
+		DeliveredAt: pgtype.Timestamptz{Time: deliveredAt, Valid: true},
+	})
+}
+
 func (r *repository) Delete(ctx context.Context, id string) error {
 	var uuidID pgtype.UUID
 	if err := uuidID.Scan(id); err != nil {
 		return err
 	}
 
-	return r.q.DeleteOrder(ctx, uuidID)
+	return r.queries(ctx).DeleteOrder(ctx, uuidID)
 }
 
 func (r *repository) CreateOrderPayment(ctx context.Context, req CreateOrderPaymentInput) error {
@@ -276,16 +486,25 @@ func (r *repository) CreateOrderPayment(ctx context.Context, req CreateOrderPaym
 	}
 
 	params := sqlc.CreatePaymentParams{
-		OrderID:       orderUUID,
-		Provider:      req.Provider,
-		ProviderTxnID: pgtype.Text{String: req.ProviderTxnID, Valid: req.ProviderTxnID != ""},
-		PaymentMethod: req.PaymentMethod,		
-		AmountCents:   req.AmountCents,
-		Currency:      req.Currency,
-		Status:        req.Status,
+		OrderID:           orderUUID,
+		Provider:          req.Provider,
+		ProviderTxnID:     pgtype.Text{String: req.ProviderTxnID, Valid: req.ProviderTxnID != ""},
+		PaymentMethod:     req.PaymentMethod,
+		AmountCents:       req.AmountCents,
+		Currency:          req.Currency,
+		Status:            req.Status,
+		CaptureMethod:     req.CaptureMethod,
+		Reference:         pgtype.Text{String: req.Reference, Valid: req.Reference != ""},
+		CheckoutSessionID: pgtype.Text{String: req.CheckoutSessionID, Valid: req.CheckoutSessionID != ""},
+	}
+	if params.CaptureMethod == "" {
+		params.CaptureMethod = gateway.CaptureAutomatic
+	}
+	if req.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *req.ExpiresAt, Valid: true}
 	}
 
-	_, err := r.q.CreatePayment(ctx, params)
+	_, err := r.queries(ctx).CreatePayment(ctx, params)
 	if err != nil {
 		return err
 	}
@@ -293,19 +512,506 @@ func (r *repository) CreateOrderPayment(ctx context.Context, req CreateOrderPaym
 	return nil
 }
 
+func (r *repository) GetOrderPayment(ctx context.Context, orderID string) (OrderPayment, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return OrderPayment{}, err
+	}
+
+	p, err := r.queries(ctx).GetPaymentByOrderID(ctx, orderUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OrderPayment{}, errs.ErrNotFound
+		}
+		return OrderPayment{}, err
+	}
+
+	return mapOrderPayment(p), nil
+}
+
+func (r *repository) UpdateOrderPaymentStatus(ctx context.Context, paymentID, status string) error {
+	var paymentUUID pgtype.UUID
+	if err := paymentUUID.Scan(paymentID); err != nil {
+		return err
+	}
+
+	_, err := r.queries(ctx).UpdatePaymentStatus(ctx, sqlc.UpdatePaymentStatusParams{
+		ID:     paymentUUID,
+		Status: status,
+	})
+	return err
+}
+
+// LockPayment fetches a payment and holds a row lock on it until the
+// surrounding transaction ends, so concurrent refunds see each other.
+func (r *repository) LockPayment(ctx context.Context, paymentID string) (OrderPayment, error) {
+	var paymentUUID pgtype.UUID
+	if err := paymentUUID.Scan(paymentID); err != nil {
+		return OrderPayment{}, err
+	}
+
+	p, err := r.queries(ctx).LockPayment(ctx, paymentUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OrderPayment{}, errs.ErrNotFound
+		}
+		return OrderPayment{}, err
+	}
+
+	return mapOrderPayment(p), nil
+}
+
+// CapturePayment records the capture of an authorized payment for
+// amountCents, returning errs.ErrConflict if it is no longer AUTHORIZED.
+func (r *repository) CapturePayment(ctx context.Context, paymentID string, amountCents int64) (OrderPayment, error) {
+	var paymentUUID pgtype.UUID
+	if err := paymentUUID.Scan(paymentID); err != nil {
+		return OrderPayment{}, err
+	}
+
+	p, err := r.queries(ctx).CapturePayment(ctx, sqlc.CapturePaymentParams{
+		ID:          paymentUUID,
+		AmountCents: amountCents,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OrderPayment{}, errs.ErrConflict
+		}
+		return OrderPayment{}, err
+	}
+
+	return mapOrderPayment(p), nil
+}
+
+func (r *repository) ListStaleAuthorizations(ctx context.Context, authorizedBefore time.Time, limit int32) ([]OrderPayment, error) {
+	rows, err := r.queries(ctx).ListStaleAuthorizations(ctx, sqlc.ListStaleAuthorizationsParams{
+		AuthorizedBefore: pgtype.Timestamptz{Time: authorizedBefore, Valid: true},
+		RowLimit:         limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]OrderPayment, len(rows))
+	for i, row := range rows {
+		payments[i] = mapOrderPayment(row)
+	}
+
+	return payments, nil
+}
+
+// ConfirmOfflinePayment records that an offline payment was received,
+// returning errs.ErrConflict if it is no longer waiting to be paid.
+func (r *repository) ConfirmOfflinePayment(ctx context.Context, paymentID, confirmedBy string) (OrderPayment, error) {
+	var paymentUUID, confirmedByUUID pgtype.UUID
+	if err := paymentUUID.Scan(paymentID); err != nil {
+		return OrderPayment{}, err
+	}
+	if confirmedBy != "" {
+		if err := confirmedByUUID.Scan(confirmedBy); err != nil {
+			return OrderPayment{}, err
+		}
+	}
+
+	p, err := r.queries(ctx).ConfirmOfflinePayment(ctx, sqlc.ConfirmOfflinePaymentParams{
+		ID:          paymentUUID,
+		ConfirmedBy: confirmedByUUID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OrderPayment{}, errs.ErrConflict
+		}
+		return OrderPayment{}, err
+	}
+
+	return mapOrderPayment(p), nil
+}
+
+// ExpireOfflinePayment cancels an offline payment that was never received,
+// returning errs.ErrConflict if it is no longer waiting to be paid.
+func (r *repository) ExpireOfflinePayment(ctx context.Context, paymentID, reason string) (OrderPayment, error) {
+	var paymentUUID pgtype.UUID
+	if err := paymentUUID.Scan(paymentID); err != nil {
+		return OrderPayment{}, err
+	}
+
+	p, err := r.queries(ctx).ExpireOfflinePayment(ctx, sqlc.ExpireOfflinePaymentParams{
+		ID:            paymentUUID,
+		FailureReason: pgtype.Text{String: reason, Valid: reason != ""},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return OrderPayment{}, errs.ErrConflict
+		}
+		return OrderPayment{}, err
+	}
+
+	return mapOrderPayment(p), nil
+}
+
+func (r *repository) ListExpiredOfflinePayments(ctx context.Context, expiredBefore time.Time, limit int32) ([]OrderPayment, error) {
+	rows, err := r.queries(ctx).ListExpiredOfflinePayments(ctx, sqlc.ListExpiredOfflinePaymentsParams{
+		ExpiredBefore: pgtype.Timestamptz{Time: expiredBefore, Valid: true},
+		RowLimit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	payments := make([]OrderPayment, len(rows))
+	for i, row := range rows {
+		payments[i] = mapOrderPayment(row)
+	}
+
+	return payments, nil
+}
+
+func (r *repository) CreateRefund(ctx context.Context, req CreateRefundInput) (Refund, error) {
+	var paymentUUID, orderUUID, createdByUUID pgtype.UUID
+	if err := paymentUUID.Scan(req.PaymentID); err != nil {
+		return Refund{}, err
+	}
+	if err := orderUUID.Scan(req.OrderID); err != nil {
+		return Refund{}, err
+	}
+	if req.CreatedBy != "" {
+		if err := createdByUUID.Scan(req.CreatedBy); err != nil {
+			return Refund{}, err
+		}
+	}
+
+	row, err := r.queries(ctx).CreateRefund(ctx, sqlc.CreateRefundParams{
+		PaymentID:   paymentUUID,
+		OrderID:     orderUUID,
+		Provider:    req.Provider,
+		AmountCents: req.AmountCents,
+		Currency:    req.Currency,
+		Reason:      pgtype.Text{String: req.Reason, Valid: req.Reason != ""},
+		Status:      gateway.RefundPending,
+		CreatedBy:   createdByUUID,
+	})
+	if err != nil {
+		return Refund{}, err
+	}
+
+	return mapRefund(row), nil
+}
+
+func (r *repository) GetRefund(ctx context.Context, id string) (Refund, error) {
+	var refundUUID pgtype.UUID
+	if err := refundUUID.Scan(id); err != nil {
+		return Refund{}, err
+	}
+
+	row, err := r.queries(ctx).GetRefund(ctx, refundUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Refund{}, errs.ErrNotFound
+		}
+		return Refund{}, err
+	}
+
+	return mapRefund(row), nil
+}
+
+// UpdateRefundResult records the provider's answer to a PENDING refund,
+// returning errs.ErrConflict if the refund was already settled.
+func (r *repository) UpdateRefundResult(ctx context.Context, id, providerRefundID, status, failureReason string) (Refund, error) {
+	var refundUUID pgtype.UUID
+	if err := refundUUID.Scan(id); err != nil {
+		return Refund{}, err
+	}
+
+	row, err := r.queries(ctx).UpdateRefundResult(ctx, sqlc.UpdateRefundResultParams{
+		ID:               refundUUID,
+		ProviderRefundID: pgtype.Text{String: providerRefundID, Valid: providerRefundID != ""},
+		Status:           status,
+		FailureReason:    pgtype.Text{String: failureReason, Valid: failureReason != ""},
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Refund{}, errs.ErrConflict
+		}
+		return Refund{}, err
+	}
+
+	return mapRefund(row), nil
+}
+
+// GetRefundTotals sums a payment's refunds: succeededCents is what the
+// provider has confirmed, committedCents adds refunds still pending.
+func (r *repository) GetRefundTotals(ctx context.Context, paymentID string) (int64, int64, error) {
+	var paymentUUID pgtype.UUID
+	if err := paymentUUID.Scan(paymentID); err != nil {
+		return 0, 0, err
+	}
+
+	totals, err := r.queries(ctx).GetPaymentRefundTotals(ctx, paymentUUID)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return totals.SucceededCents, totals.CommittedCents, nil
+}
+
+func (r *repository) CreateItems(ctx context.Context, orderID string, items []CreateOrderItemInput) ([]OrderItem, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	created := make([]OrderItem, 0, len(items))
+	for _, item := range items {
+		var productUUID pgtype.UUID
+		if err := productUUID.Scan(item.ProductID); err != nil {
+			return nil, err
+		}
+
+		params := sqlc.CreateOrderItemParams{
+			OrderID:         orderUUID,
+			ProductID:       productUUID,
+			Sku:             pgtype.Text{String: item.SKU, Valid: item.SKU != ""},
+			Name:            pgtype.Text{String: item.Name, Valid: true},
+			Qty:             int32(item.Qty),
+			UnitPriceCents:  int32(item.PriceCents),
+			TotalPriceCents: int32(item.Qty * item.PriceCents),
+		}
+
+		row, err := r.queries(ctx).CreateOrderItem(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+
+		created = append(created, mapOrderItem(row))
+	}
+
+	return created, nil
+}
+
+// CreateTaxLines stores the per-item tax breakdown of an order.
+func (r *repository) CreateTaxLines(ctx context.Context, orderID string, lines []CreateTaxLineInput) ([]TaxLine, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	created := make([]TaxLine, 0, len(lines))
+	for _, line := range lines {
+		var orderItemUUID, taxRateUUID pgtype.UUID
+		if line.OrderItemID != "" {
+			if err := orderItemUUID.Scan(line.OrderItemID); err != nil {
+				return nil, err
+			}
+		}
+		if line.TaxRateID != "" {
+			if err := taxRateUUID.Scan(line.TaxRateID); err != nil {
+				return nil, err
+			}
+		}
+
+		row, err := r.queries(ctx).CreateOrderTaxLine(ctx, sqlc.CreateOrderTaxLineParams{
+			OrderID:      orderUUID,
+			OrderItemID:  orderItemUUID,
+			TaxRateID:    taxRateUUID,
+			Jurisdiction: line.Jurisdiction,
+			TaxCategory:  line.TaxCategory,
+			RateBps:      line.RateBps,
+			IsInclusive:  line.IsInclusive,
+			TaxableCents: line.TaxableCents,
+			TaxCents:     line.TaxCents,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		created = append(created, mapTaxLine(row))
+	}
+
+	return created, nil
+}
+
+func (r *repository) ListTaxLines(ctx context.Context, orderID string) ([]TaxLine, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListOrderTaxLines(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := make([]TaxLine, len(rows))
+	for i, row := range rows {
+		lines[i] = mapTaxLine(row)
+	}
+
+	return lines, nil
+}
+
+// CreateAdjustments stores the pricing steps of an order, keeping the order
+// they were applied in.
+func (r *repository) CreateAdjustments(ctx context.Context, orderID string, adjustments []CreateAdjustmentInput) ([]Adjustment, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	created := make([]Adjustment, 0, len(adjustments))
+	for i, adj := range adjustments {
+		var orderItemUUID pgtype.UUID
+		if adj.OrderItemID != "" {
+			if err := orderItemUUID.Scan(adj.OrderItemID); err != nil {
+				return nil, err
+			}
+		}
+
+		row, err := r.queries(ctx).CreateOrderAdjustment(ctx, sqlc.CreateOrderAdjustmentParams{
+			OrderID:     orderUUID,
+			OrderItemID: orderItemUUID,
+			Kind:        adj.Kind,
+			Code:        adj.Code,
+			Description: adj.Description,
+			AmountCents: adj.AmountCents,
+			Position:    int32(i),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		created = append(created, mapAdjustment(row))
+	}
+
+	return created, nil
+}
+
+func (r *repository) ListAdjustments(ctx context.Context, orderID string) ([]Adjustment, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListOrderAdjustments(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustments := make([]Adjustment, len(rows))
+	for i, row := range rows {
+		adjustments[i] = mapAdjustment(row)
+	}
+
+	return adjustments, nil
+}
+
+func (r *repository) CreateStatusHistory(ctx context.Context, entry StatusHistoryInput) (StatusHistory, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(entry.OrderID); err != nil {
+		return StatusHistory{}, err
+	}
+
+	// System-initiated changes (webhooks, jobs) have no actor
+	var changedBy pgtype.UUID
+	if entry.ChangedBy != "" {
+		if err := changedBy.Scan(entry.ChangedBy); err != nil {
+			return StatusHistory{}, err
+		}
+	}
+
+	params := sqlc.CreateOrderStatusHistoryParams{
+		OrderID:    orderUUID,
+		FromStatus: entry.FromStatus,
+		ToStatus:   entry.ToStatus,
+		ChangedBy:  changedBy,
+		Reason:     pgtype.Text{String: entry.Reason, Valid: entry.Reason != ""},
+	}
+
+	row, err := r.queries(ctx).CreateOrderStatusHistory(ctx, params)
+	if err != nil {
+		return StatusHistory{}, err
+	}
+
+	return mapStatusHistory(row), nil
+}
+
+func (r *repository) ListStatusHistory(ctx context.Context, orderID string) ([]StatusHistory, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListOrderStatusHistory(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]StatusHistory, len(rows))
+	for i, row := range rows {
+		history[i] = mapStatusHistory(row)
+	}
+
+	return history, nil
+}
+
+// orderItemRow mirrors the to_jsonb(order_items) shape aggregated by the
+// *WithItems queries.
+type orderItemRow struct {
+	ID              uuid.UUID `json:"id"`
+	OrderID         uuid.UUID `json:"order_id"`
+	ProductID       uuid.UUID `json:"product_id"`
+	SKU             string    `json:"sku"`
+	Name            string    `json:"name"`
+	Qty             int       `json:"qty"`
+	UnitPriceCents  int64     `json:"unit_price_cents"`
+	TotalPriceCents int64     `json:"total_price_cents"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
 // --- Helper to map JSON items ---
 func mapOrderItems(itemsJSON interface{}) ([]OrderItem, error) {
 	bytes, err := json.Marshal(itemsJSON)
 	if err != nil {
 		return nil, err
 	}
-	var items []OrderItem
-	if err := json.Unmarshal(bytes, &items); err != nil {
+	var rows []orderItemRow
+	if err := json.Unmarshal(bytes, &rows); err != nil {
 		return nil, err
 	}
+
+	items := make([]OrderItem, len(rows))
+	for i, row := range rows {
+		items[i] = OrderItem{
+			ID:              row.ID,
+			OrderID:         row.OrderID,
+			ProductID:       row.ProductID,
+			SKU:             row.SKU,
+			Name:            row.Name,
+			Qty:             row.Qty,
+			UnitPriceCents:  row.UnitPriceCents,
+			TotalPriceCents: row.TotalPriceCents,
+			CreatedAt:       row.CreatedAt,
+			UpdatedAt:       row.UpdatedAt,
+		}
+	}
 	return items, nil
 }
 
+func mapOrderItem(row sqlc.OrderItem) OrderItem {
+	return OrderItem{
+		ID:              uuid.UUID(row.ID.Bytes),
+		OrderID:         uuid.UUID(row.OrderID.Bytes),
+		ProductID:       uuid.UUID(row.ProductID.Bytes),
+		SKU:             row.Sku.String,
+		Name:            row.Name.String,
+		Qty:             int(row.Qty),
+		UnitPriceCents:  int64(row.UnitPriceCents),
+		TotalPriceCents: int64(row.TotalPriceCents),
+		CreatedAt:       row.CreatedAt.Time,
+		UpdatedAt:       row.UpdatedAt.Time,
+	}
+}
+
 func mapOrder(row sqlc.Order) Order {
 	return Order{
 		ID:            uuid.UUID(row.ID.Bytes),
@@ -322,5 +1028,111 @@ func mapOrder(row sqlc.Order) Order {
 		Notes:         row.Notes.String,
 		CreatedAt:     row.CreatedAt.Time,
 		UpdatedAt:     row.UpdatedAt.Time,
+		PaidAt:        timePtr(row.PaidAt),
+		ShippedAt:     timePtr(row.ShippedAt),
+		DeliveredAt:   timePtr(row.DeliveredAt),
+		CancelledAt:   timePtr(row.CancelledAt),
+		RefundedAt:    timePtr(row.RefundedAt),
+		RefundedCents: row.RefundedCents,
+		ShippingMethodID:   uuidPtr(row.ShippingMethodID),
+		ShippingMethodName: row.ShippingMethodName.String,
 		Items: 	   []OrderItem{},
-}}
\ No newline at end of file
+}}
+
+func mapStatusHistory(row sqlc.OrderStatusHistory) StatusHistory {
+	var changedBy *uuid.UUID
+	if row.ChangedBy.Valid {
+		id := uuid.UUID(row.ChangedBy.Bytes)
+		changedBy = &id
+	}
+
+	return StatusHistory{
+		ID:         uuid.UUID(row.ID.Bytes),
+		OrderID:    uuid.UUID(row.OrderID.Bytes),
+		FromStatus: row.FromStatus,
+		ToStatus:   row.ToStatus,
+		ChangedBy:  changedBy,
+		Reason:     row.Reason.String,
+		CreatedAt:  row.CreatedAt.Time,
+	}
+}
+
+func mapTaxLine(row sqlc.OrderTaxLine) TaxLine {
+	return TaxLine{
+		ID:           uuid.UUID(row.ID.Bytes),
+		OrderID:      uuid.UUID(row.OrderID.Bytes),
+		OrderItemID:  uuidPtr(row.OrderItemID),
+		TaxRateID:    uuidPtr(row.TaxRateID),
+		Jurisdiction: row.Jurisdiction,
+		TaxCategory:  row.TaxCategory,
+		RateBps:      row.RateBps,
+		IsInclusive:  row.IsInclusive,
+		TaxableCents: row.TaxableCents,
+		TaxCents:     row.TaxCents,
+		CreatedAt:    row.CreatedAt.Time,
+	}
+}
+
+func mapAdjustment(row sqlc.OrderAdjustment) Adjustment {
+	return Adjustment{
+		ID:          uuid.UUID(row.ID.Bytes),
+		OrderID:     uuid.UUID(row.OrderID.Bytes),
+		OrderItemID: uuidPtr(row.OrderItemID),
+		Kind:        row.Kind,
+		Code:        row.Code,
+		Description: row.Description,
+		AmountCents: row.AmountCents,
+		CreatedAt:   row.CreatedAt.Time,
+	}
+}
+
+func mapOrderPayment(p sqlc.Payment) OrderPayment {
+	return OrderPayment{
+		ID:                p.ID.Bytes,
+		OrderID:           p.OrderID.Bytes,
+		Provider:          p.Provider,
+		ProviderTxnID:     p.ProviderTxnID.String,
+		PaymentMethod:     p.PaymentMethod,
+		AmountCents:       p.AmountCents,
+		Currency:          p.Currency,
+		Status:            p.Status,
+		CaptureMethod:     p.CaptureMethod,
+		Reference:         p.Reference.String,
+		ExpiresAt:         timePtr(p.ExpiresAt),
+		CheckoutSessionID: p.CheckoutSessionID.String,
+	}
+}
+
+func mapRefund(row sqlc.Refund) Refund {
+	return Refund{
+		ID:               row.ID.Bytes,
+		PaymentID:        row.PaymentID.Bytes,
+		OrderID:          row.OrderID.Bytes,
+		Provider:         row.Provider,
+		ProviderRefundID: row.ProviderRefundID.String,
+		AmountCents:      row.AmountCents,
+		Currency:         row.Currency,
+		Reason:           row.Reason.String,
+		Status:           row.Status,
+		FailureReason:    row.FailureReason.String,
+		CreatedBy:        uuidPtr(row.CreatedBy),
+		CreatedAt:        row.CreatedAt.Time,
+		UpdatedAt:        row.UpdatedAt.Time,
+	}
+}
+
+func uuidPtr(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	u := uuid.UUID(id.Bytes)
+	return &u
+}
+
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
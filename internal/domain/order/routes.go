@@ -1,22 +1,30 @@
 package order
 
 import (
+	"net/http"
+
 	"ecommerce-app/internal/pkg/middleware"
 	"ecommerce-app/internal/pkg/validator"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func Routes(svc Service) chi.Router {
+// Routes mounts the order endpoints. idempotent guards the endpoints that
+// create orders and payments against client retries.
+func Routes(svc Service, idempotent func(http.Handler) http.Handler) chi.Router {
 	h := NewHandler(svc)
 	r := chi.NewRouter()
 
-	r.With(validator.Validate[CreateOrderRequest]()).With(middleware.RoleMiddleware("customer")).Post("/", h.CreateOrder)
+	r.With(middleware.RoleMiddleware("customer")).With(idempotent).With(validator.Validate[CreateOrderRequest]()).Post("/", h.CreateOrder)
+	r.With(middleware.RoleMiddleware("customer")).With(idempotent).With(validator.Validate[CheckoutRequest]()).Post("/checkout", h.Checkout)
+	r.With(middleware.RoleMiddleware("customer")).With(validator.Validate[CheckoutSummaryRequest]()).Post("/checkout/summary", h.CheckoutSummary)
 	
 	r.With(middleware.RoleMiddleware("customer")).Get("/", h.GetOrdersByUser)
 	r.Get("/{id}", h.GetOrderByID)
-	r.With(middleware.RoleMiddleware("customer")).Get("/all", h.GetAllOrders)
+	r.With(middleware.RoleMiddleware("admin", "support")).Get("/{id}/history", h.GetOrderStatusHistory)
+	r.With(middleware.RoleMiddleware("admin", "support")).Get("/all", h.GetAllOrders)
 
+	r.With(validator.Validate[CancelOrderRequest]()).With(middleware.RoleMiddleware("customer", "admin")).Post("/{id}/cancel", h.CancelOrder)
 	r.With(validator.Validate[UpdateOrderStatusRequest]()).With(middleware.RoleMiddleware("admin")).Put("/{id}", h.UpdateOrderStatus)
 	r.With(middleware.RoleMiddleware("admin")).Delete("/{id}", h.DeleteOrder)
 
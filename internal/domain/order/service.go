@@ -3,106 +3,619 @@ package order
 
 import (
 	"context"
-	"ecommerce-app/internal/domain/stripe"
+	"ecommerce-app/internal/domain/cartitem"
+	"ecommerce-app/internal/domain/gateway"
+	"ecommerce-app/internal/domain/giftcard"
+	"ecommerce-app/internal/domain/inventory"
+	"ecommerce-app/internal/domain/wallet"
+	"ecommerce-app/internal/pkg/database"
 	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/httputil"
 	"ecommerce-app/internal/pkg/logger"
 	"ecommerce-app/internal/pkg/response"
 	"ecommerce-app/pkg/idgen"
 	"ecommerce-app/pkg/pagination"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 )
 
 type Service interface {
 	CreateOrder(ctx context.Context, userID string, req CreateOrderRequest) (OrderWithClientSecret, *errs.AppError)
+	Checkout(ctx context.Context, userID string, req CheckoutRequest) (OrderWithClientSecret, *errs.AppError)
+	CheckoutSummary(ctx context.Context, userID string, req CheckoutSummaryRequest) (CheckoutSummary, *errs.AppError)
 	GetOrderByID(ctx context.Context, id string) (Order, *errs.AppError)
 	GetOrdersByUserID(ctx context.Context, userID string, page, perPage int) (OrdersWithMeta, *errs.AppError)
-	GetAllOrders(ctx context.Context, page, perPage int) (OrdersWithMeta, *errs.AppError)
-	UpdateOrderStatus(ctx context.Context, id string, status string) (Order, *errs.AppError)
+	GetAllOrders(ctx context.Context, filter OrderFilter, sort httputil.SortParams, page, perPage int) (OrdersWithMeta, *errs.AppError)
+	UpdateOrderStatus(ctx context.Context, id string, status string, changedBy string, reason string) (Order, *errs.AppError)
+	GetOrderStatusHistory(ctx context.Context, id string) ([]StatusHistory, *errs.AppError)
+	CancelOrder(ctx context.Context, userID, role, id string, req CancelOrderRequest) (Order, *errs.AppError)
+	RefundOrder(ctx context.Context, id string, amountCents int64, changedBy, reason string) (Order, *errs.AppError)
+	RefundPrepaid(ctx context.Context, id string, amountCents int64, changedBy, reason string) (int64, *errs.AppError)
+	RefundPayment(ctx context.Context, paymentID string, amountCents int64, toWallet bool, changedBy, reason string) (Refund, *errs.AppError)
+	ApplyRefund(ctx context.Context, paymentID string, amountCents int64, changedBy, reason string) (Order, *errs.AppError)
+	ReverseLatePayment(ctx context.Context, paymentID, reason string) *errs.AppError
+	CapturePayment(ctx context.Context, orderID string, unshipped []UnshippedItem) (Order, *errs.AppError)
+	CancelStaleAuthorizations(ctx context.Context, olderThan time.Duration) (int, *errs.AppError)
+	ConfirmOfflinePayment(ctx context.Context, paymentID, changedBy string) (Order, *errs.AppError)
+	ConfirmCashOnDelivery(ctx context.Context, orderID, courierID string) (Order, *errs.AppError)
+	MarkDelivered(ctx context.Context, orderID string, deliveredAt time.Time) *errs.AppError
+	ExpireOfflinePayment(ctx context.Context, paymentID, changedBy, reason string) (Order, *errs.AppError)
+	ExpireOfflinePayments(ctx context.Context) (int, *errs.AppError)
+	ReleaseReservedStock(ctx context.Context, orderID string) *errs.AppError
 	DeleteOrder(ctx context.Context, id string) *errs.AppError
 	CreateOrderPayment(ctx context.Context, order Order, providerName, providerTxnID, status string) *errs.AppError
 }
 
+// sortableOrderFields are the sort_by values the admin order search accepts
+var sortableOrderFields = map[string]bool{
+	"created_at":   true,
+	"paid_at":      true,
+	"total_cents":  true,
+	"order_number": true,
+}
+
 type service struct {
 	repo Repository
 	productSvc ProductProvider
+	cartSvc CartProvider
+	cartItemSvc CartItemProvider
+	couponSvc CouponProvider
+	taxSvc TaxCalculator
+	shippingSvc ShippingQuoter
+	payments PaymentProvider
+	walletSvc WalletProvider
+	giftCardSvc GiftCardProvider
+	offline OfflinePaymentConfig
+	inventorySvc InventoryProvider
+	reservationTTL time.Duration
 }
 
-func NewService(repo Repository, productSvc ProductProvider) Service {
-	return &service{repo: repo, productSvc: productSvc}
+// NewService wires the order service. Stock reserved for an order paid
+// through the gateway is released if it is not paid within reservationTTL;
+// 0 holds it until the order is paid or cancelled.
+func NewService(repo Repository, productSvc ProductProvider, cartSvc CartProvider, cartItemSvc CartItemProvider, couponSvc CouponProvider, taxSvc TaxCalculator, shippingSvc ShippingQuoter, payments PaymentProvider, walletSvc WalletProvider, giftCardSvc GiftCardProvider, offline OfflinePaymentConfig, inventorySvc InventoryProvider, reservationTTL time.Duration) Service {
+	return &service{repo: repo, productSvc: productSvc, cartSvc: cartSvc, cartItemSvc: cartItemSvc, couponSvc: couponSvc, taxSvc: taxSvc, shippingSvc: shippingSvc, payments: payments, walletSvc: walletSvc, giftCardSvc: giftCardSvc, offline: offline, inventorySvc: inventorySvc, reservationTTL: reservationTTL}
 }
 
 func (s *service) CreateOrder(ctx context.Context, userID string, req CreateOrderRequest) (OrderWithClientSecret, *errs.AppError) {
+	return s.placeOrder(ctx, pricingRequest{
+		UserID:           userID,
+		Items:            req.Items,
+		ShippingInfo:     req.ShippingInfo,
+		ShippingMethodID: req.ShippingMethodID,
+		CouponCode:       req.CouponCode,
+		UseWallet:        req.UseWallet,
+		GiftCardCodes:    req.GiftCardCodes,
+		PaymentMethod:    req.PaymentMethod,
+		CheckoutMode:     req.CheckoutMode,
+		SuccessURL:       req.SuccessURL,
+		CancelURL:        req.CancelURL,
+	}, req.Notes, nil)
+}
+
+func (s *service) Checkout(ctx context.Context, userID string, req CheckoutRequest) (OrderWithClientSecret, *errs.AppError) {
+	cartItems, items, appErr := s.activeCartItems(ctx, userID)
+	if appErr != nil {
+		return OrderWithClientSecret{}, appErr
+	}
+
+	// Only the cart items that were priced leave the cart, so anything added
+	// or changed while the order was being placed stays. Removing them runs
+	// in the transaction that completes the order, so a failed checkout
+	// leaves the cart untouched.
+	clearCart := func(ctx context.Context, _ Order) *errs.AppError {
+		return s.cartItemSvc.RemoveOrderedItems(ctx, cartItems)
+	}
+
+	return s.placeOrder(ctx, pricingRequest{
+		UserID:           userID,
+		Items:            items,
+		ShippingInfo:     req.ShippingInfo,
+		ShippingMethodID: req.ShippingMethodID,
+		CouponCode:       req.CouponCode,
+		UseWallet:        req.UseWallet,
+		GiftCardCodes:    req.GiftCardCodes,
+		PaymentMethod:    req.PaymentMethod,
+		CheckoutMode:     req.CheckoutMode,
+		SuccessURL:       req.SuccessURL,
+		CancelURL:        req.CancelURL,
+	}, req.Notes, clearCart)
+}
+
+// CheckoutSummary prices the user's active cart exactly as Checkout would,
+// so the customer sees discounts, shipping and tax before placing the order.
+// Shipping is left out when no method is given.
+func (s *service) CheckoutSummary(ctx context.Context, userID string, req CheckoutSummaryRequest) (CheckoutSummary, *errs.AppError) {
+	_, items, appErr := s.activeCartItems(ctx, userID)
+	if appErr != nil {
+		return CheckoutSummary{}, appErr
+	}
+
+	priced, appErr := s.priceItems(ctx, pricingRequest{
+		UserID:           userID,
+		Items:            items,
+		ShippingInfo:     req.ShippingInfo,
+		ShippingMethodID: req.ShippingMethodID,
+		CouponCode:       req.CouponCode,
+	})
+	if appErr != nil {
+		return CheckoutSummary{}, appErr
+	}
 
-	// Calculate order total, generate order number, etc.
-	subTotalCents := int64(0)
-	for _, item := range req.Items {
-		// Fetch product price
-		prod, appErr := s.productSvc.GetProductByID(ctx, item.ProductID)
+	summary := CheckoutSummary{
+		Items:         make([]CheckoutSummaryItem, len(priced.items)),
+		SubtotalCents: priced.subtotalCents,
+		DiscountCents: priced.discountCents,
+		TaxCents:      priced.tax.TaxCents,
+		ShippingCents: priced.shippingCents(),
+		TotalCents:    priced.totalCents(),
+		CouponCode:    priced.couponCode(),
+		TaxExempt:     priced.tax.Exempt,
+		TaxLines:      priced.tax.Lines,
+		ShippingMethod: priced.shipping,
+		Adjustments:   priced.adjustments,
+	}
+	summary.AmountDueCents = summary.TotalCents
+	if len(req.GiftCardCodes) > 0 {
+		// Orders are placed in the store currency
+		giftCardCents, appErr := s.giftCardSvc.PreviewRedemption(ctx, req.GiftCardCodes, summary.AmountDueCents, wallet.DefaultCurrency)
+		if appErr != nil {
+			return CheckoutSummary{}, appErr
+		}
+		summary.GiftCardCents = giftCardCents
+		summary.AmountDueCents -= giftCardCents
+	}
+	if req.UseWallet {
+		w, appErr := s.walletSvc.GetWallet(ctx, userID)
 		if appErr != nil {
-			return OrderWithClientSecret{}, appErr
+			return CheckoutSummary{}, appErr
+		}
+		// Orders are placed in the store currency; credit held in another
+		// one cannot be applied
+		if w.BalanceCents > 0 && strings.EqualFold(w.Currency, wallet.DefaultCurrency) {
+			summary.WalletCents = min(w.BalanceCents, summary.AmountDueCents)
+			summary.AmountDueCents -= summary.WalletCents
+		}
+	}
+	for i, item := range priced.items {
+		summary.Items[i] = CheckoutSummaryItem{
+			ProductID:      item.ProductID,
+			SKU:            item.SKU,
+			Name:           item.Name,
+			Qty:            item.Qty,
+			UnitPriceCents: int64(item.PriceCents),
+			DiscountCents:  priced.lineDiscounts[i],
+			TotalCents:     priced.lineCents(i),
+		}
+	}
+	if summary.Adjustments == nil {
+		summary.Adjustments = []PriceAdjustment{}
+	}
+
+	return summary, nil
+}
+
+// activeCartItems returns the lines of the user's non-expired cart, as read
+// and as order items. An expired or empty cart is a bad request.
+func (s *service) activeCartItems(ctx context.Context, userID string) ([]cartitem.CartItem, []CreateOrderItem, *errs.AppError) {
+	// Only a non-expired cart counts; an expired one is reported as missing
+	c, appErr := s.cartSvc.GetCartByUserID(ctx, userID)
+	if appErr != nil {
+		if errors.Is(appErr, errs.ErrNotFound) {
+			return nil, nil, errs.ErrBadRequest.WithMessage("No active cart found, it may have expired")
 		}
+		return nil, nil, appErr
+	}
 
-		itemPriceCents := prod.PriceCents
+	cartItems, appErr := s.cartItemSvc.GetItemsByUserID(ctx, userID)
+	if appErr != nil {
+		return nil, nil, appErr
+	}
+
+	active := make([]cartitem.CartItem, 0, len(cartItems))
+	items := make([]CreateOrderItem, 0, len(cartItems))
+	for _, ci := range cartItems {
+		if ci.CartID != c.ID {
+			continue
+		}
+		active = append(active, ci)
+		items = append(items, CreateOrderItem{
+			ProductID: ci.ProductID.String(),
+			Quantity:  int(ci.Quantity),
+		})
+	}
 
-		subTotalCents += int64(item.Quantity) * int64(itemPriceCents)
+	if len(items) == 0 {
+		return nil, nil, errs.ErrBadRequest.WithMessage("Cart is empty")
 	}
-	
+
+	return active, items, nil
+}
+
+// placeOrder prices the requested lines, then writes the order header, line
+// items, pricing adjustments, tax breakdown and stock reservation in one
+// transaction, redeeming the coupon if one was applied. Gift cards, then
+// the customer's wallet when UseWallet is set, pay what they can and only
+// the rest is charged; an order they pay in full is PAID at once and has
+// no payment. A bank transfer or cash on delivery skips the gateway and
+// leaves the order PENDING with payment instructions instead of a client
+// secret. A card payment is opened with the provider once the order has
+// committed (see openCardPayment). In checkout session mode the customer
+// pays on the provider's hosted page and gets its URL instead of a client
+// secret. afterCreate, when set, runs in the transaction that completes
+// the order: the order's own, or the card payment's.
+func (s *service) placeOrder(ctx context.Context, req pricingRequest, notes string, afterCreate func(ctx context.Context, order Order) *errs.AppError) (OrderWithClientSecret, *errs.AppError) {
+	if req.CheckoutMode == CheckoutModeSession {
+		if req.PaymentMethod != "" && req.PaymentMethod != PaymentMethodCard {
+			return OrderWithClientSecret{}, errs.ErrBadRequest.WithMessage("checkout_session mode is only available for card payments")
+		}
+		if req.SuccessURL == "" || req.CancelURL == "" {
+			return OrderWithClientSecret{}, errs.ErrBadRequest.WithMessage("success_url and cancel_url are required in checkout_session mode")
+		}
+	}
+
+	priced, appErr := s.priceItems(ctx, req)
+	if appErr != nil {
+		return OrderWithClientSecret{}, appErr
+	}
+
+	userID := req.UserID
 	orderNumber := idgen.GenerateReadableID("ORD")
 	dbReq := CreateOrderRequestInput{
 		UserID:       userID,
-		Items:        req.Items,
+		Items:        priced.items,
 		ShippingInfo: req.ShippingInfo,
-		Notes:        req.Notes,
+		Notes:        notes,
 		OrderNumber:  orderNumber,
-		SubtotalCents: subTotalCents,
-		TotalCents:   subTotalCents,
-		FinalCents:    subTotalCents,
+		SubtotalCents: priced.subtotalCents,
+		DiscountCents: priced.discountCents,
+		TaxCents:     priced.tax.TaxCents,
+		ShippingCents: priced.shippingCents(),
+		TotalCents:   priced.totalCents(),
+		FinalCents:    priced.totalCents(),
+	}
+	if priced.shipping != nil {
+		dbReq.ShippingMethodID = priced.shipping.MethodID.String()
+		dbReq.ShippingMethodName = priced.shipping.Name
 	}
 
-	// Create order in DB
-	order, err := s.repo.Create(ctx, userID, dbReq)
+	var res OrderWithClientSecret
+	var awaitingCard bool
+
+	// Order header, line items and the stock reservation are written in one
+	// transaction so a failure at any step leaves nothing behind.
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		order, err := s.repo.Create(ctx, userID, dbReq)
+		if err != nil {
+			logger.Error("Failed to create order for user %s: %v", userID, err)
+			return errs.ErrInternal.WithMessage("Failed to create order")
+		}
+
+		orderItems, err := s.repo.CreateItems(ctx, order.ID.String(), dbReq.Items)
+		if err != nil {
+			logger.Error("Failed to create items for order %s: %v", order.ID.String(), err)
+			return errs.ErrInternal.WithMessage("Failed to create order items")
+		}
+		order.Items = orderItems
+
+		// Stock is held for every line before anything is charged; an order
+		// that cannot get all of it is not placed
+		if appErr := s.reserveStock(ctx, order, req.PaymentMethod, parseDestination(req.ShippingInfo).Country); appErr != nil {
+			return appErr
+		}
+
+		if len(priced.adjustments) > 0 {
+			adjustments, err := s.repo.CreateAdjustments(ctx, order.ID.String(), adjustmentInputs(priced.adjustments, orderItems))
+			if err != nil {
+				logger.Error("Failed to create adjustments for order %s: %v", order.ID.String(), err)
+				return errs.ErrInternal.WithMessage("Failed to create order adjustments")
+			}
+			order.Adjustments = adjustments
+		}
+
+		if len(priced.tax.Lines) > 0 {
+			taxLines, err := s.repo.CreateTaxLines(ctx, order.ID.String(), taxLineInputs(priced.tax, orderItems))
+			if err != nil {
+				logger.Error("Failed to create tax lines for order %s: %v", order.ID.String(), err)
+				return errs.ErrInternal.WithMessage("Failed to create order tax lines")
+			}
+			order.TaxLines = taxLines
+		}
+
+		// The coupon was checked while pricing; redeeming it here makes sure
+		// concurrent orders cannot use it more often than allowed.
+		if priced.coupon != nil {
+			if _, appErr := s.couponSvc.RedeemCoupon(ctx, priced.coupon.Code); appErr != nil {
+				return appErr
+			}
+		}
+
+		// Gift cards and wallet credit are spent in this transaction, so a
+		// failed checkout leaves them untouched
+		var prepaidCents int64
+		if len(req.GiftCardCodes) > 0 {
+			giftCardCents, appErr := s.giftCardSvc.RedeemForOrder(ctx, order.ID.String(), req.GiftCardCodes, order.FinalCents, order.Currency)
+			if appErr != nil {
+				return appErr
+			}
+			prepaidCents += giftCardCents
+		}
+		if req.UseWallet {
+			walletCents, appErr := s.walletSvc.DebitForOrder(ctx, userID, order.ID.String(), order.FinalCents-prepaidCents, order.Currency)
+			if appErr != nil {
+				return appErr
+			}
+			prepaidCents += walletCents
+		}
+
+		if prepaidCents > 0 {
+			order.FinalCents -= prepaidCents
+			if err := s.repo.SetFinalCents(ctx, order.ID.String(), order.FinalCents); err != nil {
+				logger.Error("Failed to apply gift cards and wallet credit to order %s: %v", order.ID.String(), err)
+				return errs.ErrInternal.WithMessage("Failed to apply gift cards and wallet credit")
+			}
+
+			// Nothing is left to charge, so there is no payment to wait for
+			if order.FinalCents == 0 {
+				if afterCreate != nil {
+					if appErr := afterCreate(ctx, order); appErr != nil {
+						return appErr
+					}
+				}
+				paid, appErr := s.UpdateOrderStatus(ctx, order.ID.String(), StatusPaid, userID, "Paid with gift cards and wallet credit")
+				if appErr != nil {
+					return appErr
+				}
+				paid.Items = order.Items
+				paid.Adjustments = order.Adjustments
+				paid.TaxLines = order.TaxLines
+				res = OrderWithClientSecret{Order: paid}
+				return nil
+			}
+		}
+
+		if req.PaymentMethod == PaymentMethodBankTransfer || req.PaymentMethod == PaymentMethodCashOnDelivery {
+			instructions, appErr := s.createOfflinePayment(ctx, order, req.PaymentMethod)
+			if appErr != nil {
+				return appErr
+			}
+			if afterCreate != nil {
+				if appErr := afterCreate(ctx, order); appErr != nil {
+					return appErr
+				}
+			}
+			res = OrderWithClientSecret{
+				Order:               order,
+				PaymentInstructions: &instructions,
+			}
+			return nil
+		}
+
+		res = OrderWithClientSecret{Order: order}
+		awaitingCard = true
+		return nil
+	})
 	if err != nil {
-		return OrderWithClientSecret{}, errs.ErrInternal.WithMessage("Failed to create order")
+		return OrderWithClientSecret{}, errs.EnsureAppError(err)
+	}
+
+	if !awaitingCard {
+		return res, nil
 	}
 
-	// Create stripe payment intent
-	stripeClient := stripe.NewStripeProvider()
-	meta := map[string]string{"user_id": userID, "order_id": order.ID.String()}
+	return s.openCardPayment(ctx, res.Order, req, afterCreate)
+}
+
+// openCardPayment asks the provider for an intent, or a checkout session,
+// for what is left to pay on a committed order, then records the INITIATED
+// payment and runs afterCreate in a short transaction of its own. The
+// provider is never called while the order's stock is locked, and an order
+// that fails before this point leaves nothing at the provider. If the
+// payment cannot be opened the order is cancelled, which gives back its
+// stock, coupon, gift cards and wallet credit, and whatever the provider
+// opened is voided.
+func (s *service) openCardPayment(ctx context.Context, order Order, req pricingRequest, afterCreate func(ctx context.Context, order Order) *errs.AppError) (OrderWithClientSecret, *errs.AppError) {
+	orderID := order.ID.String()
+	meta := map[string]string{"user_id": req.UserID, "order_id": orderID}
+
+	var payment CreateOrderPaymentInput
+	res := OrderWithClientSecret{Order: order}
+
+	if req.CheckoutMode == CheckoutModeSession {
+		session, err := s.payments.CreateCheckoutSession(ctx, gateway.CheckoutSessionRequest{
+			AmountCents: order.FinalCents,
+			Currency:    order.Currency,
+			Lines:       checkoutLines(order),
+			SuccessURL:  req.SuccessURL,
+			CancelURL:   req.CancelURL,
+			Metadata:    meta,
+		})
+		if err != nil {
+			logger.Error("Failed to create checkout session for order %s: %v", orderID, err)
+			s.abandonOrder(ctx, order, OrderPayment{})
+			return OrderWithClientSecret{}, errs.ErrInternal.WithMessage("Failed to create checkout session")
+		}
+
+		logger.Info("Created %s checkout session %s for Order %s", s.payments.Name(), session.ID, orderID)
+
+		// The payment is found by its session until a webhook names the
+		// intent the session created
+		payment = CreateOrderPaymentInput{
+			ProviderTxnID:     session.IntentID,
+			CaptureMethod:     session.CaptureMethod,
+			CheckoutSessionID: session.ID,
+		}
+		res.CheckoutURL = session.URL
+	} else {
+		intent, err := s.payments.CreateIntent(ctx, gateway.IntentRequest{
+			AmountCents: order.FinalCents,
+			Currency:    order.Currency,
+			Metadata:    meta,
+		})
+		if err != nil {
+			logger.Error("Failed to create payment intent for order %s: %v", orderID, err)
+			s.abandonOrder(ctx, order, OrderPayment{})
+			return OrderWithClientSecret{}, errs.ErrInternal.WithMessage("Failed to create payment intent")
+		}
+
+		logger.Info("Created %s payment intent %s for Order %s", s.payments.Name(), intent.ID, orderID)
+
+		payment = CreateOrderPaymentInput{
+			ProviderTxnID: intent.ID,
+			CaptureMethod: intent.CaptureMethod,
+		}
+		res.ClientSecret = intent.ClientSecret
+	}
 
-	paymentIntent, err := stripeClient.CreatePaymentIntent(ctx, subTotalCents, "usd", meta)
+	payment.OrderID = orderID
+	payment.Provider = s.payments.Name()
+	payment.PaymentMethod = PaymentMethodCard
+	payment.AmountCents = order.FinalCents
+	payment.Currency = order.Currency
+	payment.Status = gateway.StatusInitiated
 
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.CreateOrderPayment(ctx, payment); err != nil {
+			logger.Error("Failed to create payment record for order %s: %v", orderID, err)
+			return errs.ErrInternal.WithMessage("Failed to create payment record")
+		}
+
+		if afterCreate != nil {
+			return afterCreate(ctx, order)
+		}
+		return nil
+	})
 	if err != nil {
-		logger.Error("Failed to create payment intent for order %s: %v", order.ID.String(), err)
-		return OrderWithClientSecret{}, errs.ErrInternal.WithMessage("Failed to create payment intent")
+		s.abandonOrder(ctx, order, OrderPayment{
+			OrderID:           order.ID,
+			ProviderTxnID:     payment.ProviderTxnID,
+			CheckoutSessionID: payment.CheckoutSessionID,
+			Status:            gateway.StatusInitiated,
+		})
+		return OrderWithClientSecret{}, errs.EnsureAppError(err)
+	}
+
+	return res, nil
+}
+
+// abandonOrder cancels an order whose card payment could not be opened,
+// voiding what the provider already opened for it. Failures are logged;
+// the order's stock reservation lapses all the same.
+func (s *service) abandonOrder(ctx context.Context, order Order, payment OrderPayment) {
+	orderID := order.ID.String()
+
+	if _, appErr := s.UpdateOrderStatus(ctx, orderID, StatusCancelled, "", "Payment could not be opened"); appErr != nil {
+		logger.Error("Failed to cancel order %s after its payment could not be opened: %s", orderID, appErr.Message)
+	}
+
+	if payment.ProviderTxnID != "" || payment.CheckoutSessionID != "" {
+		s.voidPayment(ctx, payment)
+	}
+}
+
+// reserveStock holds the stock for an order's lines. Reservations of orders
+// paid through the gateway expire after reservationTTL; offline payments
+// hold theirs until the payment is confirmed or expires. country is where
+// the order ships to, which the closest allocation strategy picks
+// warehouses by.
+func (s *service) reserveStock(ctx context.Context, order Order, paymentMethod, country string) *errs.AppError {
+	lines := make([]inventory.ReservationLine, len(order.Items))
+	for i, item := range order.Items {
+		lines[i] = inventory.ReservationLine{
+			OrderItemID: item.ID.String(),
+			ProductID:   item.ProductID.String(),
+			Name:        item.Name,
+			Qty:         int32(item.Qty),
+		}
+	}
+
+	var expiresAt *time.Time
+	offline := paymentMethod == PaymentMethodBankTransfer || paymentMethod == PaymentMethodCashOnDelivery
+	if !offline && s.reservationTTL > 0 {
+		t := time.Now().Add(s.reservationTTL)
+		expiresAt = &t
+	}
+
+	return s.inventorySvc.ReserveStock(ctx, order.ID.String(), country, lines, expiresAt)
+}
+
+// checkoutLines mirrors the order items on the hosted checkout page at the
+// price paid for them, followed by shipping and tax not itemized against
+// them. A line whose paid amount does not split evenly across its units is
+// shown as a single line for the whole quantity. Gift cards and wallet
+// credit cannot be shown as negative lines, so an order they paid part of
+// is shown as one line for the amount due.
+func checkoutLines(order Order) []gateway.CheckoutLine {
+	lines := make([]gateway.CheckoutLine, 0, len(order.Items)+1)
+	var total int64
+	for _, item := range order.Items {
+		paid := order.LinePaidCents(item, int32(item.Qty))
+		total += paid
+		if paid%int64(item.Qty) == 0 {
+			lines = append(lines, gateway.CheckoutLine{Name: item.Name, Quantity: int64(item.Qty), UnitAmountCents: paid / int64(item.Qty)})
+			continue
+		}
+		lines = append(lines, gateway.CheckoutLine{Name: fmt.Sprintf("%s × %d", item.Name, item.Qty), Quantity: 1, UnitAmountCents: paid})
 	}
 
-	logger.Info("Created Stripe PaymentIntent %s for Order %s", paymentIntent.ID, order.ID.String())
+	rest := order.FinalCents - total
+	switch {
+	case rest == 0:
+		return lines
+	case rest > 0 && rest == order.ShippingCents:
+		return append(lines, gateway.CheckoutLine{Name: "Shipping", Quantity: 1, UnitAmountCents: rest})
+	case rest > 0:
+		return append(lines, gateway.CheckoutLine{Name: "Shipping and tax", Quantity: 1, UnitAmountCents: rest})
+	}
+
+	return []gateway.CheckoutLine{{Name: "Order " + order.OrderNumber, Quantity: 1, UnitAmountCents: order.FinalCents}}
+}
 
-	// Create payment record with INITIATED status in DB
-	err = s.repo.CreateOrderPayment(ctx, CreateOrderPaymentInput{
+// createOfflinePayment opens the INITIATED payment of an order paid by bank
+// transfer or cash on delivery and returns what the customer needs to pay
+// it. The order stays PENDING until the payment is confirmed.
+func (s *service) createOfflinePayment(ctx context.Context, order Order, method string) (PaymentInstructions, *errs.AppError) {
+	instructions := PaymentInstructions{
+		PaymentMethod: method,
+		Reference:     idgen.GenerateReadableID("PAY"),
+		AmountCents:   order.FinalCents,
+		Currency:      order.Currency,
+	}
+	if method == PaymentMethodBankTransfer {
+		instructions.Details = s.offline.BankTransferDetails
+		if s.offline.BankTransferTTL > 0 {
+			expiresAt := time.Now().Add(s.offline.BankTransferTTL)
+			instructions.ExpiresAt = &expiresAt
+		}
+	}
+
+	err := s.repo.CreateOrderPayment(ctx, CreateOrderPaymentInput{
 		OrderID:       order.ID.String(),
-		Provider:      "STRIPE",
-		ProviderTxnID: paymentIntent.ID,
-		PaymentMethod: "CREDIT_CARD",
+		Provider:      OfflineProvider,
+		PaymentMethod: method,
 		AmountCents:   order.FinalCents,
 		Currency:      order.Currency,
-		Status:        "INITIATED",
+		Status:        gateway.StatusInitiated,
+		Reference:     instructions.Reference,
+		ExpiresAt:     instructions.ExpiresAt,
 	})
 	if err != nil {
-		logger.Error("Failed to create payment record for order %s: %v", order.ID.String(), err)
-		return OrderWithClientSecret{}, errs.ErrInternal.WithMessage("Failed to create payment record")
-	}
-
-	res:= OrderWithClientSecret{
-		Order: order,
-		ClientSecret: paymentIntent.ClientSecret,
+		logger.Error("Failed to create %s payment for order %s: %v", method, order.ID.String(), err)
+		return PaymentInstructions{}, errs.ErrInternal.WithMessage("Failed to create payment record")
 	}
 
-	return res, nil
+	logger.Info("Awaiting %s payment %s for order %s", method, instructions.Reference, order.ID.String())
+	return instructions, nil
 }
 
 func (s *service) GetOrderByID(ctx context.Context, id string) (Order, *errs.AppError) {
 	order, err := s.repo.GetByID(ctx, id)
 	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Order{}, errs.ErrNotFound.WithMessage("Order not found")
+		}
 		return Order{}, errs.ErrInternal.WithMessage("Failed to get order")
 	}
 
@@ -138,17 +651,32 @@ func (s *service) GetOrdersByUserID(ctx context.Context, userID string, page, pe
 	return result, nil
 }
 
-func (s *service) GetAllOrders(ctx context.Context, page, perPage int) (OrdersWithMeta, *errs.AppError) {
+// GetAllOrders is the admin order search. The total in the returned meta
+// counts every order matching filter, not just the current page.
+func (s *service) GetAllOrders(ctx context.Context, filter OrderFilter, sort httputil.SortParams, page, perPage int) (OrdersWithMeta, *errs.AppError) {
+	for _, status := range filter.Statuses {
+		if _, ok := statusTransitions[status]; !ok {
+			return OrdersWithMeta{}, errs.ErrBadRequest.WithMessage(fmt.Sprintf("Unknown order status %s", status))
+		}
+	}
+
+	if sort.SortBy == "" {
+		sort.SortBy = "created_at"
+	}
+	if !sortableOrderFields[sort.SortBy] {
+		return OrdersWithMeta{}, errs.ErrBadRequest.WithMessage(fmt.Sprintf("Cannot sort orders by %s", sort.SortBy))
+	}
+
     p := pagination.New(page, perPage)
 	limit := int32(p.PerPage)
 	offset := int32(p.Offset())
 
-	orders, err := s.repo.GetAll(ctx, limit, offset)
+	orders, err := s.repo.GetAll(ctx, filter, sort, limit, offset)
 	if err != nil {
 		return OrdersWithMeta{}, errs.ErrInternal.WithMessage("Failed to get all orders")
 	}
 
-	total, err := s.repo.CountAll(ctx)
+	total, err := s.repo.CountAll(ctx, filter)
 	if err != nil {
 
 		return OrdersWithMeta{}, errs.ErrInternal.WithMessage("Failed to count orders")
@@ -168,18 +696,1124 @@ func (s *service) GetAllOrders(ctx context.Context, page, perPage int) (OrdersWi
 	return result, nil
 }
 
-func (s *service) UpdateOrderStatus(ctx context.Context, id string, status string) (Order, *errs.AppError) {
-	_, err := s.repo.GetByID(ctx, id)
+// UpdateOrderStatus moves an order to status if the transition graph allows
+// it, stamping the status timestamp and recording the change in the order's
+// status history, then runs what the new status sets off (see
+// afterStatusChange). changedBy is empty for system-initiated changes.
+// Re-applying the current status is a no-op. An order whose payment is
+// still only authorized cannot be marked SHIPPED; shipping it through its
+// shipments captures the payment first.
+func (s *service) UpdateOrderStatus(ctx context.Context, id string, status string, changedBy string, reason string) (Order, *errs.AppError) {
+	var updated Order
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Order not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order for update")
+		}
+
+		if current.Status == status {
+			updated = current
+			return nil
+		}
+
+		if !CanTransition(current.Status, status) {
+			return errs.ErrConflict.WithMessage(fmt.Sprintf("Cannot change order status from %s to %s", current.Status, status))
+		}
+
+		if status == StatusShipped {
+			payment, err := s.repo.GetOrderPayment(ctx, id)
+			if err != nil && !errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrInternal.WithMessage("Failed to get order payment")
+			}
+			if err == nil && payment.Status == gateway.StatusAuthorized {
+				return errs.ErrConflict.WithMessage("Order payment has not been captured; ship it through its shipments")
+			}
+		}
+
+		order, err := s.repo.UpdateStatus(ctx, id, current.Status, status)
+		if err != nil {
+			if errors.Is(err, errs.ErrConflict) {
+				return errs.ErrConflict.WithMessage("Order status was changed by another request, please retry")
+			}
+			return errs.ErrInternal.WithMessage("Failed to update order status")
+		}
+
+		_, err = s.repo.CreateStatusHistory(ctx, StatusHistoryInput{
+			OrderID:    id,
+			FromStatus: current.Status,
+			ToStatus:   status,
+			ChangedBy:  changedBy,
+			Reason:     reason,
+		})
+		if err != nil {
+			logger.Error("Failed to record status history for order %s: %v", id, err)
+			return errs.ErrInternal.WithMessage("Failed to record order status history")
+		}
+
+		if appErr := s.afterStatusChange(ctx, current, status, changedBy); appErr != nil {
+			return appErr
+		}
+
+		order.Items = current.Items
+		updated = order
+		return nil
+	})
+	if err != nil {
+		return Order{}, errs.EnsureAppError(err)
+	}
+
+	return updated, nil
+}
+
+// afterStatusChange runs inside the status change's transaction. A PAID
+// order takes its reserved stock and issues the gift cards it bought; a
+// CANCELLED one gives its stock and its coupon use back; a CANCELLED or
+// REFUNDED one voids its gift cards and gives back what it took from gift
+// cards and the wallet.
+func (s *service) afterStatusChange(ctx context.Context, current Order, status, changedBy string) *errs.AppError {
+	id := current.ID.String()
+
+	switch status {
+	case StatusPaid:
+		if appErr := s.inventorySvc.CommitReservations(ctx, id); appErr != nil {
+			return appErr
+		}
+		return s.issueGiftCards(ctx, current)
+	case StatusCancelled, StatusRefunded:
+		if status == StatusCancelled {
+			if appErr := s.inventorySvc.CancelReservations(ctx, id); appErr != nil {
+				return appErr
+			}
+			if code := current.CouponCode(); code != "" {
+				if appErr := s.couponSvc.ReleaseCoupon(ctx, code); appErr != nil {
+					return appErr
+				}
+			}
+		}
+		if _, appErr := s.giftCardSvc.ReverseOrderRedemptions(ctx, id); appErr != nil {
+			return appErr
+		}
+		if _, appErr := s.giftCardSvc.VoidOrderGiftCards(ctx, id); appErr != nil {
+			return appErr
+		}
+		walletReason := fmt.Sprintf("Order %s %s", current.OrderNumber, strings.ToLower(status))
+		if _, appErr := s.walletSvc.RestoreOrderDebit(ctx, current.UserID.String(), id, changedBy, walletReason); appErr != nil {
+			return appErr
+		}
+	}
+
+	return nil
+}
+
+// issueGiftCards issues a card for every unit of the order's gift-card
+// products, each worth the product's list price
+func (s *service) issueGiftCards(ctx context.Context, o Order) *errs.AppError {
+	var lines []giftcard.IssueLine
+	for _, item := range o.Items {
+		prod, appErr := s.productSvc.GetProductByID(ctx, item.ProductID.String())
+		if appErr != nil {
+			return appErr
+		}
+		if !prod.IsGiftCard {
+			continue
+		}
+		lines = append(lines, giftcard.IssueLine{
+			OrderItemID: item.ID.String(),
+			Qty:         item.Qty,
+			AmountCents: item.UnitPriceCents,
+		})
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	cards, appErr := s.giftCardSvc.IssueForOrder(ctx, giftcard.IssueForOrderInput{
+		OrderID:  o.ID.String(),
+		UserID:   o.UserID.String(),
+		Currency: o.Currency,
+		Lines:    lines,
+	})
+	if appErr != nil {
+		return appErr
+	}
+
+	logger.Info("Issued %d gift cards for order %s", len(cards), o.ID.String())
+	return nil
+}
+
+// CancelOrder lets a customer cancel their own order, or an admin any
+// order, before it ships. A PENDING order, or a PAID one whose payment is
+// only authorized, has its PaymentIntent voided and ends CANCELLED; a
+// captured PAID order is refunded in full through RefundOrder and ends
+// REFUNDED once the provider confirms the refund. Its stock goes back on the shelf once the provider has taken
+// the refund, and CapturePayment keeps it from shipping while the refund is
+// pending. The provider is only called once the cancellation has
+// committed, so a rollback never leaves a voided or refunded payment behind.
+func (s *service) CancelOrder(ctx context.Context, userID, role, id string, req CancelOrderRequest) (Order, *errs.AppError) {
+	var cancelled Order
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Order not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order")
+		}
+
+		if role != "admin" && current.UserID.String() != userID {
+			return errs.ErrForbidden.WithMessage("You can only cancel your own orders")
+		}
+
+		if current.ShippedAt != nil || (current.Status != StatusPending && current.Status != StatusPaid) {
+			return errs.ErrConflict.WithMessage(fmt.Sprintf("Order in status %s can no longer be cancelled", current.Status))
+		}
+
+		// Gift cards bought in the order are voided by the cancellation;
+		// once one has been spent from, the order has been used
+		if appErr := s.giftCardSvc.CheckOrderGiftCardsUnused(ctx, id); appErr != nil {
+			return appErr
+		}
+
+		payment, err := s.repo.GetOrderPayment(ctx, id)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) && current.FinalCents == 0 {
+				// Paid in full with gift cards or wallet credit, which the
+				// cancellation gives back
+				order, appErr := s.UpdateOrderStatus(ctx, id, StatusCancelled, userID, req.Reason)
+				if appErr != nil {
+					return appErr
+				}
+				cancelled = order
+				return nil
+			}
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrConflict.WithMessage("Order has no payment to cancel")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order payment")
+		}
+
+		if payment.Status == gateway.StatusAuthorized {
+			order, appErr := s.cancelAuthorization(ctx, payment.ID.String(), userID, req.Reason)
+			if appErr != nil {
+				return appErr
+			}
+			cancelled = order
+			return nil
+		}
+
+		if current.Status == StatusPaid {
+			order, appErr := s.RefundOrder(ctx, id, current.FinalCents-current.RefundedCents, userID, req.Reason)
+			if appErr != nil {
+				return appErr
+			}
+			// A refunded order keeps its stock committed, since it has
+			// usually shipped; this one never left the warehouse. Queued
+			// after the refund, so it sees the provider's answer.
+			paymentID := payment.ID.String()
+			database.AfterCommit(ctx, func(ctx context.Context) {
+				s.restockRefundedOrder(ctx, id, paymentID)
+			})
+			cancelled = order
+			return nil
+		}
+
+		order, appErr := s.UpdateOrderStatus(ctx, id, StatusCancelled, userID, req.Reason)
+		if appErr != nil {
+			return appErr
+		}
+
+		if err := s.repo.UpdateOrderPaymentStatus(ctx, payment.ID.String(), "CANCELLED"); err != nil {
+			return errs.ErrInternal.WithMessage("Failed to update payment status")
+		}
+
+		if !payment.IsOffline() {
+			database.AfterCommit(ctx, func(ctx context.Context) {
+				s.voidPayment(ctx, payment)
+			})
+		}
+
+		cancelled = order
+		return nil
+	})
+	if err != nil {
+		return Order{}, errs.EnsureAppError(err)
+	}
+
+	// A refund the provider settled at once has already moved the order on
+	if cancelled.Status == StatusPaid {
+		if latest, err := s.repo.GetByID(ctx, id); err == nil {
+			cancelled = latest
+		}
+	}
+
+	return cancelled, nil
+}
+
+// voidPayment voids a cancelled order's payment at the provider. An unpaid
+// checkout session owns its intent; expiring the session closes the hosted
+// page and voids the intent with it. It runs after the cancellation has
+// committed, so a failure is only logged; should the customer pay all the
+// same, the payment's webhook reverses it (see ReverseLatePayment).
+func (s *service) voidPayment(ctx context.Context, payment OrderPayment) {
+	orderID := payment.OrderID.String()
+
+	if payment.CheckoutSessionID != "" && payment.Status == gateway.StatusInitiated {
+		if err := s.payments.ExpireCheckoutSession(ctx, payment.CheckoutSessionID); err != nil {
+			logger.Error("Failed to expire checkout session %s for cancelled order %s: %v", payment.CheckoutSessionID, orderID, err)
+		}
+		return
+	}
+
+	if _, err := s.payments.CancelIntent(ctx, payment.ProviderTxnID); err != nil {
+		logger.Error("Failed to cancel payment %s for cancelled order %s: %v", payment.ProviderTxnID, orderID, err)
+	}
+}
+
+// restockRefundedOrder puts the stock of an order cancelled after capture
+// back on the shelf and gives its coupon use back, unless the provider
+// turned its refund down; the order then stays PAID and may still ship.
+func (s *service) restockRefundedOrder(ctx context.Context, orderID, paymentID string) {
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		payment, err := s.repo.LockPayment(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+
+		_, committed, err := s.repo.GetRefundTotals(ctx, paymentID)
+		if err != nil {
+			return err
+		}
+		if committed < payment.AmountCents {
+			logger.Warn("Refund of cancelled order %s was declined; its stock stays committed", orderID)
+			return nil
+		}
+
+		if appErr := s.inventorySvc.CancelReservations(ctx, orderID); appErr != nil {
+			return appErr
+		}
+
+		o, err := s.repo.GetByID(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if code := o.CouponCode(); code != "" {
+			if appErr := s.couponSvc.ReleaseCoupon(ctx, code); appErr != nil {
+				return appErr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to restock cancelled order %s: %v", orderID, err)
+	}
+}
+
+// RefundOrder refunds amountCents of a paid order against its latest
+// payment. An offline payment is refunded to the customer's wallet, as there
+// is nothing at the provider to refund against. A refund the provider
+// declines is an error; one it leaves pending is applied to the order once
+// its webhook reports success. Inside a caller's transaction the provider
+// is only asked once that transaction commits, so a decline is left on the
+// refund ledger instead.
+func (s *service) RefundOrder(ctx context.Context, id string, amountCents int64, changedBy, reason string) (Order, *errs.AppError) {
+	if amountCents <= 0 {
+		return Order{}, errs.ErrBadRequest.WithMessage("Refund amount must be greater than zero")
+	}
+
+	var refunded Order
+	var refund Refund
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.GetByID(ctx, id); err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Order not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order")
+		}
+
+		payment, err := s.repo.GetOrderPayment(ctx, id)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrConflict.WithMessage("Order has no payment to refund")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order payment")
+		}
+
+		order, opened, appErr := s.refundPayment(ctx, payment.ID.String(), amountCents, payment.IsOffline(), changedBy, reason)
+		if appErr != nil {
+			return appErr
+		}
+
+		refunded = order
+		refund = opened
+		return nil
+	})
+	if err != nil {
+		return Order{}, errs.EnsureAppError(err)
+	}
+
+	refund, err = s.repo.GetRefund(ctx, refund.ID.String())
 	if err != nil {
-		return Order{}, errs.ErrInternal.WithMessage("Failed to get order for update")
+		return Order{}, errs.ErrInternal.WithMessage("Failed to get refund")
+	}
+	if refund.Status == gateway.RefundFailed || refund.Status == gateway.RefundCancelled {
+		return Order{}, errs.ErrConflict.WithMessage(fmt.Sprintf("Refund was declined by the provider: %s", refund.FailureReason))
 	}
 
-	updateOrder, err:= s.repo.UpdateStatus(ctx, id, status)
+	if refund.Status == gateway.RefundSucceeded {
+		if latest, err := s.repo.GetByID(ctx, id); err == nil {
+			refunded = latest
+		}
+	}
+
+	return refunded, nil
+}
+
+// RefundPrepaid gives back up to amountCents of what an order paid with
+// wallet credit and gift cards, the wallet first, and returns how much it
+// gave back. It leaves the order's status and refunded total alone, which
+// only track its payment.
+func (s *service) RefundPrepaid(ctx context.Context, id string, amountCents int64, changedBy, reason string) (int64, *errs.AppError) {
+	if amountCents <= 0 {
+		return 0, nil
+	}
+
+	var restored int64
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Order not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order")
+		}
+
+		fromWallet, appErr := s.walletSvc.RestoreOrderDebitUpTo(ctx, current.UserID.String(), id, amountCents, changedBy, reason)
+		if appErr != nil {
+			return appErr
+		}
+
+		fromCards, appErr := s.giftCardSvc.RestoreOrderRedemptions(ctx, id, amountCents-fromWallet)
+		if appErr != nil {
+			return appErr
+		}
+
+		restored = fromWallet + fromCards
+		return nil
+	})
+	if err != nil {
+		return 0, errs.EnsureAppError(err)
+	}
+
+	return restored, nil
+}
+
+// RefundPayment refunds amountCents of a payment, or everything not yet
+// refunded when amountCents is 0, and records it in the refund ledger. With
+// toWallet the money goes to the customer's wallet instead of back to the
+// card. The returned refund carries the provider's answer, which may still
+// be PENDING; inside a caller's transaction it is always PENDING, as the
+// provider is only asked once that transaction commits.
+func (s *service) RefundPayment(ctx context.Context, paymentID string, amountCents int64, toWallet bool, changedBy, reason string) (Refund, *errs.AppError) {
+	if amountCents < 0 {
+		return Refund{}, errs.ErrBadRequest.WithMessage("Refund amount must not be negative")
+	}
+
+	var refund Refund
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		var appErr *errs.AppError
+		_, refund, appErr = s.refundPayment(ctx, paymentID, amountCents, toWallet, changedBy, reason)
+		if appErr != nil {
+			return appErr
+		}
+		return nil
+	})
+	if err != nil {
+		return Refund{}, errs.EnsureAppError(err)
+	}
+
+	latest, err := s.repo.GetRefund(ctx, refund.ID.String())
+	if err != nil {
+		return Refund{}, errs.ErrInternal.WithMessage("Failed to get refund")
+	}
+
+	return latest, nil
+}
+
+// refundPayment runs inside the caller's transaction. The refund is opened
+// as PENDING under a lock on the payment, so concurrent refunds cannot
+// together exceed what was charged, and sent to the provider once the
+// transaction commits (see sendRefund), so the ledger always has the refund
+// before any money moves. A refund to the wallet never reaches the provider
+// and succeeds at once.
+func (s *service) refundPayment(ctx context.Context, paymentID string, amountCents int64, toWallet bool, changedBy, reason string) (Order, Refund, *errs.AppError) {
+	payment, err := s.repo.LockPayment(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Order{}, Refund{}, errs.ErrNotFound.WithMessage("Payment not found")
+		}
+		return Order{}, Refund{}, errs.ErrInternal.WithMessage("Failed to get payment")
+	}
+
+	if payment.Status == gateway.StatusRefunded {
+		return Order{}, Refund{}, errs.ErrConflict.WithMessage("Payment has already been refunded in full")
+	}
+	if payment.Status != gateway.StatusSucceeded {
+		return Order{}, Refund{}, errs.ErrConflict.WithMessage(fmt.Sprintf("Payment in status %s cannot be refunded", payment.Status))
+	}
+
+	orderID := payment.OrderID.String()
+	current, err := s.repo.GetByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Order{}, Refund{}, errs.ErrNotFound.WithMessage("Order not found")
+		}
+		return Order{}, Refund{}, errs.ErrInternal.WithMessage("Failed to get order")
+	}
+
+	// A cancelled order can still hold a payment that went through after it
+	// was cancelled (see ReverseLatePayment)
+	if current.Status != StatusPaid && current.Status != StatusProcessing && current.Status != StatusShipped && current.Status != StatusCancelled {
+		return Order{}, Refund{}, errs.ErrConflict.WithMessage(fmt.Sprintf("Order in status %s cannot be refunded", current.Status))
+	}
+
+	_, committed, err := s.repo.GetRefundTotals(ctx, paymentID)
+	if err != nil {
+		return Order{}, Refund{}, errs.ErrInternal.WithMessage("Failed to get payment refunds")
+	}
+
+	remaining := payment.AmountCents - committed
+	if amountCents == 0 {
+		amountCents = remaining
+	}
+	if remaining <= 0 {
+		return Order{}, Refund{}, errs.ErrConflict.WithMessage("Payment has nothing left to refund")
+	}
+	if amountCents > remaining {
+		return Order{}, Refund{}, errs.ErrConflict.WithMessage(fmt.Sprintf("Refund exceeds the %d cents remaining on the payment", remaining))
+	}
+
+	// Refunding everything left makes the order REFUNDED, which voids the
+	// gift cards it bought; once one has been spent from, it cannot be
+	if amountCents == remaining {
+		if appErr := s.giftCardSvc.CheckOrderGiftCardsUnused(ctx, orderID); appErr != nil {
+			return Order{}, Refund{}, appErr
+		}
+	}
+
+	// Offline payments have nothing at the gateway to refund against
+	if payment.IsOffline() && !toWallet {
+		return Order{}, Refund{}, errs.ErrConflict.WithMessage(fmt.Sprintf("%s payments can only be refunded to the wallet", payment.PaymentMethod))
+	}
+
+	provider := payment.Provider
+	if toWallet {
+		provider = wallet.ProviderName
+	}
+
+	refund, err := s.repo.CreateRefund(ctx, CreateRefundInput{
+		PaymentID:   paymentID,
+		OrderID:     orderID,
+		Provider:    provider,
+		AmountCents: amountCents,
+		Currency:    payment.Currency,
+		Reason:      reason,
+		CreatedBy:   changedBy,
+	})
+	if err != nil {
+		logger.Error("Failed to record refund for payment %s: %v", paymentID, err)
+		return Order{}, Refund{}, errs.ErrInternal.WithMessage("Failed to record refund")
+	}
+
+	if toWallet {
+		return s.refundToWallet(ctx, current, refund, changedBy, reason)
+	}
+
+	database.AfterCommit(ctx, func(ctx context.Context) {
+		s.sendRefund(ctx, payment, refund, changedBy, reason)
+	})
+
+	return current, refund, nil
+}
+
+// sendRefund asks the provider to pay out a PENDING refund and records its
+// answer, applying the refund to the order when it succeeded at once. A
+// provider error fails the refund, which frees its amount to be refunded
+// again. The answer is recorded together with its effect on the order; if
+// that fails the refund stays PENDING and its webhook, which carries the
+// refund's ID, settles it instead.
+func (s *service) sendRefund(ctx context.Context, payment OrderPayment, refund Refund, changedBy, reason string) {
+	orderID := payment.OrderID.String()
+	refundID := refund.ID.String()
+
+	result, err := s.payments.Refund(ctx, gateway.RefundRequest{
+		IntentID:    payment.ProviderTxnID,
+		AmountCents: refund.AmountCents,
+		Metadata: map[string]string{
+			"order_id":                 orderID,
+			"reason":                   reason,
+			gateway.RefundReferenceKey: refundID,
+		},
+	})
+	if err != nil {
+		logger.Error("Failed to refund payment %s for order %s: %v", payment.ProviderTxnID, orderID, err)
+		if _, err := s.repo.UpdateRefundResult(ctx, refundID, "", gateway.RefundFailed, err.Error()); err != nil && !errors.Is(err, errs.ErrConflict) {
+			logger.Error("Failed to record failed refund %s for order %s: %v", refundID, orderID, err)
+		}
+		return
+	}
+
+	err = s.repo.WithTx(ctx, func(ctx context.Context) error {
+		if _, err := s.repo.UpdateRefundResult(ctx, refundID, result.ID, result.Status, result.FailureReason); err != nil {
+			return err
+		}
+		if result.Status != gateway.RefundSucceeded {
+			return nil
+		}
+		if _, appErr := s.ApplyRefund(ctx, payment.ID.String(), refund.AmountCents, changedBy, reason); appErr != nil {
+			return appErr
+		}
+		return nil
+	})
+	if errors.Is(err, errs.ErrConflict) {
+		// The refund's webhook got here first and settled it
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to record provider refund %s for order %s: %v", result.ID, orderID, err)
+	}
+}
+
+// refundToWallet pays an opened refund out as store credit and applies it
+// to the order. The wallet transaction stands in for the provider refund.
+func (s *service) refundToWallet(ctx context.Context, current Order, refund Refund, changedBy, reason string) (Order, Refund, *errs.AppError) {
+	txn, appErr := s.walletSvc.RefundToWallet(ctx, wallet.RefundInput{
+		UserID:      current.UserID.String(),
+		OrderID:     current.ID.String(),
+		RefundID:    refund.ID.String(),
+		AmountCents: refund.AmountCents,
+		Currency:    refund.Currency,
+		Reason:      reason,
+		CreatedBy:   changedBy,
+	})
+	if appErr != nil {
+		return Order{}, Refund{}, appErr
+	}
+
+	updated, err := s.repo.UpdateRefundResult(ctx, refund.ID.String(), txn.ID.String(), gateway.RefundSucceeded, "")
+	if err != nil {
+		logger.Error("Failed to record wallet refund %s: %v", refund.ID.String(), err)
+		return Order{}, Refund{}, errs.ErrInternal.WithMessage("Failed to record refund")
+	}
+
+	order, appErr := s.ApplyRefund(ctx, updated.PaymentID.String(), updated.AmountCents, changedBy, reason)
+	if appErr != nil {
+		return Order{}, Refund{}, appErr
+	}
+
+	return order, updated, nil
+}
+
+// ApplyRefund adds a refund the provider has confirmed to the order's
+// refunded total. The payment moves to REFUNDED once its confirmed refunds
+// cover it, and the order once its refunded total reaches final_cents;
+// partial refunds leave both statuses alone.
+func (s *service) ApplyRefund(ctx context.Context, paymentID string, amountCents int64, changedBy, reason string) (Order, *errs.AppError) {
+	var applied Order
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		payment, err := s.repo.LockPayment(ctx, paymentID)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Payment not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get payment")
+		}
+
+		orderID := payment.OrderID.String()
+		current, err := s.repo.GetByID(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Order not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order")
+		}
+
+		order, err := s.repo.AddRefundedCents(ctx, orderID, amountCents)
+		if err != nil {
+			if errors.Is(err, errs.ErrConflict) {
+				return errs.ErrConflict.WithMessage("Refund exceeds the amount remaining on the order")
+			}
+			return errs.ErrInternal.WithMessage("Failed to record order refund")
+		}
+		order.Items = current.Items
+
+		succeeded, _, err := s.repo.GetRefundTotals(ctx, paymentID)
+		if err != nil {
+			return errs.ErrInternal.WithMessage("Failed to get payment refunds")
+		}
+
+		if succeeded >= payment.AmountCents && payment.Status != gateway.StatusRefunded {
+			if err := s.repo.UpdateOrderPaymentStatus(ctx, paymentID, gateway.StatusRefunded); err != nil {
+				return errs.ErrInternal.WithMessage("Failed to update payment status")
+			}
+		}
+
+		// A cancelled order stays CANCELLED when a late payment is refunded
+		if order.RefundedCents >= order.FinalCents && CanTransition(current.Status, StatusRefunded) {
+			var appErr *errs.AppError
+			order, appErr = s.UpdateOrderStatus(ctx, orderID, StatusRefunded, changedBy, reason)
+			if appErr != nil {
+				return appErr
+			}
+		}
+
+		applied = order
+		return nil
+	})
+	if err != nil {
+		return Order{}, errs.EnsureAppError(err)
+	}
+
+	return applied, nil
+}
+
+// ReverseLatePayment gives back a payment that went through after its order
+// was cancelled, e.g. when the customer paid on a page left open: a
+// captured payment is refunded in full and an authorization is voided. It
+// runs in the caller's transaction and asks the provider once that
+// commits. An order that is not CANCELLED is a conflict.
+func (s *service) ReverseLatePayment(ctx context.Context, paymentID, reason string) *errs.AppError {
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		payment, err := s.repo.LockPayment(ctx, paymentID)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Payment not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get payment")
+		}
+
+		current, err := s.repo.GetByID(ctx, payment.OrderID.String())
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Order not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order")
+		}
+
+		if current.Status != StatusCancelled {
+			return errs.ErrConflict.WithMessage(fmt.Sprintf("Order in status %s has no payment to reverse", current.Status))
+		}
+
+		switch payment.Status {
+		case gateway.StatusAuthorized:
+			if err := s.repo.UpdateOrderPaymentStatus(ctx, paymentID, gateway.StatusCancelled); err != nil {
+				return errs.ErrInternal.WithMessage("Failed to update payment status")
+			}
+			database.AfterCommit(ctx, func(ctx context.Context) {
+				s.voidPayment(ctx, payment)
+			})
+		case gateway.StatusSucceeded:
+			if _, _, appErr := s.refundPayment(ctx, paymentID, 0, false, "", reason); appErr != nil {
+				return appErr
+			}
+		default:
+			return errs.ErrConflict.WithMessage(fmt.Sprintf("Payment in status %s has nothing to reverse", payment.Status))
+		}
+
+		logger.Warn("Reversing %s payment %s of cancelled order %s", payment.Status, payment.ProviderTxnID, current.ID.String())
+		return nil
+	})
+	if err != nil {
+		return errs.EnsureAppError(err)
+	}
+
+	return nil
+}
+
+// staleAuthorizationBatch caps how many authorizations one sweep cancels
+const staleAuthorizationBatch = 100
+
+// CapturePayment captures an order's authorized payment as it ships,
+// leaving out what was paid for the unshipped items. The part of the
+// authorization that is not captured is released and counted as refunded,
+// so the order still ends REFUNDED if the captured amount is refunded later.
+// A cancelled or refunded order cannot ship, nor can one whose payment has
+// a refund of everything left on it pending, nor one that has not been paid
+// unless it is paid in cash on delivery. Any other order whose payment is
+// not awaiting capture is returned unchanged. The capture is recorded first
+// and the provider asked for it once that has committed, so the payment is
+// never captured twice and its row is not held while the provider answers.
+func (s *service) CapturePayment(ctx context.Context, orderID string, unshipped []UnshippedItem) (Order, *errs.AppError) {
+	var captured Order
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.GetByID(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Order not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order")
+		}
+
+		if current.Status == StatusCancelled || current.Status == StatusRefunded {
+			return errs.ErrConflict.WithMessage(fmt.Sprintf("Order in status %s cannot be shipped", current.Status))
+		}
+
+		payment, err := s.repo.GetOrderPayment(ctx, orderID)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) && current.FinalCents == 0 {
+				// Paid in full with gift cards or wallet credit
+				captured = current
+				return nil
+			}
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrConflict.WithMessage("Order has no payment to capture")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get order payment")
+		}
+
+		payment, err = s.repo.LockPayment(ctx, payment.ID.String())
+		if err != nil {
+			return errs.ErrInternal.WithMessage("Failed to get order payment")
+		}
+
+		if payment.Status != gateway.StatusAuthorized {
+			paid := payment.Status == gateway.StatusSucceeded || current.Status == StatusPaid || current.Status == StatusProcessing
+			cashOnDelivery := payment.PaymentMethod == PaymentMethodCashOnDelivery && payment.Status == gateway.StatusInitiated
+			if !paid && !cashOnDelivery {
+				return errs.ErrConflict.WithMessage(fmt.Sprintf("Order in status %s has not been paid and cannot be shipped", current.Status))
+			}
+
+			succeeded, committed, err := s.repo.GetRefundTotals(ctx, payment.ID.String())
+			if err != nil {
+				return errs.ErrInternal.WithMessage("Failed to get payment refunds")
+			}
+			if committed > succeeded && committed >= payment.AmountCents {
+				return errs.ErrConflict.WithMessage("Order is being refunded in full and cannot be shipped")
+			}
+			captured = current
+			return nil
+		}
+
+		amount := payment.AmountCents
+		for _, u := range unshipped {
+			item, ok := findOrderItem(current.Items, u.OrderItemID)
+			if !ok {
+				return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Item %s is not part of this order", u.OrderItemID))
+			}
+			if int(u.Qty) > item.Qty {
+				return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Only %d of %s were ordered", item.Qty, item.Name))
+			}
+			amount -= current.LinePaidCents(item, u.Qty)
+		}
+		if amount <= 0 {
+			return errs.ErrBadRequest.WithMessage("Nothing is left to capture; cancel the order instead")
+		}
+
+		if _, err := s.repo.CapturePayment(ctx, payment.ID.String(), amount); err != nil {
+			if errors.Is(err, errs.ErrConflict) {
+				return errs.ErrConflict.WithMessage("Payment is no longer awaiting capture")
+			}
+			return errs.ErrInternal.WithMessage("Failed to record payment capture")
+		}
+
+		order := current
+		if released := payment.AmountCents - amount; released > 0 {
+			order, err = s.repo.AddRefundedCents(ctx, orderID, released)
+			if err != nil {
+				return errs.ErrInternal.WithMessage("Failed to record released authorization")
+			}
+			order.Items = current.Items
+		}
+
+		authorized := payment.AmountCents
+		database.AfterCommit(ctx, func(ctx context.Context) {
+			s.captureIntent(ctx, payment, amount, authorized)
+		})
+
+		captured = order
+		return nil
+	})
+	if err != nil {
+		return Order{}, errs.EnsureAppError(err)
+	}
+
+	return captured, nil
+}
+
+// captureIntent asks the provider for a capture CapturePayment recorded.
+// It runs after the capture has committed, so a failure is only logged;
+// payment reconciliation asks for the capture again while the provider
+// still holds the authorization.
+func (s *service) captureIntent(ctx context.Context, payment OrderPayment, amountCents, authorizedCents int64) {
+	orderID := payment.OrderID.String()
+
+	if _, err := s.payments.CaptureIntent(ctx, payment.ProviderTxnID, amountCents); err != nil {
+		logger.Error("Failed to capture payment %s for order %s: %v", payment.ProviderTxnID, orderID, err)
+		return
+	}
+
+	logger.Info("Captured %d of %d cents for order %s", amountCents, authorizedCents, orderID)
+}
+
+// CancelStaleAuthorizations cancels orders whose payment has been
+// authorized for longer than olderThan without shipping, before the
+// provider lets the authorization lapse. It returns how many were
+// cancelled; failures are logged and retried on the next sweep.
+func (s *service) CancelStaleAuthorizations(ctx context.Context, olderThan time.Duration) (int, *errs.AppError) {
+	payments, err := s.repo.ListStaleAuthorizations(ctx, time.Now().Add(-olderThan), staleAuthorizationBatch)
+	if err != nil {
+		return 0, errs.ErrInternal.WithMessage("Failed to list stale authorizations")
+	}
+
+	cancelled := 0
+	for _, p := range payments {
+		if _, appErr := s.cancelAuthorization(ctx, p.ID.String(), "", "Payment authorization expired before the order shipped"); appErr != nil {
+			logger.Error("Failed to cancel stale authorization %s for order %s: %s", p.ProviderTxnID, p.OrderID.String(), appErr.Message)
+			continue
+		}
+		cancelled++
+	}
+
+	return cancelled, nil
+}
+
+// cancelAuthorization cancels an authorized payment and its order, and
+// voids the authorization at the provider once that has committed. A
+// provider failure is only logged; an authorization that is never captured
+// lapses at the provider by itself.
+func (s *service) cancelAuthorization(ctx context.Context, paymentID, changedBy, reason string) (Order, *errs.AppError) {
+	var cancelled Order
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		payment, err := s.repo.LockPayment(ctx, paymentID)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Payment not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get payment")
+		}
+
+		if payment.Status != gateway.StatusAuthorized {
+			return errs.ErrConflict.WithMessage(fmt.Sprintf("Payment in status %s is not an open authorization", payment.Status))
+		}
+
+		order, appErr := s.UpdateOrderStatus(ctx, payment.OrderID.String(), StatusCancelled, changedBy, reason)
+		if appErr != nil {
+			return appErr
+		}
+
+		if err := s.repo.UpdateOrderPaymentStatus(ctx, paymentID, gateway.StatusCancelled); err != nil {
+			return errs.ErrInternal.WithMessage("Failed to update payment status")
+		}
+
+		database.AfterCommit(ctx, func(ctx context.Context) {
+			s.voidPayment(ctx, payment)
+		})
+
+		cancelled = order
+		return nil
+	})
+	if err != nil {
+		return Order{}, errs.EnsureAppError(err)
+	}
+
+	return cancelled, nil
+}
+
+// offlinePaymentExpiryBatch caps how many offline payments one sweep expires
+const offlinePaymentExpiryBatch = 100
+
+// ConfirmOfflinePayment records that a bank transfer or cash-on-delivery
+// payment was received and marks its order PAID. changedBy is the admin or
+// courier who confirmed it.
+func (s *service) ConfirmOfflinePayment(ctx context.Context, paymentID, changedBy string) (Order, *errs.AppError) {
+	var paid Order
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		payment, appErr := s.lockOpenOfflinePayment(ctx, paymentID)
+		if appErr != nil {
+			return appErr
+		}
+
+		if _, err := s.repo.ConfirmOfflinePayment(ctx, paymentID, changedBy); err != nil {
+			if errors.Is(err, errs.ErrConflict) {
+				return errs.ErrConflict.WithMessage("Payment was already confirmed or cancelled")
+			}
+			return errs.ErrInternal.WithMessage("Failed to confirm payment")
+		}
+
+		reason := "Bank transfer received"
+		if payment.PaymentMethod == PaymentMethodCashOnDelivery {
+			reason = "Cash collected on delivery"
+		}
+
+		order, appErr := s.UpdateOrderStatus(ctx, payment.OrderID.String(), StatusPaid, changedBy, reason)
+		if appErr != nil {
+			return appErr
+		}
+
+		logger.Info("Confirmed %s payment %s for order %s", payment.PaymentMethod, payment.Reference, payment.OrderID.String())
+
+		paid = order
+		return nil
+	})
+	if err != nil {
+		return Order{}, errs.EnsureAppError(err)
+	}
+
+	return paid, nil
+}
+
+// ConfirmCashOnDelivery confirms an order's cash-on-delivery payment once
+// the courier has collected it. An order paid any other way, or whose cash
+// was already confirmed, is returned unchanged.
+func (s *service) ConfirmCashOnDelivery(ctx context.Context, orderID, courierID string) (Order, *errs.AppError) {
+	current, err := s.repo.GetByID(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Order{}, errs.ErrNotFound.WithMessage("Order not found")
+		}
+		return Order{}, errs.ErrInternal.WithMessage("Failed to get order")
+	}
+
+	payment, err := s.repo.GetOrderPayment(ctx, orderID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return current, nil
+		}
+		return Order{}, errs.ErrInternal.WithMessage("Failed to get order payment")
+	}
+
+	if payment.PaymentMethod != PaymentMethodCashOnDelivery || payment.Status == gateway.StatusSucceeded {
+		return current, nil
+	}
+	if payment.Status != gateway.StatusInitiated {
+		return Order{}, errs.ErrConflict.WithMessage(fmt.Sprintf("Cash-on-delivery payment in status %s cannot be collected", payment.Status))
+	}
+
+	return s.ConfirmOfflinePayment(ctx, payment.ID.String(), courierID)
+}
+
+// MarkDelivered records that a shipment of the order reached the customer.
+// The order's return window runs from its latest delivery.
+func (s *service) MarkDelivered(ctx context.Context, orderID string, deliveredAt time.Time) *errs.AppError {
+	if err := s.repo.SetDeliveredAt(ctx, orderID, deliveredAt); err != nil {
+		logger.Error("Failed to record delivery of order %s: %v", orderID, err)
+		return errs.ErrInternal.WithMessage("Failed to record order delivery")
+	}
+
+	return nil
+}
+
+// ExpireOfflinePayment cancels an offline payment that was never received
+// together with its order, which gives back any gift cards and wallet
+// credit it used. changedBy is empty when the expiry sweep runs it.
+func (s *service) ExpireOfflinePayment(ctx context.Context, paymentID, changedBy, reason string) (Order, *errs.AppError) {
+	var cancelled Order
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		payment, appErr := s.lockOpenOfflinePayment(ctx, paymentID)
+		if appErr != nil {
+			return appErr
+		}
+
+		if _, err := s.repo.ExpireOfflinePayment(ctx, paymentID, reason); err != nil {
+			if errors.Is(err, errs.ErrConflict) {
+				return errs.ErrConflict.WithMessage("Payment was already confirmed or cancelled")
+			}
+			return errs.ErrInternal.WithMessage("Failed to expire payment")
+		}
+
+		order, appErr := s.UpdateOrderStatus(ctx, payment.OrderID.String(), StatusCancelled, changedBy, reason)
+		if appErr != nil {
+			return appErr
+		}
+
+		cancelled = order
+		return nil
+	})
+	if err != nil {
+		return Order{}, errs.EnsureAppError(err)
+	}
+
+	return cancelled, nil
+}
+
+// ExpireOfflinePayments cancels the orders whose bank transfer was not
+// received before it expired. It returns how many were cancelled; failures
+// are logged and retried on the next sweep.
+func (s *service) ExpireOfflinePayments(ctx context.Context) (int, *errs.AppError) {
+	payments, err := s.repo.ListExpiredOfflinePayments(ctx, time.Now(), offlinePaymentExpiryBatch)
+	if err != nil {
+		return 0, errs.ErrInternal.WithMessage("Failed to list expired offline payments")
+	}
+
+	expired := 0
+	for _, p := range payments {
+		reason := fmt.Sprintf("%s payment was not received in time", p.PaymentMethod)
+		if _, appErr := s.ExpireOfflinePayment(ctx, p.ID.String(), "", reason); appErr != nil {
+			logger.Error("Failed to expire payment %s for order %s: %s", p.Reference, p.OrderID.String(), appErr.Message)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// ReleaseReservedStock frees the stock an unpaid order holds, e.g. after its
+// payment failed. The order stays PENDING; if it is paid after all, the
+// stock is taken again when it becomes PAID.
+func (s *service) ReleaseReservedStock(ctx context.Context, orderID string) *errs.AppError {
+	return s.inventorySvc.ReleaseReservations(ctx, orderID)
+}
+
+// lockOpenOfflinePayment locks an offline payment that is still waiting to
+// be paid, so it is confirmed or expired only once.
+func (s *service) lockOpenOfflinePayment(ctx context.Context, paymentID string) (OrderPayment, *errs.AppError) {
+	payment, err := s.repo.LockPayment(ctx, paymentID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return OrderPayment{}, errs.ErrNotFound.WithMessage("Payment not found")
+		}
+		return OrderPayment{}, errs.ErrInternal.WithMessage("Failed to get payment")
+	}
+
+	if !payment.IsOffline() {
+		return OrderPayment{}, errs.ErrConflict.WithMessage("Only bank transfer and cash-on-delivery payments are confirmed by hand")
+	}
+	if payment.Status != gateway.StatusInitiated {
+		return OrderPayment{}, errs.ErrConflict.WithMessage(fmt.Sprintf("Payment in status %s is not awaiting payment", payment.Status))
+	}
+
+	return payment, nil
+}
+
+func findOrderItem(items []OrderItem, id string) (OrderItem, bool) {
+	for _, item := range items {
+		if item.ID.String() == id {
+			return item, true
+		}
+	}
+	return OrderItem{}, false
+}
+
+func (s *service) GetOrderStatusHistory(ctx context.Context, id string) ([]StatusHistory, *errs.AppError) {
+	history, err := s.repo.ListStatusHistory(ctx, id)
 	if err != nil {
-		return Order{}, errs.ErrInternal.WithMessage("Failed to update order status")
+		return nil, errs.ErrInternal.WithMessage("Failed to get order status history")
 	}
 
-	return updateOrder, nil
+	return history, nil
 }
 
 func (s *service) DeleteOrder(ctx context.Context, id string) *errs.AppError {
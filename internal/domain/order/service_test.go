@@ -0,0 +1,417 @@
+package order
+
+import (
+	"context"
+	"ecommerce-app/internal/domain/gateway"
+	"ecommerce-app/internal/domain/wallet"
+	"ecommerce-app/internal/pkg/errs"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// refundLedger holds one order, its payment and the payment's refund
+// ledger, enforcing what the refund queries enforce
+type refundLedger struct {
+	Repository
+	order   Order
+	payment OrderPayment
+	refunds []Refund
+}
+
+func newRefundLedger(status string, finalCents int64, payment OrderPayment) *refundLedger {
+	orderID := uuid.New()
+	payment.ID = uuid.New()
+	payment.OrderID = orderID
+	payment.Currency = "usd"
+	if payment.Status == "" {
+		payment.Status = gateway.StatusSucceeded
+	}
+	return &refundLedger{
+		order:   Order{ID: orderID, UserID: uuid.New(), Status: status, FinalCents: finalCents, TotalCents: finalCents},
+		payment: payment,
+	}
+}
+
+// refunded records a refund made earlier; succeeded ones are already part
+// of the order's refunded total
+func (l *refundLedger) refunded(status string, cents int64) *refundLedger {
+	l.refunds = append(l.refunds, Refund{ID: uuid.New(), PaymentID: l.payment.ID, OrderID: l.order.ID, AmountCents: cents, Status: status})
+	if status == gateway.RefundSucceeded {
+		l.order.RefundedCents += cents
+	}
+	return l
+}
+
+func (l *refundLedger) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (l *refundLedger) GetByID(ctx context.Context, id string) (Order, error) {
+	return l.order, nil
+}
+
+func (l *refundLedger) LockPayment(ctx context.Context, paymentID string) (OrderPayment, error) {
+	return l.payment, nil
+}
+
+func (l *refundLedger) GetOrderPayment(ctx context.Context, orderID string) (OrderPayment, error) {
+	return l.payment, nil
+}
+
+func (l *refundLedger) UpdateOrderPaymentStatus(ctx context.Context, paymentID, status string) error {
+	l.payment.Status = status
+	return nil
+}
+
+func (l *refundLedger) GetRefundTotals(ctx context.Context, paymentID string) (int64, int64, error) {
+	var succeeded, committed int64
+	for _, r := range l.refunds {
+		if r.Status == gateway.RefundSucceeded {
+			succeeded += r.AmountCents
+		}
+		if r.Status == gateway.RefundSucceeded || r.Status == gateway.RefundPending {
+			committed += r.AmountCents
+		}
+	}
+	return succeeded, committed, nil
+}
+
+func (l *refundLedger) CreateRefund(ctx context.Context, params CreateRefundInput) (Refund, error) {
+	refund := Refund{
+		ID:          uuid.New(),
+		PaymentID:   l.payment.ID,
+		OrderID:     l.order.ID,
+		Provider:    params.Provider,
+		AmountCents: params.AmountCents,
+		Currency:    params.Currency,
+		Status:      gateway.RefundPending,
+	}
+	l.refunds = append(l.refunds, refund)
+	return refund, nil
+}
+
+func (l *refundLedger) GetRefund(ctx context.Context, id string) (Refund, error) {
+	for _, r := range l.refunds {
+		if r.ID.String() == id {
+			return r, nil
+		}
+	}
+	return Refund{}, errs.ErrNotFound
+}
+
+func (l *refundLedger) UpdateRefundResult(ctx context.Context, id, providerRefundID, status, failureReason string) (Refund, error) {
+	for i, r := range l.refunds {
+		if r.ID.String() == id {
+			l.refunds[i].ProviderRefundID = providerRefundID
+			l.refunds[i].Status = status
+			return l.refunds[i], nil
+		}
+	}
+	return Refund{}, errs.ErrNotFound
+}
+
+func (l *refundLedger) AddRefundedCents(ctx context.Context, id string, amountCents int64) (Order, error) {
+	if l.order.RefundedCents+amountCents > l.order.FinalCents {
+		return Order{}, errs.ErrConflict
+	}
+	l.order.RefundedCents += amountCents
+	return l.order, nil
+}
+
+func (l *refundLedger) UpdateStatus(ctx context.Context, id, fromStatus, toStatus string) (Order, error) {
+	l.order.Status = toStatus
+	return l.order, nil
+}
+
+func (l *refundLedger) CreateStatusHistory(ctx context.Context, entry StatusHistoryInput) (StatusHistory, error) {
+	return StatusHistory{}, nil
+}
+
+// settlingGateway answers every refund at once with status
+type settlingGateway struct {
+	PaymentProvider
+	status string
+}
+
+func (g settlingGateway) Refund(ctx context.Context, req gateway.RefundRequest) (gateway.Refund, error) {
+	return gateway.Refund{ID: "re_" + uuid.NewString(), IntentID: req.IntentID, AmountCents: req.AmountCents, Status: g.status}, nil
+}
+
+// refundGiftCards holds no redemptions; spent says whether a card the
+// order bought has been spent from
+type refundGiftCards struct {
+	GiftCardProvider
+	spent bool
+}
+
+func (g refundGiftCards) CheckOrderGiftCardsUnused(ctx context.Context, orderID string) *errs.AppError {
+	if g.spent {
+		return errs.ErrConflict.WithMessage("Gift card bought in this order has already been used")
+	}
+	return nil
+}
+
+func (g refundGiftCards) ReverseOrderRedemptions(ctx context.Context, orderID string) (int64, *errs.AppError) {
+	return 0, nil
+}
+
+func (g refundGiftCards) VoidOrderGiftCards(ctx context.Context, orderID string) (int, *errs.AppError) {
+	return 0, nil
+}
+
+type refundWallet struct {
+	WalletProvider
+}
+
+func (refundWallet) RefundToWallet(ctx context.Context, in wallet.RefundInput) (wallet.Transaction, *errs.AppError) {
+	return wallet.Transaction{ID: uuid.New(), AmountCents: in.AmountCents}, nil
+}
+
+func (refundWallet) RestoreOrderDebit(ctx context.Context, userID, orderID, changedBy, reason string) (int64, *errs.AppError) {
+	return 0, nil
+}
+
+func TestRefundPayment(t *testing.T) {
+	card := OrderPayment{Provider: "fake", ProviderTxnID: "pi_1", PaymentMethod: PaymentMethodCard, AmountCents: 5000}
+	transfer := OrderPayment{Provider: OfflineProvider, PaymentMethod: PaymentMethodBankTransfer, AmountCents: 5000}
+
+	tests := []struct {
+		name          string
+		ledger        *refundLedger
+		amountCents   int64
+		toWallet      bool
+		cardsSpent    bool
+		wantCode      int
+		wantRefund    int64
+		wantRefunded  int64
+		wantOrder     string
+		wantPayment   string
+		providerState string
+	}{
+		{
+			name:         "everything left by default",
+			ledger:       newRefundLedger(StatusPaid, 5000, card),
+			wantRefund:   5000,
+			wantRefunded: 5000,
+			wantOrder:    StatusRefunded,
+			wantPayment:  gateway.StatusRefunded,
+		},
+		{
+			name:         "partial refund leaves the statuses alone",
+			ledger:       newRefundLedger(StatusShipped, 5000, card),
+			amountCents:  2000,
+			wantRefund:   2000,
+			wantRefunded: 2000,
+			wantOrder:    StatusShipped,
+			wantPayment:  gateway.StatusSucceeded,
+		},
+		{
+			name:         "last of several partial refunds",
+			ledger:       newRefundLedger(StatusShipped, 5000, card).refunded(gateway.RefundSucceeded, 3000),
+			amountCents:  2000,
+			wantRefund:   2000,
+			wantRefunded: 5000,
+			wantOrder:    StatusRefunded,
+			wantPayment:  gateway.StatusRefunded,
+		},
+		{
+			name:        "more than is left",
+			ledger:      newRefundLedger(StatusShipped, 5000, card).refunded(gateway.RefundSucceeded, 3000),
+			amountCents: 2500,
+			wantCode:    http.StatusConflict,
+		},
+		{
+			name:        "pending refunds count against what is left",
+			ledger:      newRefundLedger(StatusShipped, 5000, card).refunded(gateway.RefundPending, 3000),
+			amountCents: 2500,
+			wantCode:    http.StatusConflict,
+		},
+		{
+			name:         "failed refunds free their amount",
+			ledger:       newRefundLedger(StatusShipped, 5000, card).refunded(gateway.RefundFailed, 3000),
+			amountCents:  5000,
+			wantRefund:   5000,
+			wantRefunded: 5000,
+			wantOrder:    StatusRefunded,
+			wantPayment:  gateway.StatusRefunded,
+		},
+		{
+			name:     "nothing left",
+			ledger:   newRefundLedger(StatusShipped, 5000, card).refunded(gateway.RefundPending, 5000),
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:        "negative amount",
+			ledger:      newRefundLedger(StatusPaid, 5000, card),
+			amountCents: -1,
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:     "payment only authorized",
+			ledger:   newRefundLedger(StatusPaid, 5000, OrderPayment{Provider: "fake", AmountCents: 5000, Status: gateway.StatusAuthorized}),
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "order not paid",
+			ledger:   newRefundLedger(StatusPending, 5000, card),
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:     "offline payment back to the provider",
+			ledger:   newRefundLedger(StatusPaid, 5000, transfer),
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:         "offline payment to the wallet",
+			ledger:       newRefundLedger(StatusPaid, 5000, transfer),
+			toWallet:     true,
+			wantRefund:   5000,
+			wantRefunded: 5000,
+			wantOrder:    StatusRefunded,
+			wantPayment:  gateway.StatusRefunded,
+		},
+		{
+			name:       "full refund after a bought gift card was spent",
+			ledger:     newRefundLedger(StatusPaid, 5000, card),
+			cardsSpent: true,
+			wantCode:   http.StatusConflict,
+		},
+		{
+			name:         "partial refund after a bought gift card was spent",
+			ledger:       newRefundLedger(StatusPaid, 5000, card),
+			amountCents:  1000,
+			cardsSpent:   true,
+			wantRefund:   1000,
+			wantRefunded: 1000,
+			wantOrder:    StatusPaid,
+			wantPayment:  gateway.StatusSucceeded,
+		},
+		{
+			name:          "provider still working on it",
+			ledger:        newRefundLedger(StatusPaid, 5000, card),
+			providerState: gateway.RefundPending,
+			wantRefund:    5000,
+			wantRefunded:  0,
+			wantOrder:     StatusPaid,
+			wantPayment:   gateway.StatusSucceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			providerState := tt.providerState
+			if providerState == "" {
+				providerState = gateway.RefundSucceeded
+			}
+			svc := &service{
+				repo:        tt.ledger,
+				payments:    settlingGateway{status: providerState},
+				giftCardSvc: refundGiftCards{spent: tt.cardsSpent},
+				walletSvc:   refundWallet{},
+			}
+
+			refund, appErr := svc.RefundPayment(context.Background(), tt.ledger.payment.ID.String(), tt.amountCents, tt.toWallet, "", "test")
+			if tt.wantCode != 0 {
+				if appErr == nil || appErr.Code != tt.wantCode {
+					t.Fatalf("RefundPayment error = %v, want code %d", appErr, tt.wantCode)
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatalf("RefundPayment: %s", appErr.Message)
+			}
+
+			if refund.AmountCents != tt.wantRefund {
+				t.Errorf("refunded %d, want %d", refund.AmountCents, tt.wantRefund)
+			}
+			if got := tt.ledger.order.RefundedCents; got != tt.wantRefunded {
+				t.Errorf("order refunded total %d, want %d", got, tt.wantRefunded)
+			}
+			if got := tt.ledger.order.Status; got != tt.wantOrder {
+				t.Errorf("order status %s, want %s", got, tt.wantOrder)
+			}
+			if got := tt.ledger.payment.Status; got != tt.wantPayment {
+				t.Errorf("payment status %s, want %s", got, tt.wantPayment)
+			}
+		})
+	}
+}
+
+func TestApplyRefund(t *testing.T) {
+	tests := []struct {
+		name        string
+		ledger      *refundLedger
+		amountCents int64
+		wantCode    int
+		wantOrder   string
+		wantPayment string
+	}{
+		{
+			name:        "reaching final_cents refunds the order",
+			ledger:      newRefundLedger(StatusShipped, 5000, OrderPayment{AmountCents: 5000}).refunded(gateway.RefundSucceeded, 2000).refunded(gateway.RefundSucceeded, 3000),
+			amountCents: 3000,
+			wantOrder:   StatusRefunded,
+			wantPayment: gateway.StatusRefunded,
+		},
+		{
+			name:        "below final_cents",
+			ledger:      newRefundLedger(StatusShipped, 5000, OrderPayment{AmountCents: 5000}).refunded(gateway.RefundSucceeded, 2000).refunded(gateway.RefundSucceeded, 2999),
+			amountCents: 2999,
+			wantOrder:   StatusShipped,
+			wantPayment: gateway.StatusSucceeded,
+		},
+		{
+			// 1000 of the authorization was released when the order
+			// shipped without one of its items
+			name:        "partly captured payment refunded in full",
+			ledger:      newRefundLedger(StatusShipped, 5000, OrderPayment{AmountCents: 4000}).refunded(gateway.RefundSucceeded, 1000).refunded(gateway.RefundSucceeded, 4000),
+			amountCents: 4000,
+			wantOrder:   StatusRefunded,
+			wantPayment: gateway.StatusRefunded,
+		},
+		{
+			name:        "late payment on a cancelled order",
+			ledger:      newRefundLedger(StatusCancelled, 5000, OrderPayment{AmountCents: 5000}).refunded(gateway.RefundSucceeded, 5000),
+			amountCents: 5000,
+			wantOrder:   StatusCancelled,
+			wantPayment: gateway.StatusRefunded,
+		},
+		{
+			name:        "more than the order has left",
+			ledger:      newRefundLedger(StatusShipped, 5000, OrderPayment{AmountCents: 5000}).refunded(gateway.RefundSucceeded, 5000),
+			amountCents: 1000,
+			wantCode:    http.StatusConflict,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The refund being applied is already SUCCEEDED in the ledger
+			// but not yet part of the order's refunded total
+			if tt.wantCode == 0 {
+				tt.ledger.order.RefundedCents -= tt.amountCents
+			}
+			svc := &service{repo: tt.ledger, giftCardSvc: refundGiftCards{}, walletSvc: refundWallet{}}
+
+			_, appErr := svc.ApplyRefund(context.Background(), tt.ledger.payment.ID.String(), tt.amountCents, "", "test")
+			if tt.wantCode != 0 {
+				if appErr == nil || appErr.Code != tt.wantCode {
+					t.Fatalf("ApplyRefund error = %v, want code %d", appErr, tt.wantCode)
+				}
+				return
+			}
+			if appErr != nil {
+				t.Fatalf("ApplyRefund: %s", appErr.Message)
+			}
+
+			if got := tt.ledger.order.Status; got != tt.wantOrder {
+				t.Errorf("order status %s, want %s", got, tt.wantOrder)
+			}
+			if got := tt.ledger.payment.Status; got != tt.wantPayment {
+				t.Errorf("payment status %s, want %s", got, tt.wantPayment)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package order
+
+// Order statuses, matching the orders.status CHECK constraint.
+const (
+	StatusPending    = "PENDING"
+	StatusPaid       = "PAID"
+	StatusProcessing = "PROCESSING"
+	StatusShipped    = "SHIPPED"
+	StatusCancelled  = "CANCELLED"
+	StatusRefunded   = "REFUNDED"
+)
+
+// statusTransitions lists, for each status, the statuses an order may move
+// to next. CANCELLED and REFUNDED are terminal.
+var statusTransitions = map[string][]string{
+	StatusPending:    {StatusPaid, StatusCancelled},
+	StatusPaid:       {StatusProcessing, StatusShipped, StatusCancelled, StatusRefunded},
+	StatusProcessing: {StatusShipped, StatusCancelled, StatusRefunded},
+	StatusShipped:    {StatusRefunded},
+	StatusCancelled:  {},
+	StatusRefunded:   {},
+}
+
+// CanTransition reports whether an order in status from may move to status to.
+func CanTransition(from, to string) bool {
+	for _, next := range statusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
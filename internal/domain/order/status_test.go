@@ -0,0 +1,55 @@
+package order
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	statuses := []string{StatusPending, StatusPaid, StatusProcessing, StatusShipped, StatusCancelled, StatusRefunded}
+	allowed := map[[2]string]bool{
+		{StatusPending, StatusPaid}:         true,
+		{StatusPending, StatusCancelled}:    true,
+		{StatusPaid, StatusProcessing}:      true,
+		{StatusPaid, StatusShipped}:         true,
+		{StatusPaid, StatusCancelled}:       true,
+		{StatusPaid, StatusRefunded}:        true,
+		{StatusProcessing, StatusShipped}:   true,
+		{StatusProcessing, StatusCancelled}: true,
+		{StatusProcessing, StatusRefunded}:  true,
+		{StatusShipped, StatusRefunded}:     true,
+	}
+
+	for _, from := range statuses {
+		for _, to := range statuses {
+			want := allowed[[2]string{from, to}]
+			if got := CanTransition(from, to); got != want {
+				t.Errorf("CanTransition(%s, %s) = %v, want %v", from, to, got, want)
+			}
+		}
+	}
+}
+
+func TestCanTransitionUnknownStatus(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+	}{
+		{from: "", to: StatusPaid},
+		{from: "DRAFT", to: StatusPaid},
+		{from: StatusPending, to: "DRAFT"},
+		{from: "paid", to: StatusShipped},
+	}
+
+	for _, tt := range tests {
+		if CanTransition(tt.from, tt.to) {
+			t.Errorf("CanTransition(%q, %q) = true, want false", tt.from, tt.to)
+		}
+	}
+}
+
+func TestTerminalStatuses(t *testing.T) {
+	for status, next := range statusTransitions {
+		terminal := status == StatusCancelled || status == StatusRefunded
+		if terminal != (len(next) == 0) {
+			t.Errorf("%s has transitions %v, terminal %v", status, next, terminal)
+		}
+	}
+}
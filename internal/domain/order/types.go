@@ -2,7 +2,16 @@ package order
 
 import (
 	"context"
+	"ecommerce-app/internal/domain/cart"
+	"ecommerce-app/internal/domain/cartitem"
+	"ecommerce-app/internal/domain/coupon"
+	"ecommerce-app/internal/domain/gateway"
+	"ecommerce-app/internal/domain/giftcard"
+	"ecommerce-app/internal/domain/inventory"
 	"ecommerce-app/internal/domain/product"
+	"ecommerce-app/internal/domain/shipping"
+	"ecommerce-app/internal/domain/tax"
+	"ecommerce-app/internal/domain/wallet"
 	"ecommerce-app/internal/pkg/errs"
 	"ecommerce-app/internal/pkg/response"
 	"time"
@@ -27,7 +36,17 @@ type Order struct {
 	Notes         string      `json:"notes,omitempty"`
 	CreatedAt     time.Time   `json:"created_at"`
 	UpdatedAt     time.Time   `json:"updated_at"`
+	PaidAt        *time.Time  `json:"paid_at,omitempty"`
+	ShippedAt     *time.Time  `json:"shipped_at,omitempty"`
+	DeliveredAt   *time.Time  `json:"delivered_at,omitempty"`
+	CancelledAt   *time.Time  `json:"cancelled_at,omitempty"`
+	RefundedAt    *time.Time  `json:"refunded_at,omitempty"`
+	RefundedCents int64       `json:"refunded_cents"`
+	ShippingMethodID   *uuid.UUID `json:"shipping_method_id,omitempty"`
+	ShippingMethodName string     `json:"shipping_method_name,omitempty"`
 	Items         []OrderItem `json:"items,omitempty"`
+	TaxLines      []TaxLine   `json:"tax_lines,omitempty"`
+	Adjustments   []Adjustment `json:"adjustments,omitempty"`
 }
 
 type OrderItem struct {
@@ -44,6 +63,113 @@ type OrderItem struct {
 
 }
 
+// TaxLine is the tax charged on one order item, snapshotted from the rate
+// that applied when the order was placed.
+type TaxLine struct {
+	ID           uuid.UUID  `json:"id"`
+	OrderID      uuid.UUID  `json:"order_id"`
+	OrderItemID  *uuid.UUID `json:"order_item_id,omitempty"`
+	TaxRateID    *uuid.UUID `json:"tax_rate_id,omitempty"`
+	Jurisdiction string     `json:"jurisdiction"`
+	TaxCategory  string     `json:"tax_category"`
+	RateBps      int32      `json:"rate_bps"`
+	IsInclusive  bool       `json:"is_inclusive"`
+	TaxableCents int64      `json:"taxable_cents"`
+	TaxCents     int64      `json:"tax_cents"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// Adjustment is one persisted step of the pricing pipeline: a sale or coupon
+// discount on an item (negative), the shipping charge, or exclusive tax.
+type Adjustment struct {
+	ID          uuid.UUID  `json:"id"`
+	OrderID     uuid.UUID  `json:"order_id"`
+	OrderItemID *uuid.UUID `json:"order_item_id,omitempty"`
+	Kind        string     `json:"kind"`
+	Code        string     `json:"code,omitempty"`
+	Description string     `json:"description"`
+	AmountCents int64      `json:"amount_cents"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+type StatusHistory struct {
+	ID         uuid.UUID  `json:"id"`
+	OrderID    uuid.UUID  `json:"order_id"`
+	FromStatus string     `json:"from_status"`
+	ToStatus   string     `json:"to_status"`
+	ChangedBy  *uuid.UUID `json:"changed_by,omitempty"`
+	Reason     string     `json:"reason,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// Payment methods a customer can choose at checkout, matching the
+// payments.payment_method CHECK constraint. Bank transfers and cash on
+// delivery are paid outside the gateway.
+const (
+	PaymentMethodCard           = "CREDIT_CARD"
+	PaymentMethodBankTransfer   = "BANK_TRANSFER"
+	PaymentMethodCashOnDelivery = "CASH_ON_DELIVERY"
+)
+
+// Checkout modes: a payment intent hands the frontend a client secret to
+// pay with embedded card fields, a checkout session sends the customer to
+// the provider's hosted page.
+const (
+	CheckoutModeIntent  = "payment_intent"
+	CheckoutModeSession = "checkout_session"
+)
+
+// OfflineProvider is the provider recorded on payments that never reach the
+// gateway; they are confirmed by hand against their reference.
+const OfflineProvider = "OFFLINE"
+
+// OfflinePaymentConfig is what checkout tells customers paying by bank
+// transfer. Transfers not received within BankTransferTTL are cancelled;
+// 0 leaves them open until an admin expires them.
+type OfflinePaymentConfig struct {
+	BankTransferDetails string
+	BankTransferTTL     time.Duration
+}
+
+// OrderPayment is the payment record backing an order
+type OrderPayment struct {
+	ID                uuid.UUID  `json:"id"`
+	OrderID           uuid.UUID  `json:"order_id"`
+	Provider          string     `json:"provider"`
+	ProviderTxnID     string     `json:"provider_txn_id"`
+	PaymentMethod     string     `json:"payment_method"`
+	AmountCents       int64      `json:"amount_cents"`
+	Currency          string     `json:"currency"`
+	Status            string     `json:"status"`
+	CaptureMethod     string     `json:"capture_method"`
+	Reference         string     `json:"reference,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	CheckoutSessionID string `json:"checkout_session_id,omitempty"`
+}
+
+// IsOffline reports whether the payment is settled outside the gateway
+func (p OrderPayment) IsOffline() bool {
+	return p.Provider == OfflineProvider
+}
+
+// Refund is one entry in a payment's refund ledger. ProviderRefundID is
+// empty until the provider has accepted the refund.
+type Refund struct {
+	ID               uuid.UUID  `json:"id"`
+	PaymentID        uuid.UUID  `json:"payment_id"`
+	OrderID          uuid.UUID  `json:"order_id"`
+	Provider         string     `json:"provider"`
+	ProviderRefundID string     `json:"provider_refund_id,omitempty"`
+	AmountCents      int64      `json:"amount_cents"`
+	Currency         string     `json:"currency"`
+	Reason           string     `json:"reason,omitempty"`
+	Status           string     `json:"status"`
+	FailureReason    string     `json:"failure_reason,omitempty"`
+	CreatedBy        *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
 // --- Wrapper Types ---
 type OrdersWithMeta struct {
 	Orders []Order       `json:"orders"`
@@ -51,18 +177,138 @@ type OrdersWithMeta struct {
 }
 
 type OrderWithClientSecret struct {
-	Order        Order  `json:"order"`
-	ClientSecret string `json:"client_secret"`
+	Order               Order                `json:"order"`
+	ClientSecret        string               `json:"client_secret"`
+	CheckoutURL         string               `json:"checkout_url,omitempty"`
+	PaymentInstructions *PaymentInstructions `json:"payment_instructions,omitempty"`
+}
+
+// PaymentInstructions tell the customer how to pay an order placed with an
+// offline payment method. The reference must be quoted with the payment.
+type PaymentInstructions struct {
+	PaymentMethod string     `json:"payment_method"`
+	Reference     string     `json:"reference"`
+	AmountCents   int64      `json:"amount_cents"`
+	Currency      string     `json:"currency"`
+	Details       string     `json:"details,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
 }
 
 type OrderClientSecret string
 
+// CheckoutSummary prices the current cart the way checkout would, without
+// placing an order.
+type CheckoutSummary struct {
+	Items          []CheckoutSummaryItem `json:"items"`
+	SubtotalCents  int64                 `json:"subtotal_cents"`
+	DiscountCents  int64                 `json:"discount_cents"`
+	TaxCents       int64                 `json:"tax_cents"`
+	ShippingCents  int64                 `json:"shipping_cents"`
+	TotalCents     int64                 `json:"total_cents"`
+	GiftCardCents  int64                 `json:"gift_card_cents"`
+	WalletCents    int64                 `json:"wallet_cents"`
+	AmountDueCents int64                 `json:"amount_due_cents"`
+	CouponCode     string                `json:"coupon_code,omitempty"`
+	TaxExempt      bool                  `json:"tax_exempt"`
+	TaxLines       []tax.LineTax         `json:"tax_lines"`
+	ShippingMethod *shipping.Quote       `json:"shipping_method,omitempty"`
+	Adjustments    []PriceAdjustment     `json:"adjustments"`
+}
+
+// CheckoutSummaryItem is a cart line at its list price; TotalCents is what
+// the line costs after its discounts.
+type CheckoutSummaryItem struct {
+	ProductID      string `json:"product_id"`
+	SKU            string `json:"sku"`
+	Name           string `json:"name"`
+	Qty            int    `json:"qty"`
+	UnitPriceCents int64  `json:"unit_price_cents"`
+	DiscountCents  int64  `json:"discount_cents"`
+	TotalCents     int64  `json:"total_cents"`
+}
+
+// PriceAdjustment is one step of the pricing pipeline as shown before the
+// order exists. Item-level adjustments name the product they apply to.
+type PriceAdjustment struct {
+	Kind        string `json:"kind"`
+	ProductID   string `json:"product_id,omitempty"`
+	Code        string `json:"code,omitempty"`
+	Description string `json:"description"`
+	AmountCents int64  `json:"amount_cents"`
+
+	line int // index of the priced line, -1 for order-level adjustments
+}
+
 
 // --- Dependency Injection Interface ---
 type ProductProvider interface {
 	GetProductByID(ctx context.Context, id string) (product.Product, *errs.AppError)
 }
 
+type CartProvider interface {
+	GetCartByUserID(ctx context.Context, userID string) (cart.Cart, *errs.AppError)
+}
+
+type CartItemProvider interface {
+	GetItemsByUserID(ctx context.Context, userID string) ([]cartitem.CartItem, *errs.AppError)
+	RemoveOrderedItems(ctx context.Context, items []cartitem.CartItem) *errs.AppError
+}
+
+type CouponProvider interface {
+	GetCouponByCode(ctx context.Context, code string) (coupon.Coupon, *errs.AppError)
+	RedeemCoupon(ctx context.Context, code string) (coupon.Coupon, *errs.AppError)
+	ReleaseCoupon(ctx context.Context, code string) *errs.AppError
+}
+
+type TaxCalculator interface {
+	Calculate(ctx context.Context, req tax.CalculationRequest) (tax.Calculation, *errs.AppError)
+}
+
+type ShippingQuoter interface {
+	QuoteMethod(ctx context.Context, methodID string, addr shipping.Address, parcel shipping.Parcel) (shipping.Quote, *errs.AppError)
+}
+
+// WalletProvider is the customer's store-credit wallet. Debits taken at
+// checkout are given back when the order is cancelled or refunded, or in
+// part when some of it is returned.
+type WalletProvider interface {
+	GetWallet(ctx context.Context, userID string) (wallet.Wallet, *errs.AppError)
+	DebitForOrder(ctx context.Context, userID, orderID string, maxCents int64, currency string) (int64, *errs.AppError)
+	RefundToWallet(ctx context.Context, in wallet.RefundInput) (wallet.Transaction, *errs.AppError)
+	RestoreOrderDebit(ctx context.Context, userID, orderID, changedBy, reason string) (int64, *errs.AppError)
+	RestoreOrderDebitUpTo(ctx context.Context, userID, orderID string, maxCents int64, changedBy, reason string) (int64, *errs.AppError)
+}
+
+// GiftCardProvider redeems gift cards at checkout and issues the cards an
+// order buys. Both are undone when the order is cancelled or refunded.
+type GiftCardProvider interface {
+	PreviewRedemption(ctx context.Context, codes []string, maxCents int64, currency string) (int64, *errs.AppError)
+	RedeemForOrder(ctx context.Context, orderID string, codes []string, maxCents int64, currency string) (int64, *errs.AppError)
+	RestoreOrderRedemptions(ctx context.Context, orderID string, maxCents int64) (int64, *errs.AppError)
+	ReverseOrderRedemptions(ctx context.Context, orderID string) (int64, *errs.AppError)
+	IssueForOrder(ctx context.Context, in giftcard.IssueForOrderInput) ([]giftcard.GiftCard, *errs.AppError)
+	CheckOrderGiftCardsUnused(ctx context.Context, orderID string) *errs.AppError
+	VoidOrderGiftCards(ctx context.Context, orderID string) (int, *errs.AppError)
+}
+
+// InventoryProvider holds stock for an order's lines from checkout until
+// the order is paid, when the units leave stock, or cancelled, when they
+// are given back.
+type InventoryProvider interface {
+	ReserveStock(ctx context.Context, orderID, country string, lines []inventory.ReservationLine, expiresAt *time.Time) *errs.AppError
+	CommitReservations(ctx context.Context, orderID string) *errs.AppError
+	ReleaseReservations(ctx context.Context, orderID string) *errs.AppError
+	CancelReservations(ctx context.Context, orderID string) *errs.AppError
+}
+
+// PaymentProvider is the payment gateway orders are charged, captured,
+// voided and refunded through.
 type PaymentProvider interface {
-	// CreatePayment(ctx context.Context, req  ) (string, *errs.AppError)
+	Name() string
+	CreateIntent(ctx context.Context, req gateway.IntentRequest) (gateway.Intent, error)
+	CancelIntent(ctx context.Context, intentID string) (gateway.Intent, error)
+	CreateCheckoutSession(ctx context.Context, req gateway.CheckoutSessionRequest) (gateway.CheckoutSession, error)
+	ExpireCheckoutSession(ctx context.Context, sessionID string) error
+	CaptureIntent(ctx context.Context, intentID string, amountCents int64) (gateway.Intent, error)
+	Refund(ctx context.Context, req gateway.RefundRequest) (gateway.Refund, error)
 }
\ No newline at end of file
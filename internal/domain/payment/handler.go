@@ -2,8 +2,14 @@ package payment
 
 import (
 	"ecommerce-app/internal/pkg/logger"
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/internal/pkg/validator"
+	"ecommerce-app/pkg/pagination"
 	"io"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -17,10 +23,77 @@ func NewPaymentHandler(svc PaymentService) *PaymentHandler {
 }
 
 
-func (h *PaymentHandler) GetPaymentByOrderID(w http.ResponseWriter, r *http.Request) {
+// GetPaymentsByOrderID lists every payment attempt made for an order
+func (h *PaymentHandler) GetPaymentsByOrderID(w http.ResponseWriter, r *http.Request) {
 	orderID := chi.URLParam(r, "orderID")
-	// implement using repo
-	w.Write([]byte("TODO: Get payment by order ID: " + orderID))
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	role := r.Context().Value(middleware.UserRoleKey).(string)
+	page, perPage := pagination.GetPaginationParams(r)
+
+	result, appErr := h.svc.ListOrderPayments(r.Context(), userID, role, orderID, page, perPage)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.SuccessWithMeta(w, http.StatusOK, result.Payments, result.Meta)
+}
+
+func (h *PaymentHandler) GetPayment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	role := r.Context().Value(middleware.UserRoleKey).(string)
+
+	payment, appErr := h.svc.GetPayment(r.Context(), userID, role, id)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, payment, "Payment fetched successfully")
+}
+
+func (h *PaymentHandler) CreateRefund(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[CreateRefundRequest](r)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	refund, appErr := h.svc.RefundPayment(r.Context(), userID, id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, refund, "Refund created with status "+refund.Status)
+}
+
+// MarkPaymentReceived confirms an offline payment arrived
+func (h *PaymentHandler) MarkPaymentReceived(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	payment, appErr := h.svc.MarkPaymentReceived(r.Context(), userID, id)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, payment, "Payment marked received")
+}
+
+// MarkPaymentExpired cancels an offline payment that never arrived
+func (h *PaymentHandler) MarkPaymentExpired(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[ExpirePaymentRequest](r)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	payment, appErr := h.svc.MarkPaymentExpired(r.Context(), userID, id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, payment, "Payment marked expired")
 }
 
 func (h *PaymentHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
@@ -32,10 +105,7 @@ func (h *PaymentHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	sigHeader := r.Header.Get("Stripe-Signature")
-	logger.Info("stripe sigHeader: %s", sigHeader)
-
-	if err := h.svc.HandleWebhook(r.Context(), provider, payload, sigHeader); err != nil {
+	if err := h.svc.HandleWebhook(r.Context(), provider, payload, r.Header); err != nil {
 		logger.Error("Failed to handle webhook: %v", err)
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
@@ -45,3 +115,55 @@ func (h *PaymentHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Webhook processed successfully"))
 }
 
+// ListWebhookEvents lists recorded webhook events; ?status=FAILED narrows
+// it to the ones that need attention.
+func (h *PaymentHandler) ListWebhookEvents(w http.ResponseWriter, r *http.Request) {
+	page, perPage := pagination.GetPaginationParams(r)
+	status := r.URL.Query().Get("status")
+
+	result, appErr := h.svc.ListWebhookEvents(r.Context(), status, page, perPage)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OkWithMeta(w, result.Events, result.Meta)
+}
+
+// RetryWebhookEvent re-runs a failed webhook event
+func (h *PaymentHandler) RetryWebhookEvent(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	event, appErr := h.svc.RetryWebhookEvent(r.Context(), id)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, event, "Webhook event re-run finished with status "+event.Status)
+}
+
+// defaultReconcileAfter is how long a payment must have been left alone
+// before a manual run checks it, unless ?older_than_minutes says otherwise
+const defaultReconcileAfter = 30 * time.Minute
+
+// Reconcile runs a payment reconciliation and returns its report
+func (h *PaymentHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	olderThan := defaultReconcileAfter
+	if value := r.URL.Query().Get("older_than_minutes"); value != "" {
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes < 0 {
+			response.Error(w, http.StatusBadRequest, "Invalid older_than_minutes")
+			return
+		}
+		olderThan = time.Duration(minutes) * time.Minute
+	}
+
+	report, appErr := h.svc.Reconcile(r.Context(), olderThan)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, report, "Payment reconciliation finished")
+}
@@ -0,0 +1,267 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-app/internal/domain/gateway"
+	db "ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/logger"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// reconcileBatchSize is how many payments are loaded at a time
+const reconcileBatchSize = 200
+
+// reconcileLookback bounds how long before the cutoff a payment may have
+// been left untouched and still be checked. Abandoned checkouts stay
+// INITIATED forever and authorizations are cancelled well within it.
+const reconcileLookback = 7 * 24 * time.Hour
+
+// reconcileOrphanWindow is how far back before the cutoff a run looks for
+// intents that never got a payment row.
+const reconcileOrphanWindow = 24 * time.Hour
+
+// Reconcile compares payments still waiting on the provider, and unchanged
+// for olderThan, with the provider's view of their intents. Transitions a
+// lost webhook would have brought are recorded as webhook events and
+// applied through the webhook path, and captures the provider did not take
+// are asked for again; anything else that does not match is listed in the
+// report.
+func (s *paymentService) Reconcile(ctx context.Context, olderThan time.Duration) (ReconciliationReport, *errs.AppError) {
+	if olderThan < 0 {
+		return ReconciliationReport{}, errs.ErrBadRequest.WithMessage("older_than must not be negative")
+	}
+
+	provider := s.gateway.Name()
+	cutoff := time.Now().Add(-olderThan)
+	report := ReconciliationReport{
+		Provider:   provider,
+		Cutoff:     cutoff,
+		Mismatches: []ReconciliationMismatch{},
+	}
+
+	from := pgtype.Timestamptz{Time: cutoff.Add(-reconcileLookback), Valid: true}
+	after, afterID := from, pgtype.UUID{Valid: true}
+	for {
+		payments, err := s.repo.ListPaymentsToReconcile(ctx, db.ListPaymentsToReconcileParams{
+			Provider:       provider,
+			UpdatedAfter:   from,
+			UpdatedBefore:  pgtype.Timestamptz{Time: cutoff, Valid: true},
+			AfterUpdatedAt: after,
+			AfterID:        afterID,
+			RowLimit:       reconcileBatchSize,
+		})
+		if err != nil {
+			logger.Error("Failed to list payments to reconcile: %v", err)
+			return ReconciliationReport{}, errs.ErrInternal.WithMessage("Failed to list payments to reconcile")
+		}
+
+		for _, payment := range payments {
+			if err := s.reconcilePayment(ctx, payment, &report); err != nil {
+				logger.Error("Failed to reconcile payment %s: %v", uuid.UUID(payment.ID.Bytes).String(), err)
+				report.Errors++
+			}
+		}
+
+		if len(payments) < reconcileBatchSize {
+			break
+		}
+		last := payments[len(payments)-1]
+		after, afterID = last.UpdatedAt, last.ID
+	}
+
+	if err := s.findOrphanedIntents(ctx, cutoff, &report); err != nil {
+		logger.Error("Failed to look for orphaned %s intents: %v", provider, err)
+		report.Errors++
+	}
+
+	return report, nil
+}
+
+// reconcilePayment checks one payment against its intent and applies the
+// intent's status when it has moved on.
+func (s *paymentService) reconcilePayment(ctx context.Context, payment db.Payment, report *ReconciliationReport) error {
+	mismatch := ReconciliationMismatch{
+		PaymentID:        uuid.UUID(payment.ID.Bytes).String(),
+		OrderID:          uuid.UUID(payment.OrderID.Bytes).String(),
+		ProviderTxnID:    payment.ProviderTxnID.String,
+		LocalStatus:      payment.Status,
+		LocalAmountCents: payment.AmountCents,
+	}
+	// A checkout session creates its intent only once the customer pays;
+	// until a webhook names it there is nothing to compare
+	if !payment.ProviderTxnID.Valid && payment.CheckoutSessionID.Valid {
+		return nil
+	}
+
+	report.Checked++
+
+	if !payment.ProviderTxnID.Valid {
+		mismatch.Kind = MismatchMissingIntent
+		mismatch.Detail = "payment has no provider transaction ID"
+		report.Mismatches = append(report.Mismatches, mismatch)
+		return nil
+	}
+
+	intent, err := s.gateway.GetIntent(ctx, payment.ProviderTxnID.String)
+	if errors.Is(err, gateway.ErrIntentNotFound) {
+		mismatch.Kind = MismatchMissingIntent
+		mismatch.Detail = "provider has no such intent"
+		report.Mismatches = append(report.Mismatches, mismatch)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	mismatch.ProviderStatus = intent.PaymentStatus
+	mismatch.ProviderAmountCents = intent.AmountCents
+
+	// A capture is recorded before the provider is asked for it; one the
+	// provider still holds as an authorization is asked for again
+	if payment.Status == gateway.StatusSucceeded && payment.CapturedAt.Valid {
+		if intent.PaymentStatus != gateway.StatusAuthorized {
+			return nil
+		}
+		if _, err := s.gateway.CaptureIntent(ctx, payment.ProviderTxnID.String, payment.AmountCents); err != nil {
+			mismatch.Kind = MismatchUnresolved
+			mismatch.Detail = fmt.Sprintf("capturing %d cents failed: %v", payment.AmountCents, err)
+			report.Mismatches = append(report.Mismatches, mismatch)
+			return nil
+		}
+		logger.Info("Reconciled payment %s: captured %d cents", mismatch.PaymentID, payment.AmountCents)
+		report.Applied++
+		return nil
+	}
+
+	if intent.AmountCents != payment.AmountCents {
+		m := mismatch
+		m.Kind = MismatchAmount
+		m.Detail = fmt.Sprintf("provider holds %d cents, payment is for %d", intent.AmountCents, payment.AmountCents)
+		report.Mismatches = append(report.Mismatches, m)
+	}
+	if intent.Currency != "" && !strings.EqualFold(intent.Currency, payment.Currency) {
+		m := mismatch
+		m.Kind = MismatchCurrency
+		m.Detail = fmt.Sprintf("provider currency is %s, payment currency is %s", intent.Currency, payment.Currency)
+		report.Mismatches = append(report.Mismatches, m)
+	}
+
+	if intent.PaymentStatus == payment.Status {
+		return nil
+	}
+
+	// The webhook path only applies settled statuses; an intent reported
+	// as pending while the payment already moved on needs a person
+	if !settledPaymentStatuses[intent.PaymentStatus] {
+		mismatch.Kind = MismatchUnresolved
+		mismatch.Detail = fmt.Sprintf("provider reports %s", intent.PaymentStatus)
+		report.Mismatches = append(report.Mismatches, mismatch)
+		return nil
+	}
+
+	applied, err := s.applyIntentStatus(ctx, payment, intent)
+	if err != nil {
+		mismatch.Kind = MismatchUnresolved
+		mismatch.Detail = fmt.Sprintf("applying %s failed: %v", intent.PaymentStatus, err)
+		report.Mismatches = append(report.Mismatches, mismatch)
+		return nil
+	}
+
+	if applied.Status != WebhookProcessed || applied.Error.Valid {
+		mismatch.Kind = MismatchUnresolved
+		mismatch.Detail = fmt.Sprintf("%s event was %s", intent.PaymentStatus, strings.ToLower(applied.Status))
+		if applied.Error.Valid {
+			mismatch.Detail += ": " + applied.Error.String
+		}
+		report.Mismatches = append(report.Mismatches, mismatch)
+		return nil
+	}
+
+	logger.Info("Reconciled payment %s: %s -> %s", mismatch.PaymentID, payment.Status, intent.PaymentStatus)
+	report.Applied++
+	return nil
+}
+
+// applyIntentStatus records the intent's status as a webhook event and
+// processes it the way a delivered webhook would be. The event ID is
+// derived from the intent and status, so repeated runs apply it once.
+func (s *paymentService) applyIntentStatus(ctx context.Context, payment db.Payment, intent gateway.Intent) (db.WebhookEvent, error) {
+	event := &gateway.WebhookEvent{
+		EventID:       fmt.Sprintf("reconcile:%s:%s", intent.ID, intent.PaymentStatus),
+		Type:          ReconciliationEventType,
+		Provider:      s.gateway.Name(),
+		ProviderTxnID: intent.ID,
+		OrderID:       uuid.UUID(payment.OrderID.Bytes).String(),
+		Status:        intent.PaymentStatus,
+		FailureReason: intent.FailureReason,
+		OccurredAt:    time.Now(),
+	}
+
+	// Only what the run saw; the intent itself carries the client secret
+	payload, _ := json.Marshal(map[string]interface{}{
+		"intent_id":       intent.ID,
+		"provider_status": intent.Status,
+		"payment_status":  intent.PaymentStatus,
+		"amount_cents":    intent.AmountCents,
+		"currency":        intent.Currency,
+	})
+
+	recorded, err := s.recordEvent(ctx, event, payload)
+	if err != nil {
+		return db.WebhookEvent{}, err
+	}
+
+	if recorded.Status == WebhookProcessed || recorded.Status == WebhookIgnored {
+		return recorded, nil
+	}
+
+	return s.processEvent(ctx, recorded.ID)
+}
+
+// findOrphanedIntents reports intents created in the day before cutoff
+// that no payment row refers to, e.g. because checkout failed after the
+// intent was created. Failed and cancelled intents hold no money and are
+// left out.
+func (s *paymentService) findOrphanedIntents(ctx context.Context, cutoff time.Time, report *ReconciliationReport) error {
+	intents, err := s.gateway.ListIntents(ctx, cutoff.Add(-reconcileOrphanWindow), cutoff)
+	if err != nil {
+		return err
+	}
+
+	for _, intent := range intents {
+		if intent.PaymentStatus == gateway.StatusFailed || intent.PaymentStatus == gateway.StatusCancelled {
+			continue
+		}
+
+		_, err := s.repo.GetPaymentByProviderTxnID(ctx, db.GetPaymentByProviderTxnIDParams{
+			Provider:      report.Provider,
+			ProviderTxnID: pgtype.Text{String: intent.ID, Valid: true},
+		})
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+
+		report.Mismatches = append(report.Mismatches, ReconciliationMismatch{
+			Kind:                MismatchOrphanedIntent,
+			OrderID:             intent.OrderID,
+			ProviderTxnID:       intent.ID,
+			ProviderStatus:      intent.PaymentStatus,
+			ProviderAmountCents: intent.AmountCents,
+			Detail:              "no payment refers to this intent",
+		})
+	}
+
+	return nil
+}
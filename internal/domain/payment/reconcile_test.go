@@ -0,0 +1,262 @@
+package payment
+
+import (
+	"context"
+	"database/sql"
+	"ecommerce-app/internal/domain/gateway"
+	"ecommerce-app/internal/domain/order"
+	db "ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// reconcileRepo keeps payments and webhook events in memory. Only the
+// methods reconciliation reaches are implemented; the embedded interface
+// panics on anything else.
+type reconcileRepo struct {
+	PaymentRepository
+	payments []db.Payment
+	events   map[pgtype.UUID]db.WebhookEvent
+}
+
+func newReconcileRepo(payments ...db.Payment) *reconcileRepo {
+	return &reconcileRepo{payments: payments, events: map[pgtype.UUID]db.WebhookEvent{}}
+}
+
+func (r *reconcileRepo) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (r *reconcileRepo) ListPaymentsToReconcile(ctx context.Context, arg db.ListPaymentsToReconcileParams) ([]db.Payment, error) {
+	var waiting []db.Payment
+	for _, p := range r.payments {
+		unconfirmed := p.Status == gateway.StatusSucceeded && p.CapturedAt.Valid &&
+			(!p.LastEventAt.Valid || p.LastEventAt.Time.Before(p.CapturedAt.Time))
+		if p.Provider == arg.Provider && (p.Status == gateway.StatusInitiated || p.Status == gateway.StatusAuthorized || unconfirmed) {
+			waiting = append(waiting, p)
+		}
+	}
+	return waiting, nil
+}
+
+func (r *reconcileRepo) GetPaymentByProviderTxnID(ctx context.Context, arg db.GetPaymentByProviderTxnIDParams) (db.Payment, error) {
+	for _, p := range r.payments {
+		if p.Provider == arg.Provider && p.ProviderTxnID == arg.ProviderTxnID {
+			return p, nil
+		}
+	}
+	return db.Payment{}, sql.ErrNoRows
+}
+
+func (r *reconcileRepo) ApplyPaymentEvent(ctx context.Context, arg db.ApplyPaymentEventParams) (db.Payment, error) {
+	for i, p := range r.payments {
+		if p.ID == arg.ID {
+			r.payments[i].Status = arg.Status
+			r.payments[i].LastEventAt = arg.LastEventAt
+			return r.payments[i], nil
+		}
+	}
+	return db.Payment{}, sql.ErrNoRows
+}
+
+func (r *reconcileRepo) CreateWebhookEvent(ctx context.Context, arg db.CreateWebhookEventParams) (db.WebhookEvent, error) {
+	for _, ev := range r.events {
+		if ev.Provider == arg.Provider && ev.EventID == arg.EventID {
+			return db.WebhookEvent{}, sql.ErrNoRows
+		}
+	}
+
+	ev := db.WebhookEvent{
+		ID:            pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		Provider:      arg.Provider,
+		EventID:       arg.EventID,
+		EventType:     arg.EventType,
+		ProviderTxnID: arg.ProviderTxnID,
+		OrderID:       arg.OrderID,
+		PaymentStatus: arg.PaymentStatus,
+		FailureReason: arg.FailureReason,
+		OccurredAt:    arg.OccurredAt,
+		Payload:       arg.Payload,
+		Status:        WebhookReceived,
+	}
+	r.events[ev.ID] = ev
+	return ev, nil
+}
+
+func (r *reconcileRepo) LockWebhookEvent(ctx context.Context, id pgtype.UUID) (db.WebhookEvent, error) {
+	ev, ok := r.events[id]
+	if !ok {
+		return db.WebhookEvent{}, sql.ErrNoRows
+	}
+	return ev, nil
+}
+
+func (r *reconcileRepo) UpdateWebhookEventStatus(ctx context.Context, arg db.UpdateWebhookEventStatusParams) (db.WebhookEvent, error) {
+	ev, ok := r.events[arg.ID]
+	if !ok {
+		return db.WebhookEvent{}, sql.ErrNoRows
+	}
+	ev.Status = arg.Status
+	ev.Error = arg.Error
+	ev.Attempts++
+	r.events[arg.ID] = ev
+	return ev, nil
+}
+
+// reconcileOrders records the status changes the webhook path asks for
+type reconcileOrders struct {
+	OrderProvider
+	statuses map[string]string
+}
+
+func (o *reconcileOrders) UpdateOrderStatus(ctx context.Context, orderID string, status string, changedBy string, reason string) (order.Order, *errs.AppError) {
+	o.statuses[orderID] = status
+	return order.Order{ID: uuid.MustParse(orderID), Status: status}, nil
+}
+
+// lostPayment creates an intent on the fake gateway that settles without
+// sending its webhook, and the INITIATED payment the shop holds for it
+func lostPayment(t *testing.T, gw *gateway.FakeGateway, intentCents, paymentCents int64) db.Payment {
+	t.Helper()
+
+	orderID := uuid.New()
+	intent, err := gw.CreateIntent(context.Background(), gateway.IntentRequest{
+		AmountCents: intentCents,
+		Currency:    "usd",
+		Metadata:    map[string]string{"order_id": orderID.String()},
+	})
+	if err != nil {
+		t.Fatalf("CreateIntent: %v", err)
+	}
+
+	created := time.Now().Add(-time.Hour)
+	return db.Payment{
+		ID:            pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		OrderID:       pgtype.UUID{Bytes: orderID, Valid: true},
+		Provider:      gw.Name(),
+		ProviderTxnID: pgtype.Text{String: intent.ID, Valid: true},
+		AmountCents:   paymentCents,
+		Currency:      "usd",
+		PaymentMethod: "CARD",
+		Status:        gateway.StatusInitiated,
+		CaptureMethod: gateway.CaptureAutomatic,
+		CreatedAt:     pgtype.Timestamptz{Time: created, Valid: true},
+		UpdatedAt:     pgtype.Timestamptz{Time: created, Valid: true},
+	}
+}
+
+func TestReconcileAppliesLostWebhook(t *testing.T) {
+	gw := gateway.NewFakeGateway(gateway.FakeConfig{Outcome: gateway.FakeOutcomeLost})
+	payment := lostPayment(t, gw, 5000, 5000)
+	repo := newReconcileRepo(payment)
+	orders := &reconcileOrders{statuses: map[string]string{}}
+	svc := NewPaymentService(repo, orders, gw)
+
+	report, appErr := svc.Reconcile(context.Background(), 0)
+	if appErr != nil {
+		t.Fatalf("Reconcile: %s", appErr.Message)
+	}
+
+	if report.Checked != 1 || report.Applied != 1 || report.Errors != 0 {
+		t.Fatalf("checked %d, applied %d, errors %d; want 1, 1, 0", report.Checked, report.Applied, report.Errors)
+	}
+	if len(report.Mismatches) != 0 {
+		t.Fatalf("got mismatches %+v, want none", report.Mismatches)
+	}
+
+	if got := repo.payments[0].Status; got != gateway.StatusSucceeded {
+		t.Errorf("payment status %s, want %s", got, gateway.StatusSucceeded)
+	}
+	orderID := uuid.UUID(payment.OrderID.Bytes).String()
+	if got := orders.statuses[orderID]; got != order.StatusPaid {
+		t.Errorf("order status %q, want %s", got, order.StatusPaid)
+	}
+
+	// The event ID is derived from the intent, so a second run finds
+	// nothing left to apply
+	again, appErr := svc.Reconcile(context.Background(), 0)
+	if appErr != nil {
+		t.Fatalf("second Reconcile: %s", appErr.Message)
+	}
+	if again.Checked != 0 || again.Applied != 0 {
+		t.Errorf("second run checked %d, applied %d; want 0, 0", again.Checked, again.Applied)
+	}
+	if len(repo.events) != 1 {
+		t.Errorf("recorded %d reconciliation events, want 1", len(repo.events))
+	}
+}
+
+func TestReconcileReportsAmountMismatch(t *testing.T) {
+	gw := gateway.NewFakeGateway(gateway.FakeConfig{Outcome: gateway.FakeOutcomeLost})
+	payment := lostPayment(t, gw, 7000, 6000)
+	repo := newReconcileRepo(payment)
+	orders := &reconcileOrders{statuses: map[string]string{}}
+	svc := NewPaymentService(repo, orders, gw)
+
+	report, appErr := svc.Reconcile(context.Background(), 0)
+	if appErr != nil {
+		t.Fatalf("Reconcile: %s", appErr.Message)
+	}
+
+	if len(report.Mismatches) != 1 {
+		t.Fatalf("got %d mismatches %+v, want 1", len(report.Mismatches), report.Mismatches)
+	}
+	m := report.Mismatches[0]
+	if m.Kind != MismatchAmount {
+		t.Errorf("mismatch kind %s, want %s", m.Kind, MismatchAmount)
+	}
+	if m.PaymentID != uuid.UUID(payment.ID.Bytes).String() {
+		t.Errorf("mismatch payment %s, want %s", m.PaymentID, uuid.UUID(payment.ID.Bytes).String())
+	}
+	if m.LocalAmountCents != 6000 || m.ProviderAmountCents != 7000 {
+		t.Errorf("mismatch amounts local %d, provider %d; want 6000, 7000", m.LocalAmountCents, m.ProviderAmountCents)
+	}
+
+	// The status still moved on at the provider, so it is applied all the
+	// same; the amount is left for a person to look at
+	if report.Applied != 1 {
+		t.Errorf("applied %d, want 1", report.Applied)
+	}
+}
+
+func TestReconcileRetriesFailedCapture(t *testing.T) {
+	gw := gateway.NewFakeGateway(gateway.FakeConfig{Outcome: gateway.FakeOutcomeLost, CaptureMethod: gateway.CaptureManual})
+	payment := lostPayment(t, gw, 5000, 4000)
+	// The capture was recorded, but the provider never took it
+	payment.Status = gateway.StatusSucceeded
+	payment.CaptureMethod = gateway.CaptureManual
+	payment.AuthorizedCents = pgtype.Int8{Int64: 5000, Valid: true}
+	payment.CapturedAt = payment.UpdatedAt
+	repo := newReconcileRepo(payment)
+	svc := NewPaymentService(repo, &reconcileOrders{statuses: map[string]string{}}, gw)
+
+	report, appErr := svc.Reconcile(context.Background(), 0)
+	if appErr != nil {
+		t.Fatalf("Reconcile: %s", appErr.Message)
+	}
+	if report.Checked != 1 || report.Applied != 1 || len(report.Mismatches) != 0 {
+		t.Fatalf("checked %d, applied %d, mismatches %+v; want 1, 1, none", report.Checked, report.Applied, report.Mismatches)
+	}
+
+	intent, err := gw.GetIntent(context.Background(), payment.ProviderTxnID.String)
+	if err != nil {
+		t.Fatalf("GetIntent: %v", err)
+	}
+	if intent.PaymentStatus != gateway.StatusSucceeded {
+		t.Errorf("intent status %s, want %s", intent.PaymentStatus, gateway.StatusSucceeded)
+	}
+
+	// Until its webhook arrives the payment is checked again, but the
+	// provider has it captured now
+	again, appErr := svc.Reconcile(context.Background(), 0)
+	if appErr != nil {
+		t.Fatalf("second Reconcile: %s", appErr.Message)
+	}
+	if again.Applied != 0 || len(again.Mismatches) != 0 {
+		t.Errorf("second run applied %d, mismatches %+v; want 0, none", again.Applied, again.Mismatches)
+	}
+}
@@ -2,6 +2,7 @@ package payment
 
 import (
 	"context"
+	"ecommerce-app/internal/pkg/database"
 	db "ecommerce-app/internal/pkg/database/sqlc"
 
 	"github.com/jackc/pgx/v5/pgtype"
@@ -9,26 +10,145 @@ import (
 
 type PaymentRepository interface {
 	CreatePayment(ctx context.Context, arg db.CreatePaymentParams) (db.Payment, error)
+	GetPayment(ctx context.Context, id pgtype.UUID) (db.Payment, error)
 	GetPaymentByOrderID(ctx context.Context, orderID pgtype.UUID) (db.Payment, error)
+	ListPaymentsByOrderID(ctx context.Context, arg db.ListPaymentsByOrderIDParams) ([]db.Payment, error)
+	CountPaymentsByOrderID(ctx context.Context, orderID pgtype.UUID) (int64, error)
+	GetPaymentByProviderTxnID(ctx context.Context, arg db.GetPaymentByProviderTxnIDParams) (db.Payment, error)
+	GetPaymentByCheckoutSessionID(ctx context.Context, arg db.GetPaymentByCheckoutSessionIDParams) (db.Payment, error)
+	AttachPaymentIntent(ctx context.Context, arg db.AttachPaymentIntentParams) error
+	ListPaymentsToReconcile(ctx context.Context, arg db.ListPaymentsToReconcileParams) ([]db.Payment, error)
 	UpdatePaymentStatus(ctx context.Context, arg db.UpdatePaymentStatusParams) (db.Payment, error)
+	ApplyPaymentEvent(ctx context.Context, arg db.ApplyPaymentEventParams) (db.Payment, error)
+	CreateWebhookEvent(ctx context.Context, arg db.CreateWebhookEventParams) (db.WebhookEvent, error)
+	GetWebhookEvent(ctx context.Context, id pgtype.UUID) (db.WebhookEvent, error)
+	GetWebhookEventByEventID(ctx context.Context, arg db.GetWebhookEventByEventIDParams) (db.WebhookEvent, error)
+	LockWebhookEvent(ctx context.Context, id pgtype.UUID) (db.WebhookEvent, error)
+	ListWebhookEvents(ctx context.Context, arg db.ListWebhookEventsParams) ([]db.WebhookEvent, error)
+	CountWebhookEvents(ctx context.Context, status pgtype.Text) (int64, error)
+	UpdateWebhookEventStatus(ctx context.Context, arg db.UpdateWebhookEventStatusParams) (db.WebhookEvent, error)
+	CreateRefund(ctx context.Context, arg db.CreateRefundParams) (db.Refund, error)
+	GetRefundByProviderRefundID(ctx context.Context, arg db.GetRefundByProviderRefundIDParams) (db.Refund, error)
+	GetRefund(ctx context.Context, id pgtype.UUID) (db.Refund, error)
+	UpdateRefundResult(ctx context.Context, arg db.UpdateRefundResultParams) (db.Refund, error)
+	GetPaymentRefundTotals(ctx context.Context, paymentID pgtype.UUID) (db.GetPaymentRefundTotalsRow, error)
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type paymentRepository struct {
-	q *db.Queries
+	q  *db.Queries
+	tx database.Transactor
 }
 
-func NewPaymentRepository(q *db.Queries) PaymentRepository {
-	return &paymentRepository{q}
+func NewPaymentRepository(q *db.Queries, tx database.Transactor) PaymentRepository {
+	return &paymentRepository{q: q, tx: tx}
+}
+
+// WithTx runs fn in a single transaction; every repository call made with
+// the ctx passed to fn takes part in it.
+func (r *paymentRepository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithTx(ctx, r.tx, fn)
+}
+
+func (r *paymentRepository) queries(ctx context.Context) *db.Queries {
+	return database.Queries(ctx, r.q)
 }
 
 func (r *paymentRepository) CreatePayment(ctx context.Context, arg db.CreatePaymentParams) (db.Payment, error) {
-	return r.q.CreatePayment(ctx, arg)
+	return r.queries(ctx).CreatePayment(ctx, arg)
+}
+
+func (r *paymentRepository) GetPayment(ctx context.Context, id pgtype.UUID) (db.Payment, error) {
+	return r.queries(ctx).GetPayment(ctx, id)
 }
 
+// GetPaymentByOrderID returns the order's latest payment attempt
 func (r *paymentRepository) GetPaymentByOrderID(ctx context.Context, orderID pgtype.UUID) (db.Payment, error) {
-	return r.q.GetPaymentByOrderID(ctx, orderID)
+	return r.queries(ctx).GetPaymentByOrderID(ctx, orderID)
+}
+
+func (r *paymentRepository) ListPaymentsByOrderID(ctx context.Context, arg db.ListPaymentsByOrderIDParams) ([]db.Payment, error) {
+	return r.queries(ctx).ListPaymentsByOrderID(ctx, arg)
+}
+
+func (r *paymentRepository) CountPaymentsByOrderID(ctx context.Context, orderID pgtype.UUID) (int64, error) {
+	return r.queries(ctx).CountPaymentsByOrderID(ctx, orderID)
+}
+
+func (r *paymentRepository) GetPaymentByProviderTxnID(ctx context.Context, arg db.GetPaymentByProviderTxnIDParams) (db.Payment, error) {
+	return r.queries(ctx).GetPaymentByProviderTxnID(ctx, arg)
+}
+
+func (r *paymentRepository) GetPaymentByCheckoutSessionID(ctx context.Context, arg db.GetPaymentByCheckoutSessionIDParams) (db.Payment, error) {
+	return r.queries(ctx).GetPaymentByCheckoutSessionID(ctx, arg)
+}
+
+// AttachPaymentIntent records the intent a checkout session created; a
+// payment that already has one keeps it
+func (r *paymentRepository) AttachPaymentIntent(ctx context.Context, arg db.AttachPaymentIntentParams) error {
+	return r.queries(ctx).AttachPaymentIntent(ctx, arg)
+}
+
+func (r *paymentRepository) ListPaymentsToReconcile(ctx context.Context, arg db.ListPaymentsToReconcileParams) ([]db.Payment, error) {
+	return r.queries(ctx).ListPaymentsToReconcile(ctx, arg)
 }
 
 func (r *paymentRepository) UpdatePaymentStatus(ctx context.Context, arg db.UpdatePaymentStatusParams) (db.Payment, error) {
-	return r.q.UpdatePaymentStatus(ctx, arg)
+	return r.queries(ctx).UpdatePaymentStatus(ctx, arg)
+}
+
+func (r *paymentRepository) ApplyPaymentEvent(ctx context.Context, arg db.ApplyPaymentEventParams) (db.Payment, error) {
+	return r.queries(ctx).ApplyPaymentEvent(ctx, arg)
+}
+
+// CreateWebhookEvent records a delivery. It returns sql.ErrNoRows when the
+// provider event was already recorded.
+func (r *paymentRepository) CreateWebhookEvent(ctx context.Context, arg db.CreateWebhookEventParams) (db.WebhookEvent, error) {
+	return r.queries(ctx).CreateWebhookEvent(ctx, arg)
+}
+
+func (r *paymentRepository) GetWebhookEvent(ctx context.Context, id pgtype.UUID) (db.WebhookEvent, error) {
+	return r.queries(ctx).GetWebhookEvent(ctx, id)
+}
+
+func (r *paymentRepository) GetWebhookEventByEventID(ctx context.Context, arg db.GetWebhookEventByEventIDParams) (db.WebhookEvent, error) {
+	return r.queries(ctx).GetWebhookEventByEventID(ctx, arg)
+}
+
+// LockWebhookEvent fetches the event and holds a row lock on it until the
+// surrounding transaction ends, so concurrent deliveries apply it once.
+func (r *paymentRepository) LockWebhookEvent(ctx context.Context, id pgtype.UUID) (db.WebhookEvent, error) {
+	return r.queries(ctx).LockWebhookEvent(ctx, id)
+}
+
+func (r *paymentRepository) ListWebhookEvents(ctx context.Context, arg db.ListWebhookEventsParams) ([]db.WebhookEvent, error) {
+	return r.queries(ctx).ListWebhookEvents(ctx, arg)
+}
+
+func (r *paymentRepository) CountWebhookEvents(ctx context.Context, status pgtype.Text) (int64, error) {
+	return r.queries(ctx).CountWebhookEvents(ctx, status)
+}
+
+func (r *paymentRepository) UpdateWebhookEventStatus(ctx context.Context, arg db.UpdateWebhookEventStatusParams) (db.WebhookEvent, error) {
+	return r.queries(ctx).UpdateWebhookEventStatus(ctx, arg)
+}
+
+func (r *paymentRepository) CreateRefund(ctx context.Context, arg db.CreateRefundParams) (db.Refund, error) {
+	return r.queries(ctx).CreateRefund(ctx, arg)
+}
+
+func (r *paymentRepository) GetRefundByProviderRefundID(ctx context.Context, arg db.GetRefundByProviderRefundIDParams) (db.Refund, error) {
+	return r.queries(ctx).GetRefundByProviderRefundID(ctx, arg)
+}
+
+func (r *paymentRepository) GetRefund(ctx context.Context, id pgtype.UUID) (db.Refund, error) {
+	return r.queries(ctx).GetRefund(ctx, id)
+}
+
+func (r *paymentRepository) UpdateRefundResult(ctx context.Context, arg db.UpdateRefundResultParams) (db.Refund, error) {
+	return r.queries(ctx).UpdateRefundResult(ctx, arg)
+}
+
+func (r *paymentRepository) GetPaymentRefundTotals(ctx context.Context, paymentID pgtype.UUID) (db.GetPaymentRefundTotalsRow, error) {
+	return r.queries(ctx).GetPaymentRefundTotals(ctx, paymentID)
 }
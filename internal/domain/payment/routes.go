@@ -1,17 +1,34 @@
 package payment
 
 import (
+	"net/http"
+
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/validator"
+
 	"github.com/go-chi/chi/v5"
 )
 type PaymentRoutes struct{}
 
-func Routes(svc PaymentService) chi.Router {
+// Routes mounts the payment endpoints. idempotent guards refunds against
+// client retries; confirming or expiring an offline payment twice is
+// rejected by its status check instead.
+func Routes(svc PaymentService, idempotent func(http.Handler) http.Handler) chi.Router {
 	h := NewPaymentHandler(svc)
 	r := chi.NewRouter()
 	
-	r.Get("/order/{orderID}", h.GetPaymentByOrderID)
+	r.With(middleware.RoleMiddleware("customer", "admin")).Get("/order/{orderID}", h.GetPaymentsByOrderID)
 
 	r.Post("/webhook/{provider}", h.HandleWebhook)
 
+	r.With(middleware.RoleMiddleware("admin")).Get("/webhook-events", h.ListWebhookEvents)
+	r.With(middleware.RoleMiddleware("admin")).Post("/webhook-events/{id}/retry", h.RetryWebhookEvent)
+	r.With(middleware.RoleMiddleware("admin")).Post("/reconcile", h.Reconcile)
+
+	r.With(middleware.RoleMiddleware("customer", "admin")).Get("/{id}", h.GetPayment)
+	r.With(middleware.RoleMiddleware("admin", "support")).With(idempotent).With(validator.Validate[CreateRefundRequest]()).Post("/{id}/refunds", h.CreateRefund)
+	r.With(middleware.RoleMiddleware("admin")).Post("/{id}/received", h.MarkPaymentReceived)
+	r.With(middleware.RoleMiddleware("admin")).With(validator.Validate[ExpirePaymentRequest]()).Post("/{id}/expired", h.MarkPaymentExpired)
+
 	return r
 }
\ No newline at end of file
@@ -2,71 +2,732 @@ package payment
 
 import (
 	"context"
-	"ecommerce-app/internal/domain/stripe"
+	"database/sql"
+	"ecommerce-app/internal/domain/gateway"
+	"ecommerce-app/internal/domain/order"
 	db "ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
 	"ecommerce-app/internal/pkg/logger"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/pkg/pagination"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type PaymentService interface {
-	HandleWebhook(ctx context.Context, providerName string, payload []byte, sigHeader string) error
+	HandleWebhook(ctx context.Context, providerName string, payload []byte, header http.Header) error
+	ListWebhookEvents(ctx context.Context, status string, page, perPage int) (WebhookEventsWithMeta, *errs.AppError)
+	RetryWebhookEvent(ctx context.Context, id string) (WebhookEvent, *errs.AppError)
+	GetPayment(ctx context.Context, userID, role, id string) (PaymentResponse, *errs.AppError)
+	ListOrderPayments(ctx context.Context, userID, role, orderID string, page, perPage int) (PaymentsWithMeta, *errs.AppError)
+	RefundPayment(ctx context.Context, userID, paymentID string, req CreateRefundRequest) (order.Refund, *errs.AppError)
+	MarkPaymentReceived(ctx context.Context, userID, paymentID string) (PaymentResponse, *errs.AppError)
+	MarkPaymentExpired(ctx context.Context, userID, paymentID string, req ExpirePaymentRequest) (PaymentResponse, *errs.AppError)
+	Reconcile(ctx context.Context, olderThan time.Duration) (ReconciliationReport, *errs.AppError)
 }
 
 type paymentService struct {
 	repo      PaymentRepository
 	orderSvc  OrderProvider
+	gateway   Gateway
 }
 
-func NewPaymentService(repo PaymentRepository, orderSvc OrderProvider) PaymentService {
-	return &paymentService{repo: repo, orderSvc: orderSvc}
+func NewPaymentService(repo PaymentRepository, orderSvc OrderProvider, gateway Gateway) PaymentService {
+	return &paymentService{repo: repo, orderSvc: orderSvc, gateway: gateway}
 }
 
+// GetPayment fetches one payment attempt. Customers only see payments for
+// their own orders.
+func (s *paymentService) GetPayment(ctx context.Context, userID, role, id string) (PaymentResponse, *errs.AppError) {
+	var paymentUUID pgtype.UUID
+	if err := paymentUUID.Scan(id); err != nil {
+		return PaymentResponse{}, errs.ErrBadRequest.WithMessage("Invalid payment ID")
+	}
+
+	payment, err := s.repo.GetPayment(ctx, paymentUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return PaymentResponse{}, errs.ErrNotFound.WithMessage("Payment not found")
+		}
+		return PaymentResponse{}, errs.ErrInternal.WithMessage("Failed to get payment")
+	}
+
+	if appErr := s.checkOrderAccess(ctx, userID, role, uuid.UUID(payment.OrderID.Bytes).String()); appErr != nil {
+		if appErr.Code == http.StatusNotFound {
+			return PaymentResponse{}, errs.ErrNotFound.WithMessage("Payment not found")
+		}
+		return PaymentResponse{}, appErr
+	}
+
+	return mapPayment(payment), nil
+}
+
+// ListOrderPayments pages through every payment attempt made for an
+// order, newest first.
+func (s *paymentService) ListOrderPayments(ctx context.Context, userID, role, orderID string, page, perPage int) (PaymentsWithMeta, *errs.AppError) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return PaymentsWithMeta{}, errs.ErrBadRequest.WithMessage("Invalid order ID")
+	}
+
+	if appErr := s.checkOrderAccess(ctx, userID, role, orderID); appErr != nil {
+		return PaymentsWithMeta{}, appErr
+	}
+
+	p := pagination.New(page, perPage)
+
+	rows, err := s.repo.ListPaymentsByOrderID(ctx, db.ListPaymentsByOrderIDParams{
+		OrderID: orderUUID,
+		Limit:   int32(p.PerPage),
+		Offset:  int32(p.Offset()),
+	})
+	if err != nil {
+		logger.Error("Failed to list payments for order %s: %v", orderID, err)
+		return PaymentsWithMeta{}, errs.ErrInternal.WithMessage("Failed to list payments")
+	}
+
+	total, err := s.repo.CountPaymentsByOrderID(ctx, orderUUID)
+	if err != nil {
+		return PaymentsWithMeta{}, errs.ErrInternal.WithMessage("Failed to count payments")
+	}
+
+	payments := make([]PaymentResponse, len(rows))
+	for i, row := range rows {
+		payments[i] = mapPayment(row)
+	}
+
+	return PaymentsWithMeta{
+		Payments: payments,
+		Meta: response.Meta{
+			Page:    p.Page,
+			PerPage: p.PerPage,
+			Total:   int(total),
+		},
+	}, nil
+}
+
+// RefundPayment refunds a payment through the gateway, or into the
+// customer's wallet when req.ToWallet is set, and records it in the refund
+// ledger. The order only moves to REFUNDED once everything charged has been
+// refunded.
+func (s *paymentService) RefundPayment(ctx context.Context, userID, paymentID string, req CreateRefundRequest) (order.Refund, *errs.AppError) {
+	if _, err := uuid.Parse(paymentID); err != nil {
+		return order.Refund{}, errs.ErrBadRequest.WithMessage("Invalid payment ID")
+	}
+
+	return s.orderSvc.RefundPayment(ctx, paymentID, req.AmountCents, req.ToWallet, userID, req.Reason)
+}
+
+// MarkPaymentReceived confirms that a bank transfer or cash-on-delivery
+// payment arrived, which marks its order PAID.
+func (s *paymentService) MarkPaymentReceived(ctx context.Context, userID, paymentID string) (PaymentResponse, *errs.AppError) {
+	var paymentUUID pgtype.UUID
+	if err := paymentUUID.Scan(paymentID); err != nil {
+		return PaymentResponse{}, errs.ErrBadRequest.WithMessage("Invalid payment ID")
+	}
+
+	if _, appErr := s.orderSvc.ConfirmOfflinePayment(ctx, paymentID, userID); appErr != nil {
+		return PaymentResponse{}, appErr
+	}
+
+	payment, err := s.repo.GetPayment(ctx, paymentUUID)
+	if err != nil {
+		return PaymentResponse{}, errs.ErrInternal.WithMessage("Failed to get payment")
+	}
+
+	return mapPayment(payment), nil
+}
+
+// MarkPaymentExpired cancels a bank transfer or cash-on-delivery payment
+// that never arrived, together with its order.
+func (s *paymentService) MarkPaymentExpired(ctx context.Context, userID, paymentID string, req ExpirePaymentRequest) (PaymentResponse, *errs.AppError) {
+	var paymentUUID pgtype.UUID
+	if err := paymentUUID.Scan(paymentID); err != nil {
+		return PaymentResponse{}, errs.ErrBadRequest.WithMessage("Invalid payment ID")
+	}
+
+	if _, appErr := s.orderSvc.ExpireOfflinePayment(ctx, paymentID, userID, req.Reason); appErr != nil {
+		return PaymentResponse{}, appErr
+	}
+
+	payment, err := s.repo.GetPayment(ctx, paymentUUID)
+	if err != nil {
+		return PaymentResponse{}, errs.ErrInternal.WithMessage("Failed to get payment")
+	}
+
+	return mapPayment(payment), nil
+}
+
+// checkOrderAccess lets admins through and customers only for their own
+// orders. Other customers' orders are reported as missing.
+func (s *paymentService) checkOrderAccess(ctx context.Context, userID, role, orderID string) *errs.AppError {
+	o, appErr := s.orderSvc.GetOrderByID(ctx, orderID)
+	if appErr != nil {
+		return appErr
+	}
+
+	if role != "admin" && o.UserID.String() != userID {
+		return errs.ErrNotFound.WithMessage("Order not found")
+	}
+
+	return nil
+}
+
+// HandleWebhook verifies a delivery, records it in the webhook event log and
+// applies it. Deliveries of an event that was already applied or ignored
+// are acknowledged without touching the payment again.
+func (s *paymentService) HandleWebhook(ctx context.Context, providerName string, payload []byte, header http.Header) error {
+	if !strings.EqualFold(providerName, s.gateway.Name()) {
+		return fmt.Errorf("unknown payment provider %q", providerName)
+	}
 
-func (s *paymentService) HandleWebhook(ctx context.Context, providerName string, payload []byte, sigHeader string) error {
-	stripeClient := stripe.NewStripeProvider()
-	event, err := stripeClient.HandleWebhook(payload, sigHeader)
+	event, err := s.gateway.ParseWebhook(ctx, payload, header)
 	if err != nil {
 		return err
 	}
 
-	if event.Status == "INITIATED" {
-		// No action needed for initiated payments
+	// Event types the gateway does not map are acknowledged and dropped
+	if event == nil {
 		return nil
 	}
 
-	// Map order_id to UUID
-	orderUUID := pgtype.UUID{}
-	_ = orderUUID.Scan(event.OrderID)
-
-	// Get payment record for that order
-	payment, err := s.repo.GetPaymentByOrderID(ctx, orderUUID)
+	recorded, err := s.recordEvent(ctx, event, payload)
 	if err != nil {
-		logger.Error("Failed to get payment by order ID: %v", err)
+		logger.Error("Failed to record webhook event %s: %v", event.EventID, err)
 		return err
 	}
 
-	// Update payment status based on event
-	update := db.UpdatePaymentStatusParams{
-		ID:     payment.ID,
-		Status: event.Status,
+	if recorded.Status == WebhookProcessed || recorded.Status == WebhookIgnored {
+		logger.Info("Skipping duplicate webhook event %s (%s)", event.EventID, recorded.Status)
+		return nil
+	}
+
+	_, err = s.processEvent(ctx, recorded.ID)
+	return err
+}
+
+// ListWebhookEvents pages through the webhook event log, newest first,
+// optionally narrowed to one processing status.
+func (s *paymentService) ListWebhookEvents(ctx context.Context, status string, page, perPage int) (WebhookEventsWithMeta, *errs.AppError) {
+	var statusFilter pgtype.Text
+	if status != "" {
+		status = strings.ToUpper(status)
+		switch status {
+		case WebhookReceived, WebhookProcessed, WebhookIgnored, WebhookFailed:
+		default:
+			return WebhookEventsWithMeta{}, errs.ErrBadRequest.WithMessage("Invalid status filter: " + status)
+		}
+		statusFilter = pgtype.Text{String: status, Valid: true}
+	}
+
+	p := pagination.New(page, perPage)
+
+	rows, err := s.repo.ListWebhookEvents(ctx, db.ListWebhookEventsParams{
+		Status:    statusFilter,
+		RowLimit:  int32(p.PerPage),
+		RowOffset: int32(p.Offset()),
+	})
+	if err != nil {
+		logger.Error("Failed to list webhook events: %v", err)
+		return WebhookEventsWithMeta{}, errs.ErrInternal.WithMessage("Failed to list webhook events")
 	}
 
-	_, err = s.repo.UpdatePaymentStatus(ctx, update)
+	total, err := s.repo.CountWebhookEvents(ctx, statusFilter)
 	if err != nil {
+		return WebhookEventsWithMeta{}, errs.ErrInternal.WithMessage("Failed to count webhook events")
+	}
+
+	events := make([]WebhookEvent, len(rows))
+	for i, row := range rows {
+		events[i] = mapWebhookEvent(row)
+	}
+
+	return WebhookEventsWithMeta{
+		Events: events,
+		Meta: response.Meta{
+			Page:    p.Page,
+			PerPage: p.PerPage,
+			Total:   int(total),
+		},
+	}, nil
+}
+
+// RetryWebhookEvent re-runs a failed or unfinished event from its stored
+// copy. The provider signature is not checked again; the event was verified
+// when it was first received.
+func (s *paymentService) RetryWebhookEvent(ctx context.Context, id string) (WebhookEvent, *errs.AppError) {
+	var eventUUID pgtype.UUID
+	if err := eventUUID.Scan(id); err != nil {
+		return WebhookEvent{}, errs.ErrBadRequest.WithMessage("Invalid webhook event ID")
+	}
+
+	current, err := s.repo.GetWebhookEvent(ctx, eventUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return WebhookEvent{}, errs.ErrNotFound.WithMessage("Webhook event not found")
+		}
+		return WebhookEvent{}, errs.ErrInternal.WithMessage("Failed to get webhook event")
+	}
+
+	if current.Status != WebhookFailed && current.Status != WebhookReceived {
+		return WebhookEvent{}, errs.ErrConflict.WithMessage(fmt.Sprintf("Webhook event is already %s", current.Status))
+	}
+
+	// A failed re-run is recorded on the event; the caller reads the outcome
+	// from its status and error.
+	updated, err := s.processEvent(ctx, eventUUID)
+	if err != nil {
+		logger.Warn("Re-run of webhook event %s failed: %v", current.EventID, err)
+	}
+	if !updated.ID.Valid {
+		return WebhookEvent{}, errs.ErrInternal.WithMessage("Failed to re-run webhook event")
+	}
+
+	return mapWebhookEvent(updated), nil
+}
+
+// recordEvent stores the delivery, or returns the row already stored for
+// the same provider event.
+func (s *paymentService) recordEvent(ctx context.Context, event *gateway.WebhookEvent, payload []byte) (db.WebhookEvent, error) {
+	var orderUUID pgtype.UUID
+	_ = orderUUID.Scan(event.OrderID)
+
+	if !json.Valid(payload) {
+		payload, _ = json.Marshal(string(payload))
+	}
+
+	provider := s.gateway.Name()
+	params := db.CreateWebhookEventParams{
+		Provider:          provider,
+		EventID:           event.EventID,
+		EventType:         event.Type,
+		ProviderTxnID:     pgtype.Text{String: event.ProviderTxnID, Valid: event.ProviderTxnID != ""},
+		CheckoutSessionID: pgtype.Text{String: event.CheckoutSessionID, Valid: event.CheckoutSessionID != ""},
+		OrderID:           orderUUID,
+		PaymentStatus:     event.Status,
+		FailureReason:     pgtype.Text{String: event.FailureReason, Valid: event.FailureReason != ""},
+		OccurredAt:        pgtype.Timestamptz{Time: event.OccurredAt, Valid: true},
+		Payload:           payload,
+	}
+	if event.Refund != nil {
+		params.ProviderRefundID = pgtype.Text{String: event.Refund.ID, Valid: event.Refund.ID != ""}
+		params.RefundStatus = pgtype.Text{String: event.Refund.Status, Valid: true}
+		params.RefundAmountCents = pgtype.Int8{Int64: event.Refund.AmountCents, Valid: true}
+		ref := event.Refund.Metadata[gateway.RefundReferenceKey]
+		params.RefundReference = pgtype.Text{String: ref, Valid: ref != ""}
+	} else if event.Status == gateway.StatusRefunded {
+		params.RefundAmountCents = pgtype.Int8{Int64: event.AmountRefundedCents, Valid: true}
+	}
+
+	row, err := s.repo.CreateWebhookEvent(ctx, params)
+	if errors.Is(err, sql.ErrNoRows) {
+		return s.repo.GetWebhookEventByEventID(ctx, db.GetWebhookEventByEventIDParams{
+			Provider: provider,
+			EventID:  event.EventID,
+		})
+	}
+
+	return row, err
+}
+
+// processEvent applies a recorded event under a row lock and records the
+// outcome. When applying fails the transaction rolls back and the event is
+// marked FAILED, so the provider's retry or an admin re-run can pick it up.
+func (s *paymentService) processEvent(ctx context.Context, id pgtype.UUID) (db.WebhookEvent, error) {
+	var updated db.WebhookEvent
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		ev, err := s.repo.LockWebhookEvent(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		// A concurrent delivery got here first
+		if ev.Status == WebhookProcessed || ev.Status == WebhookIgnored {
+			updated = ev
+			return nil
+		}
+
+		status, note, err := s.applyEvent(ctx, ev)
+		if err != nil {
+			return err
+		}
+
+		updated, err = s.repo.UpdateWebhookEventStatus(ctx, db.UpdateWebhookEventStatusParams{
+			ID:     id,
+			Status: status,
+			Error:  pgtype.Text{String: note, Valid: note != ""},
+		})
 		return err
+	})
+	if err != nil {
+		failed, uerr := s.repo.UpdateWebhookEventStatus(ctx, db.UpdateWebhookEventStatusParams{
+			ID:     id,
+			Status: WebhookFailed,
+			Error:  pgtype.Text{String: err.Error(), Valid: true},
+		})
+		if uerr != nil {
+			logger.Error("Failed to mark webhook event %s as failed: %v", id.String(), uerr)
+		}
+		return failed, err
+	}
+
+	return updated, nil
+}
+
+// applyEvent moves the payment and its order to the state the event
+// reports. It returns the event's processing status and a note explaining
+// an ignored event or a rejected order transition.
+func (s *paymentService) applyEvent(ctx context.Context, ev db.WebhookEvent) (string, string, error) {
+	if !settledPaymentStatuses[ev.PaymentStatus] {
+		return WebhookIgnored, fmt.Sprintf("nothing to apply for payment status %s", ev.PaymentStatus), nil
 	}
 
-	orderStatus := event.Status
-	if event.Status == "SUCCESS" {
-		orderStatus = "PAID"
+	payment, err := s.findPayment(ctx, ev)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", "", fmt.Errorf("no payment found for event %s", ev.EventID)
+		}
+		return "", "", err
+	}
+
+	// A checkout session's payment learns its intent from the first event
+	// that names it, so later intent and refund events find it directly
+	if !payment.ProviderTxnID.Valid && ev.ProviderTxnID.Valid && ev.CheckoutSessionID.Valid {
+		err := s.repo.AttachPaymentIntent(ctx, db.AttachPaymentIntentParams{
+			ID:            payment.ID,
+			ProviderTxnID: ev.ProviderTxnID,
+		})
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	// Refunds are reconciled against the refund ledger rather than by
+	// overwriting the payment status
+	if ev.PaymentStatus == gateway.StatusRefunded {
+		return s.applyRefundEvent(ctx, ev, payment)
+	}
+
+	// Providers do not deliver in order; never let an older event overwrite
+	// what a newer one already applied.
+	if payment.LastEventAt.Valid && ev.OccurredAt.Time.Before(payment.LastEventAt.Time) {
+		return WebhookIgnored, "older than the last event applied to the payment", nil
+	}
+
+	// Captures and voids of authorizations are recorded here as they happen,
+	// so a late authorization event must not reopen the payment
+	if ev.PaymentStatus == gateway.StatusAuthorized && payment.Status != gateway.StatusInitiated && payment.Status != gateway.StatusFailed {
+		return WebhookIgnored, fmt.Sprintf("payment is already %s", payment.Status), nil
 	}
 
-	_, appErr := s.orderSvc.UpdateOrderStatus(ctx, payment.OrderID.String(), orderStatus)
+	// A manually captured payment only succeeds through CapturePayment,
+	// which records the capture before asking the provider for it; a
+	// success reported earlier is the authorization seen as a charge
+	if ev.PaymentStatus == gateway.StatusSucceeded && payment.CaptureMethod == gateway.CaptureManual && !payment.CapturedAt.Valid {
+		return WebhookIgnored, "payment has not been captured", nil
+	}
+
+	_, err = s.repo.ApplyPaymentEvent(ctx, db.ApplyPaymentEventParams{
+		ID:            payment.ID,
+		Status:        ev.PaymentStatus,
+		FailureReason: ev.FailureReason,
+		LastEventAt:   ev.OccurredAt,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	// A manually captured payment moved its order to PAID when it was
+	// authorized; the capture settles the payment only
+	if ev.PaymentStatus == gateway.StatusSucceeded && payment.CaptureMethod == gateway.CaptureManual {
+		return WebhookProcessed, "", nil
+	}
+
+	// Payment statuses that move the order; anything else (e.g. FAILED) leaves
+	// the order PENDING so the customer can retry. A failed payment gives
+	// up the order's stock until a retry succeeds.
+	orderStatus, ok := orderStatusForPayment[ev.PaymentStatus]
+	if !ok {
+		if ev.PaymentStatus == gateway.StatusFailed {
+			if appErr := s.orderSvc.ReleaseReservedStock(ctx, uuid.UUID(payment.OrderID.Bytes).String()); appErr != nil {
+				return "", "", appErr
+			}
+		}
+		return WebhookProcessed, "", nil
+	}
+
+	orderID := uuid.UUID(payment.OrderID.Bytes).String()
+	reason := fmt.Sprintf("%s webhook: payment %s", ev.Provider, ev.PaymentStatus)
+	_, appErr := s.orderSvc.UpdateOrderStatus(ctx, orderID, orderStatus, "", reason)
+
+	if appErr != nil && appErr.Code == http.StatusConflict && orderStatus == order.StatusPaid {
+		current, getErr := s.orderSvc.GetOrderByID(ctx, orderID)
+		if getErr != nil {
+			return "", "", getErr
+		}
+
+		// The customer paid for an order that was cancelled in the
+		// meantime; the money is given back. If that fails the event fails
+		// with it, so it shows up for an admin to re-run.
+		if current.Status == order.StatusCancelled {
+			if revErr := s.orderSvc.ReverseLatePayment(ctx, uuid.UUID(payment.ID.Bytes).String(), reason+" after the order was cancelled"); revErr != nil {
+				return "", "", fmt.Errorf("payment %s on a cancelled order could not be reversed: %s", ev.PaymentStatus, revErr.Message)
+			}
+			logger.Warn("Reversed %s payment for cancelled order %s", ev.PaymentStatus, orderID)
+			return WebhookProcessed, fmt.Sprintf("payment %s after the order was cancelled; reversed", ev.PaymentStatus), nil
+		}
+	}
 
 	if appErr != nil {
-		return appErr
+		// Any other transition the state machine rejects means the event is
+		// stale (e.g. a late success on a shipped order); acknowledge it so
+		// the provider stops retrying.
+		if appErr.Code == http.StatusConflict {
+			logger.Warn("Ignoring webhook for order %s: %s", orderID, appErr.Message)
+			return WebhookProcessed, appErr.Message, nil
+		}
+		return "", "", appErr
 	}
 
-	return nil
+	return WebhookProcessed, "", nil
+}
+
+// applyRefundEvent reconciles a refund webhook with the refund ledger. An
+// event about a single refund moves that refund on, recording it first if
+// it was made at the provider, and applies it to the order once it has
+// succeeded. A charge-level event only checks the provider's refunded total
+// against the ledger.
+func (s *paymentService) applyRefundEvent(ctx context.Context, ev db.WebhookEvent, payment db.Payment) (string, string, error) {
+	if !ev.ProviderRefundID.Valid {
+		totals, err := s.repo.GetPaymentRefundTotals(ctx, payment.ID)
+		if err != nil {
+			return "", "", err
+		}
+		// Refunds paid out as store credit never reach the provider
+		if charged := totals.SucceededCents - totals.WalletCents; ev.RefundAmountCents.Int64 != charged {
+			note := fmt.Sprintf("provider reports %d cents refunded, ledger has %d", ev.RefundAmountCents.Int64, charged)
+			logger.Warn("Refund mismatch on payment %s: %s", uuid.UUID(payment.ID.Bytes).String(), note)
+			return WebhookProcessed, note, nil
+		}
+		return WebhookProcessed, "", nil
+	}
+
+	refund, err := s.repo.GetRefundByProviderRefundID(ctx, db.GetRefundByProviderRefundIDParams{
+		Provider:         ev.Provider,
+		ProviderRefundID: ev.ProviderRefundID,
+	})
+	if errors.Is(err, sql.ErrNoRows) && ev.RefundReference.Valid {
+		// A refund started here whose provider answer has not been recorded;
+		// the reference is its ID on the ledger
+		refund, err = s.getRefundByReference(ctx, ev.RefundReference.String)
+	} else if errors.Is(err, sql.ErrNoRows) {
+		refund, err = s.repo.CreateRefund(ctx, db.CreateRefundParams{
+			PaymentID:        payment.ID,
+			OrderID:          payment.OrderID,
+			Provider:         ev.Provider,
+			ProviderRefundID: ev.ProviderRefundID,
+			AmountCents:      ev.RefundAmountCents.Int64,
+			Currency:         payment.Currency,
+			Reason:           pgtype.Text{String: "Refunded at provider", Valid: true},
+			Status:           gateway.RefundPending,
+		})
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	status := ev.RefundStatus.String
+	if refund.Status == status {
+		return WebhookProcessed, "refund is already " + status, nil
+	}
+	if refund.Status != gateway.RefundPending {
+		return WebhookIgnored, fmt.Sprintf("refund is already %s", refund.Status), nil
+	}
+
+	_, err = s.repo.UpdateRefundResult(ctx, db.UpdateRefundResultParams{
+		ID:               refund.ID,
+		ProviderRefundID: ev.ProviderRefundID,
+		Status:           status,
+		FailureReason:    ev.FailureReason,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		// The refund request's own answer settled it first
+		return WebhookProcessed, "refund was already settled", nil
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	if status != gateway.RefundSucceeded {
+		return WebhookProcessed, "", nil
+	}
+
+	reason := fmt.Sprintf("%s webhook: refund %s succeeded", ev.Provider, ev.ProviderRefundID.String)
+	_, appErr := s.orderSvc.ApplyRefund(ctx, uuid.UUID(payment.ID.Bytes).String(), refund.AmountCents, "", reason)
+	if appErr != nil {
+		if appErr.Code == http.StatusConflict {
+			logger.Warn("Refund %s not applied to order: %s", ev.ProviderRefundID.String, appErr.Message)
+			return WebhookProcessed, appErr.Message, nil
+		}
+		return "", "", appErr
+	}
+
+	return WebhookProcessed, "", nil
+}
+
+// getRefundByReference looks up a refund by the ledger ID it was sent to
+// the provider with. One that is not there yet belongs to a transaction
+// that has not committed, so the event fails for the provider's retry.
+func (s *paymentService) getRefundByReference(ctx context.Context, reference string) (db.Refund, error) {
+	var refundUUID pgtype.UUID
+	if err := refundUUID.Scan(reference); err != nil {
+		return db.Refund{}, fmt.Errorf("invalid refund reference %q", reference)
+	}
+
+	refund, err := s.repo.GetRefund(ctx, refundUUID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return db.Refund{}, fmt.Errorf("refund %s is not recorded yet", reference)
+	}
+
+	return refund, err
+}
+
+// findPayment locates the payment an event is about, by provider
+// transaction ID first, then by checkout session and then by order.
+func (s *paymentService) findPayment(ctx context.Context, ev db.WebhookEvent) (db.Payment, error) {
+	if ev.ProviderTxnID.Valid {
+		payment, err := s.repo.GetPaymentByProviderTxnID(ctx, db.GetPaymentByProviderTxnIDParams{
+			Provider:      ev.Provider,
+			ProviderTxnID: ev.ProviderTxnID,
+		})
+		if err == nil || !errors.Is(err, sql.ErrNoRows) {
+			return payment, err
+		}
+	}
+
+	if ev.CheckoutSessionID.Valid {
+		payment, err := s.repo.GetPaymentByCheckoutSessionID(ctx, db.GetPaymentByCheckoutSessionIDParams{
+			Provider:          ev.Provider,
+			CheckoutSessionID: ev.CheckoutSessionID,
+		})
+		if err == nil || !errors.Is(err, sql.ErrNoRows) {
+			return payment, err
+		}
+	}
+
+	if !ev.OrderID.Valid {
+		return db.Payment{}, sql.ErrNoRows
+	}
+
+	return s.repo.GetPaymentByOrderID(ctx, ev.OrderID)
+}
+
+// settledPaymentStatuses are the payment statuses a webhook can apply.
+// INITIATED carries no news, and anything the gateway could not map would
+// fail the payments CHECK constraint.
+var settledPaymentStatuses = map[string]bool{
+	gateway.StatusAuthorized: true,
+	gateway.StatusSucceeded:  true,
+	gateway.StatusFailed:     true,
+	gateway.StatusCancelled:  true,
+	gateway.StatusRefunded:   true,
+}
+
+// orderStatusForPayment maps payment statuses to the order status they imply.
+// REFUNDED is left to the refund ledger.
+var orderStatusForPayment = map[string]string{
+	gateway.StatusAuthorized: order.StatusPaid,
+	gateway.StatusSucceeded:  order.StatusPaid,
+	gateway.StatusCancelled:  order.StatusCancelled,
+}
+
+func mapPayment(row db.Payment) PaymentResponse {
+	payment := PaymentResponse{
+		ID:            uuid.UUID(row.ID.Bytes).String(),
+		OrderID:       uuid.UUID(row.OrderID.Bytes).String(),
+		Provider:      row.Provider,
+		ProviderTxnID: row.ProviderTxnID.String,
+		AmountCents:   row.AmountCents,
+		Currency:      row.Currency,
+		PaymentMethod: row.PaymentMethod,
+		Status:        row.Status,
+		Details:       json.RawMessage(row.Details),
+		FailureReason: row.FailureReason.String,
+		CaptureMethod: row.CaptureMethod,
+		CreatedAt:     row.CreatedAt.Time,
+		UpdatedAt:     row.UpdatedAt.Time,
+	}
+	if row.AuthorizedCents.Valid {
+		amount := row.AuthorizedCents.Int64
+		payment.AuthorizedCents = &amount
+	}
+	if row.AuthorizedAt.Valid {
+		t := row.AuthorizedAt.Time
+		payment.AuthorizedAt = &t
+	}
+	if row.CapturedAt.Valid {
+		t := row.CapturedAt.Time
+		payment.CapturedAt = &t
+	}
+	if row.Reference.Valid {
+		payment.Reference = row.Reference.String
+	}
+	if row.CheckoutSessionID.Valid {
+		payment.CheckoutSessionID = row.CheckoutSessionID.String
+	}
+	if row.ExpiresAt.Valid {
+		t := row.ExpiresAt.Time
+		payment.ExpiresAt = &t
+	}
+	if row.ConfirmedAt.Valid {
+		t := row.ConfirmedAt.Time
+		payment.ConfirmedAt = &t
+	}
+
+	return payment
+}
+
+func mapWebhookEvent(row db.WebhookEvent) WebhookEvent {
+	event := WebhookEvent{
+		ID:            uuid.UUID(row.ID.Bytes),
+		Provider:      row.Provider,
+		EventID:       row.EventID,
+		EventType:     row.EventType,
+		ProviderTxnID: row.ProviderTxnID.String,
+		PaymentStatus: row.PaymentStatus,
+		FailureReason: row.FailureReason.String,
+		OccurredAt:    row.OccurredAt.Time,
+		Payload:       json.RawMessage(row.Payload),
+		Status:        row.Status,
+		Error:         row.Error.String,
+		Attempts:      row.Attempts,
+		CreatedAt:     row.CreatedAt.Time,
+
+		ProviderRefundID: row.ProviderRefundID.String,
+		RefundStatus:     row.RefundStatus.String,
+
+		CheckoutSessionID: row.CheckoutSessionID.String,
+	}
+	if row.OrderID.Valid {
+		id := uuid.UUID(row.OrderID.Bytes)
+		event.OrderID = &id
+	}
+	if row.ProcessedAt.Valid {
+		t := row.ProcessedAt.Time
+		event.ProcessedAt = &t
+	}
+	if row.RefundAmountCents.Valid {
+		amount := row.RefundAmountCents.Int64
+		event.RefundAmountCents = &amount
+	}
+
+	return event
 }
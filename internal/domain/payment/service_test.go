@@ -0,0 +1,149 @@
+package payment
+
+import (
+	"context"
+	"ecommerce-app/internal/domain/gateway"
+	"ecommerce-app/internal/domain/order"
+	db "ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// cancelledOrders holds orders that were cancelled before their payment
+// arrived. Like the order state machine it refuses to mark them PAID, and
+// it records the payments it is asked to reverse.
+type cancelledOrders struct {
+	OrderProvider
+	reverseErr *errs.AppError
+	reversed   []string
+}
+
+func (o *cancelledOrders) UpdateOrderStatus(ctx context.Context, orderID string, status string, changedBy string, reason string) (order.Order, *errs.AppError) {
+	return order.Order{}, errs.ErrConflict.WithMessage("Cannot change order status from CANCELLED to " + status)
+}
+
+func (o *cancelledOrders) GetOrderByID(ctx context.Context, id string) (order.Order, *errs.AppError) {
+	return order.Order{ID: uuid.MustParse(id), Status: order.StatusCancelled}, nil
+}
+
+func (o *cancelledOrders) ReverseLatePayment(ctx context.Context, paymentID, reason string) *errs.AppError {
+	if o.reverseErr != nil {
+		return o.reverseErr
+	}
+	o.reversed = append(o.reversed, paymentID)
+	return nil
+}
+
+func TestLatePaymentOnCancelledOrder(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       string
+		reverseErr   *errs.AppError
+		wantEvent    string
+		wantReversed bool
+	}{
+		{name: "captured payment is reversed", status: gateway.StatusSucceeded, wantEvent: WebhookProcessed, wantReversed: true},
+		{name: "authorization is reversed", status: gateway.StatusAuthorized, wantEvent: WebhookProcessed, wantReversed: true},
+		{name: "failed reversal fails the event", status: gateway.StatusSucceeded, reverseErr: errs.ErrInternal.WithMessage("Failed to record refund"), wantEvent: WebhookFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := db.Payment{
+				ID:            pgtype.UUID{Bytes: uuid.New(), Valid: true},
+				OrderID:       pgtype.UUID{Bytes: uuid.New(), Valid: true},
+				Provider:      "fake",
+				ProviderTxnID: pgtype.Text{String: "pi_late", Valid: true},
+				AmountCents:   5000,
+				Currency:      "usd",
+				Status:        gateway.StatusInitiated,
+				CaptureMethod: gateway.CaptureAutomatic,
+			}
+			repo := newReconcileRepo(payment)
+			orders := &cancelledOrders{reverseErr: tt.reverseErr}
+			svc := &paymentService{repo: repo, orderSvc: orders}
+
+			ev, err := repo.CreateWebhookEvent(context.Background(), db.CreateWebhookEventParams{
+				Provider:      "fake",
+				EventID:       "evt_late",
+				EventType:     "payment_intent.succeeded",
+				ProviderTxnID: payment.ProviderTxnID,
+				PaymentStatus: tt.status,
+				OccurredAt:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+				Payload:       []byte(`{}`),
+			})
+			if err != nil {
+				t.Fatalf("CreateWebhookEvent: %v", err)
+			}
+
+			updated, _ := svc.processEvent(context.Background(), ev.ID)
+
+			if updated.Status != tt.wantEvent {
+				t.Errorf("event status %s (%s), want %s", updated.Status, updated.Error.String, tt.wantEvent)
+			}
+			if got := len(orders.reversed) == 1; got != tt.wantReversed {
+				t.Errorf("reversed %v, want reversed %v", orders.reversed, tt.wantReversed)
+			}
+		})
+	}
+}
+
+func TestManualPaymentSucceedsOnlyOnceCaptured(t *testing.T) {
+	tests := []struct {
+		name       string
+		capturedAt pgtype.Timestamptz
+		wantEvent  string
+		wantStatus string
+	}{
+		{name: "success before capture is ignored", wantEvent: WebhookIgnored, wantStatus: gateway.StatusInitiated},
+		{name: "success after capture is applied", capturedAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}, wantEvent: WebhookProcessed, wantStatus: gateway.StatusSucceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment := db.Payment{
+				ID:            pgtype.UUID{Bytes: uuid.New(), Valid: true},
+				OrderID:       pgtype.UUID{Bytes: uuid.New(), Valid: true},
+				Provider:      "fake",
+				ProviderTxnID: pgtype.Text{String: "pi_manual", Valid: true},
+				AmountCents:   5000,
+				Currency:      "usd",
+				Status:        gateway.StatusInitiated,
+				CaptureMethod: gateway.CaptureManual,
+				CapturedAt:    tt.capturedAt,
+			}
+			repo := newReconcileRepo(payment)
+			orders := &reconcileOrders{statuses: map[string]string{}}
+			svc := &paymentService{repo: repo, orderSvc: orders}
+
+			ev, err := repo.CreateWebhookEvent(context.Background(), db.CreateWebhookEventParams{
+				Provider:      "fake",
+				EventID:       "evt_charge",
+				EventType:     "charge.succeeded",
+				ProviderTxnID: payment.ProviderTxnID,
+				PaymentStatus: gateway.StatusSucceeded,
+				OccurredAt:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
+				Payload:       []byte(`{}`),
+			})
+			if err != nil {
+				t.Fatalf("CreateWebhookEvent: %v", err)
+			}
+
+			updated, _ := svc.processEvent(context.Background(), ev.ID)
+
+			if updated.Status != tt.wantEvent {
+				t.Errorf("event status %s (%s), want %s", updated.Status, updated.Error.String, tt.wantEvent)
+			}
+			if got := repo.payments[0].Status; got != tt.wantStatus {
+				t.Errorf("payment status %s, want %s", got, tt.wantStatus)
+			}
+			if len(orders.statuses) != 0 {
+				t.Errorf("order moved to %v, want it left alone", orders.statuses)
+			}
+		})
+	}
+}
@@ -2,8 +2,15 @@ package payment
 
 import (
 	"context"
+	"ecommerce-app/internal/domain/gateway"
 	"ecommerce-app/internal/domain/order"
 	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/response"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Payment struct {
@@ -35,18 +42,46 @@ type UpdatePaymentStatusRequest struct {
 	Status string `json:"status" validate:"required,oneof=INITIATED SUCCESS FAILED REFUNDED"`
 }	
 
+// CreateRefundRequest refunds part of a payment; an amount of 0 refunds
+// whatever has not been refunded yet.
+type CreateRefundRequest struct {
+	AmountCents int64  `json:"amount_cents" validate:"gte=0"`
+	Reason      string `json:"reason" validate:"required,min=3,max=500"`
+	ToWallet    bool   `json:"to_wallet"`
+}
+
+// ExpirePaymentRequest cancels an offline payment that never arrived,
+// together with its order.
+type ExpirePaymentRequest struct {
+	Reason string `json:"reason" validate:"required,min=3,max=500"`
+}
+
 type PaymentResponse struct {
-	ID             string      `json:"id"`
-	OrderID        string      `json:"order_id"`
-	Provider       string      `json:"provider"`
-	ProviderTxnID  string      `json:"provider_txn_id"`
-	AmountCents    int64       `json:"amount_cents"`
-	Currency       string      `json:"currency"`
-	PaymentMethod  string      `json:"payment_method"`
-	Status         string      `json:"status"`
-	Details        interface{} `json:"details"`
-	FailureReason  string      `json:"failure_reason,omitempty"`
-	CreatedAt      string      `json:"created_at"`
+	ID                string          `json:"id"`
+	OrderID           string          `json:"order_id"`
+	Provider          string          `json:"provider"`
+	ProviderTxnID     string          `json:"provider_txn_id"`
+	CheckoutSessionID string          `json:"checkout_session_id,omitempty"`
+	AmountCents       int64           `json:"amount_cents"`
+	Currency          string          `json:"currency"`
+	PaymentMethod     string          `json:"payment_method"`
+	Status            string          `json:"status"`
+	Details           json.RawMessage `json:"details,omitempty"`
+	FailureReason     string          `json:"failure_reason,omitempty"`
+	CaptureMethod     string          `json:"capture_method"`
+	AuthorizedCents   *int64          `json:"authorized_cents,omitempty"`
+	AuthorizedAt      *time.Time      `json:"authorized_at,omitempty"`
+	CapturedAt        *time.Time      `json:"captured_at,omitempty"`
+	Reference         string          `json:"reference,omitempty"`
+	ExpiresAt         *time.Time      `json:"expires_at,omitempty"`
+	ConfirmedAt       *time.Time      `json:"confirmed_at,omitempty"`
+	CreatedAt         time.Time       `json:"created_at"`
+	UpdatedAt         time.Time       `json:"updated_at"`
+}
+
+type PaymentsWithMeta struct {
+	Payments []PaymentResponse `json:"payments"`
+	Meta     response.Meta     `json:"meta"`
 }
 
 type ProviderResponse struct {
@@ -65,8 +100,107 @@ type ProviderWebhookEvent struct {
 	RawEvent       interface{}
 }
 
+// Webhook event processing statuses, matching the webhook_events.status
+// CHECK constraint.
+const (
+	WebhookReceived  = "RECEIVED"
+	WebhookProcessed = "PROCESSED"
+	WebhookIgnored   = "IGNORED"
+	WebhookFailed    = "FAILED"
+)
+
+// WebhookEvent is a recorded provider webhook and what became of it
+type WebhookEvent struct {
+	ID            uuid.UUID       `json:"id"`
+	Provider      string          `json:"provider"`
+	EventID       string          `json:"event_id"`
+	EventType     string          `json:"event_type"`
+	ProviderTxnID string          `json:"provider_txn_id,omitempty"`
+	OrderID       *uuid.UUID      `json:"order_id,omitempty"`
+	PaymentStatus string          `json:"payment_status"`
+	FailureReason string          `json:"failure_reason,omitempty"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+	Payload       json.RawMessage `json:"payload"`
+	Status        string          `json:"status"`
+	Error         string          `json:"error,omitempty"`
+	Attempts      int32           `json:"attempts"`
+	CreatedAt     time.Time       `json:"created_at"`
+	ProcessedAt   *time.Time      `json:"processed_at,omitempty"`
+
+	// Set on refund events
+	ProviderRefundID  string `json:"provider_refund_id,omitempty"`
+	RefundStatus      string `json:"refund_status,omitempty"`
+	RefundAmountCents *int64 `json:"refund_amount_cents,omitempty"`
+
+	// Set on checkout session events
+	CheckoutSessionID string `json:"checkout_session_id,omitempty"`
+}
+
+type WebhookEventsWithMeta struct {
+	Events []WebhookEvent `json:"events"`
+	Meta   response.Meta  `json:"meta"`
+}
+
+// ReconciliationEventType is the event type recorded for transitions that
+// reconciliation applies on behalf of a lost webhook
+const ReconciliationEventType = "reconciliation"
+
+// Kinds of mismatch a reconciliation run reports
+const (
+	MismatchAmount         = "AMOUNT_MISMATCH"
+	MismatchCurrency       = "CURRENCY_MISMATCH"
+	MismatchMissingIntent  = "MISSING_INTENT"
+	MismatchOrphanedIntent = "ORPHANED_INTENT"
+	MismatchUnresolved     = "UNRESOLVED"
+)
+
+// ReconciliationReport is the outcome of one reconciliation run. Checked
+// counts the payments compared with the provider and Applied the missed
+// transitions that were applied to them.
+type ReconciliationReport struct {
+	Provider   string                   `json:"provider"`
+	Cutoff     time.Time                `json:"cutoff"`
+	Checked    int                      `json:"checked"`
+	Applied    int                      `json:"applied"`
+	Errors     int                      `json:"errors"`
+	Mismatches []ReconciliationMismatch `json:"mismatches"`
+}
+
+// ReconciliationMismatch is a difference between a payment and its intent
+// that reconciliation could not or should not fix by itself
+type ReconciliationMismatch struct {
+	Kind                string `json:"kind"`
+	PaymentID           string `json:"payment_id,omitempty"`
+	OrderID             string `json:"order_id,omitempty"`
+	ProviderTxnID       string `json:"provider_txn_id,omitempty"`
+	LocalStatus         string `json:"local_status,omitempty"`
+	ProviderStatus      string `json:"provider_status,omitempty"`
+	LocalAmountCents    int64  `json:"local_amount_cents,omitempty"`
+	ProviderAmountCents int64  `json:"provider_amount_cents,omitempty"`
+	Detail              string `json:"detail"`
+}
 
 // Dependency Injection Interfaces
+
+// OrderProvider looks up the order a payment belongs to, moves it along as
+// its payments settle and refunds it
 type OrderProvider interface {
-	UpdateOrderStatus(ctx context.Context, orderID string, status string) (order.Order, *errs.AppError)
+	GetOrderByID(ctx context.Context, id string) (order.Order, *errs.AppError)
+	UpdateOrderStatus(ctx context.Context, orderID string, status string, changedBy string, reason string) (order.Order, *errs.AppError)
+	RefundPayment(ctx context.Context, paymentID string, amountCents int64, toWallet bool, changedBy, reason string) (order.Refund, *errs.AppError)
+	ApplyRefund(ctx context.Context, paymentID string, amountCents int64, changedBy, reason string) (order.Order, *errs.AppError)
+	ReverseLatePayment(ctx context.Context, paymentID, reason string) *errs.AppError
+	ConfirmOfflinePayment(ctx context.Context, paymentID, changedBy string) (order.Order, *errs.AppError)
+	ExpireOfflinePayment(ctx context.Context, paymentID, changedBy, reason string) (order.Order, *errs.AppError)
+	ReleaseReservedStock(ctx context.Context, orderID string) *errs.AppError
+}
+
+// Gateway is the part of the payment gateway that decodes its webhooks and
+// reports the state of its intents
+type Gateway interface {
+	Name() string
+	ParseWebhook(ctx context.Context, payload []byte, header http.Header) (*gateway.WebhookEvent, error)
+	GetIntent(ctx context.Context, intentID string) (gateway.Intent, error)
+	CaptureIntent(ctx context.Context, intentID string, amountCents int64) (gateway.Intent, error)
+	ListIntents(ctx context.Context, from, to time.Time) ([]gateway.Intent, error)
 }
\ No newline at end of file
@@ -14,6 +14,12 @@ type CreateProductRequest struct {
 	Images      []string  `json:"images,omitempty"`
 	DiscountPercent int32  `json:"discount_percent,omitempty" validate:"omitempty,gte=0,lte=100"`
 	DiscountValidUntil *string `json:"discount_valid_until,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+	TaxCategory string    `json:"tax_category,omitempty" validate:"omitempty,min=2,max=50"`
+	WeightGrams int32     `json:"weight_grams,omitempty" validate:"omitempty,gte=0"`
+	LengthMm    int32     `json:"length_mm,omitempty" validate:"omitempty,gte=0"`
+	WidthMm     int32     `json:"width_mm,omitempty" validate:"omitempty,gte=0"`
+	HeightMm    int32     `json:"height_mm,omitempty" validate:"omitempty,gte=0"`
+	IsGiftCard  bool      `json:"is_gift_card,omitempty"`
 }
 
 type UpdateProductRequest struct {
@@ -28,6 +34,11 @@ type UpdateProductRequest struct {
 	DiscountPercent *int32  `json:"discount_percent,omitempty" validate:"omitempty,gte=0,lte=100"`
 	DiscountValidUntil *string `json:"discount_valid_until,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
 	IsActive    *bool      `json:"is_active,omitempty"`
+	TaxCategory *string    `json:"tax_category,omitempty" validate:"omitempty,min=2,max=50"`
+	WeightGrams *int32     `json:"weight_grams,omitempty" validate:"omitempty,gte=0"`
+	LengthMm    *int32     `json:"length_mm,omitempty" validate:"omitempty,gte=0"`
+	WidthMm     *int32     `json:"width_mm,omitempty" validate:"omitempty,gte=0"`
+	HeightMm    *int32     `json:"height_mm,omitempty" validate:"omitempty,gte=0"`
 }
 
 type UpdatePriceRequest struct {
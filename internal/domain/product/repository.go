@@ -18,6 +18,8 @@ type Repository interface {
 	Count(ctx context.Context) (int32, error)
 	UpdatePrice(ctx context.Context, id string, price int32) (Product, error)
 	UpdateProduct(ctx context.Context,id string, req UpdateProductRequest) (Product, error)
+	UpdateTaxCategory(ctx context.Context, id string, taxCategory string) (Product, error)
+	UpdateShippingDetails(ctx context.Context, id string, p Product) (Product, error)
 	Delete(ctx context.Context, id string) error
 }
 
@@ -68,6 +70,12 @@ func (r *repository) Create(ctx context.Context, p Product) (Product, error) {
 		Images:         imagesBytes,
 		DiscountPercent: pgtype.Int4{Int32: p.DiscountPercent, Valid: true},
 		DiscountValidUntil: discountValidUntil,
+		TaxCategory:    p.TaxCategory,
+		WeightGrams:    p.WeightGrams,
+		LengthMm:       p.LengthMm,
+		WidthMm:        p.WidthMm,
+		HeightMm:       p.HeightMm,
+		IsGiftCard:     p.IsGiftCard,
 	}
 
 	row, err := r.q.CreateProduct(ctx, params)
@@ -179,6 +187,42 @@ func (r *repository) UpdateProduct(ctx context.Context,	id string, p UpdateProdu
 	return mapProduct(row), nil
 }
 
+func (r *repository) UpdateTaxCategory(ctx context.Context, id string, taxCategory string) (Product, error) {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(id); err != nil {
+		return Product{}, err
+	}
+
+	row, err := r.q.UpdateProductTaxCategory(ctx, sqlc.UpdateProductTaxCategoryParams{
+		ID:          uuid,
+		TaxCategory: taxCategory,
+	})
+	if err != nil {
+		return Product{}, err
+	}
+	return mapProduct(row), nil
+}
+
+// UpdateShippingDetails saves the weight and dimensions of p.
+func (r *repository) UpdateShippingDetails(ctx context.Context, id string, p Product) (Product, error) {
+	var uuid pgtype.UUID
+	if err := uuid.Scan(id); err != nil {
+		return Product{}, err
+	}
+
+	row, err := r.q.UpdateProductShippingDetails(ctx, sqlc.UpdateProductShippingDetailsParams{
+		ID:          uuid,
+		WeightGrams: p.WeightGrams,
+		LengthMm:    p.LengthMm,
+		WidthMm:     p.WidthMm,
+		HeightMm:    p.HeightMm,
+	})
+	if err != nil {
+		return Product{}, err
+	}
+	return mapProduct(row), nil
+}
+
 func (r *repository) Delete(ctx context.Context, id string) error {
 	var uuid pgtype.UUID
 	if err := uuid.Scan(id); err != nil {
@@ -219,6 +263,12 @@ func mapProduct(row sqlc.Product) Product {
 		Images: 	images,
 		DiscountPercent: row.DiscountPercent.Int32,
 		DiscountValidUntil: discountValidUntil,
+		TaxCategory: row.TaxCategory,
+		WeightGrams: row.WeightGrams,
+		LengthMm:    row.LengthMm,
+		WidthMm:     row.WidthMm,
+		HeightMm:    row.HeightMm,
+		IsGiftCard:  row.IsGiftCard,
 		IsActive:    row.IsActive.Bool,
 		CreatedAt:   row.CreatedAt.Time,
 		UpdatedAt:   row.UpdatedAt.Time,
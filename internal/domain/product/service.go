@@ -50,6 +50,11 @@ func (s *service) CreateProduct(ctx context.Context,req CreateProductRequest) (P
 		discountValidUntil = &parsed
 	}	
 
+	taxCategory := req.TaxCategory
+	if taxCategory == "" {
+		taxCategory = DefaultTaxCategory
+	}
+
 	product := Product{
 		Name:        req.Name,
 		Description: req.Description,
@@ -62,6 +67,12 @@ func (s *service) CreateProduct(ctx context.Context,req CreateProductRequest) (P
 		Images: 	req.Images,
 		DiscountPercent: req.DiscountPercent,
 		DiscountValidUntil: discountValidUntil,
+		TaxCategory: taxCategory,
+		WeightGrams: req.WeightGrams,
+		LengthMm:    req.LengthMm,
+		WidthMm:     req.WidthMm,
+		HeightMm:    req.HeightMm,
+		IsGiftCard:  req.IsGiftCard,
 	}
 
 	createdProduct, err := s.repo.Create(ctx, product)
@@ -149,6 +160,34 @@ func (s *service) UpdateProduct(ctx context.Context,id string, req UpdateProduct
 	if err != nil {
 		return Product{}, errs.ErrInternal.WithMessage("Failed to update product")
 	}
+
+	if req.TaxCategory != nil && *req.TaxCategory != updatedProduct.TaxCategory {
+		updatedProduct, err = s.repo.UpdateTaxCategory(ctx, id, *req.TaxCategory)
+		if err != nil {
+			return Product{}, errs.ErrInternal.WithMessage("Failed to update product tax category")
+		}
+	}
+
+	if req.WeightGrams != nil || req.LengthMm != nil || req.WidthMm != nil || req.HeightMm != nil {
+		details := updatedProduct
+		if req.WeightGrams != nil {
+			details.WeightGrams = *req.WeightGrams
+		}
+		if req.LengthMm != nil {
+			details.LengthMm = *req.LengthMm
+		}
+		if req.WidthMm != nil {
+			details.WidthMm = *req.WidthMm
+		}
+		if req.HeightMm != nil {
+			details.HeightMm = *req.HeightMm
+		}
+
+		updatedProduct, err = s.repo.UpdateShippingDetails(ctx, id, details)
+		if err != nil {
+			return Product{}, errs.ErrInternal.WithMessage("Failed to update product shipping details")
+		}
+	}
 	return updatedProduct, nil
 }
 
@@ -20,6 +20,14 @@ type Product struct {
 	Images      []string  `json:"images"`
 	DiscountPercent int32 `json:"discount_percent"`
 	DiscountValidUntil *time.Time `json:"discount_valid_until,omitempty"`
+	TaxCategory string    `json:"tax_category"`
+	WeightGrams int32     `json:"weight_grams"`
+	LengthMm    int32     `json:"length_mm"`
+	WidthMm     int32     `json:"width_mm"`
+	HeightMm    int32     `json:"height_mm"`
+	// IsGiftCard products issue a gift card worth their price per unit
+	// once the order is paid
+	IsGiftCard  bool      `json:"is_gift_card"`
 	IsActive    bool      `json:"is_active"`
 	IsDeleted   bool      `json:"is_deleted"`
 	CreatedAt time.Time `json:"created_at"`
@@ -27,6 +35,22 @@ type Product struct {
 }
 
 
+// SaleDiscountCents is how much the product's own discount takes off one
+// unit at t. The discount applies while DiscountValidUntil is unset or still
+// in the future.
+func (p Product) SaleDiscountCents(t time.Time) int64 {
+	if p.DiscountPercent <= 0 {
+		return 0
+	}
+	if p.DiscountValidUntil != nil && !t.Before(*p.DiscountValidUntil) {
+		return 0
+	}
+	return (int64(p.PriceCents)*int64(p.DiscountPercent) + 50) / 100
+}
+
+// DefaultTaxCategory is used for products created without a tax category.
+const DefaultTaxCategory = "standard"
+
 type ProductsWithMeta struct {
 	Products []Product    `json:"products"`
 	Meta     response.Meta `json:"meta"`
@@ -0,0 +1,37 @@
+package returns
+
+// --- Request DTOs ---
+type CreateReturnRequest struct {
+	OrderID string                    `json:"order_id" validate:"required,uuid4"`
+	Reason  string                    `json:"reason" validate:"required,min=3,max=500"`
+	Items   []CreateReturnItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+type CreateReturnItemRequest struct {
+	OrderItemID string `json:"order_item_id" validate:"required,uuid4"`
+	Qty         int32  `json:"qty" validate:"required,min=1"`
+}
+
+type ReviewReturnRequest struct {
+	Note string `json:"note,omitempty" validate:"omitempty,max=500"`
+}
+
+type ReceiveReturnRequest struct {
+	Carrier        string `json:"carrier" validate:"required"`
+	TrackingNumber string `json:"tracking_number,omitempty"`
+}
+
+// --- Repository Inputs ---
+type CreateReturnInput struct {
+	OrderID     string
+	UserID      string
+	Reason      string
+	RefundCents int64
+	Items       []CreateReturnItemInput
+}
+
+type CreateReturnItemInput struct {
+	OrderItemID string
+	Qty         int32
+	RefundCents int64
+}
@@ -0,0 +1,111 @@
+package returns
+
+import (
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/internal/pkg/validator"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) RequestReturn(w http.ResponseWriter, r *http.Request) {
+	req := validator.GetValidatedBody[CreateReturnRequest](r)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	ret, appErr := h.svc.RequestReturn(r.Context(), userID, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, ret, "Return requested successfully")
+}
+
+func (h *Handler) GetReturn(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	role := r.Context().Value(middleware.UserRoleKey).(string)
+
+	ret, appErr := h.svc.GetReturn(r.Context(), userID, role, id)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, ret, "Return fetched successfully")
+}
+
+func (h *Handler) GetMyReturns(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	returns, appErr := h.svc.GetReturnsByUserID(r.Context(), userID)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, returns, "Returns fetched successfully")
+}
+
+func (h *Handler) GetReturnsByOrderID(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderID")
+
+	returns, appErr := h.svc.GetReturnsByOrderID(r.Context(), orderID)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, returns, "Returns fetched successfully")
+}
+
+func (h *Handler) ApproveReturn(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[ReviewReturnRequest](r)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	ret, appErr := h.svc.ApproveReturn(r.Context(), userID, id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, ret, "Return approved successfully")
+}
+
+func (h *Handler) RejectReturn(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[ReviewReturnRequest](r)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	ret, appErr := h.svc.RejectReturn(r.Context(), userID, id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, ret, "Return rejected successfully")
+}
+
+func (h *Handler) ReceiveReturn(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[ReceiveReturnRequest](r)
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	ret, appErr := h.svc.ReceiveReturn(r.Context(), userID, id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, ret, "Return received and refunded successfully")
+}
@@ -0,0 +1,298 @@
+package returns
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ecommerce-app/internal/pkg/database"
+	"ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Repository interface {
+	Create(ctx context.Context, req CreateReturnInput) (Return, error)
+	GetByID(ctx context.Context, id string) (Return, error)
+	ListByOrderID(ctx context.Context, orderID string) ([]Return, error)
+	ListByUserID(ctx context.Context, userID string) ([]Return, error)
+	LockOrder(ctx context.Context, orderID string) error
+	ReturnedQtyByOrderItem(ctx context.Context, orderID string) (map[string]int32, error)
+	Review(ctx context.Context, id, status, reviewedBy, note string) (Return, error)
+	Complete(ctx context.Context, id, shipmentID string, refundCents int64) (Return, error)
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// repository implements Repository
+type repository struct {
+	q  *sqlc.Queries
+	db database.Transactor
+}
+
+func NewRepository(q *sqlc.Queries, db database.Transactor) Repository {
+	return &repository{q: q, db: db}
+}
+
+// WithTx runs fn in a single transaction; every repository call made with
+// the ctx passed to fn takes part in it.
+func (r *repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithTx(ctx, r.db, fn)
+}
+
+func (r *repository) queries(ctx context.Context) *sqlc.Queries {
+	return database.Queries(ctx, r.q)
+}
+
+func (r *repository) Create(ctx context.Context, req CreateReturnInput) (Return, error) {
+	var orderUUID, userUUID pgtype.UUID
+	if err := orderUUID.Scan(req.OrderID); err != nil {
+		return Return{}, err
+	}
+	if err := userUUID.Scan(req.UserID); err != nil {
+		return Return{}, err
+	}
+
+	row, err := r.queries(ctx).CreateReturn(ctx, sqlc.CreateReturnParams{
+		OrderID:     orderUUID,
+		UserID:      userUUID,
+		Reason:      req.Reason,
+		RefundCents: req.RefundCents,
+	})
+	if err != nil {
+		return Return{}, err
+	}
+
+	ret := mapReturn(row)
+	for _, item := range req.Items {
+		var orderItemUUID pgtype.UUID
+		if err := orderItemUUID.Scan(item.OrderItemID); err != nil {
+			return Return{}, err
+		}
+
+		itemRow, err := r.queries(ctx).CreateReturnItem(ctx, sqlc.CreateReturnItemParams{
+			ReturnID:    row.ID,
+			OrderItemID: orderItemUUID,
+			Qty:         item.Qty,
+			RefundCents: item.RefundCents,
+		})
+		if err != nil {
+			return Return{}, err
+		}
+
+		ret.Items = append(ret.Items, mapReturnItem(itemRow))
+	}
+
+	return ret, nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id string) (Return, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Return{}, err
+	}
+
+	row, err := r.queries(ctx).GetReturn(ctx, uuidID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Return{}, errs.ErrNotFound
+		}
+		return Return{}, err
+	}
+
+	return r.withItems(ctx, row)
+}
+
+func (r *repository) ListByOrderID(ctx context.Context, orderID string) ([]Return, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListReturnsByOrder(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.listWithItems(ctx, rows)
+}
+
+func (r *repository) ListByUserID(ctx context.Context, userID string) ([]Return, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListReturnsByUser(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.listWithItems(ctx, rows)
+}
+
+// LockOrder holds a row lock on the order until the transaction ends
+func (r *repository) LockOrder(ctx context.Context, orderID string) error {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return err
+	}
+
+	if _, err := r.queries(ctx).LockReturnOrder(ctx, orderUUID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return errs.ErrNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// ReturnedQtyByOrderItem maps order item IDs to the quantity already claimed
+// by returns on the order that were not rejected.
+func (r *repository) ReturnedQtyByOrderItem(ctx context.Context, orderID string) (map[string]int32, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListReturnedQtyByOrder(ctx, orderUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	returned := make(map[string]int32, len(rows))
+	for _, row := range rows {
+		returned[uuid.UUID(row.OrderItemID.Bytes).String()] = row.ReturnedQty
+	}
+
+	return returned, nil
+}
+
+// Review approves or rejects a REQUESTED return, returning errs.ErrConflict
+// if it has already been reviewed.
+func (r *repository) Review(ctx context.Context, id, status, reviewedBy, note string) (Return, error) {
+	var uuidID, reviewerUUID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Return{}, err
+	}
+	if err := reviewerUUID.Scan(reviewedBy); err != nil {
+		return Return{}, err
+	}
+
+	row, err := r.queries(ctx).ReviewReturn(ctx, sqlc.ReviewReturnParams{
+		Status:         status,
+		ResolutionNote: pgtype.Text{String: note, Valid: note != ""},
+		ReviewedBy:     reviewerUUID,
+		ID:             uuidID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Return{}, errs.ErrConflict
+		}
+		return Return{}, err
+	}
+
+	return r.withItems(ctx, row)
+}
+
+// Complete marks an APPROVED return as received and refunded, returning
+// errs.ErrConflict if it is not awaiting receipt.
+func (r *repository) Complete(ctx context.Context, id, shipmentID string, refundCents int64) (Return, error) {
+	var uuidID, shipmentUUID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Return{}, err
+	}
+	if err := shipmentUUID.Scan(shipmentID); err != nil {
+		return Return{}, err
+	}
+
+	row, err := r.queries(ctx).CompleteReturn(ctx, sqlc.CompleteReturnParams{
+		ShipmentID:  shipmentUUID,
+		RefundCents: refundCents,
+		ID:          uuidID,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Return{}, errs.ErrConflict
+		}
+		return Return{}, err
+	}
+
+	return r.withItems(ctx, row)
+}
+
+func (r *repository) withItems(ctx context.Context, row sqlc.Return) (Return, error) {
+	itemRows, err := r.queries(ctx).ListReturnItems(ctx, row.ID)
+	if err != nil {
+		return Return{}, err
+	}
+
+	ret := mapReturn(row)
+	for _, item := range itemRows {
+		ret.Items = append(ret.Items, mapReturnItem(item))
+	}
+
+	return ret, nil
+}
+
+func (r *repository) listWithItems(ctx context.Context, rows []sqlc.Return) ([]Return, error) {
+	returns := make([]Return, len(rows))
+	for i, row := range rows {
+		ret, err := r.withItems(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		returns[i] = ret
+	}
+
+	return returns, nil
+}
+
+func mapReturn(row sqlc.Return) Return {
+	return Return{
+		ID:             uuid.UUID(row.ID.Bytes),
+		OrderID:        uuid.UUID(row.OrderID.Bytes),
+		UserID:         uuid.UUID(row.UserID.Bytes),
+		Status:         row.Status,
+		Reason:         row.Reason,
+		ResolutionNote: row.ResolutionNote.String,
+		ReviewedBy:     uuidPtr(row.ReviewedBy),
+		ShipmentID:     uuidPtr(row.ShipmentID),
+		RefundCents:    row.RefundCents,
+		CreatedAt:      row.CreatedAt.Time,
+		UpdatedAt:      row.UpdatedAt.Time,
+		ReviewedAt:     timePtr(row.ReviewedAt),
+		ReceivedAt:     timePtr(row.ReceivedAt),
+		Items:          []ReturnItem{},
+	}
+}
+
+func mapReturnItem(row sqlc.ReturnItem) ReturnItem {
+	return ReturnItem{
+		ID:          uuid.UUID(row.ID.Bytes),
+		ReturnID:    uuid.UUID(row.ReturnID.Bytes),
+		OrderItemID: uuid.UUID(row.OrderItemID.Bytes),
+		Qty:         row.Qty,
+		RefundCents: row.RefundCents,
+		CreatedAt:   row.CreatedAt.Time,
+	}
+}
+
+func uuidPtr(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	u := uuid.UUID(id.Bytes)
+	return &u
+}
+
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
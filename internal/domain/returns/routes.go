@@ -0,0 +1,24 @@
+package returns
+
+import (
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes(svc Service) chi.Router {
+	h := NewHandler(svc)
+	r := chi.NewRouter()
+
+	r.With(validator.Validate[CreateReturnRequest]()).With(middleware.RoleMiddleware("customer")).Post("/", h.RequestReturn)
+	r.With(middleware.RoleMiddleware("customer")).Get("/", h.GetMyReturns)
+	r.With(middleware.RoleMiddleware("customer", "admin", "support")).Get("/{id}", h.GetReturn)
+	r.With(middleware.RoleMiddleware("admin", "support")).Get("/order/{orderID}", h.GetReturnsByOrderID)
+
+	r.With(validator.Validate[ReviewReturnRequest]()).With(middleware.RoleMiddleware("admin", "support")).Post("/{id}/approve", h.ApproveReturn)
+	r.With(validator.Validate[ReviewReturnRequest]()).With(middleware.RoleMiddleware("admin", "support")).Post("/{id}/reject", h.RejectReturn)
+	r.With(validator.Validate[ReceiveReturnRequest]()).With(middleware.RoleMiddleware("admin", "support")).Post("/{id}/receive", h.ReceiveReturn)
+
+	return r
+}
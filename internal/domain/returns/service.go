@@ -0,0 +1,321 @@
+package returns
+
+import (
+	"context"
+	"ecommerce-app/internal/domain/inventory"
+	"ecommerce-app/internal/domain/order"
+	"ecommerce-app/internal/domain/shipment"
+	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/logger"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type Service interface {
+	RequestReturn(ctx context.Context, userID string, req CreateReturnRequest) (Return, *errs.AppError)
+	GetReturn(ctx context.Context, userID, role, id string) (Return, *errs.AppError)
+	GetReturnsByOrderID(ctx context.Context, orderID string) ([]Return, *errs.AppError)
+	GetReturnsByUserID(ctx context.Context, userID string) ([]Return, *errs.AppError)
+	ApproveReturn(ctx context.Context, reviewerID, id string, req ReviewReturnRequest) (Return, *errs.AppError)
+	RejectReturn(ctx context.Context, reviewerID, id string, req ReviewReturnRequest) (Return, *errs.AppError)
+	ReceiveReturn(ctx context.Context, receiverID, id string, req ReceiveReturnRequest) (Return, *errs.AppError)
+}
+
+type service struct {
+	repo         Repository
+	orderSvc     OrderProvider
+	productSvc   ProductProvider
+	shipmentSvc  ShipmentProvider
+	inventorySvc InventoryProvider
+}
+
+func NewService(repo Repository, orderSvc OrderProvider, productSvc ProductProvider, shipmentSvc ShipmentProvider, inventorySvc InventoryProvider) Service {
+	return &service{
+		repo:         repo,
+		orderSvc:     orderSvc,
+		productSvc:   productSvc,
+		shipmentSvc:  shipmentSvc,
+		inventorySvc: inventorySvc,
+	}
+}
+
+// RequestReturn opens a return for some of the lines of a shipped order.
+// Quantities are checked against what earlier, non-rejected returns on the
+// same order have already claimed, under a lock on the order so concurrent
+// requests cannot claim the same units twice. Gift cards cannot be returned;
+// the cards they issued stay with the customer.
+func (s *service) RequestReturn(ctx context.Context, userID string, req CreateReturnRequest) (Return, *errs.AppError) {
+	o, appErr := s.orderSvc.GetOrderByID(ctx, req.OrderID)
+	if appErr != nil {
+		return Return{}, appErr
+	}
+
+	if o.UserID.String() != userID {
+		return Return{}, errs.ErrForbidden.WithMessage("You can only return items from your own orders")
+	}
+
+	if o.Status != order.StatusShipped {
+		return Return{}, errs.ErrConflict.WithMessage("Only shipped orders can be returned")
+	}
+
+	since := o.DeliveredAt
+	if since == nil {
+		since = o.ShippedAt
+	}
+	if since == nil || time.Since(*since) > ReturnWindow {
+		return Return{}, errs.ErrConflict.WithMessage("The return window for this order has closed")
+	}
+
+	var created Return
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.LockOrder(ctx, req.OrderID); err != nil {
+			logger.Error("Failed to lock order %s for a return: %v", req.OrderID, err)
+			return errs.ErrInternal.WithMessage("Failed to create return")
+		}
+
+		returned, err := s.repo.ReturnedQtyByOrderItem(ctx, req.OrderID)
+		if err != nil {
+			return errs.ErrInternal.WithMessage("Failed to get returned quantities")
+		}
+
+		orderItems := make(map[string]order.OrderItem, len(o.Items))
+		for _, item := range o.Items {
+			orderItems[item.ID.String()] = item
+		}
+
+		input := CreateReturnInput{
+			OrderID: req.OrderID,
+			UserID:  userID,
+			Reason:  req.Reason,
+			Items:   make([]CreateReturnItemInput, 0, len(req.Items)),
+		}
+
+		seen := make(map[string]bool, len(req.Items))
+		for _, reqItem := range req.Items {
+			item, ok := orderItems[reqItem.OrderItemID]
+			if !ok {
+				return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Item %s is not part of this order", reqItem.OrderItemID))
+			}
+			if seen[reqItem.OrderItemID] {
+				return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Item %s is listed more than once", reqItem.OrderItemID))
+			}
+			seen[reqItem.OrderItemID] = true
+
+			prod, appErr := s.productSvc.GetProductByID(ctx, item.ProductID.String())
+			if appErr != nil {
+				return appErr
+			}
+			if prod.IsGiftCard {
+				return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Gift card %s cannot be returned", item.Name))
+			}
+
+			remaining := int32(item.Qty) - returned[reqItem.OrderItemID]
+			if reqItem.Qty > remaining {
+				return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Only %d of %s can still be returned", remaining, item.Name))
+			}
+
+			refund := o.LinePaidCents(item, reqItem.Qty)
+			input.RefundCents += refund
+			input.Items = append(input.Items, CreateReturnItemInput{
+				OrderItemID: reqItem.OrderItemID,
+				Qty:         reqItem.Qty,
+				RefundCents: refund,
+			})
+		}
+
+		ret, err := s.repo.Create(ctx, input)
+		if err != nil {
+			logger.Error("Failed to create return for order %s: %v", req.OrderID, err)
+			return errs.ErrInternal.WithMessage("Failed to create return")
+		}
+
+		created = ret
+		return nil
+	})
+	if err != nil {
+		return Return{}, errs.EnsureAppError(err)
+	}
+
+	return created, nil
+}
+
+// GetReturn fetches a return. Customers only see their own returns.
+func (s *service) GetReturn(ctx context.Context, userID, role, id string) (Return, *errs.AppError) {
+	ret, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Return{}, errs.ErrNotFound.WithMessage("Return not found")
+		}
+		return Return{}, errs.ErrInternal.WithMessage("Failed to get return")
+	}
+
+	if role == "customer" && ret.UserID.String() != userID {
+		return Return{}, errs.ErrNotFound.WithMessage("Return not found")
+	}
+
+	return ret, nil
+}
+
+func (s *service) GetReturnsByOrderID(ctx context.Context, orderID string) ([]Return, *errs.AppError) {
+	returns, err := s.repo.ListByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, errs.ErrInternal.WithMessage("Failed to get returns for order")
+	}
+
+	return returns, nil
+}
+
+func (s *service) GetReturnsByUserID(ctx context.Context, userID string) ([]Return, *errs.AppError) {
+	returns, err := s.repo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, errs.ErrInternal.WithMessage("Failed to get returns")
+	}
+
+	return returns, nil
+}
+
+func (s *service) ApproveReturn(ctx context.Context, reviewerID, id string, req ReviewReturnRequest) (Return, *errs.AppError) {
+	return s.review(ctx, reviewerID, id, StatusApproved, req.Note)
+}
+
+func (s *service) RejectReturn(ctx context.Context, reviewerID, id string, req ReviewReturnRequest) (Return, *errs.AppError) {
+	return s.review(ctx, reviewerID, id, StatusRejected, req.Note)
+}
+
+func (s *service) review(ctx context.Context, reviewerID, id, status, note string) (Return, *errs.AppError) {
+	if _, err := s.repo.GetByID(ctx, id); err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Return{}, errs.ErrNotFound.WithMessage("Return not found")
+		}
+		return Return{}, errs.ErrInternal.WithMessage("Failed to get return")
+	}
+
+	ret, err := s.repo.Review(ctx, id, status, reviewerID, note)
+	if err != nil {
+		if errors.Is(err, errs.ErrConflict) {
+			return Return{}, errs.ErrConflict.WithMessage("Return is no longer awaiting review")
+		}
+		return Return{}, errs.ErrInternal.WithMessage("Failed to review return")
+	}
+
+	return ret, nil
+}
+
+// ReceiveReturn books the returned goods in: it records a RETURNED shipment,
+// puts the items back in stock and refunds the return's lines. The refund
+// is split pro rata between what the order paid with wallet credit and gift
+// cards, which is given back to them, and what it paid through its payment,
+// which is refunded through the payment, or to the wallet for an offline
+// payment; the provider is asked for it once the receipt has committed.
+// Neither part is given back beyond what is still refundable on it, so an
+// order an admin already refunded from returns less; the return records
+// what was refunded.
+func (s *service) ReceiveReturn(ctx context.Context, receiverID, id string, req ReceiveReturnRequest) (Return, *errs.AppError) {
+	var received Return
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		ret, err := s.repo.GetByID(ctx, id)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return errs.ErrNotFound.WithMessage("Return not found")
+			}
+			return errs.ErrInternal.WithMessage("Failed to get return")
+		}
+
+		if ret.Status != StatusApproved {
+			return errs.ErrConflict.WithMessage(fmt.Sprintf("Return in status %s cannot be received", ret.Status))
+		}
+
+		if err := s.repo.LockOrder(ctx, ret.OrderID.String()); err != nil {
+			logger.Error("Failed to lock order %s for return %s: %v", ret.OrderID, id, err)
+			return errs.ErrInternal.WithMessage("Failed to complete return")
+		}
+
+		o, appErr := s.orderSvc.GetOrderByID(ctx, ret.OrderID.String())
+		if appErr != nil {
+			return appErr
+		}
+
+		now := time.Now()
+		shp, appErr := s.shipmentSvc.CreateShipment(ctx, shipment.CreateShipmentRequest{
+			OrderID:        ret.OrderID.String(),
+			Carrier:        req.Carrier,
+			TrackingNumber: req.TrackingNumber,
+			Status:         "RETURNED",
+			DeliveredAt:    &now,
+		})
+		if appErr != nil {
+			return appErr
+		}
+
+		productIDs := make(map[string]string, len(o.Items))
+		for _, item := range o.Items {
+			productIDs[item.ID.String()] = item.ProductID.String()
+		}
+
+		for _, item := range ret.Items {
+			_, appErr := s.inventorySvc.AdjustStock(ctx, inventory.AdjustStockInput{
+				ProductID: productIDs[item.OrderItemID.String()],
+				Delta:     item.Qty,
+				Reason:    inventory.MovementReturned,
+				OrderID:   ret.OrderID.String(),
+				ReturnID:  ret.ID.String(),
+				ActorID:   receiverID,
+			})
+			if appErr != nil {
+				return appErr
+			}
+		}
+
+		paymentShare, prepaidShare := refundSplit(o, ret.RefundCents)
+
+		reason := fmt.Sprintf("Return %s received", id)
+		prepaid, appErr := s.orderSvc.RefundPrepaid(ctx, ret.OrderID.String(), prepaidShare, receiverID, reason)
+		if appErr != nil {
+			return appErr
+		}
+
+		if refunded := prepaid + paymentShare; refunded < ret.RefundCents {
+			logger.Warn("Return %s refunds %d of %d cents; the rest of order %s was already refunded", id, refunded, ret.RefundCents, ret.OrderID)
+		}
+
+		completed, err := s.repo.Complete(ctx, id, shp.ID, prepaid+paymentShare)
+		if err != nil {
+			if errors.Is(err, errs.ErrConflict) {
+				return errs.ErrConflict.WithMessage("Return was changed by another request, please retry")
+			}
+			return errs.ErrInternal.WithMessage("Failed to complete return")
+		}
+
+		if paymentShare > 0 {
+			if _, appErr := s.orderSvc.RefundOrder(ctx, ret.OrderID.String(), paymentShare, receiverID, reason); appErr != nil {
+				return appErr
+			}
+		}
+
+		received = completed
+		return nil
+	})
+	if err != nil {
+		return Return{}, errs.EnsureAppError(err)
+	}
+
+	return received, nil
+}
+
+// refundSplit divides a return's refund into the part refunded through the
+// order's payment and the part given back to the wallet credit and gift
+// cards that paid for the order, pro rata: final_cents is what was left to
+// pay once they had paid their part of total_cents. The payment's part is
+// capped at what has not been refunded from it yet.
+func refundSplit(o order.Order, refundCents int64) (paymentShare, prepaidShare int64) {
+	paymentShare = refundCents
+	if o.TotalCents > 0 {
+		paymentShare = refundCents * o.FinalCents / o.TotalCents
+	}
+	prepaidShare = refundCents - paymentShare
+	paymentShare = min(paymentShare, max(o.FinalCents-o.RefundedCents, 0))
+	return paymentShare, prepaidShare
+}
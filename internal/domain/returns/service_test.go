@@ -0,0 +1,227 @@
+package returns
+
+import (
+	"context"
+	"ecommerce-app/internal/domain/order"
+	"ecommerce-app/internal/domain/shipment"
+	"ecommerce-app/internal/pkg/errs"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// approvedReturn holds a single APPROVED return and records the refund it
+// is completed with
+type approvedReturn struct {
+	Repository
+	ret       Return
+	completed int64
+}
+
+func (r *approvedReturn) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+func (r *approvedReturn) GetByID(ctx context.Context, id string) (Return, error) {
+	return r.ret, nil
+}
+
+func (r *approvedReturn) LockOrder(ctx context.Context, orderID string) error {
+	return nil
+}
+
+func (r *approvedReturn) Complete(ctx context.Context, id, shipmentID string, refundCents int64) (Return, error) {
+	r.completed = refundCents
+	ret := r.ret
+	ret.Status = StatusRefunded
+	ret.RefundCents = refundCents
+	return ret, nil
+}
+
+// refundingOrders gives back prepaid amounts up to what is left of them and
+// records what is refunded through the payment
+type refundingOrders struct {
+	OrderProvider
+	order       order.Order
+	prepaidLeft int64
+	prepaid     int64
+	payment     int64
+}
+
+func (o *refundingOrders) GetOrderByID(ctx context.Context, id string) (order.Order, *errs.AppError) {
+	return o.order, nil
+}
+
+func (o *refundingOrders) RefundPrepaid(ctx context.Context, id string, amountCents int64, changedBy, reason string) (int64, *errs.AppError) {
+	o.prepaid = min(amountCents, o.prepaidLeft)
+	return o.prepaid, nil
+}
+
+func (o *refundingOrders) RefundOrder(ctx context.Context, id string, amountCents int64, changedBy, reason string) (order.Order, *errs.AppError) {
+	o.payment = amountCents
+	return o.order, nil
+}
+
+type returnShipments struct {
+	ShipmentProvider
+}
+
+func (returnShipments) CreateShipment(ctx context.Context, req shipment.CreateShipmentRequest) (shipment.Shipment, *errs.AppError) {
+	return shipment.Shipment{ID: uuid.NewString(), OrderID: req.OrderID, Status: req.Status}, nil
+}
+
+func TestRefundSplit(t *testing.T) {
+	tests := []struct {
+		name        string
+		order       order.Order
+		refundCents int64
+		wantPayment int64
+		wantPrepaid int64
+	}{
+		{
+			name:        "paid through the payment only",
+			order:       order.Order{TotalCents: 10000, FinalCents: 10000},
+			refundCents: 2500,
+			wantPayment: 2500,
+		},
+		{
+			name:        "paid with wallet credit and gift cards only",
+			order:       order.Order{TotalCents: 10000, FinalCents: 0},
+			refundCents: 2500,
+			wantPrepaid: 2500,
+		},
+		{
+			name:        "split pro rata",
+			order:       order.Order{TotalCents: 10000, FinalCents: 6000},
+			refundCents: 2500,
+			wantPayment: 1500,
+			wantPrepaid: 1000,
+		},
+		{
+			name:        "rounding favours the prepaid share",
+			order:       order.Order{TotalCents: 3000, FinalCents: 1000},
+			refundCents: 1001,
+			wantPayment: 333,
+			wantPrepaid: 668,
+		},
+		{
+			name:        "earlier returns left enough on the payment",
+			order:       order.Order{TotalCents: 10000, FinalCents: 10000, RefundedCents: 7500},
+			refundCents: 2500,
+			wantPayment: 2500,
+		},
+		{
+			name:        "admin already refunded part of the payment",
+			order:       order.Order{TotalCents: 10000, FinalCents: 6000, RefundedCents: 5000},
+			refundCents: 2500,
+			wantPayment: 1000,
+			wantPrepaid: 1000,
+		},
+		{
+			name:        "payment refunded in full",
+			order:       order.Order{TotalCents: 10000, FinalCents: 6000, RefundedCents: 6000},
+			refundCents: 2500,
+			wantPrepaid: 1000,
+		},
+		{
+			name:        "refunded beyond the payment",
+			order:       order.Order{TotalCents: 10000, FinalCents: 6000, RefundedCents: 7000},
+			refundCents: 2500,
+			wantPrepaid: 1000,
+		},
+		{
+			name:        "order without a total",
+			order:       order.Order{},
+			refundCents: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payment, prepaid := refundSplit(tt.order, tt.refundCents)
+			if payment != tt.wantPayment || prepaid != tt.wantPrepaid {
+				t.Errorf("refundSplit = %d payment, %d prepaid; want %d, %d", payment, prepaid, tt.wantPayment, tt.wantPrepaid)
+			}
+		})
+	}
+}
+
+func TestReceiveReturnRefunds(t *testing.T) {
+	tests := []struct {
+		name          string
+		order         order.Order
+		prepaidLeft   int64
+		refundCents   int64
+		wantPrepaid   int64
+		wantPayment   int64
+		wantCompleted int64
+	}{
+		{
+			name:          "paid through the payment only",
+			order:         order.Order{TotalCents: 10000, FinalCents: 10000},
+			refundCents:   2500,
+			wantPayment:   2500,
+			wantCompleted: 2500,
+		},
+		{
+			name:          "paid with wallet credit and gift cards only",
+			order:         order.Order{TotalCents: 10000, FinalCents: 0},
+			prepaidLeft:   10000,
+			refundCents:   2500,
+			wantPrepaid:   2500,
+			wantCompleted: 2500,
+		},
+		{
+			name:          "split between the payment and what was prepaid",
+			order:         order.Order{TotalCents: 10000, FinalCents: 6000},
+			prepaidLeft:   4000,
+			refundCents:   2500,
+			wantPrepaid:   1000,
+			wantPayment:   1500,
+			wantCompleted: 2500,
+		},
+		{
+			name:          "admin already refunded part of the payment",
+			order:         order.Order{TotalCents: 10000, FinalCents: 6000, RefundedCents: 5000},
+			prepaidLeft:   4000,
+			refundCents:   2500,
+			wantPrepaid:   1000,
+			wantPayment:   1000,
+			wantCompleted: 2000,
+		},
+		{
+			name:          "prepaid amounts already given back",
+			order:         order.Order{TotalCents: 10000, FinalCents: 6000},
+			refundCents:   2500,
+			wantPayment:   1500,
+			wantCompleted: 1500,
+		},
+		{
+			name:          "everything already refunded",
+			order:         order.Order{TotalCents: 10000, FinalCents: 6000, RefundedCents: 6000},
+			refundCents:   2500,
+			wantCompleted: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.order.ID = uuid.New()
+			repo := &approvedReturn{ret: Return{ID: uuid.New(), OrderID: tt.order.ID, Status: StatusApproved, RefundCents: tt.refundCents}}
+			orders := &refundingOrders{order: tt.order, prepaidLeft: tt.prepaidLeft}
+			svc := NewService(repo, orders, nil, returnShipments{}, nil)
+
+			received, appErr := svc.ReceiveReturn(context.Background(), uuid.NewString(), repo.ret.ID.String(), ReceiveReturnRequest{})
+			if appErr != nil {
+				t.Fatalf("ReceiveReturn: %s", appErr.Message)
+			}
+
+			if orders.prepaid != tt.wantPrepaid || orders.payment != tt.wantPayment {
+				t.Errorf("refunded %d prepaid, %d through the payment; want %d, %d", orders.prepaid, orders.payment, tt.wantPrepaid, tt.wantPayment)
+			}
+			if received.RefundCents != tt.wantCompleted {
+				t.Errorf("return refunded %d, want %d", received.RefundCents, tt.wantCompleted)
+			}
+		})
+	}
+}
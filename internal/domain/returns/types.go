@@ -0,0 +1,72 @@
+package returns
+
+import (
+	"context"
+	"ecommerce-app/internal/domain/inventory"
+	"ecommerce-app/internal/domain/order"
+	"ecommerce-app/internal/domain/product"
+	"ecommerce-app/internal/domain/shipment"
+	"ecommerce-app/internal/pkg/errs"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Return statuses. A return is REQUESTED by the customer, APPROVED or
+// REJECTED by support, and REFUNDED once the goods are received back.
+const (
+	StatusRequested = "REQUESTED"
+	StatusApproved  = "APPROVED"
+	StatusRejected  = "REJECTED"
+	StatusRefunded  = "REFUNDED"
+)
+
+// ReturnWindow is how long after delivery (or shipping, when delivery was
+// never recorded) a customer may request a return.
+const ReturnWindow = 30 * 24 * time.Hour
+
+// --- Domain Models ---
+type Return struct {
+	ID             uuid.UUID    `json:"id"`
+	OrderID        uuid.UUID    `json:"order_id"`
+	UserID         uuid.UUID    `json:"user_id"`
+	Status         string       `json:"status"`
+	Reason         string       `json:"reason"`
+	ResolutionNote string       `json:"resolution_note,omitempty"`
+	ReviewedBy     *uuid.UUID   `json:"reviewed_by,omitempty"`
+	ShipmentID     *uuid.UUID   `json:"shipment_id,omitempty"`
+	RefundCents    int64        `json:"refund_cents"`
+	CreatedAt      time.Time    `json:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"`
+	ReviewedAt     *time.Time   `json:"reviewed_at,omitempty"`
+	ReceivedAt     *time.Time   `json:"received_at,omitempty"`
+	Items          []ReturnItem `json:"items"`
+}
+
+type ReturnItem struct {
+	ID          uuid.UUID `json:"id"`
+	ReturnID    uuid.UUID `json:"return_id"`
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	Qty         int32     `json:"qty"`
+	RefundCents int64     `json:"refund_cents"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// --- Dependency Injection Interface ---
+type OrderProvider interface {
+	GetOrderByID(ctx context.Context, id string) (order.Order, *errs.AppError)
+	RefundOrder(ctx context.Context, id string, amountCents int64, changedBy, reason string) (order.Order, *errs.AppError)
+	RefundPrepaid(ctx context.Context, id string, amountCents int64, changedBy, reason string) (int64, *errs.AppError)
+}
+
+type ProductProvider interface {
+	GetProductByID(ctx context.Context, id string) (product.Product, *errs.AppError)
+}
+
+type ShipmentProvider interface {
+	CreateShipment(ctx context.Context, req shipment.CreateShipmentRequest) (shipment.Shipment, *errs.AppError)
+}
+
+type InventoryProvider interface {
+	AdjustStock(ctx context.Context, in inventory.AdjustStockInput) (inventory.Inventory, *errs.AppError)
+}
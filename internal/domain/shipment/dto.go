@@ -1,11 +1,16 @@
 package shipment
 
-import "time"
+import (
+	"time"
+
+	"ecommerce-app/internal/domain/order"
+)
 
 // Request DTOs
 
 type CreateShipmentRequest struct {
 	OrderID        string     `json:"order_id"`
+	WarehouseID    string     `json:"warehouse_id,omitempty" validate:"omitempty,uuid4"`
 	Carrier        string     `json:"carrier"`
 	TrackingNumber string     `json:"tracking_number"`
 	Status         string     `json:"status"`
@@ -13,9 +18,17 @@ type CreateShipmentRequest struct {
 	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
 }
 
+// CreateWarehouseShipmentsRequest splits an order into one PENDING shipment
+// per warehouse its lines were allocated to
+type CreateWarehouseShipmentsRequest struct {
+	Carrier string `json:"carrier" validate:"required"`
+}
 
 type UpdateShipmentStatusRequest struct {
 	Status      string     `json:"status"`
 	ShippedAt   *time.Time `json:"shipped_at,omitempty"`
 	DeliveredAt *time.Time `json:"delivered_at,omitempty"`
+	// UnshippedItems are left out of the payment capture when the order's
+	// first shipment leaves PENDING
+	UnshippedItems []order.UnshippedItem `json:"unshipped_items,omitempty" validate:"omitempty,dive"`
 }
\ No newline at end of file
@@ -1,6 +1,7 @@
 package shipment
 
 import (
+	"ecommerce-app/internal/pkg/middleware"
 	"ecommerce-app/internal/pkg/response"
 	"ecommerce-app/internal/pkg/validator"
 	"net/http"
@@ -28,6 +29,19 @@ func (h *Handler) CreateShipment(w http.ResponseWriter, r *http.Request) {
 	response.Created(w, shipment)
 }
 
+func (h *Handler) CreateWarehouseShipments(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderID")
+	req := validator.GetValidatedBody[CreateWarehouseShipmentsRequest](r)
+
+	shipments, appErr := h.svc.CreateWarehouseShipments(r.Context(), orderID, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, shipments)
+}
+
 func (h *Handler) GetShipmentsByOrderID(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -52,9 +66,17 @@ func (h *Handler) GetShipment(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, shipment)
 }
 
+// UpdateShipmentStatus is open to admins and couriers; only admins may leave
+// items out of the payment capture
 func (h *Handler) UpdateShipmentStatus(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	req := validator.GetValidatedBody[UpdateShipmentStatusRequest](r)
+	role, _ := r.Context().Value(middleware.UserRoleKey).(string)
+
+	if len(req.UnshippedItems) > 0 && role != "admin" {
+		response.Forbidden(w, "only admins can list unshipped items")
+		return
+	}
 
 	updatedShipment, appErr := h.svc.UpdateShipmentStatus(r.Context(), id, req)
 	if appErr != nil {
@@ -65,6 +87,19 @@ func (h *Handler) UpdateShipmentStatus(w http.ResponseWriter, r *http.Request) {
 	response.OK(w, updatedShipment, "Shipment status updated successfully")
 }
 
+func (h *Handler) DeliverShipment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	shipment, appErr := h.svc.DeliverShipment(r.Context(), id, userID)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, shipment, "Shipment delivered successfully")
+}
+
 func (h *Handler) DeleteShipment(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 
@@ -2,6 +2,7 @@ package shipment
 
 import (
 	"context"
+	"ecommerce-app/internal/pkg/database"
 	"ecommerce-app/internal/pkg/database/sqlc"
 
 	"time"
@@ -10,27 +11,47 @@ import (
 )
 
 type Repository interface {
-	CreateShipment(ctx context.Context, orderID, carrier, trackingNumber, status string, shippedAt, deliveredAt *time.Time) (Shipment, error)
+	CreateShipment(ctx context.Context, orderID, warehouseID, carrier, trackingNumber, status string, shippedAt, deliveredAt *time.Time) (Shipment, error)
 	GetShipment(ctx context.Context, id string) (Shipment, error)
 	ListShipmentsByOrder(ctx context.Context, orderID string) ([]Shipment, error)
 	UpdateShipmentStatus(ctx context.Context, id, status string, shippedAt, deliveredAt *time.Time) (Shipment, error)
 	DeleteShipment(ctx context.Context, id string) error
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
 }
 
 type repository struct {
-	q *sqlc.Queries
+	q  *sqlc.Queries
+	db database.Transactor
 }
 
-func NewRepository(q *sqlc.Queries) Repository {
-	return &repository{q: q}
+func NewRepository(q *sqlc.Queries, db database.Transactor) Repository {
+	return &repository{q: q, db: db}
 }
 
-func (r *repository) CreateShipment(ctx context.Context, orderID, carrier, trackingNumber, status string, shippedAt, deliveredAt *time.Time) (Shipment, error) {
+// WithTx runs fn in a single transaction; every repository call made with
+// the ctx passed to fn takes part in it.
+func (r *repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithTx(ctx, r.db, fn)
+}
+
+// queries joins the transaction carried by ctx, if any
+func (r *repository) queries(ctx context.Context) *sqlc.Queries {
+	return database.Queries(ctx, r.q)
+}
+
+func (r *repository) CreateShipment(ctx context.Context, orderID, warehouseID, carrier, trackingNumber, status string, shippedAt, deliveredAt *time.Time) (Shipment, error) {
 	var orderUUID pgtype.UUID
 	if err := orderUUID.Scan(orderID); err != nil {
 		return Shipment{}, err
 	}
 
+	var warehouseUUID pgtype.UUID
+	if warehouseID != "" {
+		if err := warehouseUUID.Scan(warehouseID); err != nil {
+			return Shipment{}, err
+		}
+	}
+
 	var shippedAtPg pgtype.Timestamptz
 	if shippedAt != nil {
 		shippedAtPg = pgtype.Timestamptz{Time: *shippedAt, Valid: true}
@@ -49,11 +70,13 @@ func (r *repository) CreateShipment(ctx context.Context, orderID, carrier, track
 		OrderID:        orderUUID,
 		Carrier:        carrier,
 		TrackingNumber: pgtype.Text{String: trackingNumber, Valid: true},
+		Status:         status,
 		ShippedAt:      shippedAtPg,
 		DeliveredAt:    deliveredAtPg,
+		WarehouseID:    warehouseUUID,
 	}
 
-	row, err := r.q.CreateShipment(ctx, params)
+	row, err := r.queries(ctx).CreateShipment(ctx, params)
 	if err != nil {
 		return Shipment{}, err
 	}
@@ -67,7 +90,7 @@ func (r *repository) GetShipment(ctx context.Context, id string) (Shipment, erro
 		return Shipment{}, err
 	}
 
-	row, err := r.q.GetShipment(ctx, shipmentUUID)
+	row, err := r.queries(ctx).GetShipment(ctx, shipmentUUID)
 	if err != nil {
 		return Shipment{}, err
 	}
@@ -81,7 +104,7 @@ func (r *repository) ListShipmentsByOrder(ctx context.Context, orderID string) (
 		return nil, err
 	}
 
-	rows, err := r.q.ListShipmentsByOrder(ctx, orderUUID)
+	rows, err := r.queries(ctx).ListShipmentsByOrder(ctx, orderUUID)
 	if err != nil {
 		return nil, err
 	}
@@ -121,7 +144,7 @@ func (r *repository) UpdateShipmentStatus(ctx context.Context, id, status string
 		DeliveredAt: deliveredAtPg,
 	}
 
-	row, err := r.q.UpdateShipmentStatus(ctx, params)
+	row, err := r.queries(ctx).UpdateShipmentStatus(ctx, params)
 	if err != nil {
 		return Shipment{}, err
 	}
@@ -135,7 +158,7 @@ func (r *repository) DeleteShipment(ctx context.Context, id string) error {
 		return err
 	}
 
-	return r.q.DeleteShipment(ctx, shipmentUUID)
+	return r.queries(ctx).DeleteShipment(ctx, shipmentUUID)
 }
 
 func mapShipment(row sqlc.Shipment) Shipment {
@@ -149,9 +172,15 @@ func mapShipment(row sqlc.Shipment) Shipment {
 		deliveredAt = &row.DeliveredAt.Time
 	}
 
+	var warehouseID string
+	if row.WarehouseID.Valid {
+		warehouseID = row.WarehouseID.String()
+	}
+
 	return Shipment{
 		ID:            row.ID.String(),
 		OrderID:       row.OrderID.String(),
+		WarehouseID:   warehouseID,
 		Carrier:       row.Carrier,
 		TrackingNumber: row.TrackingNumber.String,
 		Status:        row.Status,
@@ -1,6 +1,7 @@
 package shipment
 
 import (
+	"ecommerce-app/internal/pkg/middleware"
 	"ecommerce-app/internal/pkg/validator"
 
 	"github.com/go-chi/chi/v5"
@@ -11,10 +12,12 @@ func Routes(svc Service) chi.Router {
 	r := chi.NewRouter()
 
 	r.With(validator.Validate[CreateShipmentRequest]()).Post("/", h.CreateShipment)
+	r.With(validator.Validate[CreateWarehouseShipmentsRequest]()).With(middleware.RoleMiddleware("admin")).Post("/order/{orderID}/warehouses", h.CreateWarehouseShipments)
 
 	r.Get("/order/{orderID}", h.GetShipmentsByOrderID)
 
-	r.With(validator.Validate[UpdateShipmentStatusRequest]()).Patch("/{id}/status", h.UpdateShipmentStatus)
+	r.With(validator.Validate[UpdateShipmentStatusRequest]()).With(middleware.RoleMiddleware("admin", "delivery")).Patch("/{id}/status", h.UpdateShipmentStatus)
+	r.With(middleware.RoleMiddleware("delivery")).Post("/{id}/deliver", h.DeliverShipment)
 
 	return r
 }
\ No newline at end of file
@@ -2,32 +2,101 @@ package shipment
 
 import (
 	"context"
+	"ecommerce-app/internal/domain/inventory"
 	"ecommerce-app/internal/pkg/errs"
+	"fmt"
+	"time"
 )
 
 type Service interface {
 	CreateShipment(ctx context.Context, req CreateShipmentRequest) (Shipment, *errs.AppError)
+	CreateWarehouseShipments(ctx context.Context, orderID string, req CreateWarehouseShipmentsRequest) ([]WarehouseShipment, *errs.AppError)
 	GetShipment(ctx context.Context, id string) (Shipment, *errs.AppError)
 	GetShipmentsByOrderID(ctx context.Context, orderID string) ([]Shipment, *errs.AppError)
 	UpdateShipmentStatus(ctx context.Context, id string, req UpdateShipmentStatusRequest) (Shipment, *errs.AppError)
+	DeliverShipment(ctx context.Context, id, courierID string) (Shipment, *errs.AppError)
 	DeleteShipment(ctx context.Context, id string) *errs.AppError
 }
 
 type service struct {
-	repo Repository
+	repo         Repository
+	orderSvc     OrderProvider
+	inventorySvc InventoryProvider
 }
 
-func NewService(repo Repository) Service {
-	return &service{repo}
+func NewService(repo Repository, orderSvc OrderProvider, inventorySvc InventoryProvider) Service {
+	return &service{repo: repo, orderSvc: orderSvc, inventorySvc: inventorySvc}
 }
 
+// CreateShipment records a shipment. One created already on its way
+// captures the order's payment, the way moving a PENDING shipment on does.
 func (s *service) CreateShipment(ctx context.Context, req CreateShipmentRequest) (Shipment, *errs.AppError) {
-	shipment, err := s.repo.CreateShipment(ctx, req.OrderID, req.Carrier, req.TrackingNumber,req.Status, req.ShippedAt, req.DeliveredAt)
+	var created Shipment
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		shipment, err := s.repo.CreateShipment(ctx, req.OrderID, req.WarehouseID, req.Carrier, req.TrackingNumber,req.Status, req.ShippedAt, req.DeliveredAt)
+		if err != nil {
+			return errs.ErrInternal.WithMessage("failed to create shipment")
+		}
+
+		if ships(req.Status) {
+			if _, appErr := s.orderSvc.CapturePayment(ctx, req.OrderID, nil); appErr != nil {
+				return appErr
+			}
+		}
+
+		created = shipment
+		return nil
+	})
 	if err != nil {
-		return Shipment{}, errs.ErrInternal.WithMessage("failed to create shipment")
+		return Shipment{}, errs.EnsureAppError(err)
 	}
 
-	return shipment, nil
+	return created, nil
+}
+
+// CreateWarehouseShipments creates a PENDING shipment for every warehouse
+// the order's lines were allocated to. Warehouses that already have a
+// shipment for the order are skipped, so it can be called again once more
+// of the order is allocated.
+func (s *service) CreateWarehouseShipments(ctx context.Context, orderID string, req CreateWarehouseShipmentsRequest) ([]WarehouseShipment, *errs.AppError) {
+	allocations, appErr := s.inventorySvc.ListOrderAllocations(ctx, orderID)
+	if appErr != nil {
+		return nil, appErr
+	}
+	if len(allocations) == 0 {
+		return nil, errs.ErrConflict.WithMessage("order has no stock allocated to a warehouse")
+	}
+
+	existing, err := s.repo.ListShipmentsByOrder(ctx, orderID)
+	if err != nil {
+		return nil, errs.ErrInternal.WithMessage("failed to list shipments by order")
+	}
+	shipped := make(map[string]bool, len(existing))
+	for _, sh := range existing {
+		shipped[sh.WarehouseID] = true
+	}
+
+	// Allocations come ordered by warehouse, so each warehouse's lines are
+	// contiguous
+	var res []WarehouseShipment
+	for _, a := range allocations {
+		if shipped[a.WarehouseID] {
+			continue
+		}
+		if n := len(res); n > 0 && res[n-1].Shipment.WarehouseID == a.WarehouseID {
+			res[n-1].Lines = append(res[n-1].Lines, a)
+			continue
+		}
+
+		shipment, err := s.repo.CreateShipment(ctx, orderID, a.WarehouseID, req.Carrier, "", StatusPending, nil, nil)
+		if err != nil {
+			return nil, errs.ErrInternal.WithMessage("failed to create shipment")
+		}
+		res = append(res, WarehouseShipment{Shipment: shipment, Lines: []inventory.Allocation{a}})
+	}
+
+	return res, nil
 }
 
 func (s *service) GetShipment(ctx context.Context, id string) (Shipment, *errs.AppError) {
@@ -48,8 +117,89 @@ func (s *service) GetShipmentsByOrderID(ctx context.Context, orderID string) ([]
 	return shipments, nil
 }
 
+// UpdateShipmentStatus moves a shipment along. When a PENDING shipment goes
+// IN_TRANSIT, or straight to DELIVERED, the order's authorized payment is
+// captured, less the unshipped items; once captured, later shipments leave
+// the payment alone, and an order that is not paid cannot ship. A DELIVERED
+// shipment stamps the order's delivery time, now unless given. The
+// shipment and the order change in one transaction, and the provider is
+// asked for the capture once it has committed.
 func (s *service) UpdateShipmentStatus(ctx context.Context, id string, req UpdateShipmentStatusRequest) (Shipment, *errs.AppError) {
-	shipment, err := s.repo.UpdateShipmentStatus(ctx, id, req.Status, req.ShippedAt, req.DeliveredAt)
+	var updated Shipment
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		current, err := s.repo.GetShipment(ctx, id)
+		if err != nil {
+			return errs.ErrInternal.WithMessage("failed to get shipment")
+		}
+
+		if req.Status == StatusDelivered {
+			deliveredAt := time.Now()
+			if req.DeliveredAt != nil {
+				deliveredAt = *req.DeliveredAt
+			}
+			if appErr := s.orderSvc.MarkDelivered(ctx, current.OrderID, deliveredAt); appErr != nil {
+				return appErr
+			}
+			req.DeliveredAt = &deliveredAt
+		}
+
+		shipment, err := s.repo.UpdateShipmentStatus(ctx, id, req.Status, req.ShippedAt, req.DeliveredAt)
+		if err != nil {
+			return errs.ErrInternal.WithMessage("failed to update shipment status")
+		}
+
+		if current.Status == StatusPending && ships(req.Status) {
+			if _, appErr := s.orderSvc.CapturePayment(ctx, current.OrderID, req.UnshippedItems); appErr != nil {
+				return appErr
+			}
+		}
+
+		updated = shipment
+		return nil
+	})
+	if err != nil {
+		return Shipment{}, errs.EnsureAppError(err)
+	}
+
+	return updated, nil
+}
+
+// DeliverShipment is how the courier marks a shipment DELIVERED, stamping
+// the order's delivery time. For a cash-on-delivery order it also confirms
+// the cash they collected, which marks the order PAID; the payment is
+// confirmed first so a retry after a failed update does not collect twice.
+// A shipment delivered without going IN_TRANSIT captures the order's
+// payment, which refuses an order that is not paid.
+func (s *service) DeliverShipment(ctx context.Context, id, courierID string) (Shipment, *errs.AppError) {
+	current, err := s.repo.GetShipment(ctx, id)
+	if err != nil {
+		return Shipment{}, errs.ErrInternal.WithMessage("failed to get shipment")
+	}
+
+	if current.Status == StatusDelivered {
+		return current, nil
+	}
+	if current.Status == StatusReturned {
+		return Shipment{}, errs.ErrConflict.WithMessage(fmt.Sprintf("shipment in status %s cannot be delivered", current.Status))
+	}
+
+	if _, appErr := s.orderSvc.ConfirmCashOnDelivery(ctx, current.OrderID, courierID); appErr != nil {
+		return Shipment{}, appErr
+	}
+
+	if current.Status == StatusPending {
+		if _, appErr := s.orderSvc.CapturePayment(ctx, current.OrderID, nil); appErr != nil {
+			return Shipment{}, appErr
+		}
+	}
+
+	deliveredAt := time.Now()
+	if appErr := s.orderSvc.MarkDelivered(ctx, current.OrderID, deliveredAt); appErr != nil {
+		return Shipment{}, appErr
+	}
+
+	shipment, err := s.repo.UpdateShipmentStatus(ctx, id, StatusDelivered, current.ShippedAt, &deliveredAt)
 	if err != nil {
 		return Shipment{}, errs.ErrInternal.WithMessage("failed to update shipment status")
 	}
@@ -64,4 +214,8 @@ func (s *service) DeleteShipment(ctx context.Context, id string) *errs.AppError
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+// ships reports whether a shipment in status has left the warehouse
+func ships(status string) bool {
+	return status == StatusInTransit || status == StatusDelivered
+}
@@ -1,10 +1,27 @@
 package shipment
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"ecommerce-app/internal/domain/inventory"
+	"ecommerce-app/internal/domain/order"
+	"ecommerce-app/internal/pkg/errs"
+)
+
+// Shipment statuses, matching the shipments.status CHECK constraint.
+const (
+	StatusPending   = "PENDING"
+	StatusInTransit = "IN_TRANSIT"
+	StatusDelivered = "DELIVERED"
+	StatusReturned  = "RETURNED"
+)
 
 type Shipment struct {
 	ID            string
 	OrderID       string
+	// WarehouseID is the warehouse the shipment leaves from, if known
+	WarehouseID   string
 	Carrier       string
 	TrackingNumber string
 	Status        string
@@ -13,3 +30,27 @@ type Shipment struct {
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 }
+
+// WarehouseShipment is the shipment created for one warehouse's part of an
+// order, with the order lines it carries
+type WarehouseShipment struct {
+	Shipment Shipment
+	Lines    []inventory.Allocation
+}
+
+// Dependency Injection Interfaces
+
+// OrderProvider captures an order's authorized payment once it ships,
+// confirms the cash collected for a cash-on-delivery order and records when
+// the order was delivered
+type OrderProvider interface {
+	CapturePayment(ctx context.Context, orderID string, unshipped []order.UnshippedItem) (order.Order, *errs.AppError)
+	ConfirmCashOnDelivery(ctx context.Context, orderID, courierID string) (order.Order, *errs.AppError)
+	MarkDelivered(ctx context.Context, orderID string, deliveredAt time.Time) *errs.AppError
+}
+
+// InventoryProvider tells which warehouses an order's lines were allocated
+// to
+type InventoryProvider interface {
+	ListOrderAllocations(ctx context.Context, orderID string) ([]inventory.Allocation, *errs.AppError)
+}
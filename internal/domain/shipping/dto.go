@@ -0,0 +1,44 @@
+package shipping
+
+// --- Request DTOs ---
+type RegionRequest struct {
+	Country string `json:"country" validate:"required,len=2,alpha"`
+	State   string `json:"state,omitempty" validate:"omitempty,max=50"`
+}
+
+type CreateZoneRequest struct {
+	Name     string          `json:"name" validate:"required,min=2,max=100"`
+	IsActive *bool           `json:"is_active,omitempty"`
+	Regions  []RegionRequest `json:"regions" validate:"required,min=1,dive"`
+}
+
+type UpdateZoneRequest struct {
+	Name     *string          `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	IsActive *bool            `json:"is_active,omitempty"`
+	Regions  *[]RegionRequest `json:"regions,omitempty" validate:"omitempty,min=1,dive"`
+}
+
+type TierRequest struct {
+	UpTo  *int64 `json:"up_to,omitempty" validate:"omitempty,gt=0"`
+	Cents int64  `json:"cents" validate:"min=0"`
+}
+
+type CreateMethodRequest struct {
+	Name          string        `json:"name" validate:"required,min=2,max=100"`
+	RateType      string        `json:"rate_type" validate:"required,oneof=FLAT WEIGHT_TIERED PRICE_TIERED"`
+	FlatCents     int64         `json:"flat_cents" validate:"min=0"`
+	Tiers         []TierRequest `json:"tiers,omitempty" validate:"omitempty,dive"`
+	FreeOverCents *int64        `json:"free_over_cents,omitempty" validate:"omitempty,min=0"`
+	IsActive      *bool         `json:"is_active,omitempty"`
+}
+
+type UpdateMethodRequest struct {
+	Name          *string        `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	RateType      *string        `json:"rate_type,omitempty" validate:"omitempty,oneof=FLAT WEIGHT_TIERED PRICE_TIERED"`
+	FlatCents     *int64         `json:"flat_cents,omitempty" validate:"omitempty,min=0"`
+	Tiers         *[]TierRequest `json:"tiers,omitempty" validate:"omitempty,dive"`
+	FreeOverCents *int64         `json:"free_over_cents,omitempty" validate:"omitempty,min=0"`
+	// ClearFreeOver removes the free-shipping threshold
+	ClearFreeOver bool  `json:"clear_free_over,omitempty"`
+	IsActive      *bool `json:"is_active,omitempty"`
+}
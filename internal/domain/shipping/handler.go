@@ -0,0 +1,146 @@
+package shipping
+
+import (
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/internal/pkg/validator"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// Quote lists the shipping methods, with prices, for the user's cart sent to
+// the address given by the country, state and postal_code query parameters.
+func (h *Handler) Quote(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	query := r.URL.Query()
+
+	addr := Address{
+		Country:    query.Get("country"),
+		State:      query.Get("state"),
+		PostalCode: query.Get("postal_code"),
+	}
+
+	quote, appErr := h.svc.QuoteCart(r.Context(), userID, addr)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, quote, "Shipping quote calculated successfully")
+}
+
+func (h *Handler) CreateZone(w http.ResponseWriter, r *http.Request) {
+	req := validator.GetValidatedBody[CreateZoneRequest](r)
+
+	zone, appErr := h.svc.CreateZone(r.Context(), req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, zone, "Shipping zone created successfully")
+}
+
+func (h *Handler) ListZones(w http.ResponseWriter, r *http.Request) {
+	zones, appErr := h.svc.ListZones(r.Context())
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, zones, "Shipping zones fetched successfully")
+}
+
+func (h *Handler) GetZone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	zone, appErr := h.svc.GetZone(r.Context(), id)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, zone, "Shipping zone fetched successfully")
+}
+
+func (h *Handler) UpdateZone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[UpdateZoneRequest](r)
+
+	zone, appErr := h.svc.UpdateZone(r.Context(), id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, zone, "Shipping zone updated successfully")
+}
+
+func (h *Handler) DeleteZone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if appErr := h.svc.DeleteZone(r.Context(), id); appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+func (h *Handler) CreateMethod(w http.ResponseWriter, r *http.Request) {
+	zoneID := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[CreateMethodRequest](r)
+
+	method, appErr := h.svc.CreateMethod(r.Context(), zoneID, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, method, "Shipping method created successfully")
+}
+
+func (h *Handler) ListMethods(w http.ResponseWriter, r *http.Request) {
+	zoneID := chi.URLParam(r, "id")
+
+	methods, appErr := h.svc.ListMethods(r.Context(), zoneID)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, methods, "Shipping methods fetched successfully")
+}
+
+func (h *Handler) UpdateMethod(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[UpdateMethodRequest](r)
+
+	method, appErr := h.svc.UpdateMethod(r.Context(), id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, method, "Shipping method updated successfully")
+}
+
+func (h *Handler) DeleteMethod(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if appErr := h.svc.DeleteMethod(r.Context(), id); appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.NoContent(w)
+}
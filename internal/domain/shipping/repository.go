@@ -0,0 +1,337 @@
+package shipping
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+
+	"ecommerce-app/internal/pkg/database"
+	"ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Repository interface {
+	CreateZone(ctx context.Context, zone Zone) (Zone, error)
+	GetZoneByID(ctx context.Context, id string) (Zone, error)
+	ListZones(ctx context.Context) ([]Zone, error)
+	UpdateZone(ctx context.Context, zone Zone) (Zone, error)
+	ReplaceZoneRegions(ctx context.Context, zoneID string, regions []Region) ([]Region, error)
+	DeleteZone(ctx context.Context, id string) error
+	FindZoneForAddress(ctx context.Context, addr Address) (Zone, error)
+	CreateMethod(ctx context.Context, method Method) (Method, error)
+	GetMethodByID(ctx context.Context, id string) (Method, error)
+	ListMethodsByZone(ctx context.Context, zoneID string, activeOnly bool) ([]Method, error)
+	UpdateMethod(ctx context.Context, method Method) (Method, error)
+	DeleteMethod(ctx context.Context, id string) error
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// repository implements Repository
+type repository struct {
+	q  *sqlc.Queries
+	db database.Transactor
+}
+
+func NewRepository(q *sqlc.Queries, db database.Transactor) Repository {
+	return &repository{q: q, db: db}
+}
+
+// WithTx runs fn in a single transaction; every repository call made with
+// the ctx passed to fn takes part in it.
+func (r *repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithTx(ctx, r.db, fn)
+}
+
+func (r *repository) queries(ctx context.Context) *sqlc.Queries {
+	return database.Queries(ctx, r.q)
+}
+
+// CreateZone stores zone along with its regions.
+func (r *repository) CreateZone(ctx context.Context, zone Zone) (Zone, error) {
+	row, err := r.queries(ctx).CreateShippingZone(ctx, sqlc.CreateShippingZoneParams{
+		Name:     zone.Name,
+		IsActive: zone.IsActive,
+	})
+	if err != nil {
+		return Zone{}, err
+	}
+
+	created := mapZone(row)
+	created.Regions, err = r.ReplaceZoneRegions(ctx, created.ID.String(), zone.Regions)
+	if err != nil {
+		return Zone{}, err
+	}
+
+	return created, nil
+}
+
+func (r *repository) GetZoneByID(ctx context.Context, id string) (Zone, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Zone{}, err
+	}
+
+	row, err := r.queries(ctx).GetShippingZone(ctx, uuidID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Zone{}, errs.ErrNotFound
+		}
+		return Zone{}, err
+	}
+
+	return r.withRegions(ctx, row)
+}
+
+func (r *repository) ListZones(ctx context.Context) ([]Zone, error) {
+	rows, err := r.queries(ctx).ListShippingZones(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	zones := make([]Zone, len(rows))
+	for i, row := range rows {
+		zone, err := r.withRegions(ctx, row)
+		if err != nil {
+			return nil, err
+		}
+		zones[i] = zone
+	}
+
+	return zones, nil
+}
+
+func (r *repository) UpdateZone(ctx context.Context, zone Zone) (Zone, error) {
+	row, err := r.queries(ctx).UpdateShippingZone(ctx, sqlc.UpdateShippingZoneParams{
+		ID:       pgtype.UUID{Bytes: zone.ID, Valid: true},
+		Name:     zone.Name,
+		IsActive: zone.IsActive,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Zone{}, errs.ErrNotFound
+		}
+		return Zone{}, err
+	}
+
+	return r.withRegions(ctx, row)
+}
+
+// ReplaceZoneRegions swaps the regions of a zone for regions.
+func (r *repository) ReplaceZoneRegions(ctx context.Context, zoneID string, regions []Region) ([]Region, error) {
+	var zoneUUID pgtype.UUID
+	if err := zoneUUID.Scan(zoneID); err != nil {
+		return nil, err
+	}
+
+	if err := r.queries(ctx).DeleteShippingZoneRegions(ctx, zoneUUID); err != nil {
+		return nil, err
+	}
+
+	created := make([]Region, 0, len(regions))
+	for _, region := range regions {
+		row, err := r.queries(ctx).CreateShippingZoneRegion(ctx, sqlc.CreateShippingZoneRegionParams{
+			ZoneID:  zoneUUID,
+			Country: region.Country,
+			State:   region.State,
+		})
+		if err != nil {
+			return nil, err
+		}
+		created = append(created, mapRegion(row))
+	}
+
+	return created, nil
+}
+
+func (r *repository) DeleteZone(ctx context.Context, id string) error {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return err
+	}
+
+	return r.queries(ctx).DeleteShippingZone(ctx, uuidID)
+}
+
+// FindZoneForAddress returns the active zone covering addr, or
+// errs.ErrNotFound when there is none.
+func (r *repository) FindZoneForAddress(ctx context.Context, addr Address) (Zone, error) {
+	row, err := r.queries(ctx).FindShippingZoneForAddress(ctx, sqlc.FindShippingZoneForAddressParams{
+		Country: addr.Country,
+		State:   addr.State,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Zone{}, errs.ErrNotFound
+		}
+		return Zone{}, err
+	}
+
+	return mapZone(row), nil
+}
+
+func (r *repository) CreateMethod(ctx context.Context, method Method) (Method, error) {
+	tiers, err := json.Marshal(method.Tiers)
+	if err != nil {
+		return Method{}, err
+	}
+
+	row, err := r.queries(ctx).CreateShippingMethod(ctx, sqlc.CreateShippingMethodParams{
+		ZoneID:        pgtype.UUID{Bytes: method.ZoneID, Valid: true},
+		Name:          method.Name,
+		RateType:      method.RateType,
+		FlatCents:     method.FlatCents,
+		Tiers:         tiers,
+		FreeOverCents: int8Ptr(method.FreeOverCents),
+		IsActive:      method.IsActive,
+	})
+	if err != nil {
+		return Method{}, err
+	}
+
+	return mapMethod(row), nil
+}
+
+func (r *repository) GetMethodByID(ctx context.Context, id string) (Method, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Method{}, err
+	}
+
+	row, err := r.queries(ctx).GetShippingMethod(ctx, uuidID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Method{}, errs.ErrNotFound
+		}
+		return Method{}, err
+	}
+
+	return mapMethod(row), nil
+}
+
+func (r *repository) ListMethodsByZone(ctx context.Context, zoneID string, activeOnly bool) ([]Method, error) {
+	var zoneUUID pgtype.UUID
+	if err := zoneUUID.Scan(zoneID); err != nil {
+		return nil, err
+	}
+
+	var rows []sqlc.ShippingMethod
+	var err error
+	if activeOnly {
+		rows, err = r.queries(ctx).ListActiveShippingMethodsByZone(ctx, zoneUUID)
+	} else {
+		rows, err = r.queries(ctx).ListShippingMethodsByZone(ctx, zoneUUID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	methods := make([]Method, len(rows))
+	for i, row := range rows {
+		methods[i] = mapMethod(row)
+	}
+
+	return methods, nil
+}
+
+func (r *repository) UpdateMethod(ctx context.Context, method Method) (Method, error) {
+	tiers, err := json.Marshal(method.Tiers)
+	if err != nil {
+		return Method{}, err
+	}
+
+	row, err := r.queries(ctx).UpdateShippingMethod(ctx, sqlc.UpdateShippingMethodParams{
+		ID:            pgtype.UUID{Bytes: method.ID, Valid: true},
+		Name:          method.Name,
+		RateType:      method.RateType,
+		FlatCents:     method.FlatCents,
+		Tiers:         tiers,
+		FreeOverCents: int8Ptr(method.FreeOverCents),
+		IsActive:      method.IsActive,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Method{}, errs.ErrNotFound
+		}
+		return Method{}, err
+	}
+
+	return mapMethod(row), nil
+}
+
+func (r *repository) DeleteMethod(ctx context.Context, id string) error {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return err
+	}
+
+	return r.queries(ctx).DeleteShippingMethod(ctx, uuidID)
+}
+
+func (r *repository) withRegions(ctx context.Context, row sqlc.ShippingZone) (Zone, error) {
+	regionRows, err := r.queries(ctx).ListShippingZoneRegions(ctx, row.ID)
+	if err != nil {
+		return Zone{}, err
+	}
+
+	zone := mapZone(row)
+	for _, regionRow := range regionRows {
+		zone.Regions = append(zone.Regions, mapRegion(regionRow))
+	}
+
+	return zone, nil
+}
+
+func mapZone(row sqlc.ShippingZone) Zone {
+	return Zone{
+		ID:        uuid.UUID(row.ID.Bytes),
+		Name:      row.Name,
+		IsActive:  row.IsActive,
+		Regions:   []Region{},
+		CreatedAt: row.CreatedAt.Time,
+		UpdatedAt: row.UpdatedAt.Time,
+	}
+}
+
+func mapRegion(row sqlc.ShippingZoneRegion) Region {
+	return Region{
+		Country: row.Country,
+		State:   row.State,
+	}
+}
+
+func mapMethod(row sqlc.ShippingMethod) Method {
+	tiers := []Tier{}
+	if err := json.Unmarshal(row.Tiers, &tiers); err != nil {
+		tiers = []Tier{}
+	}
+
+	var freeOver *int64
+	if row.FreeOverCents.Valid {
+		v := row.FreeOverCents.Int64
+		freeOver = &v
+	}
+
+	return Method{
+		ID:            uuid.UUID(row.ID.Bytes),
+		ZoneID:        uuid.UUID(row.ZoneID.Bytes),
+		Name:          row.Name,
+		RateType:      row.RateType,
+		FlatCents:     row.FlatCents,
+		Tiers:         tiers,
+		FreeOverCents: freeOver,
+		IsActive:      row.IsActive,
+		CreatedAt:     row.CreatedAt.Time,
+		UpdatedAt:     row.UpdatedAt.Time,
+	}
+}
+
+func int8Ptr(v *int64) pgtype.Int8 {
+	if v == nil {
+		return pgtype.Int8{}
+	}
+	return pgtype.Int8{Int64: *v, Valid: true}
+}
@@ -0,0 +1,28 @@
+package shipping
+
+import (
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes(svc Service) chi.Router {
+	h := NewHandler(svc)
+	r := chi.NewRouter()
+
+	r.With(middleware.RoleMiddleware("customer")).Get("/quote", h.Quote)
+
+	r.With(validator.Validate[CreateZoneRequest]()).With(middleware.RoleMiddleware("admin")).Post("/zones", h.CreateZone)
+	r.With(middleware.RoleMiddleware("admin")).Get("/zones", h.ListZones)
+	r.With(middleware.RoleMiddleware("admin")).Get("/zones/{id}", h.GetZone)
+	r.With(validator.Validate[UpdateZoneRequest]()).With(middleware.RoleMiddleware("admin")).Put("/zones/{id}", h.UpdateZone)
+	r.With(middleware.RoleMiddleware("admin")).Delete("/zones/{id}", h.DeleteZone)
+
+	r.With(validator.Validate[CreateMethodRequest]()).With(middleware.RoleMiddleware("admin")).Post("/zones/{id}/methods", h.CreateMethod)
+	r.With(middleware.RoleMiddleware("admin")).Get("/zones/{id}/methods", h.ListMethods)
+	r.With(validator.Validate[UpdateMethodRequest]()).With(middleware.RoleMiddleware("admin")).Put("/methods/{id}", h.UpdateMethod)
+	r.With(middleware.RoleMiddleware("admin")).Delete("/methods/{id}", h.DeleteMethod)
+
+	return r
+}
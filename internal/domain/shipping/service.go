@@ -0,0 +1,477 @@
+package shipping
+
+import (
+	"context"
+	"ecommerce-app/internal/pkg/database"
+	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/logger"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type Service interface {
+	CreateZone(ctx context.Context, req CreateZoneRequest) (Zone, *errs.AppError)
+	GetZone(ctx context.Context, id string) (Zone, *errs.AppError)
+	ListZones(ctx context.Context) ([]Zone, *errs.AppError)
+	UpdateZone(ctx context.Context, id string, req UpdateZoneRequest) (Zone, *errs.AppError)
+	DeleteZone(ctx context.Context, id string) *errs.AppError
+	CreateMethod(ctx context.Context, zoneID string, req CreateMethodRequest) (Method, *errs.AppError)
+	ListMethods(ctx context.Context, zoneID string) ([]Method, *errs.AppError)
+	UpdateMethod(ctx context.Context, id string, req UpdateMethodRequest) (Method, *errs.AppError)
+	DeleteMethod(ctx context.Context, id string) *errs.AppError
+	QuoteCart(ctx context.Context, userID string, addr Address) (CartQuote, *errs.AppError)
+	QuoteMethod(ctx context.Context, methodID string, addr Address, parcel Parcel) (Quote, *errs.AppError)
+}
+
+type service struct {
+	repo        Repository
+	productSvc  ProductProvider
+	cartSvc     CartProvider
+	cartItemSvc CartItemProvider
+}
+
+func NewService(repo Repository, productSvc ProductProvider, cartSvc CartProvider, cartItemSvc CartItemProvider) Service {
+	return &service{repo: repo, productSvc: productSvc, cartSvc: cartSvc, cartItemSvc: cartItemSvc}
+}
+
+func (s *service) CreateZone(ctx context.Context, req CreateZoneRequest) (Zone, *errs.AppError) {
+	zone := Zone{
+		Name:     req.Name,
+		IsActive: true,
+		Regions:  regionsFromRequest(req.Regions),
+	}
+	if req.IsActive != nil {
+		zone.IsActive = *req.IsActive
+	}
+
+	var created Zone
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		z, err := s.repo.CreateZone(ctx, zone)
+		if err != nil {
+			return err
+		}
+		created = z
+		return nil
+	})
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return Zone{}, errs.ErrConflict.WithMessage("Zone name or one of its regions is already in use")
+		}
+		logger.Error("Failed to create shipping zone: %v", err)
+		return Zone{}, errs.ErrInternal.WithMessage("Failed to create shipping zone")
+	}
+
+	return created, nil
+}
+
+func (s *service) GetZone(ctx context.Context, id string) (Zone, *errs.AppError) {
+	zone, err := s.repo.GetZoneByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Zone{}, errs.ErrNotFound.WithMessage("Shipping zone not found")
+		}
+		return Zone{}, errs.ErrInternal.WithMessage("Failed to get shipping zone")
+	}
+
+	return zone, nil
+}
+
+func (s *service) ListZones(ctx context.Context) ([]Zone, *errs.AppError) {
+	zones, err := s.repo.ListZones(ctx)
+	if err != nil {
+		return nil, errs.ErrInternal.WithMessage("Failed to get shipping zones")
+	}
+
+	return zones, nil
+}
+
+func (s *service) UpdateZone(ctx context.Context, id string, req UpdateZoneRequest) (Zone, *errs.AppError) {
+	zone, appErr := s.GetZone(ctx, id)
+	if appErr != nil {
+		return Zone{}, appErr
+	}
+
+	if req.Name != nil {
+		zone.Name = *req.Name
+	}
+	if req.IsActive != nil {
+		zone.IsActive = *req.IsActive
+	}
+
+	var updated Zone
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		z, err := s.repo.UpdateZone(ctx, zone)
+		if err != nil {
+			return err
+		}
+
+		if req.Regions != nil {
+			z.Regions, err = s.repo.ReplaceZoneRegions(ctx, id, regionsFromRequest(*req.Regions))
+			if err != nil {
+				return err
+			}
+		}
+
+		updated = z
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Zone{}, errs.ErrNotFound.WithMessage("Shipping zone not found")
+		}
+		if database.IsUniqueViolation(err) {
+			return Zone{}, errs.ErrConflict.WithMessage("Zone name or one of its regions is already in use")
+		}
+		logger.Error("Failed to update shipping zone %s: %v", id, err)
+		return Zone{}, errs.ErrInternal.WithMessage("Failed to update shipping zone")
+	}
+
+	return updated, nil
+}
+
+func (s *service) DeleteZone(ctx context.Context, id string) *errs.AppError {
+	if _, appErr := s.GetZone(ctx, id); appErr != nil {
+		return appErr
+	}
+
+	if err := s.repo.DeleteZone(ctx, id); err != nil {
+		return errs.ErrInternal.WithMessage("Failed to delete shipping zone")
+	}
+
+	return nil
+}
+
+func (s *service) CreateMethod(ctx context.Context, zoneID string, req CreateMethodRequest) (Method, *errs.AppError) {
+	zone, appErr := s.GetZone(ctx, zoneID)
+	if appErr != nil {
+		return Method{}, appErr
+	}
+
+	method := Method{
+		ZoneID:        zone.ID,
+		Name:          req.Name,
+		RateType:      req.RateType,
+		FlatCents:     req.FlatCents,
+		Tiers:         tiersFromRequest(req.Tiers),
+		FreeOverCents: req.FreeOverCents,
+		IsActive:      true,
+	}
+	if req.IsActive != nil {
+		method.IsActive = *req.IsActive
+	}
+
+	if appErr := validateMethod(method); appErr != nil {
+		return Method{}, appErr
+	}
+
+	created, err := s.repo.CreateMethod(ctx, method)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return Method{}, errs.ErrConflict.WithMessage("A shipping method with this name already exists in the zone")
+		}
+		logger.Error("Failed to create shipping method for zone %s: %v", zoneID, err)
+		return Method{}, errs.ErrInternal.WithMessage("Failed to create shipping method")
+	}
+
+	return created, nil
+}
+
+func (s *service) ListMethods(ctx context.Context, zoneID string) ([]Method, *errs.AppError) {
+	if _, appErr := s.GetZone(ctx, zoneID); appErr != nil {
+		return nil, appErr
+	}
+
+	methods, err := s.repo.ListMethodsByZone(ctx, zoneID, false)
+	if err != nil {
+		return nil, errs.ErrInternal.WithMessage("Failed to get shipping methods")
+	}
+
+	return methods, nil
+}
+
+func (s *service) UpdateMethod(ctx context.Context, id string, req UpdateMethodRequest) (Method, *errs.AppError) {
+	method, appErr := s.getMethod(ctx, id)
+	if appErr != nil {
+		return Method{}, appErr
+	}
+
+	if req.Name != nil {
+		method.Name = *req.Name
+	}
+	if req.RateType != nil {
+		method.RateType = *req.RateType
+	}
+	if req.FlatCents != nil {
+		method.FlatCents = *req.FlatCents
+	}
+	if req.Tiers != nil {
+		method.Tiers = tiersFromRequest(*req.Tiers)
+	}
+	if req.FreeOverCents != nil {
+		method.FreeOverCents = req.FreeOverCents
+	}
+	if req.ClearFreeOver {
+		method.FreeOverCents = nil
+	}
+	if req.IsActive != nil {
+		method.IsActive = *req.IsActive
+	}
+
+	if appErr := validateMethod(method); appErr != nil {
+		return Method{}, appErr
+	}
+
+	updated, err := s.repo.UpdateMethod(ctx, method)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Method{}, errs.ErrNotFound.WithMessage("Shipping method not found")
+		}
+		if database.IsUniqueViolation(err) {
+			return Method{}, errs.ErrConflict.WithMessage("A shipping method with this name already exists in the zone")
+		}
+		logger.Error("Failed to update shipping method %s: %v", id, err)
+		return Method{}, errs.ErrInternal.WithMessage("Failed to update shipping method")
+	}
+
+	return updated, nil
+}
+
+func (s *service) DeleteMethod(ctx context.Context, id string) *errs.AppError {
+	if _, appErr := s.getMethod(ctx, id); appErr != nil {
+		return appErr
+	}
+
+	if err := s.repo.DeleteMethod(ctx, id); err != nil {
+		return errs.ErrInternal.WithMessage("Failed to delete shipping method")
+	}
+
+	return nil
+}
+
+// QuoteCart prices every active method of the zone covering addr for the
+// user's active cart. Methods whose tiers don't cover the cart are left out.
+func (s *service) QuoteCart(ctx context.Context, userID string, addr Address) (CartQuote, *errs.AppError) {
+	parcel, appErr := s.cartParcel(ctx, userID)
+	if appErr != nil {
+		return CartQuote{}, appErr
+	}
+
+	zone, appErr := s.zoneFor(ctx, addr)
+	if appErr != nil {
+		return CartQuote{}, appErr
+	}
+
+	methods, err := s.repo.ListMethodsByZone(ctx, zone.ID.String(), true)
+	if err != nil {
+		return CartQuote{}, errs.ErrInternal.WithMessage("Failed to get shipping methods")
+	}
+
+	quote := CartQuote{
+		ZoneID:   zone.ID,
+		ZoneName: zone.Name,
+		Parcel:   parcel,
+		Methods:  []Quote{},
+	}
+	for _, method := range methods {
+		if q, ok := method.Quote(parcel); ok {
+			quote.Methods = append(quote.Methods, q)
+		}
+	}
+
+	return quote, nil
+}
+
+// QuoteMethod prices parcel with the chosen method, checking that the method
+// is active and ships to addr.
+func (s *service) QuoteMethod(ctx context.Context, methodID string, addr Address, parcel Parcel) (Quote, *errs.AppError) {
+	method, err := s.repo.GetMethodByID(ctx, methodID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Quote{}, errs.ErrBadRequest.WithMessage("Shipping method not found")
+		}
+		return Quote{}, errs.ErrInternal.WithMessage("Failed to get shipping method")
+	}
+
+	zone, appErr := s.zoneFor(ctx, addr)
+	if appErr != nil {
+		return Quote{}, appErr
+	}
+
+	if !method.IsActive || method.ZoneID != zone.ID {
+		return Quote{}, errs.ErrBadRequest.WithMessage(fmt.Sprintf("Shipping method %s is not available for this address", method.Name))
+	}
+
+	quote, ok := method.Quote(parcel)
+	if !ok {
+		return Quote{}, errs.ErrBadRequest.WithMessage(fmt.Sprintf("Shipping method %s is not available for this order", method.Name))
+	}
+
+	return quote, nil
+}
+
+func (s *service) getMethod(ctx context.Context, id string) (Method, *errs.AppError) {
+	method, err := s.repo.GetMethodByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Method{}, errs.ErrNotFound.WithMessage("Shipping method not found")
+		}
+		return Method{}, errs.ErrInternal.WithMessage("Failed to get shipping method")
+	}
+
+	return method, nil
+}
+
+func (s *service) zoneFor(ctx context.Context, addr Address) (Zone, *errs.AppError) {
+	addr.Country = normalizeRegion(addr.Country)
+	addr.State = normalizeRegion(addr.State)
+	if addr.Country == "" {
+		return Zone{}, errs.ErrBadRequest.WithMessage("Shipping address must include a country")
+	}
+
+	zone, err := s.repo.FindZoneForAddress(ctx, addr)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Zone{}, errs.ErrBadRequest.WithMessage("We don't ship to this address")
+		}
+		return Zone{}, errs.ErrInternal.WithMessage("Failed to find shipping zone")
+	}
+
+	return zone, nil
+}
+
+// cartParcel weighs and prices the user's active cart.
+func (s *service) cartParcel(ctx context.Context, userID string) (Parcel, *errs.AppError) {
+	c, appErr := s.cartSvc.GetCartByUserID(ctx, userID)
+	if appErr != nil {
+		if errors.Is(appErr, errs.ErrNotFound) {
+			return Parcel{}, errs.ErrBadRequest.WithMessage("No active cart found, it may have expired")
+		}
+		return Parcel{}, appErr
+	}
+
+	cartItems, appErr := s.cartItemSvc.GetItemsByUserID(ctx, userID)
+	if appErr != nil {
+		return Parcel{}, appErr
+	}
+
+	var parcel Parcel
+	count := 0
+	for _, ci := range cartItems {
+		if ci.CartID != c.ID {
+			continue
+		}
+
+		prod, appErr := s.productSvc.GetProductByID(ctx, ci.ProductID.String())
+		if appErr != nil {
+			return Parcel{}, appErr
+		}
+
+		parcel.WeightGrams += int64(ci.Quantity) * int64(prod.WeightGrams)
+		parcel.SubtotalCents += int64(ci.Quantity) * int64(prod.PriceCents)
+		count++
+	}
+
+	if count == 0 {
+		return Parcel{}, errs.ErrBadRequest.WithMessage("Cart is empty")
+	}
+
+	return parcel, nil
+}
+
+// Quote prices parcel with this method. It reports false when the method is
+// tiered and no tier covers the parcel.
+func (m Method) Quote(parcel Parcel) (Quote, bool) {
+	quote := Quote{
+		MethodID: m.ID,
+		Name:     m.Name,
+		RateType: m.RateType,
+	}
+
+	if m.FreeOverCents != nil && parcel.SubtotalCents >= *m.FreeOverCents {
+		quote.IsFree = true
+		return quote, true
+	}
+
+	switch m.RateType {
+	case RateFlat:
+		quote.CostCents = m.FlatCents
+	case RateWeightTiered, RatePriceTiered:
+		measure := parcel.WeightGrams
+		if m.RateType == RatePriceTiered {
+			measure = parcel.SubtotalCents
+		}
+
+		cents, ok := tierFor(m.Tiers, measure)
+		if !ok {
+			return Quote{}, false
+		}
+		quote.CostCents = cents
+	default:
+		return Quote{}, false
+	}
+
+	quote.IsFree = quote.CostCents == 0
+	return quote, true
+}
+
+// tierFor returns the price of the first tier covering measure.
+func tierFor(tiers []Tier, measure int64) (int64, bool) {
+	for _, tier := range tiers {
+		if tier.UpTo == nil || measure <= *tier.UpTo {
+			return tier.Cents, true
+		}
+	}
+	return 0, false
+}
+
+// validateMethod checks that tiered methods have tiers in ascending order,
+// with only the last one left open-ended.
+func validateMethod(m Method) *errs.AppError {
+	if m.RateType == RateFlat {
+		return nil
+	}
+
+	if len(m.Tiers) == 0 {
+		return errs.ErrBadRequest.WithMessage(fmt.Sprintf("%s methods need at least one tier", m.RateType))
+	}
+
+	var prev int64
+	for i, tier := range m.Tiers {
+		if tier.UpTo == nil {
+			if i != len(m.Tiers)-1 {
+				return errs.ErrBadRequest.WithMessage("Only the last tier may leave up_to empty")
+			}
+			continue
+		}
+		if *tier.UpTo <= prev {
+			return errs.ErrBadRequest.WithMessage("Tier up_to values must be in ascending order")
+		}
+		prev = *tier.UpTo
+	}
+
+	return nil
+}
+
+func regionsFromRequest(reqs []RegionRequest) []Region {
+	regions := make([]Region, len(reqs))
+	for i, req := range reqs {
+		regions[i] = Region{
+			Country: normalizeRegion(req.Country),
+			State:   normalizeRegion(req.State),
+		}
+	}
+	return regions
+}
+
+func tiersFromRequest(reqs []TierRequest) []Tier {
+	tiers := make([]Tier, len(reqs))
+	for i, req := range reqs {
+		tiers[i] = Tier{UpTo: req.UpTo, Cents: req.Cents}
+	}
+	return tiers
+}
+
+func normalizeRegion(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
@@ -0,0 +1,61 @@
+package shipping
+
+import "testing"
+
+func upTo(v int64) *int64 {
+	return &v
+}
+
+func TestMethodQuote(t *testing.T) {
+	weightTiers := []Tier{
+		{UpTo: upTo(500), Cents: 400},
+		{UpTo: upTo(2000), Cents: 900},
+		{Cents: 1500},
+	}
+	priceTiers := []Tier{
+		{UpTo: upTo(2500), Cents: 700},
+		{UpTo: upTo(10000), Cents: 300},
+	}
+
+	tests := []struct {
+		name          string
+		method        Method
+		parcel        Parcel
+		wantOK        bool
+		wantCostCents int64
+		wantFree      bool
+	}{
+		{name: "flat", method: Method{RateType: RateFlat, FlatCents: 599}, parcel: Parcel{WeightGrams: 9000, SubtotalCents: 100}, wantOK: true, wantCostCents: 599},
+		{name: "flat at zero is free", method: Method{RateType: RateFlat}, wantOK: true, wantFree: true},
+		{name: "weight in first tier", method: Method{RateType: RateWeightTiered, Tiers: weightTiers}, parcel: Parcel{WeightGrams: 200}, wantOK: true, wantCostCents: 400},
+		{name: "weight on a tier bound", method: Method{RateType: RateWeightTiered, Tiers: weightTiers}, parcel: Parcel{WeightGrams: 500}, wantOK: true, wantCostCents: 400},
+		{name: "weight just over a tier bound", method: Method{RateType: RateWeightTiered, Tiers: weightTiers}, parcel: Parcel{WeightGrams: 501}, wantOK: true, wantCostCents: 900},
+		{name: "weight in open last tier", method: Method{RateType: RateWeightTiered, Tiers: weightTiers}, parcel: Parcel{WeightGrams: 50000}, wantOK: true, wantCostCents: 1500},
+		{name: "weight tiers ignore subtotal", method: Method{RateType: RateWeightTiered, Tiers: weightTiers}, parcel: Parcel{WeightGrams: 100, SubtotalCents: 1000000}, wantOK: true, wantCostCents: 400},
+		{name: "price in first tier", method: Method{RateType: RatePriceTiered, Tiers: priceTiers}, parcel: Parcel{SubtotalCents: 2500}, wantOK: true, wantCostCents: 700},
+		{name: "price in second tier", method: Method{RateType: RatePriceTiered, Tiers: priceTiers}, parcel: Parcel{SubtotalCents: 2501}, wantOK: true, wantCostCents: 300},
+		{name: "price above every tier", method: Method{RateType: RatePriceTiered, Tiers: priceTiers}, parcel: Parcel{SubtotalCents: 10001}},
+		{name: "free over threshold", method: Method{RateType: RateWeightTiered, Tiers: weightTiers, FreeOverCents: upTo(5000)}, parcel: Parcel{WeightGrams: 50000, SubtotalCents: 5000}, wantOK: true, wantFree: true},
+		{name: "below free threshold", method: Method{RateType: RateFlat, FlatCents: 599, FreeOverCents: upTo(5000)}, parcel: Parcel{SubtotalCents: 4999}, wantOK: true, wantCostCents: 599},
+		{name: "free threshold covers missing tier", method: Method{RateType: RatePriceTiered, Tiers: priceTiers, FreeOverCents: upTo(10000)}, parcel: Parcel{SubtotalCents: 10001}, wantOK: true, wantFree: true},
+		{name: "unknown rate type", method: Method{RateType: "BY_DISTANCE", FlatCents: 599}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote, ok := tt.method.Quote(tt.parcel)
+			if ok != tt.wantOK {
+				t.Fatalf("Quote ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if quote.CostCents != tt.wantCostCents {
+				t.Errorf("cost %d, want %d", quote.CostCents, tt.wantCostCents)
+			}
+			if quote.IsFree != tt.wantFree {
+				t.Errorf("free %v, want %v", quote.IsFree, tt.wantFree)
+			}
+		})
+	}
+}
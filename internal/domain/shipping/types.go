@@ -0,0 +1,104 @@
+package shipping
+
+import (
+	"context"
+	"ecommerce-app/internal/domain/cart"
+	"ecommerce-app/internal/domain/cartitem"
+	"ecommerce-app/internal/domain/product"
+	"ecommerce-app/internal/pkg/errs"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rate types. A FLAT method always costs FlatCents; tiered methods charge
+// the first tier that covers the parcel's weight in grams or subtotal in
+// cents.
+const (
+	RateFlat         = "FLAT"
+	RateWeightTiered = "WEIGHT_TIERED"
+	RatePriceTiered  = "PRICE_TIERED"
+)
+
+// --- Domain Models ---
+
+// Zone groups the countries and states that share shipping methods.
+type Zone struct {
+	ID        uuid.UUID `json:"id"`
+	Name      string    `json:"name"`
+	IsActive  bool      `json:"is_active"`
+	Regions   []Region  `json:"regions"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Region is a country, or one state of it when State is set.
+type Region struct {
+	Country string `json:"country"`
+	State   string `json:"state,omitempty"`
+}
+
+// Tier charges Cents for parcels up to and including UpTo. A nil UpTo
+// covers everything above the previous tier.
+type Tier struct {
+	UpTo  *int64 `json:"up_to"`
+	Cents int64  `json:"cents"`
+}
+
+// Method is a way of shipping to a zone. Parcels whose subtotal reaches
+// FreeOverCents ship free whatever the rate type.
+type Method struct {
+	ID            uuid.UUID `json:"id"`
+	ZoneID        uuid.UUID `json:"zone_id"`
+	Name          string    `json:"name"`
+	RateType      string    `json:"rate_type"`
+	FlatCents     int64     `json:"flat_cents"`
+	Tiers         []Tier    `json:"tiers"`
+	FreeOverCents *int64    `json:"free_over_cents,omitempty"`
+	IsActive      bool      `json:"is_active"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Address is the part of a shipping address that decides the zone.
+type Address struct {
+	Country    string `json:"country"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+}
+
+// Parcel is what is being shipped, as far as pricing is concerned.
+type Parcel struct {
+	WeightGrams   int64 `json:"weight_grams"`
+	SubtotalCents int64 `json:"subtotal_cents"`
+}
+
+// Quote is the price of shipping a parcel with one method.
+type Quote struct {
+	MethodID  uuid.UUID `json:"method_id"`
+	Name      string    `json:"name"`
+	RateType  string    `json:"rate_type"`
+	CostCents int64     `json:"cost_cents"`
+	IsFree    bool      `json:"is_free"`
+}
+
+// CartQuote lists the methods available for a cart shipped to an address.
+type CartQuote struct {
+	ZoneID   uuid.UUID `json:"zone_id"`
+	ZoneName string    `json:"zone_name"`
+	Parcel   Parcel    `json:"parcel"`
+	Methods  []Quote   `json:"methods"`
+}
+
+// --- Dependency Injection Interface ---
+type ProductProvider interface {
+	GetProductByID(ctx context.Context, id string) (product.Product, *errs.AppError)
+}
+
+type CartProvider interface {
+	GetCartByUserID(ctx context.Context, userID string) (cart.Cart, *errs.AppError)
+}
+
+type CartItemProvider interface {
+	GetItemsByUserID(ctx context.Context, userID string) ([]cartitem.CartItem, *errs.AppError)
+}
@@ -2,69 +2,294 @@ package stripe
 
 import (
 	"context"
-	"ecommerce-app/configs"
+	"ecommerce-app/internal/domain/gateway"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/stripe/stripe-go/v83"
-	"github.com/stripe/stripe-go/v83/paymentintent"
 	"github.com/stripe/stripe-go/v83/webhook"
 )
 
-const ProviderName = "stripe"
+const ProviderName = "STRIPE"
 
-func NewStripeProvider() *StripeProvider {
-	cfg := configs.Load()
-	apiKey := cfg.StripeAPIKey
-	webhookSecret := cfg.StripeWebhookSecret
+// NewStripeProvider returns a Stripe gateway authenticated with apiKey.
+// Each provider carries its own client, so the global stripe.Key is left
+// alone. captureMethod is gateway.CaptureAutomatic or gateway.CaptureManual;
+// empty means automatic.
+func NewStripeProvider(apiKey, webhookSecret, captureMethod string) *StripeProvider {
+	if captureMethod == "" {
+		captureMethod = gateway.CaptureAutomatic
+	}
+
+	return &StripeProvider{
+		client:        stripe.NewClient(apiKey),
+		webhookSecret: webhookSecret,
+		captureMethod: captureMethod,
+	}
+}
+
+func (s *StripeProvider) Name() string {
+	return ProviderName
+}
+
+// CreateIntent creates a Stripe PaymentIntent
+func (s *StripeProvider) CreateIntent(ctx context.Context, req gateway.IntentRequest) (gateway.Intent, error) {
+	params := &stripe.PaymentIntentCreateParams{
+		Amount:   stripe.Int64(req.AmountCents),
+		Currency: stripe.String(strings.ToLower(req.Currency)),
+		Metadata: req.Metadata,
+	}
+	if s.captureMethod == gateway.CaptureManual {
+		params.CaptureMethod = stripe.String(string(stripe.PaymentIntentCaptureMethodManual))
+	}
+
+	intent, err := s.client.V1PaymentIntents.Create(ctx, params)
+	if err != nil {
+		return gateway.Intent{}, err
+	}
+
+	return mapIntent(intent), nil
+}
+
+// CaptureIntent captures an authorized PaymentIntent
+func (s *StripeProvider) CaptureIntent(ctx context.Context, intentID string, amountCents int64) (gateway.Intent, error) {
+	params := &stripe.PaymentIntentCaptureParams{}
+	if amountCents > 0 {
+		params.AmountToCapture = stripe.Int64(amountCents)
+	}
+
+	intent, err := s.client.V1PaymentIntents.Capture(ctx, intentID, params)
+	if err != nil {
+		return gateway.Intent{}, err
+	}
+
+	return mapIntent(intent), nil
+}
+
+// CancelIntent voids a PaymentIntent that has not been captured yet
+func (s *StripeProvider) CancelIntent(ctx context.Context, intentID string) (gateway.Intent, error) {
+	params := &stripe.PaymentIntentCancelParams{
+		CancellationReason: stripe.String(string(stripe.PaymentIntentCancellationReasonRequestedByCustomer)),
+	}
+
+	intent, err := s.client.V1PaymentIntents.Cancel(ctx, intentID, params)
+	if err != nil {
+		return gateway.Intent{}, err
+	}
+
+	return mapIntent(intent), nil
+}
 
-	stripe.Key = apiKey
-	return &StripeProvider{webhookSecret: webhookSecret}
+// GetIntent retrieves a PaymentIntent. A missing intent is reported as
+// gateway.ErrIntentNotFound.
+func (s *StripeProvider) GetIntent(ctx context.Context, intentID string) (gateway.Intent, error) {
+	intent, err := s.client.V1PaymentIntents.Retrieve(ctx, intentID, nil)
+	if err != nil {
+		var stripeErr *stripe.Error
+		if errors.As(err, &stripeErr) && stripeErr.Code == stripe.ErrorCodeResourceMissing {
+			return gateway.Intent{}, gateway.ErrIntentNotFound
+		}
+		return gateway.Intent{}, err
+	}
+
+	return mapIntent(intent), nil
+}
+
+// ListIntents pages through the PaymentIntents created in [from, to)
+func (s *StripeProvider) ListIntents(ctx context.Context, from, to time.Time) ([]gateway.Intent, error) {
+	params := &stripe.PaymentIntentListParams{
+		CreatedRange: &stripe.RangeQueryParams{
+			GreaterThanOrEqual: from.Unix(),
+			LesserThan:         to.Unix(),
+		},
+	}
+
+	intents := []gateway.Intent{}
+	for intent, err := range s.client.V1PaymentIntents.List(ctx, params) {
+		if err != nil {
+			return nil, err
+		}
+		intents = append(intents, mapIntent(intent))
+	}
+
+	return intents, nil
 }
 
-// CreatePaymentIntent creates a Stripe PaymentIntent
-func (s *StripeProvider) CreatePaymentIntent(ctx context.Context, amountCents int64, currency string, metadata map[string]string) (*stripe.PaymentIntent, error) {
-	params := &stripe.PaymentIntentParams{
-		Amount:   stripe.Int64(amountCents),
-		Currency: stripe.String(currency),
-		Metadata: metadata,
+// Refund refunds a captured PaymentIntent. An AmountCents of 0 refunds the
+// full captured amount.
+func (s *StripeProvider) Refund(ctx context.Context, req gateway.RefundRequest) (gateway.Refund, error) {
+	params := &stripe.RefundCreateParams{
+		PaymentIntent: stripe.String(req.IntentID),
+		Reason:        stripe.String(string(stripe.RefundReasonRequestedByCustomer)),
+		Metadata:      req.Metadata,
+	}
+	if req.AmountCents > 0 {
+		params.Amount = stripe.Int64(req.AmountCents)
 	}
 
-	intent, err := paymentintent.New(params)
+	re, err := s.client.V1Refunds.Create(ctx, params)
 	if err != nil {
-		return nil, err
+		return gateway.Refund{}, err
 	}
 
-	return intent, nil
+	refund := mapRefund(re)
+	if refund.IntentID == "" {
+		refund.IntentID = req.IntentID
+	}
+
+	return refund, nil
 }
 
-func (s *StripeProvider) HandleWebhook(payload []byte, sigHeader string) (*ProviderWebhookEvent, error) {
-	event, err := s.VerifyWebhookSignature(payload, sigHeader)
+// CreateCheckoutSession creates a hosted Stripe Checkout Session in payment
+// mode. The session's PaymentIntent carries the same metadata, so its own
+// webhooks resolve to the order as well.
+func (s *StripeProvider) CreateCheckoutSession(ctx context.Context, req gateway.CheckoutSessionRequest) (gateway.CheckoutSession, error) {
+	currency := strings.ToLower(req.Currency)
+	lineItems := make([]*stripe.CheckoutSessionCreateLineItemParams, len(req.Lines))
+	for i, line := range req.Lines {
+		lineItems[i] = &stripe.CheckoutSessionCreateLineItemParams{
+			Quantity: stripe.Int64(line.Quantity),
+			PriceData: &stripe.CheckoutSessionCreateLineItemPriceDataParams{
+				Currency:   stripe.String(currency),
+				UnitAmount: stripe.Int64(line.UnitAmountCents),
+				ProductData: &stripe.CheckoutSessionCreateLineItemPriceDataProductDataParams{
+					Name: stripe.String(line.Name),
+				},
+			},
+		}
+	}
+
+	params := &stripe.CheckoutSessionCreateParams{
+		Mode:              stripe.String(string(stripe.CheckoutSessionModePayment)),
+		SuccessURL:        stripe.String(req.SuccessURL),
+		CancelURL:         stripe.String(req.CancelURL),
+		ClientReferenceID: stripe.String(req.Metadata["order_id"]),
+		LineItems:         lineItems,
+		Metadata:          req.Metadata,
+		PaymentIntentData: &stripe.CheckoutSessionCreatePaymentIntentDataParams{
+			Metadata: req.Metadata,
+		},
+	}
+	if s.captureMethod == gateway.CaptureManual {
+		params.PaymentIntentData.CaptureMethod = stripe.String(string(stripe.PaymentIntentCaptureMethodManual))
+	}
+
+	session, err := s.client.V1CheckoutSessions.Create(ctx, params)
+	if err != nil {
+		return gateway.CheckoutSession{}, err
+	}
+
+	mapped := mapCheckoutSession(session)
+	mapped.CaptureMethod = s.captureMethod
+	return mapped, nil
+}
+
+// ExpireCheckoutSession expires an open Checkout Session so it can no
+// longer be paid
+func (s *StripeProvider) ExpireCheckoutSession(ctx context.Context, sessionID string) error {
+	_, err := s.client.V1CheckoutSessions.Expire(ctx, sessionID, nil)
+	return err
+}
+
+// ParseWebhook verifies the Stripe-Signature header and maps PaymentIntent,
+// Charge, Refund and Checkout Session events to payment statuses.
+func (s *StripeProvider) ParseWebhook(ctx context.Context, payload []byte, header http.Header) (*gateway.WebhookEvent, error) {
+	event, err := s.VerifyWebhookSignature(payload, header.Get("Stripe-Signature"))
 	if err != nil {
 		return nil, fmt.Errorf("invalid webhook signature: %w", err)
 	}
 
 	// Map PaymentIntent event types to internal statuses
 	piEvents := map[string]string{
-		"payment_intent.created":        "INITIATED",
-		"payment_intent.succeeded":      "SUCCESS",
-		"payment_intent.payment_failed": "FAILED",
+		"payment_intent.created":                   gateway.StatusInitiated,
+		"payment_intent.amount_capturable_updated": gateway.StatusAuthorized,
+		"payment_intent.succeeded":                 gateway.StatusSucceeded,
+		"payment_intent.payment_failed":            gateway.StatusFailed,
+		"payment_intent.canceled":                  gateway.StatusCancelled,
 	}
 
 	// Map Charge event types to internal statuses
 	chargeEvents := map[string]string{
-		"charge.succeeded": "SUCCESS",
-		"charge.failed":    "FAILED",
-		"charge.pending":   "INITIATED", // optional
-		"charge.refunded":  "REFUNDED",
-		"charge.captured":  "SUCCESS", // for delayed capture flows
-		"charge.updated":   "UPDATED", // optional: for charge status updates
+		"charge.succeeded": gateway.StatusSucceeded,
+		"charge.failed":    gateway.StatusFailed,
+		"charge.pending":   gateway.StatusInitiated, // optional
+		"charge.refunded":  gateway.StatusRefunded,
+		"charge.captured":  gateway.StatusSucceeded, // for delayed capture flows
+		"charge.updated":   "UPDATED",               // optional: for charge status updates
+	}
+
+	// Refund events report on a single refund
+	refundEvents := map[string]bool{
+		"refund.created": true,
+		"refund.updated": true,
+		"refund.failed":  true,
+	}
+
+	// Checkout Session events; completed is resolved from the session's
+	// PaymentIntent below
+	checkoutEvents := map[string]string{
+		"checkout.session.completed":               gateway.StatusSucceeded,
+		"checkout.session.async_payment_succeeded": gateway.StatusSucceeded,
+		"checkout.session.async_payment_failed":    gateway.StatusFailed,
+		"checkout.session.expired":                 gateway.StatusCancelled,
 	}
 
 	var status string
-	if s, ok := piEvents[string(event.Type)]; ok {
-		status = s
+	if st, ok := checkoutEvents[string(event.Type)]; ok {
+		var session stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &session); err != nil {
+			return nil, err
+		}
+
+		orderID := session.Metadata["order_id"]
+		if orderID == "" {
+			orderID = session.ClientReferenceID
+		}
+		if orderID == "" {
+			return nil, errors.New("missing order_id in metadata")
+		}
+
+		if event.Type == "checkout.session.completed" {
+			st = s.completedSessionStatus(ctx, &session)
+		}
+
+		mapped := mapCheckoutSession(&session)
+		return &gateway.WebhookEvent{
+			EventID:           event.ID,
+			Type:              string(event.Type),
+			Provider:          ProviderName,
+			ProviderTxnID:     mapped.IntentID,
+			CheckoutSessionID: session.ID,
+			OrderID:           orderID,
+			Status:            st,
+			OccurredAt:        time.Unix(event.Created, 0),
+			RawEvent:          session,
+		}, nil
+	} else if refundEvents[string(event.Type)] {
+		var re stripe.Refund
+		if err := json.Unmarshal(event.Data.Raw, &re); err != nil {
+			return nil, err
+		}
+
+		refund := mapRefund(&re)
+		return &gateway.WebhookEvent{
+			EventID:       event.ID,
+			Type:          string(event.Type),
+			Provider:      ProviderName,
+			ProviderTxnID: refund.IntentID,
+			OrderID:       re.Metadata["order_id"],
+			Status:        gateway.StatusRefunded,
+			FailureReason: refund.FailureReason,
+			OccurredAt:    time.Unix(event.Created, 0),
+			Refund:        &refund,
+			RawEvent:      re,
+		}, nil
+	} else if st, ok := piEvents[string(event.Type)]; ok {
+		status = st
 		// Parse PaymentIntent
 		var intent stripe.PaymentIntent
 		if err := json.Unmarshal(event.Data.Raw, &intent); err != nil {
@@ -77,31 +302,37 @@ func (s *StripeProvider) HandleWebhook(payload []byte, sigHeader string) (*Provi
 		}
 
 		failureReason := ""
-		if status == "FAILED" && intent.LastPaymentError != nil {
+		if status == gateway.StatusFailed && intent.LastPaymentError != nil {
 			failureReason = intent.LastPaymentError.Msg
 		}
 
-		return &ProviderWebhookEvent{
+		return &gateway.WebhookEvent{
+			EventID:       event.ID,
+			Type:          string(event.Type),
 			Provider:      ProviderName,
 			ProviderTxnID: intent.ID,
 			OrderID:       orderID,
 			Status:        status,
 			FailureReason: failureReason,
+			OccurredAt:    time.Unix(event.Created, 0),
 			RawEvent:      intent,
 		}, nil
-	} else if s, ok := chargeEvents[string(event.Type)]; ok {
-		status = s
+	} else if st, ok := chargeEvents[string(event.Type)]; ok {
+		status = st
 		// Parse Charge
 		var ch stripe.Charge
 		if err := json.Unmarshal(event.Data.Raw, &ch); err != nil {
 			return nil, err
 		}
 
-		// Link to PaymentIntent for metadata if available
+		// Link to PaymentIntent for metadata if available. Payments are
+		// stored under the PaymentIntent ID, so report that as the txn ID.
 		var orderID string
+		txnID := ch.ID
 		if ch.PaymentIntent != nil {
+			txnID = ch.PaymentIntent.ID
 			// Retrieve metadata from PaymentIntent if needed
-			pi, err := paymentintent.Get(ch.PaymentIntent.ID, nil)
+			pi, err := s.client.V1PaymentIntents.Retrieve(ctx, ch.PaymentIntent.ID, nil)
 			if err == nil {
 				orderID = pi.Metadata["order_id"]
 			}
@@ -111,25 +342,35 @@ func (s *StripeProvider) HandleWebhook(payload []byte, sigHeader string) (*Provi
 		if event.Type == "charge.updated" && ch.Status != "" {
 			switch ch.Status {
 			case "succeeded":
-				status = "SUCCESS"
+				status = gateway.StatusSucceeded
 			case "failed":
-				status = "FAILED"
+				status = gateway.StatusFailed
 			case "pending":
-				status = "INITIATED"
+				status = gateway.StatusInitiated
 			case "canceled":
-				status = "CANCELLED"
+				status = gateway.StatusCancelled
 			default:
 				return nil, fmt.Errorf("unhandled charge status: %s", ch.Status)
 			}
 		}
 
-		return &ProviderWebhookEvent{
-			Provider:      ProviderName,
-			ProviderTxnID: ch.ID,
-			OrderID:       orderID,
-			Status:        status,
-			FailureReason: ch.FailureMessage,
-			RawEvent:      ch,
+		// With manual capture the charge succeeds as soon as the card is
+		// authorized; it is only paid once captured
+		if status == gateway.StatusSucceeded && !ch.Captured {
+			status = gateway.StatusAuthorized
+		}
+
+		return &gateway.WebhookEvent{
+			EventID:             event.ID,
+			Type:                string(event.Type),
+			Provider:            ProviderName,
+			ProviderTxnID:       txnID,
+			OrderID:             orderID,
+			Status:              status,
+			FailureReason:       ch.FailureMessage,
+			OccurredAt:          time.Unix(event.Created, 0),
+			AmountRefundedCents: ch.AmountRefunded,
+			RawEvent:            ch,
 		}, nil
 	}
 
@@ -152,3 +393,99 @@ func (s *StripeProvider) VerifyWebhookSignature(payload []byte, sigHeader string
 	}
 	return event, nil
 }
+
+// completedSessionStatus is the payment status a completed Checkout Session
+// leaves its payment in. A paid session has succeeded; an unpaid one is
+// either authorized for a later capture or still waiting on an
+// asynchronous payment method, which only its PaymentIntent tells apart.
+func (s *StripeProvider) completedSessionStatus(ctx context.Context, session *stripe.CheckoutSession) string {
+	if session.PaymentStatus != stripe.CheckoutSessionPaymentStatusUnpaid {
+		return gateway.StatusSucceeded
+	}
+	if session.PaymentIntent == nil {
+		return gateway.StatusInitiated
+	}
+
+	intent, err := s.client.V1PaymentIntents.Retrieve(ctx, session.PaymentIntent.ID, nil)
+	if err != nil {
+		return gateway.StatusInitiated
+	}
+
+	return mapIntent(intent).PaymentStatus
+}
+
+func mapCheckoutSession(session *stripe.CheckoutSession) gateway.CheckoutSession {
+	mapped := gateway.CheckoutSession{
+		ID:        session.ID,
+		URL:       session.URL,
+		ExpiresAt: time.Unix(session.ExpiresAt, 0),
+	}
+	if session.PaymentIntent != nil {
+		mapped.IntentID = session.PaymentIntent.ID
+	}
+
+	return mapped
+}
+
+func mapIntent(intent *stripe.PaymentIntent) gateway.Intent {
+	captureMethod := gateway.CaptureAutomatic
+	if intent.CaptureMethod == stripe.PaymentIntentCaptureMethodManual {
+		captureMethod = gateway.CaptureManual
+	}
+
+	mapped := gateway.Intent{
+		ID:            intent.ID,
+		ClientSecret:  intent.ClientSecret,
+		AmountCents:   intent.Amount,
+		Currency:      strings.ToUpper(string(intent.Currency)),
+		Status:        string(intent.Status),
+		PaymentStatus: gateway.StatusInitiated,
+		CaptureMethod: captureMethod,
+		OrderID:       intent.Metadata["order_id"],
+		CreatedAt:     time.Unix(intent.Created, 0),
+	}
+
+	switch intent.Status {
+	case stripe.PaymentIntentStatusRequiresCapture:
+		mapped.PaymentStatus = gateway.StatusAuthorized
+	case stripe.PaymentIntentStatusSucceeded:
+		mapped.PaymentStatus = gateway.StatusSucceeded
+	case stripe.PaymentIntentStatusCanceled:
+		mapped.PaymentStatus = gateway.StatusCancelled
+	case stripe.PaymentIntentStatusRequiresPaymentMethod:
+		// Back to requires_payment_method after an attempt means it failed
+		if intent.LastPaymentError != nil {
+			mapped.PaymentStatus = gateway.StatusFailed
+			mapped.FailureReason = intent.LastPaymentError.Msg
+		}
+	}
+
+	return mapped
+}
+
+// mapRefund translates a Stripe refund to the gateway's refund statuses.
+// requires_action is still waiting on the customer, so it counts as pending.
+func mapRefund(re *stripe.Refund) gateway.Refund {
+	refund := gateway.Refund{
+		ID:            re.ID,
+		AmountCents:   re.Amount,
+		FailureReason: string(re.FailureReason),
+		Metadata:      re.Metadata,
+	}
+	if re.PaymentIntent != nil {
+		refund.IntentID = re.PaymentIntent.ID
+	}
+
+	switch re.Status {
+	case stripe.RefundStatusSucceeded:
+		refund.Status = gateway.RefundSucceeded
+	case stripe.RefundStatusFailed:
+		refund.Status = gateway.RefundFailed
+	case stripe.RefundStatusCanceled:
+		refund.Status = gateway.RefundCancelled
+	default:
+		refund.Status = gateway.RefundPending
+	}
+
+	return refund
+}
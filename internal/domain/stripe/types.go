@@ -1,14 +1,10 @@
 package stripe
 
+import "github.com/stripe/stripe-go/v83"
+
+// StripeProvider is the Stripe implementation of gateway.PaymentGateway
 type StripeProvider struct {
+	client        *stripe.Client
 	webhookSecret string
-}
-
-type ProviderWebhookEvent struct {
-	Provider      string
-	ProviderTxnID string
-	OrderID       string
-	Status        string
-	FailureReason string
-	RawEvent      interface{}
+	captureMethod string
 }
@@ -0,0 +1,38 @@
+package tax
+
+// --- Request DTOs ---
+type CreateRateRequest struct {
+	Name             string `json:"name" validate:"required,min=2,max=100"`
+	Country          string `json:"country" validate:"required,len=2,alpha"`
+	State            string `json:"state,omitempty" validate:"omitempty,max=50"`
+	PostalCodePrefix string `json:"postal_code_prefix,omitempty" validate:"omitempty,max=20"`
+	TaxCategory      string `json:"tax_category,omitempty" validate:"omitempty,min=2,max=50"`
+	RateBps          int32  `json:"rate_bps" validate:"min=0,max=10000"`
+	IsInclusive      bool   `json:"is_inclusive"`
+	IsActive         *bool  `json:"is_active,omitempty"`
+}
+
+type UpdateRateRequest struct {
+	Name             *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Country          *string `json:"country,omitempty" validate:"omitempty,len=2,alpha"`
+	State            *string `json:"state,omitempty" validate:"omitempty,max=50"`
+	PostalCodePrefix *string `json:"postal_code_prefix,omitempty" validate:"omitempty,max=20"`
+	TaxCategory      *string `json:"tax_category,omitempty" validate:"omitempty,min=2,max=50"`
+	RateBps          *int32  `json:"rate_bps,omitempty" validate:"omitempty,min=0,max=10000"`
+	IsInclusive      *bool   `json:"is_inclusive,omitempty"`
+	IsActive         *bool   `json:"is_active,omitempty"`
+}
+
+type SetExemptionRequest struct {
+	CertificateNumber string  `json:"certificate_number,omitempty" validate:"omitempty,max=100"`
+	Reason            string  `json:"reason" validate:"required,min=3,max=500"`
+	ExpiresAt         *string `json:"expires_at,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+}
+
+// CalculationRequest asks for the tax on Lines shipped to Address for the
+// customer UserID, whose exemption (if any) is honoured.
+type CalculationRequest struct {
+	UserID  string
+	Address Address
+	Lines   []Line
+}
@@ -0,0 +1,126 @@
+package tax
+
+import (
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/internal/pkg/validator"
+	"ecommerce-app/pkg/pagination"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateRate(w http.ResponseWriter, r *http.Request) {
+	req := validator.GetValidatedBody[CreateRateRequest](r)
+
+	rate, appErr := h.svc.CreateRate(r.Context(), req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, rate, "Tax rate created successfully")
+}
+
+func (h *Handler) ListRates(w http.ResponseWriter, r *http.Request) {
+	page, perPage := pagination.GetPaginationParams(r)
+
+	result, appErr := h.svc.ListRates(r.Context(), page, perPage)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OkWithMeta(w, result.Rates, result.Meta)
+}
+
+func (h *Handler) GetRate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	rate, appErr := h.svc.GetRate(r.Context(), id)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, rate, "Tax rate fetched successfully")
+}
+
+func (h *Handler) UpdateRate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[UpdateRateRequest](r)
+
+	rate, appErr := h.svc.UpdateRate(r.Context(), id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, rate, "Tax rate updated successfully")
+}
+
+func (h *Handler) DeleteRate(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if appErr := h.svc.DeleteRate(r.Context(), id); appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.NoContent(w)
+}
+
+func (h *Handler) SetExemption(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	req := validator.GetValidatedBody[SetExemptionRequest](r)
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+
+	exemption, appErr := h.svc.SetExemption(r.Context(), adminID, userID, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, exemption, "Tax exemption saved successfully")
+}
+
+func (h *Handler) GetExemption(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+
+	exemption, appErr := h.svc.GetExemption(r.Context(), userID)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, exemption, "Tax exemption fetched successfully")
+}
+
+func (h *Handler) ListExemptions(w http.ResponseWriter, r *http.Request) {
+	exemptions, appErr := h.svc.ListExemptions(r.Context())
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, exemptions, "Tax exemptions fetched successfully")
+}
+
+func (h *Handler) DeleteExemption(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+
+	if appErr := h.svc.DeleteExemption(r.Context(), userID); appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.NoContent(w)
+}
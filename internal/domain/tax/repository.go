@@ -0,0 +1,251 @@
+package tax
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Repository interface {
+	CreateRate(ctx context.Context, rate Rate) (Rate, error)
+	GetRateByID(ctx context.Context, id string) (Rate, error)
+	ListRates(ctx context.Context, limit, offset int32) ([]Rate, error)
+	CountRates(ctx context.Context) (int32, error)
+	UpdateRate(ctx context.Context, rate Rate) (Rate, error)
+	DeleteRate(ctx context.Context, id string) error
+	ListApplicableRates(ctx context.Context, addr Address, categories []string) ([]Rate, error)
+	UpsertExemption(ctx context.Context, exemption Exemption) (Exemption, error)
+	GetExemption(ctx context.Context, userID string) (Exemption, error)
+	ListExemptions(ctx context.Context) ([]Exemption, error)
+	DeleteExemption(ctx context.Context, userID string) error
+}
+
+// repository implements Repository
+type repository struct {
+	q *sqlc.Queries
+}
+
+func NewRepository(q *sqlc.Queries) Repository {
+	return &repository{q: q}
+}
+
+func (r *repository) CreateRate(ctx context.Context, rate Rate) (Rate, error) {
+	row, err := r.q.CreateTaxRate(ctx, sqlc.CreateTaxRateParams{
+		Name:             rate.Name,
+		Country:          rate.Country,
+		State:            rate.State,
+		PostalCodePrefix: rate.PostalCodePrefix,
+		TaxCategory:      rate.TaxCategory,
+		RateBps:          rate.RateBps,
+		IsInclusive:      rate.IsInclusive,
+		IsActive:         rate.IsActive,
+	})
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return mapRate(row), nil
+}
+
+func (r *repository) GetRateByID(ctx context.Context, id string) (Rate, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Rate{}, err
+	}
+
+	row, err := r.q.GetTaxRate(ctx, uuidID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Rate{}, errs.ErrNotFound
+		}
+		return Rate{}, err
+	}
+
+	return mapRate(row), nil
+}
+
+func (r *repository) ListRates(ctx context.Context, limit, offset int32) ([]Rate, error) {
+	rows, err := r.q.ListTaxRates(ctx, sqlc.ListTaxRatesParams{Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]Rate, len(rows))
+	for i, row := range rows {
+		rates[i] = mapRate(row)
+	}
+
+	return rates, nil
+}
+
+func (r *repository) CountRates(ctx context.Context) (int32, error) {
+	count, err := r.q.CountTaxRates(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return int32(count), nil
+}
+
+func (r *repository) UpdateRate(ctx context.Context, rate Rate) (Rate, error) {
+	row, err := r.q.UpdateTaxRate(ctx, sqlc.UpdateTaxRateParams{
+		ID:               pgtype.UUID{Bytes: rate.ID, Valid: true},
+		Name:             rate.Name,
+		Country:          rate.Country,
+		State:            rate.State,
+		PostalCodePrefix: rate.PostalCodePrefix,
+		TaxCategory:      rate.TaxCategory,
+		RateBps:          rate.RateBps,
+		IsInclusive:      rate.IsInclusive,
+		IsActive:         rate.IsActive,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Rate{}, errs.ErrNotFound
+		}
+		return Rate{}, err
+	}
+
+	return mapRate(row), nil
+}
+
+func (r *repository) DeleteRate(ctx context.Context, id string) error {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return err
+	}
+
+	return r.q.DeleteTaxRate(ctx, uuidID)
+}
+
+// ListApplicableRates returns the active rates matching addr for categories,
+// most specific first within each category.
+func (r *repository) ListApplicableRates(ctx context.Context, addr Address, categories []string) ([]Rate, error) {
+	rows, err := r.q.ListApplicableTaxRates(ctx, sqlc.ListApplicableTaxRatesParams{
+		Country:       addr.Country,
+		State:         addr.State,
+		PostalCode:    addr.PostalCode,
+		TaxCategories: categories,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rates := make([]Rate, len(rows))
+	for i, row := range rows {
+		rates[i] = mapRate(row)
+	}
+
+	return rates, nil
+}
+
+func (r *repository) UpsertExemption(ctx context.Context, exemption Exemption) (Exemption, error) {
+	var createdBy pgtype.UUID
+	if exemption.CreatedBy != nil {
+		createdBy = pgtype.UUID{Bytes: *exemption.CreatedBy, Valid: true}
+	}
+
+	var expiresAt pgtype.Timestamptz
+	if exemption.ExpiresAt != nil {
+		expiresAt = pgtype.Timestamptz{Time: *exemption.ExpiresAt, Valid: true}
+	}
+
+	row, err := r.q.UpsertTaxExemption(ctx, sqlc.UpsertTaxExemptionParams{
+		UserID:            pgtype.UUID{Bytes: exemption.UserID, Valid: true},
+		CertificateNumber: pgtype.Text{String: exemption.CertificateNumber, Valid: exemption.CertificateNumber != ""},
+		Reason:            pgtype.Text{String: exemption.Reason, Valid: exemption.Reason != ""},
+		ExpiresAt:         expiresAt,
+		CreatedBy:         createdBy,
+	})
+	if err != nil {
+		return Exemption{}, err
+	}
+
+	return mapExemption(row), nil
+}
+
+func (r *repository) GetExemption(ctx context.Context, userID string) (Exemption, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return Exemption{}, err
+	}
+
+	row, err := r.q.GetTaxExemption(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Exemption{}, errs.ErrNotFound
+		}
+		return Exemption{}, err
+	}
+
+	return mapExemption(row), nil
+}
+
+func (r *repository) ListExemptions(ctx context.Context) ([]Exemption, error) {
+	rows, err := r.q.ListTaxExemptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	exemptions := make([]Exemption, len(rows))
+	for i, row := range rows {
+		exemptions[i] = mapExemption(row)
+	}
+
+	return exemptions, nil
+}
+
+func (r *repository) DeleteExemption(ctx context.Context, userID string) error {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return err
+	}
+
+	return r.q.DeleteTaxExemption(ctx, userUUID)
+}
+
+func mapRate(row sqlc.TaxRate) Rate {
+	return Rate{
+		ID:               uuid.UUID(row.ID.Bytes),
+		Name:             row.Name,
+		Country:          row.Country,
+		State:            row.State,
+		PostalCodePrefix: row.PostalCodePrefix,
+		TaxCategory:      row.TaxCategory,
+		RateBps:          row.RateBps,
+		IsInclusive:      row.IsInclusive,
+		IsActive:         row.IsActive,
+		CreatedAt:        row.CreatedAt.Time,
+		UpdatedAt:        row.UpdatedAt.Time,
+	}
+}
+
+func mapExemption(row sqlc.TaxExemption) Exemption {
+	var createdBy *uuid.UUID
+	if row.CreatedBy.Valid {
+		id := uuid.UUID(row.CreatedBy.Bytes)
+		createdBy = &id
+	}
+
+	var expiresAt *time.Time
+	if row.ExpiresAt.Valid {
+		t := row.ExpiresAt.Time
+		expiresAt = &t
+	}
+
+	return Exemption{
+		UserID:            uuid.UUID(row.UserID.Bytes),
+		CertificateNumber: row.CertificateNumber.String,
+		Reason:            row.Reason.String,
+		ExpiresAt:         expiresAt,
+		CreatedBy:         createdBy,
+		CreatedAt:         row.CreatedAt.Time,
+	}
+}
@@ -0,0 +1,26 @@
+package tax
+
+import (
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes(svc Service) chi.Router {
+	h := NewHandler(svc)
+	r := chi.NewRouter()
+
+	r.With(validator.Validate[CreateRateRequest]()).With(middleware.RoleMiddleware("admin")).Post("/rates", h.CreateRate)
+	r.With(middleware.RoleMiddleware("admin")).Get("/rates", h.ListRates)
+	r.With(middleware.RoleMiddleware("admin")).Get("/rates/{id}", h.GetRate)
+	r.With(validator.Validate[UpdateRateRequest]()).With(middleware.RoleMiddleware("admin")).Put("/rates/{id}", h.UpdateRate)
+	r.With(middleware.RoleMiddleware("admin")).Delete("/rates/{id}", h.DeleteRate)
+
+	r.With(middleware.RoleMiddleware("admin")).Get("/exemptions", h.ListExemptions)
+	r.With(middleware.RoleMiddleware("admin")).Get("/exemptions/{userID}", h.GetExemption)
+	r.With(validator.Validate[SetExemptionRequest]()).With(middleware.RoleMiddleware("admin")).Put("/exemptions/{userID}", h.SetExemption)
+	r.With(middleware.RoleMiddleware("admin")).Delete("/exemptions/{userID}", h.DeleteExemption)
+
+	return r
+}
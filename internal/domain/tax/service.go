@@ -0,0 +1,350 @@
+package tax
+
+import (
+	"context"
+	"ecommerce-app/internal/pkg/database"
+	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/logger"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/pkg/pagination"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	CreateRate(ctx context.Context, req CreateRateRequest) (Rate, *errs.AppError)
+	GetRate(ctx context.Context, id string) (Rate, *errs.AppError)
+	ListRates(ctx context.Context, page, perPage int) (RatesWithMeta, *errs.AppError)
+	UpdateRate(ctx context.Context, id string, req UpdateRateRequest) (Rate, *errs.AppError)
+	DeleteRate(ctx context.Context, id string) *errs.AppError
+	SetExemption(ctx context.Context, adminID, userID string, req SetExemptionRequest) (Exemption, *errs.AppError)
+	GetExemption(ctx context.Context, userID string) (Exemption, *errs.AppError)
+	ListExemptions(ctx context.Context) ([]Exemption, *errs.AppError)
+	DeleteExemption(ctx context.Context, userID string) *errs.AppError
+	Calculate(ctx context.Context, req CalculationRequest) (Calculation, *errs.AppError)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) CreateRate(ctx context.Context, req CreateRateRequest) (Rate, *errs.AppError) {
+	rate := Rate{
+		Name:             req.Name,
+		Country:          normalizeRegion(req.Country),
+		State:            normalizeRegion(req.State),
+		PostalCodePrefix: normalizePostalCode(req.PostalCodePrefix),
+		TaxCategory:      req.TaxCategory,
+		RateBps:          req.RateBps,
+		IsInclusive:      req.IsInclusive,
+		IsActive:         true,
+	}
+	if rate.TaxCategory == "" {
+		rate.TaxCategory = DefaultCategory
+	}
+	if req.IsActive != nil {
+		rate.IsActive = *req.IsActive
+	}
+
+	created, err := s.repo.CreateRate(ctx, rate)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return Rate{}, errs.ErrConflict.WithMessage("A tax rate for this jurisdiction and category already exists")
+		}
+		logger.Error("Failed to create tax rate: %v", err)
+		return Rate{}, errs.ErrInternal.WithMessage("Failed to create tax rate")
+	}
+
+	return created, nil
+}
+
+func (s *service) GetRate(ctx context.Context, id string) (Rate, *errs.AppError) {
+	rate, err := s.repo.GetRateByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Rate{}, errs.ErrNotFound.WithMessage("Tax rate not found")
+		}
+		return Rate{}, errs.ErrInternal.WithMessage("Failed to get tax rate")
+	}
+
+	return rate, nil
+}
+
+func (s *service) ListRates(ctx context.Context, page, perPage int) (RatesWithMeta, *errs.AppError) {
+	p := pagination.New(page, perPage)
+
+	rates, err := s.repo.ListRates(ctx, int32(p.PerPage), int32(p.Offset()))
+	if err != nil {
+		return RatesWithMeta{}, errs.ErrInternal.WithMessage("Failed to get tax rates")
+	}
+
+	total, err := s.repo.CountRates(ctx)
+	if err != nil {
+		return RatesWithMeta{}, errs.ErrInternal.WithMessage("Failed to count tax rates")
+	}
+
+	return RatesWithMeta{
+		Rates: rates,
+		Meta: response.Meta{
+			Page:    p.Page,
+			PerPage: p.PerPage,
+			Total:   int(total),
+		},
+	}, nil
+}
+
+func (s *service) UpdateRate(ctx context.Context, id string, req UpdateRateRequest) (Rate, *errs.AppError) {
+	rate, appErr := s.GetRate(ctx, id)
+	if appErr != nil {
+		return Rate{}, appErr
+	}
+
+	if req.Name != nil {
+		rate.Name = *req.Name
+	}
+	if req.Country != nil {
+		rate.Country = normalizeRegion(*req.Country)
+	}
+	if req.State != nil {
+		rate.State = normalizeRegion(*req.State)
+	}
+	if req.PostalCodePrefix != nil {
+		rate.PostalCodePrefix = normalizePostalCode(*req.PostalCodePrefix)
+	}
+	if req.TaxCategory != nil {
+		rate.TaxCategory = *req.TaxCategory
+	}
+	if req.RateBps != nil {
+		rate.RateBps = *req.RateBps
+	}
+	if req.IsInclusive != nil {
+		rate.IsInclusive = *req.IsInclusive
+	}
+	if req.IsActive != nil {
+		rate.IsActive = *req.IsActive
+	}
+
+	updated, err := s.repo.UpdateRate(ctx, rate)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Rate{}, errs.ErrNotFound.WithMessage("Tax rate not found")
+		}
+		if database.IsUniqueViolation(err) {
+			return Rate{}, errs.ErrConflict.WithMessage("A tax rate for this jurisdiction and category already exists")
+		}
+		logger.Error("Failed to update tax rate %s: %v", id, err)
+		return Rate{}, errs.ErrInternal.WithMessage("Failed to update tax rate")
+	}
+
+	return updated, nil
+}
+
+func (s *service) DeleteRate(ctx context.Context, id string) *errs.AppError {
+	if _, appErr := s.GetRate(ctx, id); appErr != nil {
+		return appErr
+	}
+
+	if err := s.repo.DeleteRate(ctx, id); err != nil {
+		return errs.ErrInternal.WithMessage("Failed to delete tax rate")
+	}
+
+	return nil
+}
+
+// SetExemption marks userID as tax exempt, replacing any earlier exemption.
+func (s *service) SetExemption(ctx context.Context, adminID, userID string, req SetExemptionRequest) (Exemption, *errs.AppError) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return Exemption{}, errs.ErrBadRequest.WithMessage("Invalid user ID")
+	}
+
+	exemption := Exemption{
+		UserID:            userUUID,
+		CertificateNumber: req.CertificateNumber,
+		Reason:            req.Reason,
+	}
+
+	if adminUUID, err := uuid.Parse(adminID); err == nil {
+		exemption.CreatedBy = &adminUUID
+	}
+
+	if req.ExpiresAt != nil {
+		expiresAt, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil {
+			return Exemption{}, errs.ErrBadRequest.WithMessage("Invalid expires_at format, expected RFC3339")
+		}
+		if !expiresAt.After(time.Now()) {
+			return Exemption{}, errs.ErrBadRequest.WithMessage("expires_at must be in the future")
+		}
+		exemption.ExpiresAt = &expiresAt
+	}
+
+	saved, err := s.repo.UpsertExemption(ctx, exemption)
+	if err != nil {
+		logger.Error("Failed to save tax exemption for user %s: %v", userID, err)
+		return Exemption{}, errs.ErrInternal.WithMessage("Failed to save tax exemption")
+	}
+
+	return saved, nil
+}
+
+func (s *service) GetExemption(ctx context.Context, userID string) (Exemption, *errs.AppError) {
+	exemption, err := s.repo.GetExemption(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Exemption{}, errs.ErrNotFound.WithMessage("Tax exemption not found")
+		}
+		return Exemption{}, errs.ErrInternal.WithMessage("Failed to get tax exemption")
+	}
+
+	return exemption, nil
+}
+
+func (s *service) ListExemptions(ctx context.Context) ([]Exemption, *errs.AppError) {
+	exemptions, err := s.repo.ListExemptions(ctx)
+	if err != nil {
+		return nil, errs.ErrInternal.WithMessage("Failed to get tax exemptions")
+	}
+
+	return exemptions, nil
+}
+
+func (s *service) DeleteExemption(ctx context.Context, userID string) *errs.AppError {
+	if _, appErr := s.GetExemption(ctx, userID); appErr != nil {
+		return appErr
+	}
+
+	if err := s.repo.DeleteExemption(ctx, userID); err != nil {
+		return errs.ErrInternal.WithMessage("Failed to delete tax exemption")
+	}
+
+	return nil
+}
+
+// Calculate taxes each line at the most specific active rate for its
+// category at the address. Exempt customers and addresses without a country
+// are not taxed.
+func (s *service) Calculate(ctx context.Context, req CalculationRequest) (Calculation, *errs.AppError) {
+	calc := Calculation{Lines: []LineTax{}}
+
+	if req.UserID != "" {
+		exemption, err := s.repo.GetExemption(ctx, req.UserID)
+		if err != nil && !errors.Is(err, errs.ErrNotFound) {
+			return Calculation{}, errs.ErrInternal.WithMessage("Failed to check tax exemption")
+		}
+		if err == nil && exemption.Active(time.Now()) {
+			calc.Exempt = true
+			return calc, nil
+		}
+	}
+
+	addr := Address{
+		Country:    normalizeRegion(req.Address.Country),
+		State:      normalizeRegion(req.Address.State),
+		PostalCode: normalizePostalCode(req.Address.PostalCode),
+	}
+	if addr.Country == "" || len(req.Lines) == 0 {
+		return calc, nil
+	}
+
+	seen := make(map[string]bool)
+	categories := make([]string, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		category := lineCategory(line)
+		if !seen[category] {
+			seen[category] = true
+			categories = append(categories, category)
+		}
+	}
+
+	rates, err := s.repo.ListApplicableRates(ctx, addr, categories)
+	if err != nil {
+		logger.Error("Failed to get tax rates for %s: %v", addr.Country, err)
+		return Calculation{}, errs.ErrInternal.WithMessage("Failed to get tax rates")
+	}
+
+	// Rates come most specific first, so the first one per category wins
+	byCategory := make(map[string]Rate, len(categories))
+	for _, rate := range rates {
+		if _, ok := byCategory[rate.TaxCategory]; !ok {
+			byCategory[rate.TaxCategory] = rate
+		}
+	}
+
+	for _, line := range req.Lines {
+		rate, ok := byCategory[lineCategory(line)]
+		if !ok {
+			continue
+		}
+
+		rateID := rate.ID
+		lineTax := LineTax{
+			Ref:          line.Ref,
+			TaxRateID:    &rateID,
+			Jurisdiction: rate.Jurisdiction(),
+			TaxCategory:  rate.TaxCategory,
+			RateBps:      rate.RateBps,
+			IsInclusive:  rate.IsInclusive,
+			TaxableCents: line.AmountCents,
+			TaxCents:     rate.TaxOn(line.AmountCents),
+		}
+
+		calc.TaxCents += lineTax.TaxCents
+		if !lineTax.IsInclusive {
+			calc.ExclusiveTaxCents += lineTax.TaxCents
+		}
+		calc.Lines = append(calc.Lines, lineTax)
+	}
+
+	return calc, nil
+}
+
+// TaxOn is the tax on amountCents at this rate, rounded half up. For
+// inclusive rates it is the tax already contained in amountCents.
+func (r Rate) TaxOn(amountCents int64) int64 {
+	if amountCents <= 0 || r.RateBps <= 0 {
+		return 0
+	}
+
+	bps := int64(r.RateBps)
+	divisor := int64(10000)
+	if r.IsInclusive {
+		divisor += bps
+	}
+
+	return (amountCents*bps + divisor/2) / divisor
+}
+
+// Jurisdiction names where the rate applies, e.g. "US-CA-941".
+func (r Rate) Jurisdiction() string {
+	parts := []string{r.Country}
+	if r.State != "" {
+		parts = append(parts, r.State)
+	}
+	if r.PostalCodePrefix != "" {
+		parts = append(parts, r.PostalCodePrefix)
+	}
+	return strings.Join(parts, "-")
+}
+
+func lineCategory(line Line) string {
+	if line.TaxCategory == "" {
+		return DefaultCategory
+	}
+	return line.TaxCategory
+}
+
+func normalizeRegion(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
+
+func normalizePostalCode(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+}
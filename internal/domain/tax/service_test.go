@@ -0,0 +1,34 @@
+package tax
+
+import "testing"
+
+func TestTaxOn(t *testing.T) {
+	tests := []struct {
+		name        string
+		rateBps     int32
+		isInclusive bool
+		amountCents int64
+		want        int64
+	}{
+		{name: "exclusive", rateBps: 800, amountCents: 1000, want: 80},
+		{name: "exclusive rounds half up", rateBps: 825, amountCents: 1000, want: 83},
+		{name: "exclusive rounds down below half", rateBps: 500, amountCents: 9, want: 0},
+		{name: "exclusive half a cent", rateBps: 500, amountCents: 10, want: 1},
+		{name: "inclusive", rateBps: 750, isInclusive: true, amountCents: 1075, want: 75},
+		{name: "inclusive rounds half up", rateBps: 2000, isInclusive: true, amountCents: 999, want: 167},
+		{name: "inclusive rounds down below half", rateBps: 2000, isInclusive: true, amountCents: 1003, want: 167},
+		{name: "inclusive of a whole price", rateBps: 2000, isInclusive: true, amountCents: 1200, want: 200},
+		{name: "zero amount", rateBps: 800, amountCents: 0, want: 0},
+		{name: "negative amount", rateBps: 800, amountCents: -500, want: 0},
+		{name: "zero rate", rateBps: 0, amountCents: 1000, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate := Rate{RateBps: tt.rateBps, IsInclusive: tt.isInclusive}
+			if got := rate.TaxOn(tt.amountCents); got != tt.want {
+				t.Errorf("TaxOn(%d) = %d, want %d", tt.amountCents, got, tt.want)
+			}
+		})
+	}
+}
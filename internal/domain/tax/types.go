@@ -0,0 +1,90 @@
+package tax
+
+import (
+	"ecommerce-app/internal/pkg/response"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultCategory is the tax category of products that don't set one.
+const DefaultCategory = "standard"
+
+// --- Domain Models ---
+
+// Rate is a tax rate for one product category in a jurisdiction. State and
+// PostalCodePrefix are empty when the rate applies to the whole country or
+// state. Inclusive rates are already part of the price in that jurisdiction.
+type Rate struct {
+	ID               uuid.UUID `json:"id"`
+	Name             string    `json:"name"`
+	Country          string    `json:"country"`
+	State            string    `json:"state"`
+	PostalCodePrefix string    `json:"postal_code_prefix"`
+	TaxCategory      string    `json:"tax_category"`
+	RateBps          int32     `json:"rate_bps"`
+	IsInclusive      bool      `json:"is_inclusive"`
+	IsActive         bool      `json:"is_active"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+// Exemption marks a customer as not being charged tax, optionally until
+// ExpiresAt.
+type Exemption struct {
+	UserID            uuid.UUID  `json:"user_id"`
+	CertificateNumber string     `json:"certificate_number,omitempty"`
+	Reason            string     `json:"reason,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	CreatedBy         *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+// Active reports whether the exemption applies at t.
+func (e Exemption) Active(t time.Time) bool {
+	return e.ExpiresAt == nil || t.Before(*e.ExpiresAt)
+}
+
+// Address is the part of a shipping address that decides the jurisdiction.
+type Address struct {
+	Country    string `json:"country"`
+	State      string `json:"state,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+}
+
+// Line is one priced line to tax. Ref is an opaque caller reference echoed
+// back on the matching LineTax.
+type Line struct {
+	Ref         string
+	TaxCategory string
+	AmountCents int64
+}
+
+// LineTax is the tax on one line. Lines with no applicable rate are left
+// out of a Calculation.
+type LineTax struct {
+	Ref          string     `json:"ref"`
+	TaxRateID    *uuid.UUID `json:"tax_rate_id,omitempty"`
+	Jurisdiction string     `json:"jurisdiction"`
+	TaxCategory  string     `json:"tax_category"`
+	RateBps      int32      `json:"rate_bps"`
+	IsInclusive  bool       `json:"is_inclusive"`
+	TaxableCents int64      `json:"taxable_cents"`
+	TaxCents     int64      `json:"tax_cents"`
+}
+
+// Calculation is the tax on a set of lines. TaxCents includes tax already
+// contained in inclusive prices; ExclusiveTaxCents is the part that has to
+// be added on top of the line amounts.
+type Calculation struct {
+	TaxCents          int64     `json:"tax_cents"`
+	ExclusiveTaxCents int64     `json:"exclusive_tax_cents"`
+	Exempt            bool      `json:"exempt"`
+	Lines             []LineTax `json:"lines"`
+}
+
+// --- Wrapper Types ---
+type RatesWithMeta struct {
+	Rates []Rate        `json:"rates"`
+	Meta  response.Meta `json:"meta"`
+}
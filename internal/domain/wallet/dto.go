@@ -0,0 +1,39 @@
+package wallet
+
+import "time"
+
+// IssueCreditRequest is a goodwill credit an admin gives a customer. Credit
+// with an expiry lapses if it has not been spent by then.
+type IssueCreditRequest struct {
+	AmountCents int64      `json:"amount_cents" validate:"required,gt=0"`
+	Reason      string     `json:"reason" validate:"required,min=3,max=500"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// RefundInput pays money back into a wallet. RefundID links it to the
+// refund ledger when a card refund is paid out as credit; it is empty when
+// an order's own wallet spend is given back.
+type RefundInput struct {
+	UserID      string
+	OrderID     string
+	RefundID    string
+	AmountCents int64
+	Currency    string
+	Reason      string
+	CreatedBy   string
+}
+
+// --- DB (Repository) DTOs ---
+type CreateTransactionInput struct {
+	UserID            string
+	Kind              string
+	AmountCents       int64
+	BalanceAfterCents int64
+	Currency          string
+	OrderID           string
+	RefundID          string
+	SourceID          string
+	Reason            string
+	ExpiresAt         *time.Time
+	CreatedBy         string
+}
@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/internal/pkg/validator"
+	"ecommerce-app/pkg/pagination"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+// GetMyWallet returns the signed-in user's balance
+func (h *Handler) GetMyWallet(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+
+	wallet, appErr := h.svc.GetWallet(r.Context(), userID)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, wallet, "Wallet fetched successfully")
+}
+
+// ListMyTransactions pages through the signed-in user's wallet history
+func (h *Handler) ListMyTransactions(w http.ResponseWriter, r *http.Request) {
+	userID := r.Context().Value(middleware.UserIDKey).(string)
+	page, perPage := pagination.GetPaginationParams(r)
+
+	result, appErr := h.svc.ListTransactions(r.Context(), userID, page, perPage)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OkWithMeta(w, result.Transactions, result.Meta)
+}
+
+func (h *Handler) GetUserWallet(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+
+	wallet, appErr := h.svc.GetWallet(r.Context(), userID)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, wallet, "Wallet fetched successfully")
+}
+
+func (h *Handler) ListUserTransactions(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	page, perPage := pagination.GetPaginationParams(r)
+
+	result, appErr := h.svc.ListTransactions(r.Context(), userID, page, perPage)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OkWithMeta(w, result.Transactions, result.Meta)
+}
+
+// IssueCredit gives a customer goodwill credit
+func (h *Handler) IssueCredit(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	adminID := r.Context().Value(middleware.UserIDKey).(string)
+	req := validator.GetValidatedBody[IssueCreditRequest](r)
+
+	txn, appErr := h.svc.IssueCredit(r.Context(), adminID, userID, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, txn, "Wallet credit issued")
+}
@@ -0,0 +1,277 @@
+package wallet
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"ecommerce-app/internal/pkg/database"
+	"ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Repository interface {
+	EnsureWallet(ctx context.Context, userID, currency string) error
+	GetWallet(ctx context.Context, userID string) (Wallet, error)
+	LockWallet(ctx context.Context, userID string) (Wallet, error)
+	UpdateBalance(ctx context.Context, userID string, balanceCents int64) (Wallet, error)
+	CreateTransaction(ctx context.Context, in CreateTransactionInput) (Transaction, error)
+	ListTransactions(ctx context.Context, userID string, limit, offset int32) ([]Transaction, error)
+	CountTransactions(ctx context.Context, userID string) (int64, error)
+	ListAllTransactions(ctx context.Context, userID string) ([]Transaction, error)
+	GetOrderDebit(ctx context.Context, orderID string) (int64, error)
+	ListUsersWithExpiredCredits(ctx context.Context, before time.Time, limit int32) ([]string, error)
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// repository implements Repository
+type repository struct {
+	q  *sqlc.Queries
+	db database.Transactor
+}
+
+func NewRepository(q *sqlc.Queries, db database.Transactor) Repository {
+	return &repository{q: q, db: db}
+}
+
+// WithTx runs fn in a single transaction; every repository call made with
+// the ctx passed to fn takes part in it.
+func (r *repository) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return database.WithTx(ctx, r.db, fn)
+}
+
+func (r *repository) queries(ctx context.Context) *sqlc.Queries {
+	return database.Queries(ctx, r.q)
+}
+
+func (r *repository) EnsureWallet(ctx context.Context, userID, currency string) error {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return err
+	}
+
+	return r.queries(ctx).EnsureWallet(ctx, sqlc.EnsureWalletParams{
+		UserID:   userUUID,
+		Currency: currency,
+	})
+}
+
+func (r *repository) GetWallet(ctx context.Context, userID string) (Wallet, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return Wallet{}, err
+	}
+
+	row, err := r.queries(ctx).GetWallet(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Wallet{}, errs.ErrNotFound
+		}
+		return Wallet{}, err
+	}
+
+	return mapWallet(row), nil
+}
+
+// LockWallet reads the wallet and locks it until the transaction ends
+func (r *repository) LockWallet(ctx context.Context, userID string) (Wallet, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return Wallet{}, err
+	}
+
+	row, err := r.queries(ctx).LockWallet(ctx, userUUID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Wallet{}, errs.ErrNotFound
+		}
+		return Wallet{}, err
+	}
+
+	return mapWallet(row), nil
+}
+
+func (r *repository) UpdateBalance(ctx context.Context, userID string, balanceCents int64) (Wallet, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return Wallet{}, err
+	}
+
+	row, err := r.queries(ctx).UpdateWalletBalance(ctx, sqlc.UpdateWalletBalanceParams{
+		UserID:       userUUID,
+		BalanceCents: balanceCents,
+	})
+	if err != nil {
+		return Wallet{}, err
+	}
+
+	return mapWallet(row), nil
+}
+
+func (r *repository) CreateTransaction(ctx context.Context, in CreateTransactionInput) (Transaction, error) {
+	params := sqlc.CreateWalletTransactionParams{
+		Kind:              in.Kind,
+		AmountCents:       in.AmountCents,
+		BalanceAfterCents: in.BalanceAfterCents,
+		Currency:          in.Currency,
+		Reason:            pgtype.Text{String: in.Reason, Valid: in.Reason != ""},
+	}
+	if err := params.UserID.Scan(in.UserID); err != nil {
+		return Transaction{}, err
+	}
+	if in.OrderID != "" {
+		if err := params.OrderID.Scan(in.OrderID); err != nil {
+			return Transaction{}, err
+		}
+	}
+	if in.RefundID != "" {
+		if err := params.RefundID.Scan(in.RefundID); err != nil {
+			return Transaction{}, err
+		}
+	}
+	if in.SourceID != "" {
+		if err := params.SourceID.Scan(in.SourceID); err != nil {
+			return Transaction{}, err
+		}
+	}
+	if in.CreatedBy != "" {
+		if err := params.CreatedBy.Scan(in.CreatedBy); err != nil {
+			return Transaction{}, err
+		}
+	}
+	if in.ExpiresAt != nil {
+		params.ExpiresAt = pgtype.Timestamptz{Time: *in.ExpiresAt, Valid: true}
+	}
+
+	row, err := r.queries(ctx).CreateWalletTransaction(ctx, params)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	return mapTransaction(row), nil
+}
+
+func (r *repository) ListTransactions(ctx context.Context, userID string, limit, offset int32) ([]Transaction, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListWalletTransactions(ctx, sqlc.ListWalletTransactionsParams{
+		UserID: userUUID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapTransactions(rows), nil
+}
+
+func (r *repository) CountTransactions(ctx context.Context, userID string) (int64, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return 0, err
+	}
+
+	return r.queries(ctx).CountWalletTransactions(ctx, userUUID)
+}
+
+// ListAllTransactions returns the user's whole ledger, oldest first
+func (r *repository) ListAllTransactions(ctx context.Context, userID string) ([]Transaction, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return nil, err
+	}
+
+	rows, err := r.queries(ctx).ListAllWalletTransactions(ctx, userUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mapTransactions(rows), nil
+}
+
+// GetOrderDebit returns what the order took from the wallet and has not
+// been given back
+func (r *repository) GetOrderDebit(ctx context.Context, orderID string) (int64, error) {
+	var orderUUID pgtype.UUID
+	if err := orderUUID.Scan(orderID); err != nil {
+		return 0, err
+	}
+
+	return r.queries(ctx).GetOrderWalletDebit(ctx, orderUUID)
+}
+
+func (r *repository) ListUsersWithExpiredCredits(ctx context.Context, before time.Time, limit int32) ([]string, error) {
+	rows, err := r.queries(ctx).ListUsersWithExpiredCredits(ctx, sqlc.ListUsersWithExpiredCreditsParams{
+		ExpiredBefore: pgtype.Timestamptz{Time: before, Valid: true},
+		RowLimit:      limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, len(rows))
+	for i, row := range rows {
+		userIDs[i] = uuid.UUID(row.Bytes).String()
+	}
+
+	return userIDs, nil
+}
+
+func mapWallet(row sqlc.Wallet) Wallet {
+	return Wallet{
+		UserID:       uuid.UUID(row.UserID.Bytes),
+		BalanceCents: row.BalanceCents,
+		Currency:     row.Currency,
+		UpdatedAt:    timePtr(row.UpdatedAt),
+	}
+}
+
+func mapTransaction(row sqlc.WalletTransaction) Transaction {
+	return Transaction{
+		ID:                uuid.UUID(row.ID.Bytes),
+		UserID:            uuid.UUID(row.UserID.Bytes),
+		Kind:              row.Kind,
+		AmountCents:       row.AmountCents,
+		BalanceAfterCents: row.BalanceAfterCents,
+		Currency:          row.Currency,
+		OrderID:           uuidPtr(row.OrderID),
+		RefundID:          uuidPtr(row.RefundID),
+		SourceID:          uuidPtr(row.SourceID),
+		Reason:            row.Reason.String,
+		ExpiresAt:         timePtr(row.ExpiresAt),
+		CreatedBy:         uuidPtr(row.CreatedBy),
+		CreatedAt:         row.CreatedAt.Time,
+	}
+}
+
+func mapTransactions(rows []sqlc.WalletTransaction) []Transaction {
+	txns := make([]Transaction, len(rows))
+	for i, row := range rows {
+		txns[i] = mapTransaction(row)
+	}
+	return txns
+}
+
+func uuidPtr(id pgtype.UUID) *uuid.UUID {
+	if !id.Valid {
+		return nil
+	}
+	u := uuid.UUID(id.Bytes)
+	return &u
+}
+
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time
+	return &t
+}
@@ -0,0 +1,26 @@
+package wallet
+
+import (
+	"net/http"
+
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Routes mounts the wallet endpoints. idempotent guards credits against
+// client retries.
+func Routes(svc Service, idempotent func(http.Handler) http.Handler) chi.Router {
+	h := NewHandler(svc)
+	r := chi.NewRouter()
+
+	r.With(middleware.RoleMiddleware("customer", "admin")).Get("/", h.GetMyWallet)
+	r.With(middleware.RoleMiddleware("customer", "admin")).Get("/transactions", h.ListMyTransactions)
+
+	r.With(middleware.RoleMiddleware("admin", "support")).Get("/users/{userID}", h.GetUserWallet)
+	r.With(middleware.RoleMiddleware("admin", "support")).Get("/users/{userID}/transactions", h.ListUserTransactions)
+	r.With(middleware.RoleMiddleware("admin")).With(idempotent).With(validator.Validate[IssueCreditRequest]()).Post("/users/{userID}/credits", h.IssueCredit)
+
+	return r
+}
@@ -0,0 +1,420 @@
+package wallet
+
+import (
+	"context"
+	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/logger"
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/pkg/pagination"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Service interface {
+	GetWallet(ctx context.Context, userID string) (Wallet, *errs.AppError)
+	ListTransactions(ctx context.Context, userID string, page, perPage int) (TransactionsWithMeta, *errs.AppError)
+	IssueCredit(ctx context.Context, adminID, userID string, req IssueCreditRequest) (Transaction, *errs.AppError)
+	DebitForOrder(ctx context.Context, userID, orderID string, maxCents int64, currency string) (int64, *errs.AppError)
+	RefundToWallet(ctx context.Context, in RefundInput) (Transaction, *errs.AppError)
+	RestoreOrderDebit(ctx context.Context, userID, orderID, changedBy, reason string) (int64, *errs.AppError)
+	RestoreOrderDebitUpTo(ctx context.Context, userID, orderID string, maxCents int64, changedBy, reason string) (int64, *errs.AppError)
+	ExpireCredits(ctx context.Context) (int, *errs.AppError)
+}
+
+// expiryBatch caps how many wallets one expiry sweep goes through
+const expiryBatch = 100
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+// GetWallet returns the user's wallet. A user who never had credit has an
+// empty wallet.
+func (s *service) GetWallet(ctx context.Context, userID string) (Wallet, *errs.AppError) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return Wallet{}, errs.ErrBadRequest.WithMessage("Invalid user ID")
+	}
+
+	w, err := s.repo.GetWallet(ctx, userID)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Wallet{UserID: uuid.MustParse(userID), Currency: DefaultCurrency}, nil
+		}
+		logger.Error("Failed to get wallet for user %s: %v", userID, err)
+		return Wallet{}, errs.ErrInternal.WithMessage("Failed to get wallet")
+	}
+
+	return w, nil
+}
+
+// ListTransactions pages through the user's wallet history, newest first
+func (s *service) ListTransactions(ctx context.Context, userID string, page, perPage int) (TransactionsWithMeta, *errs.AppError) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return TransactionsWithMeta{}, errs.ErrBadRequest.WithMessage("Invalid user ID")
+	}
+
+	p := pagination.New(page, perPage)
+
+	txns, err := s.repo.ListTransactions(ctx, userID, int32(p.PerPage), int32(p.Offset()))
+	if err != nil {
+		logger.Error("Failed to list wallet transactions for user %s: %v", userID, err)
+		return TransactionsWithMeta{}, errs.ErrInternal.WithMessage("Failed to list wallet transactions")
+	}
+
+	total, err := s.repo.CountTransactions(ctx, userID)
+	if err != nil {
+		return TransactionsWithMeta{}, errs.ErrInternal.WithMessage("Failed to count wallet transactions")
+	}
+
+	return TransactionsWithMeta{
+		Transactions: txns,
+		Meta: response.Meta{
+			Page:    p.Page,
+			PerPage: p.PerPage,
+			Total:   int(total),
+		},
+	}, nil
+}
+
+// IssueCredit gives a customer goodwill credit
+func (s *service) IssueCredit(ctx context.Context, adminID, userID string, req IssueCreditRequest) (Transaction, *errs.AppError) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return Transaction{}, errs.ErrBadRequest.WithMessage("Invalid user ID")
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return Transaction{}, errs.ErrBadRequest.WithMessage("Credit must expire in the future")
+	}
+
+	var txn Transaction
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		w, appErr := s.lockWallet(ctx, userID, DefaultCurrency)
+		if appErr != nil {
+			return appErr
+		}
+
+		var err error
+		txn, err = s.post(ctx, w, CreateTransactionInput{
+			UserID:      userID,
+			Kind:        KindCredit,
+			AmountCents: req.AmountCents,
+			Reason:      req.Reason,
+			ExpiresAt:   req.ExpiresAt,
+			CreatedBy:   adminID,
+		})
+		return err
+	})
+	if err != nil {
+		logger.Error("Failed to credit wallet of user %s: %v", userID, err)
+		return Transaction{}, errs.EnsureAppError(err)
+	}
+
+	return txn, nil
+}
+
+// DebitForOrder takes up to maxCents from the wallet to pay for an order
+// and returns how much it took, which is 0 for an empty wallet. It runs in
+// the caller's transaction so a failed checkout gives the money back.
+func (s *service) DebitForOrder(ctx context.Context, userID, orderID string, maxCents int64, currency string) (int64, *errs.AppError) {
+	var taken int64
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		w, err := s.repo.LockWallet(ctx, userID)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return nil
+			}
+			return errs.ErrInternal.WithMessage("Failed to get wallet")
+		}
+
+		taken = min(w.BalanceCents, maxCents)
+		if taken <= 0 {
+			taken = 0
+			return nil
+		}
+		if !strings.EqualFold(w.Currency, currency) {
+			return errs.ErrBadRequest.WithMessage(fmt.Sprintf("Wallet credit is in %s and cannot pay for an order in %s", w.Currency, currency))
+		}
+
+		_, err = s.post(ctx, w, CreateTransactionInput{
+			UserID:      userID,
+			Kind:        KindDebit,
+			AmountCents: -taken,
+			OrderID:     orderID,
+			Reason:      "Applied at checkout",
+			CreatedBy:   userID,
+		})
+		return err
+	})
+	if err != nil {
+		logger.Error("Failed to debit wallet of user %s for order %s: %v", userID, orderID, err)
+		return 0, errs.EnsureAppError(err)
+	}
+
+	return taken, nil
+}
+
+// RefundToWallet pays money back into the user's wallet as credit that
+// does not expire
+func (s *service) RefundToWallet(ctx context.Context, in RefundInput) (Transaction, *errs.AppError) {
+	if in.AmountCents <= 0 {
+		return Transaction{}, errs.ErrBadRequest.WithMessage("Refund amount must be greater than zero")
+	}
+
+	var txn Transaction
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		w, appErr := s.lockWallet(ctx, in.UserID, in.Currency)
+		if appErr != nil {
+			return appErr
+		}
+		if !strings.EqualFold(w.Currency, in.Currency) {
+			return errs.ErrConflict.WithMessage(fmt.Sprintf("Wallet holds %s and cannot take a refund in %s", w.Currency, in.Currency))
+		}
+
+		var err error
+		txn, err = s.post(ctx, w, CreateTransactionInput{
+			UserID:      in.UserID,
+			Kind:        KindRefund,
+			AmountCents: in.AmountCents,
+			OrderID:     in.OrderID,
+			RefundID:    in.RefundID,
+			Reason:      in.Reason,
+			CreatedBy:   in.CreatedBy,
+		})
+		return err
+	})
+	if err != nil {
+		logger.Error("Failed to refund %d cents to wallet of user %s: %v", in.AmountCents, in.UserID, err)
+		return Transaction{}, errs.EnsureAppError(err)
+	}
+
+	return txn, nil
+}
+
+// RestoreOrderDebit gives back whatever an order took from the wallet and
+// has not given back yet, returning the amount restored. Calling it again
+// restores nothing.
+func (s *service) RestoreOrderDebit(ctx context.Context, userID, orderID, changedBy, reason string) (int64, *errs.AppError) {
+	return s.RestoreOrderDebitUpTo(ctx, userID, orderID, math.MaxInt64, changedBy, reason)
+}
+
+// RestoreOrderDebitUpTo is RestoreOrderDebit limited to maxCents, for
+// giving back the share of a partly returned order
+func (s *service) RestoreOrderDebitUpTo(ctx context.Context, userID, orderID string, maxCents int64, changedBy, reason string) (int64, *errs.AppError) {
+	var restored int64
+
+	err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+		w, err := s.repo.LockWallet(ctx, userID)
+		if err != nil {
+			if errors.Is(err, errs.ErrNotFound) {
+				return nil
+			}
+			return errs.ErrInternal.WithMessage("Failed to get wallet")
+		}
+
+		outstanding, err := s.repo.GetOrderDebit(ctx, orderID)
+		if err != nil {
+			return errs.ErrInternal.WithMessage("Failed to get order wallet debit")
+		}
+		amount := min(outstanding, maxCents)
+		if amount <= 0 {
+			return nil
+		}
+
+		if _, err := s.post(ctx, w, CreateTransactionInput{
+			UserID:      userID,
+			Kind:        KindRefund,
+			AmountCents: amount,
+			OrderID:     orderID,
+			Reason:      reason,
+			CreatedBy:   changedBy,
+		}); err != nil {
+			return err
+		}
+
+		restored = amount
+		return nil
+	})
+	if err != nil {
+		logger.Error("Failed to restore wallet debit of order %s: %v", orderID, err)
+		return 0, errs.EnsureAppError(err)
+	}
+
+	return restored, nil
+}
+
+// ExpireCredits lapses whatever is left of credits past their expiry and
+// returns how many credits it lapsed. Failures are logged and retried on
+// the next sweep.
+func (s *service) ExpireCredits(ctx context.Context) (int, *errs.AppError) {
+	now := time.Now()
+
+	userIDs, err := s.repo.ListUsersWithExpiredCredits(ctx, now, expiryBatch)
+	if err != nil {
+		return 0, errs.ErrInternal.WithMessage("Failed to list expired wallet credits")
+	}
+
+	expired := 0
+	for _, userID := range userIDs {
+		err := s.repo.WithTx(ctx, func(ctx context.Context) error {
+			w, err := s.repo.LockWallet(ctx, userID)
+			if err != nil {
+				return err
+			}
+
+			txns, err := s.repo.ListAllTransactions(ctx, userID)
+			if err != nil {
+				return err
+			}
+
+			for _, lapse := range expiredCredits(txns, now) {
+				w.BalanceCents, err = s.postExpiry(ctx, w, lapse)
+				if err != nil {
+					return err
+				}
+				expired++
+			}
+			return nil
+		})
+		if err != nil {
+			logger.Error("Failed to expire wallet credit of user %s: %v", userID, err)
+		}
+	}
+
+	return expired, nil
+}
+
+func (s *service) postExpiry(ctx context.Context, w Wallet, lapse creditLot) (int64, error) {
+	txn, err := s.post(ctx, w, CreateTransactionInput{
+		UserID:      w.UserID.String(),
+		Kind:        KindExpiry,
+		AmountCents: -lapse.remaining,
+		SourceID:    lapse.id,
+		Reason:      "Credit expired",
+	})
+	if err != nil {
+		return 0, err
+	}
+	return txn.BalanceAfterCents, nil
+}
+
+// lockWallet opens the user's wallet in currency if they have none yet and
+// locks it for the rest of the transaction.
+func (s *service) lockWallet(ctx context.Context, userID, currency string) (Wallet, *errs.AppError) {
+	if err := s.repo.EnsureWallet(ctx, userID, strings.ToUpper(currency)); err != nil {
+		logger.Error("Failed to open wallet for user %s: %v", userID, err)
+		return Wallet{}, errs.ErrInternal.WithMessage("Failed to open wallet")
+	}
+
+	w, err := s.repo.LockWallet(ctx, userID)
+	if err != nil {
+		return Wallet{}, errs.ErrInternal.WithMessage("Failed to get wallet")
+	}
+
+	return w, nil
+}
+
+// post appends a transaction to a wallet locked by the caller and moves its
+// balance by the transaction's amount.
+func (s *service) post(ctx context.Context, w Wallet, in CreateTransactionInput) (Transaction, error) {
+	balance := w.BalanceCents + in.AmountCents
+	if balance < 0 {
+		return Transaction{}, errs.ErrConflict.WithMessage("Insufficient wallet balance")
+	}
+
+	in.BalanceAfterCents = balance
+	in.Currency = w.Currency
+
+	txn, err := s.repo.CreateTransaction(ctx, in)
+	if err != nil {
+		return Transaction{}, err
+	}
+
+	if _, err := s.repo.UpdateBalance(ctx, in.UserID, balance); err != nil {
+		return Transaction{}, err
+	}
+
+	return txn, nil
+}
+
+// creditLot is what is left of one credit or refund in a wallet
+type creditLot struct {
+	id        string
+	expiresAt *time.Time
+	remaining int64
+}
+
+// expiredCredits replays a wallet's ledger, oldest first, and returns the
+// credits that expired before now and have not been lapsed yet, with what
+// is left of each. Spending draws on the credit that expires soonest, and
+// on credit that never expires last, so expiring credit is used up first.
+func expiredCredits(txns []Transaction, now time.Time) []creditLot {
+	var lots []*creditLot
+	byID := map[string]*creditLot{}
+
+	for _, txn := range txns {
+		switch txn.Kind {
+		case KindCredit, KindRefund:
+			lot := &creditLot{id: txn.ID.String(), expiresAt: txn.ExpiresAt, remaining: txn.AmountCents}
+			lots = append(lots, lot)
+			byID[lot.id] = lot
+		case KindExpiry:
+			if txn.SourceID != nil {
+				if lot, ok := byID[txn.SourceID.String()]; ok {
+					lot.remaining = 0
+				}
+			}
+		case KindDebit:
+			sort.SliceStable(lots, func(i, j int) bool {
+				return expiresBefore(lots[i].expiresAt, lots[j].expiresAt)
+			})
+			owed := -txn.AmountCents
+			for _, lot := range lots {
+				if owed == 0 {
+					break
+				}
+				take := min(lot.remaining, owed)
+				lot.remaining -= take
+				owed -= take
+			}
+		}
+	}
+
+	lapsed := map[string]bool{}
+	for _, txn := range txns {
+		if txn.Kind == KindExpiry && txn.SourceID != nil {
+			lapsed[txn.SourceID.String()] = true
+		}
+	}
+
+	var expired []creditLot
+	for _, lot := range lots {
+		if lot.expiresAt != nil && lot.expiresAt.Before(now) && !lapsed[lot.id] {
+			expired = append(expired, *lot)
+		}
+	}
+
+	return expired
+}
+
+// expiresBefore orders expiry times soonest first, with no expiry last
+func expiresBefore(a, b *time.Time) bool {
+	if a == nil {
+		return false
+	}
+	if b == nil {
+		return true
+	}
+	return a.Before(*b)
+}
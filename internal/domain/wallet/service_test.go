@@ -0,0 +1,154 @@
+package wallet
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ledger builds a wallet's transactions oldest first, naming the credits
+// so expiries and results can refer to them
+type ledger struct {
+	txns []Transaction
+	ids  map[string]uuid.UUID
+}
+
+func newLedger() *ledger {
+	return &ledger{ids: map[string]uuid.UUID{}}
+}
+
+func (l *ledger) credit(name, kind string, cents int64, expiresAt *time.Time) *ledger {
+	l.ids[name] = uuid.New()
+	l.txns = append(l.txns, Transaction{ID: l.ids[name], Kind: kind, AmountCents: cents, ExpiresAt: expiresAt})
+	return l
+}
+
+func (l *ledger) debit(cents int64) *ledger {
+	l.txns = append(l.txns, Transaction{ID: uuid.New(), Kind: KindDebit, AmountCents: -cents})
+	return l
+}
+
+func (l *ledger) expire(name string, cents int64) *ledger {
+	source := l.ids[name]
+	l.txns = append(l.txns, Transaction{ID: uuid.New(), Kind: KindExpiry, AmountCents: -cents, SourceID: &source})
+	return l
+}
+
+func TestExpiredCredits(t *testing.T) {
+	now := time.Now()
+	yesterday := now.Add(-24 * time.Hour)
+	lastWeek := now.Add(-7 * 24 * time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		name   string
+		ledger *ledger
+		want   map[string]int64 // credit name to the cents left to lapse
+	}{
+		{
+			name:   "unspent credit lapses in full",
+			ledger: newLedger().credit("promo", KindCredit, 1000, &yesterday),
+			want:   map[string]int64{"promo": 1000},
+		},
+		{
+			name:   "credit not yet expired",
+			ledger: newLedger().credit("promo", KindCredit, 1000, &tomorrow),
+			want:   map[string]int64{},
+		},
+		{
+			name:   "credit without expiry never lapses",
+			ledger: newLedger().credit("refund", KindRefund, 1000, nil),
+			want:   map[string]int64{},
+		},
+		{
+			name:   "spending is taken from the credit",
+			ledger: newLedger().credit("promo", KindCredit, 1000, &yesterday).debit(300),
+			want:   map[string]int64{"promo": 700},
+		},
+		{
+			name: "expiring credit is spent before credit that never expires",
+			ledger: newLedger().
+				credit("refund", KindRefund, 500, nil).
+				credit("promo", KindCredit, 500, &yesterday).
+				debit(600),
+			want: map[string]int64{"promo": 0},
+		},
+		{
+			name: "soonest expiring credit is spent first",
+			ledger: newLedger().
+				credit("late", KindCredit, 500, &yesterday).
+				credit("early", KindCredit, 500, &lastWeek).
+				debit(700),
+			want: map[string]int64{"early": 0, "late": 300},
+		},
+		{
+			name: "spending before a credit arrived does not touch it",
+			ledger: newLedger().
+				credit("refund", KindRefund, 500, nil).
+				debit(500).
+				credit("promo", KindCredit, 200, &yesterday),
+			want: map[string]int64{"promo": 200},
+		},
+		{
+			name: "already lapsed credit is left alone",
+			ledger: newLedger().
+				credit("promo", KindCredit, 1000, &lastWeek).
+				expire("promo", 1000).
+				credit("gift", KindCredit, 400, &yesterday),
+			want: map[string]int64{"gift": 400},
+		},
+		{
+			name: "lapsed credit is not spent again",
+			ledger: newLedger().
+				credit("old", KindCredit, 1000, &lastWeek).
+				expire("old", 1000).
+				credit("promo", KindCredit, 1000, &yesterday).
+				debit(400),
+			want: map[string]int64{"promo": 600},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := map[string]int64{}
+			for _, lot := range expiredCredits(tt.ledger.txns, now) {
+				for name, id := range tt.ledger.ids {
+					if id.String() == lot.id {
+						got[name] = lot.remaining
+					}
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expiredCredits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpiresBefore(t *testing.T) {
+	now := time.Now()
+	later := now.Add(time.Hour)
+
+	tests := []struct {
+		name string
+		a    *time.Time
+		b    *time.Time
+		want bool
+	}{
+		{name: "sooner first", a: &now, b: &later, want: true},
+		{name: "later not first", a: &later, b: &now, want: false},
+		{name: "expiring before never expiring", a: &now, b: nil, want: true},
+		{name: "never expiring last", a: nil, b: &now, want: false},
+		{name: "neither expires", a: nil, b: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expiresBefore(tt.a, tt.b); got != tt.want {
+				t.Errorf("expiresBefore = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package wallet
+
+import (
+	"ecommerce-app/internal/pkg/response"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProviderName is recorded as the refund provider when a card refund is
+// paid out as store credit instead.
+const ProviderName = "WALLET"
+
+// DefaultCurrency is the currency a wallet is opened in
+const DefaultCurrency = "USD"
+
+// Transaction kinds, matching the wallet_transactions.kind CHECK constraint.
+// CREDIT and REFUND add to the balance, DEBIT and EXPIRY take from it.
+const (
+	KindCredit = "CREDIT"
+	KindDebit  = "DEBIT"
+	KindRefund = "REFUND"
+	KindExpiry = "EXPIRY"
+)
+
+// Wallet is a customer's store-credit balance
+type Wallet struct {
+	UserID       uuid.UUID  `json:"user_id"`
+	BalanceCents int64      `json:"balance_cents"`
+	Currency     string     `json:"currency"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+}
+
+// Transaction is one entry in a wallet's ledger. AmountCents is signed;
+// BalanceAfterCents is the balance once it was applied.
+type Transaction struct {
+	ID                uuid.UUID  `json:"id"`
+	UserID            uuid.UUID  `json:"user_id"`
+	Kind              string     `json:"kind"`
+	AmountCents       int64      `json:"amount_cents"`
+	BalanceAfterCents int64      `json:"balance_after_cents"`
+	Currency          string     `json:"currency"`
+	OrderID           *uuid.UUID `json:"order_id,omitempty"`
+	RefundID          *uuid.UUID `json:"refund_id,omitempty"`
+	SourceID          *uuid.UUID `json:"source_id,omitempty"`
+	Reason            string     `json:"reason,omitempty"`
+	ExpiresAt         *time.Time `json:"expires_at,omitempty"`
+	CreatedBy         *uuid.UUID `json:"created_by,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+}
+
+type TransactionsWithMeta struct {
+	Transactions []Transaction `json:"transactions"`
+	Meta         response.Meta `json:"meta"`
+}
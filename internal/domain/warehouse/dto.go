@@ -0,0 +1,17 @@
+package warehouse
+
+// --- Request DTOs ---
+type CreateWarehouseRequest struct {
+	Code     string `json:"code" validate:"required,min=2,max=20,alphanum"`
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Country  string `json:"country" validate:"required,len=2,alpha"`
+	Priority *int32 `json:"priority,omitempty" validate:"omitempty,min=0"`
+	IsActive *bool  `json:"is_active,omitempty"`
+}
+
+type UpdateWarehouseRequest struct {
+	Name     *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Country  *string `json:"country,omitempty" validate:"omitempty,len=2,alpha"`
+	Priority *int32  `json:"priority,omitempty" validate:"omitempty,min=0"`
+	IsActive *bool   `json:"is_active,omitempty"`
+}
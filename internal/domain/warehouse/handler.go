@@ -0,0 +1,64 @@
+package warehouse
+
+import (
+	"ecommerce-app/internal/pkg/response"
+	"ecommerce-app/internal/pkg/validator"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type Handler struct {
+	svc Service
+}
+
+func NewHandler(svc Service) *Handler {
+	return &Handler{svc: svc}
+}
+
+func (h *Handler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	req := validator.GetValidatedBody[CreateWarehouseRequest](r)
+
+	wh, appErr := h.svc.CreateWarehouse(r.Context(), req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.Created(w, wh, "Warehouse created successfully")
+}
+
+func (h *Handler) ListWarehouses(w http.ResponseWriter, r *http.Request) {
+	warehouses, appErr := h.svc.ListWarehouses(r.Context())
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, warehouses, "Warehouses fetched successfully")
+}
+
+func (h *Handler) GetWarehouse(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	wh, appErr := h.svc.GetWarehouse(r.Context(), id)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, wh, "Warehouse fetched successfully")
+}
+
+func (h *Handler) UpdateWarehouse(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	req := validator.GetValidatedBody[UpdateWarehouseRequest](r)
+
+	wh, appErr := h.svc.UpdateWarehouse(r.Context(), id, req)
+	if appErr != nil {
+		response.Error(w, appErr.Code, appErr.Message)
+		return
+	}
+
+	response.OK(w, wh, "Warehouse updated successfully")
+}
@@ -0,0 +1,120 @@
+package warehouse
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/errs"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+type Repository interface {
+	Create(ctx context.Context, w Warehouse) (Warehouse, error)
+	GetByID(ctx context.Context, id string) (Warehouse, error)
+	GetDefault(ctx context.Context) (Warehouse, error)
+	List(ctx context.Context) ([]Warehouse, error)
+	Update(ctx context.Context, w Warehouse) (Warehouse, error)
+}
+
+// repository implements Repository
+type repository struct {
+	q *sqlc.Queries
+}
+
+func NewRepository(q *sqlc.Queries) Repository {
+	return &repository{q: q}
+}
+
+func (r *repository) Create(ctx context.Context, w Warehouse) (Warehouse, error) {
+	row, err := r.q.CreateWarehouse(ctx, sqlc.CreateWarehouseParams{
+		Code:     w.Code,
+		Name:     w.Name,
+		Country:  w.Country,
+		Priority: w.Priority,
+		IsActive: w.IsActive,
+	})
+	if err != nil {
+		return Warehouse{}, err
+	}
+
+	return mapWarehouse(row), nil
+}
+
+func (r *repository) GetByID(ctx context.Context, id string) (Warehouse, error) {
+	var uuidID pgtype.UUID
+	if err := uuidID.Scan(id); err != nil {
+		return Warehouse{}, err
+	}
+
+	row, err := r.q.GetWarehouse(ctx, uuidID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Warehouse{}, errs.ErrNotFound
+		}
+		return Warehouse{}, err
+	}
+
+	return mapWarehouse(row), nil
+}
+
+// GetDefault returns the active warehouse allocated first
+func (r *repository) GetDefault(ctx context.Context) (Warehouse, error) {
+	row, err := r.q.GetDefaultWarehouse(ctx)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Warehouse{}, errs.ErrNotFound
+		}
+		return Warehouse{}, err
+	}
+
+	return mapWarehouse(row), nil
+}
+
+func (r *repository) List(ctx context.Context) ([]Warehouse, error) {
+	rows, err := r.q.ListWarehouses(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	warehouses := make([]Warehouse, len(rows))
+	for i, row := range rows {
+		warehouses[i] = mapWarehouse(row)
+	}
+
+	return warehouses, nil
+}
+
+func (r *repository) Update(ctx context.Context, w Warehouse) (Warehouse, error) {
+	row, err := r.q.UpdateWarehouse(ctx, sqlc.UpdateWarehouseParams{
+		ID:       pgtype.UUID{Bytes: w.ID, Valid: true},
+		Name:     w.Name,
+		Country:  w.Country,
+		Priority: w.Priority,
+		IsActive: w.IsActive,
+	})
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Warehouse{}, errs.ErrNotFound
+		}
+		return Warehouse{}, err
+	}
+
+	return mapWarehouse(row), nil
+}
+
+func mapWarehouse(row sqlc.Warehouse) Warehouse {
+	return Warehouse{
+		ID:        uuid.UUID(row.ID.Bytes),
+		Code:      row.Code,
+		Name:      row.Name,
+		Country:   row.Country,
+		Priority:  row.Priority,
+		IsActive:  row.IsActive,
+		CreatedAt: row.CreatedAt.Time,
+		UpdatedAt: row.UpdatedAt.Time,
+	}
+}
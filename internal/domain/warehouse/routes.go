@@ -0,0 +1,20 @@
+package warehouse
+
+import (
+	"ecommerce-app/internal/pkg/middleware"
+	"ecommerce-app/internal/pkg/validator"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func Routes(svc Service) chi.Router {
+	h := NewHandler(svc)
+	r := chi.NewRouter()
+
+	r.With(validator.Validate[CreateWarehouseRequest]()).With(middleware.RoleMiddleware("admin")).Post("/", h.CreateWarehouse)
+	r.With(middleware.RoleMiddleware("admin")).Get("/", h.ListWarehouses)
+	r.With(middleware.RoleMiddleware("admin")).Get("/{id}", h.GetWarehouse)
+	r.With(validator.Validate[UpdateWarehouseRequest]()).With(middleware.RoleMiddleware("admin")).Put("/{id}", h.UpdateWarehouse)
+
+	return r
+}
@@ -0,0 +1,126 @@
+package warehouse
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"ecommerce-app/internal/pkg/database"
+	"ecommerce-app/internal/pkg/errs"
+	"ecommerce-app/internal/pkg/logger"
+)
+
+// defaultPriority is where new warehouses are allocated when no priority
+// is given: after any warehouse given a lower one
+const defaultPriority = 100
+
+type Service interface {
+	CreateWarehouse(ctx context.Context, req CreateWarehouseRequest) (Warehouse, *errs.AppError)
+	GetWarehouse(ctx context.Context, id string) (Warehouse, *errs.AppError)
+	GetDefaultWarehouse(ctx context.Context) (Warehouse, *errs.AppError)
+	ListWarehouses(ctx context.Context) ([]Warehouse, *errs.AppError)
+	UpdateWarehouse(ctx context.Context, id string, req UpdateWarehouseRequest) (Warehouse, *errs.AppError)
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) Service {
+	return &service{repo: repo}
+}
+
+func (s *service) CreateWarehouse(ctx context.Context, req CreateWarehouseRequest) (Warehouse, *errs.AppError) {
+	w := Warehouse{
+		Code:     strings.ToUpper(strings.TrimSpace(req.Code)),
+		Name:     strings.TrimSpace(req.Name),
+		Country:  strings.ToUpper(req.Country),
+		Priority: defaultPriority,
+		IsActive: true,
+	}
+	if req.Priority != nil {
+		w.Priority = *req.Priority
+	}
+	if req.IsActive != nil {
+		w.IsActive = *req.IsActive
+	}
+
+	created, err := s.repo.Create(ctx, w)
+	if err != nil {
+		if database.IsUniqueViolation(err) {
+			return Warehouse{}, errs.ErrConflict.WithMessage("Warehouse code is already in use")
+		}
+		logger.Error("Failed to create warehouse: %v", err)
+		return Warehouse{}, errs.ErrInternal.WithMessage("Failed to create warehouse")
+	}
+
+	return created, nil
+}
+
+func (s *service) GetWarehouse(ctx context.Context, id string) (Warehouse, *errs.AppError) {
+	w, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Warehouse{}, errs.ErrNotFound.WithMessage("Warehouse not found")
+		}
+		return Warehouse{}, errs.ErrInternal.WithMessage("Failed to get warehouse")
+	}
+
+	return w, nil
+}
+
+// GetDefaultWarehouse returns the active warehouse allocated first, which
+// takes stock booked without naming a warehouse
+func (s *service) GetDefaultWarehouse(ctx context.Context) (Warehouse, *errs.AppError) {
+	w, err := s.repo.GetDefault(ctx)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Warehouse{}, errs.ErrConflict.WithMessage("No active warehouse")
+		}
+		return Warehouse{}, errs.ErrInternal.WithMessage("Failed to get default warehouse")
+	}
+
+	return w, nil
+}
+
+func (s *service) ListWarehouses(ctx context.Context) ([]Warehouse, *errs.AppError) {
+	warehouses, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, errs.ErrInternal.WithMessage("Failed to get warehouses")
+	}
+
+	return warehouses, nil
+}
+
+// UpdateWarehouse changes a warehouse's details. Warehouses are never
+// deleted since stock movements refer to them; deactivate one instead.
+func (s *service) UpdateWarehouse(ctx context.Context, id string, req UpdateWarehouseRequest) (Warehouse, *errs.AppError) {
+	w, appErr := s.GetWarehouse(ctx, id)
+	if appErr != nil {
+		return Warehouse{}, appErr
+	}
+
+	if req.Name != nil {
+		w.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Country != nil {
+		w.Country = strings.ToUpper(*req.Country)
+	}
+	if req.Priority != nil {
+		w.Priority = *req.Priority
+	}
+	if req.IsActive != nil {
+		w.IsActive = *req.IsActive
+	}
+
+	updated, err := s.repo.Update(ctx, w)
+	if err != nil {
+		if errors.Is(err, errs.ErrNotFound) {
+			return Warehouse{}, errs.ErrNotFound.WithMessage("Warehouse not found")
+		}
+		logger.Error("Failed to update warehouse %s: %v", id, err)
+		return Warehouse{}, errs.ErrInternal.WithMessage("Failed to update warehouse")
+	}
+
+	return updated, nil
+}
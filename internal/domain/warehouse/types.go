@@ -0,0 +1,23 @@
+package warehouse
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// --- Domain Models ---
+
+// Warehouse is a location stock is held and shipped from. Warehouses with
+// a lower Priority are allocated first; inactive ones keep their stock but
+// are not allocated from.
+type Warehouse struct {
+	ID        uuid.UUID `json:"id"`
+	Code      string    `json:"code"`
+	Name      string    `json:"name"`
+	Country   string    `json:"country"`
+	Priority  int32     `json:"priority"`
+	IsActive  bool      `json:"is_active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
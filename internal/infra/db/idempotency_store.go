@@ -0,0 +1,85 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"ecommerce-app/internal/pkg/database/sqlc"
+	"ecommerce-app/internal/pkg/middleware"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// IdempotencyStore keeps Idempotency-Key records in Postgres.
+type IdempotencyStore struct {
+	q *sqlc.Queries
+}
+
+var _ middleware.IdempotencyStore = (*IdempotencyStore)(nil)
+
+func NewIdempotencyStore(q *sqlc.Queries) *IdempotencyStore {
+	return &IdempotencyStore{q: q}
+}
+
+func (s *IdempotencyStore) Claim(ctx context.Context, userID, key, method, path, requestHash string) (middleware.IdempotencyRecord, bool, error) {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return middleware.IdempotencyRecord{}, false, err
+	}
+
+	_, err := s.q.ClaimIdempotencyKey(ctx, sqlc.ClaimIdempotencyKeyParams{
+		UserID:         userUUID,
+		IdempotencyKey: key,
+		Method:         method,
+		Path:           path,
+		RequestHash:    requestHash,
+	})
+	if err == nil {
+		return middleware.IdempotencyRecord{}, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return middleware.IdempotencyRecord{}, false, err
+	}
+
+	row, err := s.q.GetIdempotencyKey(ctx, sqlc.GetIdempotencyKeyParams{
+		UserID:         userUUID,
+		IdempotencyKey: key,
+	})
+	if err != nil {
+		return middleware.IdempotencyRecord{}, false, err
+	}
+
+	return middleware.IdempotencyRecord{
+		RequestHash: row.RequestHash,
+		StatusCode:  int(row.ResponseStatus.Int32),
+		Body:        row.ResponseBody,
+		Completed:   row.CompletedAt.Valid,
+	}, false, nil
+}
+
+func (s *IdempotencyStore) Complete(ctx context.Context, userID, key string, statusCode int, body []byte) error {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return err
+	}
+
+	return s.q.CompleteIdempotencyKey(ctx, sqlc.CompleteIdempotencyKeyParams{
+		UserID:         userUUID,
+		IdempotencyKey: key,
+		ResponseStatus: pgtype.Int4{Int32: int32(statusCode), Valid: true},
+		ResponseBody:   body,
+	})
+}
+
+func (s *IdempotencyStore) Release(ctx context.Context, userID, key string) error {
+	var userUUID pgtype.UUID
+	if err := userUUID.Scan(userID); err != nil {
+		return err
+	}
+
+	return s.q.DeleteIdempotencyKey(ctx, sqlc.DeleteIdempotencyKeyParams{
+		UserID:         userUUID,
+		IdempotencyKey: key,
+	})
+}
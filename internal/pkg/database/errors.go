@@ -0,0 +1,33 @@
+package database
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres SQLSTATE codes the domains react to
+const (
+	checkViolation      = "23514"
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// IsCheckViolation reports whether err was caused by a CHECK constraint
+func IsCheckViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == checkViolation
+}
+
+// IsUniqueViolation reports whether err was caused by a UNIQUE constraint
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// IsForeignKeyViolation reports whether err was caused by a FOREIGN KEY
+// constraint
+func IsForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
@@ -114,6 +114,27 @@ func (q *Queries) DeleteCartItemsByCart(ctx context.Context, cartID pgtype.UUID)
 	return err
 }
 
+const deleteOrderedCartItems = `-- name: DeleteOrderedCartItems :exec
+DELETE FROM cart_items ci
+USING (
+    SELECT unnest($1::uuid[]) AS id,
+           unnest($2::int[]) AS quantity
+) AS o(id, quantity)
+WHERE ci.id = o.id AND ci.quantity = o.quantity
+`
+
+type DeleteOrderedCartItemsParams struct {
+	Ids        []pgtype.UUID `json:"ids"`
+	Quantities []int32       `json:"quantities"`
+}
+
+// Removes the cart items a checkout ordered. Items added, or whose quantity
+// changed, after the checkout read the cart are left in it.
+func (q *Queries) DeleteOrderedCartItems(ctx context.Context, arg DeleteOrderedCartItemsParams) error {
+	_, err := q.db.Exec(ctx, deleteOrderedCartItems, arg.Ids, arg.Quantities)
+	return err
+}
+
 const getCartItem = `-- name: GetCartItem :one
 SELECT id, cart_id, product_id, quantity, created_at, updated_at
 FROM cart_items
@@ -167,6 +188,7 @@ SELECT ci.id, ci.cart_id, ci.product_id, ci.quantity, ci.created_at, ci.updated_
 FROM cart_items ci
 JOIN carts c ON ci.cart_id = c.id
 WHERE c.user_id = $1
+  AND (c.expires_at IS NULL OR c.expires_at > NOW())
 ORDER BY ci.created_at ASC
 `
 
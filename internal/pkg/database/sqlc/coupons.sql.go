@@ -201,6 +201,73 @@ func (q *Queries) IncrementCouponUsage(ctx context.Context, code string) (Coupon
 	return i, err
 }
 
+const redeemCoupon = `-- name: RedeemCoupon :one
+UPDATE coupons
+SET used_count = COALESCE(used_count, 0) + 1,
+    updated_at = NOW()
+WHERE code = $1
+  AND is_active IS TRUE
+  AND is_deleted IS NOT TRUE
+  AND valid_from <= NOW()
+  AND valid_until > NOW()
+  AND (max_uses IS NULL OR max_uses <= 0 OR COALESCE(used_count, 0) < max_uses)
+RETURNING id, code, description, discount_percent, valid_from, valid_until, max_uses, used_count, is_active, is_deleted, created_at, updated_at
+`
+
+// Counts one use of the coupon, only while it is still redeemable, so
+// concurrent orders cannot push it past max_uses. A max_uses of NULL or 0
+// means unlimited, as in Coupon.Redeemable.
+func (q *Queries) RedeemCoupon(ctx context.Context, code string) (Coupon, error) {
+	row := q.db.QueryRow(ctx, redeemCoupon, code)
+	var i Coupon
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountPercent,
+		&i.ValidFrom,
+		&i.ValidUntil,
+		&i.MaxUses,
+		&i.UsedCount,
+		&i.IsActive,
+		&i.IsDeleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const releaseCoupon = `-- name: ReleaseCoupon :one
+UPDATE coupons
+SET used_count = used_count - 1,
+    updated_at = NOW()
+WHERE code = $1
+  AND used_count > 0
+RETURNING id, code, description, discount_percent, valid_from, valid_until, max_uses, used_count, is_active, is_deleted, created_at, updated_at
+`
+
+// Gives back one use of the coupon, e.g. when the order that redeemed it is
+// cancelled. Returns no row when no use is counted.
+func (q *Queries) ReleaseCoupon(ctx context.Context, code string) (Coupon, error) {
+	row := q.db.QueryRow(ctx, releaseCoupon, code)
+	var i Coupon
+	err := row.Scan(
+		&i.ID,
+		&i.Code,
+		&i.Description,
+		&i.DiscountPercent,
+		&i.ValidFrom,
+		&i.ValidUntil,
+		&i.MaxUses,
+		&i.UsedCount,
+		&i.IsActive,
+		&i.IsDeleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const updateCoupon = `-- name: UpdateCoupon :one
 UPDATE coupons
 SET code = COALESCE($2, code),
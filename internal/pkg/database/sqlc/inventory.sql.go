@@ -11,6 +11,17 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countInventory = `-- name: CountInventory :one
+SELECT COUNT(*) FROM inventory
+`
+
+func (q *Queries) CountInventory(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countInventory)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createInventory = `-- name: CreateInventory :one
 INSERT INTO inventory (
     product_id,
@@ -18,7 +29,7 @@ INSERT INTO inventory (
     reserved
 ) VALUES (
     $1, $2, $3
-) RETURNING product_id, stock, reserved, created_at, updated_at
+) RETURNING product_id, stock, reserved, created_at, updated_at, low_stock_threshold, reorder_qty
 `
 
 type CreateInventoryParams struct {
@@ -36,6 +47,8 @@ func (q *Queries) CreateInventory(ctx context.Context, arg CreateInventoryParams
 		&i.Reserved,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LowStockThreshold,
+		&i.ReorderQty,
 	)
 	return i, err
 }
@@ -50,8 +63,35 @@ func (q *Queries) DeleteInventory(ctx context.Context, productID pgtype.UUID) er
 	return err
 }
 
+const getEffectiveStockThreshold = `-- name: GetEffectiveStockThreshold :one
+SELECT
+    COALESCE(i.low_stock_threshold, c.low_stock_threshold) AS low_stock_threshold,
+    COALESCE(i.reorder_qty, c.reorder_qty) AS reorder_qty
+FROM inventory i
+JOIN products p ON p.id = i.product_id
+LEFT JOIN category_stock_thresholds c ON c.category_id = p.category_id
+WHERE i.product_id = $1
+`
+
+type GetEffectiveStockThresholdRow struct {
+	LowStockThreshold pgtype.Int4 `json:"low_stock_threshold"`
+	ReorderQty        pgtype.Int4 `json:"reorder_qty"`
+}
+
+// The low-stock threshold and reorder quantity that apply to a product:
+// its own, else its category's.
+func (q *Queries) GetEffectiveStockThreshold(ctx context.Context, productID pgtype.UUID) (GetEffectiveStockThresholdRow, error) {
+	row := q.db.QueryRow(ctx, getEffectiveStockThreshold, productID)
+	var i GetEffectiveStockThresholdRow
+	err := row.Scan(
+		&i.LowStockThreshold,
+		&i.ReorderQty,
+	)
+	return i, err
+}
+
 const getInventoryByProductID = `-- name: GetInventoryByProductID :one
-SELECT product_id, stock, reserved, created_at, updated_at FROM inventory
+SELECT product_id, stock, reserved, created_at, updated_at, low_stock_threshold, reorder_qty FROM inventory
 WHERE product_id = $1 LIMIT 1
 `
 
@@ -64,26 +104,176 @@ func (q *Queries) GetInventoryByProductID(ctx context.Context, productID pgtype.
 		&i.Reserved,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LowStockThreshold,
+		&i.ReorderQty,
+	)
+	return i, err
+}
+
+const listLowStockInventory = `-- name: ListLowStockInventory :many
+WITH sales AS (
+    SELECT oi.product_id, SUM(oi.qty)::bigint AS units_sold
+    FROM order_items oi
+    JOIN orders o ON o.id = oi.order_id
+    WHERE o.paid_at >= $1
+      AND o.status NOT IN ('PENDING', 'CANCELLED')
+    GROUP BY oi.product_id
+)
+SELECT
+    i.product_id,
+    p.sku,
+    p.name,
+    i.stock,
+    i.reserved,
+    COALESCE(i.low_stock_threshold, c.low_stock_threshold) AS low_stock_threshold,
+    COALESCE(i.reorder_qty, c.reorder_qty) AS reorder_qty,
+    COALESCE(s.units_sold, 0)::bigint AS units_sold
+FROM inventory i
+JOIN products p ON p.id = i.product_id
+LEFT JOIN category_stock_thresholds c ON c.category_id = p.category_id
+LEFT JOIN sales s ON s.product_id = i.product_id
+WHERE i.stock - i.reserved <= COALESCE(i.low_stock_threshold, c.low_stock_threshold)
+ORDER BY i.stock - i.reserved, p.sku
+`
+
+type ListLowStockInventoryRow struct {
+	ProductID         pgtype.UUID `json:"product_id"`
+	Sku               string      `json:"sku"`
+	Name              string      `json:"name"`
+	Stock             int32       `json:"stock"`
+	Reserved          int32       `json:"reserved"`
+	LowStockThreshold pgtype.Int4 `json:"low_stock_threshold"`
+	ReorderQty        pgtype.Int4 `json:"reorder_qty"`
+	UnitsSold         int64       `json:"units_sold"`
+}
+
+// Products whose available stock is at or below the threshold that applies
+// to them, with the units sold by orders paid since sold_since, lowest
+// available first.
+func (q *Queries) ListLowStockInventory(ctx context.Context, soldSince pgtype.Timestamptz) ([]ListLowStockInventoryRow, error) {
+	rows, err := q.db.Query(ctx, listLowStockInventory, soldSince)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLowStockInventoryRow{}
+	for rows.Next() {
+		var i ListLowStockInventoryRow
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.Sku,
+			&i.Name,
+			&i.Stock,
+			&i.Reserved,
+			&i.LowStockThreshold,
+			&i.ReorderQty,
+			&i.UnitsSold,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductsBySku = `-- name: ListProductsBySku :many
+SELECT
+    p.id,
+    p.sku,
+    (i.product_id IS NOT NULL)::bool AS tracked
+FROM products p
+LEFT JOIN inventory i ON i.product_id = p.id
+WHERE p.sku = ANY($1::text[])
+`
+
+type ListProductsBySkuRow struct {
+	ID      pgtype.UUID `json:"id"`
+	Sku     string      `json:"sku"`
+	Tracked bool        `json:"tracked"`
+}
+
+// The products with the given SKUs; tracked tells whether they have an
+// inventory row.
+func (q *Queries) ListProductsBySku(ctx context.Context, skus []string) ([]ListProductsBySkuRow, error) {
+	rows, err := q.db.Query(ctx, listProductsBySku, skus)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListProductsBySkuRow{}
+	for rows.Next() {
+		var i ListProductsBySkuRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Sku,
+			&i.Tracked,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const moveInventory = `-- name: MoveInventory :one
+UPDATE inventory
+SET
+    stock = stock + $1::int,
+    reserved = reserved + $2::int,
+    updated_at = NOW()
+WHERE product_id = $3
+RETURNING product_id, stock, reserved, created_at, updated_at, low_stock_threshold, reorder_qty
+`
+
+type MoveInventoryParams struct {
+	Delta         int32       `json:"delta"`
+	ReservedDelta int32       `json:"reserved_delta"`
+	ProductID     pgtype.UUID `json:"product_id"`
+}
+
+// Moves the product's totals by a warehouse movement's deltas; the table's
+// CHECK constraints reject moves that would leave stock below zero or below
+// what is reserved.
+func (q *Queries) MoveInventory(ctx context.Context, arg MoveInventoryParams) (Inventory, error) {
+	row := q.db.QueryRow(ctx, moveInventory, arg.Delta, arg.ReservedDelta, arg.ProductID)
+	var i Inventory
+	err := row.Scan(
+		&i.ProductID,
+		&i.Stock,
+		&i.Reserved,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LowStockThreshold,
+		&i.ReorderQty,
 	)
 	return i, err
 }
 
-const updateInventoryStock = `-- name: UpdateInventoryStock :one
+const setInventoryThresholds = `-- name: SetInventoryThresholds :one
 UPDATE inventory
-SET 
-    stock = $2,
+SET
+    low_stock_threshold = $1,
+    reorder_qty = $2,
     updated_at = NOW()
-WHERE product_id = $1
-RETURNING product_id, stock, reserved, created_at, updated_at
+WHERE product_id = $3
+RETURNING product_id, stock, reserved, created_at, updated_at, low_stock_threshold, reorder_qty
 `
 
-type UpdateInventoryStockParams struct {
-	ProductID pgtype.UUID `json:"product_id"`
-	Stock     int32       `json:"stock"`
+type SetInventoryThresholdsParams struct {
+	LowStockThreshold pgtype.Int4 `json:"low_stock_threshold"`
+	ReorderQty        pgtype.Int4 `json:"reorder_qty"`
+	ProductID         pgtype.UUID `json:"product_id"`
 }
 
-func (q *Queries) UpdateInventoryStock(ctx context.Context, arg UpdateInventoryStockParams) (Inventory, error) {
-	row := q.db.QueryRow(ctx, updateInventoryStock, arg.ProductID, arg.Stock)
+// NULL clears the product's own setting, so its category's applies.
+func (q *Queries) SetInventoryThresholds(ctx context.Context, arg SetInventoryThresholdsParams) (Inventory, error) {
+	row := q.db.QueryRow(ctx, setInventoryThresholds, arg.LowStockThreshold, arg.ReorderQty, arg.ProductID)
 	var i Inventory
 	err := row.Scan(
 		&i.ProductID,
@@ -91,6 +281,8 @@ func (q *Queries) UpdateInventoryStock(ctx context.Context, arg UpdateInventoryS
 		&i.Reserved,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LowStockThreshold,
+		&i.ReorderQty,
 	)
 	return i, err
 }
@@ -196,6 +196,14 @@ type Category struct {
 	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
 }
 
+type CategoryStockThreshold struct {
+	CategoryID        pgtype.UUID        `json:"category_id"`
+	LowStockThreshold int32              `json:"low_stock_threshold"`
+	ReorderQty        pgtype.Int4        `json:"reorder_qty"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+}
+
 type Coupon struct {
 	ID              pgtype.UUID        `json:"id"`
 	Code            string             `json:"code"`
@@ -211,30 +219,116 @@ type Coupon struct {
 	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
 }
 
-type Inventory struct {
-	ProductID pgtype.UUID        `json:"product_id"`
-	Stock     int32              `json:"stock"`
-	Reserved  int32              `json:"reserved"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+type GiftCard struct {
+	ID           pgtype.UUID        `json:"id"`
+	Code         string             `json:"code"`
+	InitialCents int64              `json:"initial_cents"`
+	BalanceCents int64              `json:"balance_cents"`
+	Currency     string             `json:"currency"`
+	Status       string             `json:"status"`
+	OrderID      pgtype.UUID        `json:"order_id"`
+	OrderItemID  pgtype.UUID        `json:"order_item_id"`
+	PurchasedBy  pgtype.UUID        `json:"purchased_by"`
+	IssuedBy     pgtype.UUID        `json:"issued_by"`
+	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
 }
 
-type Order struct {
+type GiftCardRedemption struct {
 	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	OrderNumber   string             `json:"order_number"`
-	SubtotalCents int64              `json:"subtotal_cents"`
-	DiscountCents pgtype.Int8        `json:"discount_cents"`
-	TaxCents      pgtype.Int8        `json:"tax_cents"`
-	ShippingCents pgtype.Int8        `json:"shipping_cents"`
-	TotalCents    int64              `json:"total_cents"`
-	FinalCents    int64              `json:"final_cents"`
-	Currency      string             `json:"currency"`
+	GiftCardID    pgtype.UUID        `json:"gift_card_id"`
+	OrderID       pgtype.UUID        `json:"order_id"`
+	AmountCents   int64              `json:"amount_cents"`
 	Status        string             `json:"status"`
-	ShippingInfo  []byte             `json:"shipping_info"`
-	Notes         pgtype.Text        `json:"notes"`
 	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	ReversedAt    pgtype.Timestamptz `json:"reversed_at"`
+	RestoredCents int64              `json:"restored_cents"`
+}
+
+type IdempotencyKey struct {
+	ID             pgtype.UUID        `json:"id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	IdempotencyKey string             `json:"idempotency_key"`
+	Method         string             `json:"method"`
+	Path           string             `json:"path"`
+	RequestHash    string             `json:"request_hash"`
+	ResponseStatus pgtype.Int4        `json:"response_status"`
+	ResponseBody   []byte             `json:"response_body"`
+	LockedAt       pgtype.Timestamptz `json:"locked_at"`
+	CompletedAt    pgtype.Timestamptz `json:"completed_at"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+}
+
+type Inventory struct {
+	ProductID         pgtype.UUID        `json:"product_id"`
+	Stock             int32              `json:"stock"`
+	Reserved          int32              `json:"reserved"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	LowStockThreshold pgtype.Int4        `json:"low_stock_threshold"`
+	ReorderQty        pgtype.Int4        `json:"reorder_qty"`
+}
+
+type InventoryReservation struct {
+	ID          pgtype.UUID        `json:"id"`
+	OrderID     pgtype.UUID        `json:"order_id"`
+	OrderItemID pgtype.UUID        `json:"order_item_id"`
+	ProductID   pgtype.UUID        `json:"product_id"`
+	Qty         int32              `json:"qty"`
+	Status      string             `json:"status"`
+	ExpiresAt   pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	WarehouseID pgtype.UUID        `json:"warehouse_id"`
+}
+
+type LowStockAlert struct {
+	ID                pgtype.UUID        `json:"id"`
+	ProductID         pgtype.UUID        `json:"product_id"`
+	Available         int32              `json:"available"`
+	LowStockThreshold int32              `json:"low_stock_threshold"`
+	ReorderQty        pgtype.Int4        `json:"reorder_qty"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	DeliveredAt       pgtype.Timestamptz `json:"delivered_at"`
+}
+
+type Order struct {
+	ID                 pgtype.UUID        `json:"id"`
+	UserID             pgtype.UUID        `json:"user_id"`
+	OrderNumber        string             `json:"order_number"`
+	SubtotalCents      int64              `json:"subtotal_cents"`
+	DiscountCents      pgtype.Int8        `json:"discount_cents"`
+	TaxCents           pgtype.Int8        `json:"tax_cents"`
+	ShippingCents      pgtype.Int8        `json:"shipping_cents"`
+	TotalCents         int64              `json:"total_cents"`
+	FinalCents         int64              `json:"final_cents"`
+	Currency           string             `json:"currency"`
+	Status             string             `json:"status"`
+	ShippingInfo       []byte             `json:"shipping_info"`
+	Notes              pgtype.Text        `json:"notes"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	PaidAt             pgtype.Timestamptz `json:"paid_at"`
+	ShippedAt          pgtype.Timestamptz `json:"shipped_at"`
+	DeliveredAt        pgtype.Timestamptz `json:"delivered_at"`
+	CancelledAt        pgtype.Timestamptz `json:"cancelled_at"`
+	RefundedAt         pgtype.Timestamptz `json:"refunded_at"`
+	RefundedCents      int64              `json:"refunded_cents"`
+	ShippingMethodID   pgtype.UUID        `json:"shipping_method_id"`
+	ShippingMethodName pgtype.Text        `json:"shipping_method_name"`
+}
+
+type OrderAdjustment struct {
+	ID          pgtype.UUID        `json:"id"`
+	OrderID     pgtype.UUID        `json:"order_id"`
+	OrderItemID pgtype.UUID        `json:"order_item_id"`
+	Kind        string             `json:"kind"`
+	Code        string             `json:"code"`
+	Description string             `json:"description"`
+	AmountCents int64              `json:"amount_cents"`
+	Position    int32              `json:"position"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
 type OrderItem struct {
@@ -250,19 +344,53 @@ type OrderItem struct {
 	UpdatedAt       pgtype.Timestamptz `json:"updated_at"`
 }
 
+type OrderStatusHistory struct {
+	ID         pgtype.UUID        `json:"id"`
+	OrderID    pgtype.UUID        `json:"order_id"`
+	FromStatus string             `json:"from_status"`
+	ToStatus   string             `json:"to_status"`
+	ChangedBy  pgtype.UUID        `json:"changed_by"`
+	Reason     pgtype.Text        `json:"reason"`
+	CreatedAt  pgtype.Timestamptz `json:"created_at"`
+}
+
+type OrderTaxLine struct {
+	ID           pgtype.UUID        `json:"id"`
+	OrderID      pgtype.UUID        `json:"order_id"`
+	OrderItemID  pgtype.UUID        `json:"order_item_id"`
+	TaxRateID    pgtype.UUID        `json:"tax_rate_id"`
+	Jurisdiction string             `json:"jurisdiction"`
+	TaxCategory  string             `json:"tax_category"`
+	RateBps      int32              `json:"rate_bps"`
+	IsInclusive  bool               `json:"is_inclusive"`
+	TaxableCents int64              `json:"taxable_cents"`
+	TaxCents     int64              `json:"tax_cents"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
 type Payment struct {
-	ID            pgtype.UUID        `json:"id"`
-	OrderID       pgtype.UUID        `json:"order_id"`
-	Provider      string             `json:"provider"`
-	ProviderTxnID pgtype.Text        `json:"provider_txn_id"`
-	AmountCents   int64              `json:"amount_cents"`
-	Currency      string             `json:"currency"`
-	PaymentMethod string             `json:"payment_method"`
-	Status        string             `json:"status"`
-	Details       []byte             `json:"details"`
-	FailureReason pgtype.Text        `json:"failure_reason"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	ID                pgtype.UUID        `json:"id"`
+	OrderID           pgtype.UUID        `json:"order_id"`
+	Provider          string             `json:"provider"`
+	ProviderTxnID     pgtype.Text        `json:"provider_txn_id"`
+	AmountCents       int64              `json:"amount_cents"`
+	Currency          string             `json:"currency"`
+	PaymentMethod     string             `json:"payment_method"`
+	Status            string             `json:"status"`
+	Details           []byte             `json:"details"`
+	FailureReason     pgtype.Text        `json:"failure_reason"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt         pgtype.Timestamptz `json:"updated_at"`
+	LastEventAt       pgtype.Timestamptz `json:"last_event_at"`
+	CaptureMethod     string             `json:"capture_method"`
+	AuthorizedAt      pgtype.Timestamptz `json:"authorized_at"`
+	AuthorizedCents   pgtype.Int8        `json:"authorized_cents"`
+	CapturedAt        pgtype.Timestamptz `json:"captured_at"`
+	Reference         pgtype.Text        `json:"reference"`
+	ExpiresAt         pgtype.Timestamptz `json:"expires_at"`
+	ConfirmedBy       pgtype.UUID        `json:"confirmed_by"`
+	ConfirmedAt       pgtype.Timestamptz `json:"confirmed_at"`
+	CheckoutSessionID pgtype.Text        `json:"checkout_session_id"`
 }
 
 type Product struct {
@@ -282,6 +410,53 @@ type Product struct {
 	IsDeleted          pgtype.Bool        `json:"is_deleted"`
 	CreatedAt          pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	TaxCategory        string             `json:"tax_category"`
+	WeightGrams        int32              `json:"weight_grams"`
+	LengthMm           int32              `json:"length_mm"`
+	WidthMm            int32              `json:"width_mm"`
+	HeightMm           int32              `json:"height_mm"`
+	IsGiftCard         bool               `json:"is_gift_card"`
+}
+
+type Refund struct {
+	ID               pgtype.UUID        `json:"id"`
+	PaymentID        pgtype.UUID        `json:"payment_id"`
+	OrderID          pgtype.UUID        `json:"order_id"`
+	Provider         string             `json:"provider"`
+	ProviderRefundID pgtype.Text        `json:"provider_refund_id"`
+	AmountCents      int64              `json:"amount_cents"`
+	Currency         string             `json:"currency"`
+	Reason           pgtype.Text        `json:"reason"`
+	Status           string             `json:"status"`
+	FailureReason    pgtype.Text        `json:"failure_reason"`
+	CreatedBy        pgtype.UUID        `json:"created_by"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Return struct {
+	ID             pgtype.UUID        `json:"id"`
+	OrderID        pgtype.UUID        `json:"order_id"`
+	UserID         pgtype.UUID        `json:"user_id"`
+	Status         string             `json:"status"`
+	Reason         string             `json:"reason"`
+	ResolutionNote pgtype.Text        `json:"resolution_note"`
+	ReviewedBy     pgtype.UUID        `json:"reviewed_by"`
+	ShipmentID     pgtype.UUID        `json:"shipment_id"`
+	RefundCents    int64              `json:"refund_cents"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	ReviewedAt     pgtype.Timestamptz `json:"reviewed_at"`
+	ReceivedAt     pgtype.Timestamptz `json:"received_at"`
+}
+
+type ReturnItem struct {
+	ID          pgtype.UUID        `json:"id"`
+	ReturnID    pgtype.UUID        `json:"return_id"`
+	OrderItemID pgtype.UUID        `json:"order_item_id"`
+	Qty         int32              `json:"qty"`
+	RefundCents int64              `json:"refund_cents"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
 }
 
 type Review struct {
@@ -304,6 +479,75 @@ type Shipment struct {
 	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
 	CreatedAt      pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
+	WarehouseID    pgtype.UUID        `json:"warehouse_id"`
+}
+
+type ShippingMethod struct {
+	ID            pgtype.UUID        `json:"id"`
+	ZoneID        pgtype.UUID        `json:"zone_id"`
+	Name          string             `json:"name"`
+	RateType      string             `json:"rate_type"`
+	FlatCents     int64              `json:"flat_cents"`
+	Tiers         []byte             `json:"tiers"`
+	FreeOverCents pgtype.Int8        `json:"free_over_cents"`
+	IsActive      bool               `json:"is_active"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+}
+
+type ShippingZone struct {
+	ID        pgtype.UUID        `json:"id"`
+	Name      string             `json:"name"`
+	IsActive  bool               `json:"is_active"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type ShippingZoneRegion struct {
+	ID        pgtype.UUID        `json:"id"`
+	ZoneID    pgtype.UUID        `json:"zone_id"`
+	Country   string             `json:"country"`
+	State     string             `json:"state"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type StockMovement struct {
+	ID            pgtype.UUID        `json:"id"`
+	ProductID     pgtype.UUID        `json:"product_id"`
+	Delta         int32              `json:"delta"`
+	ReservedDelta int32              `json:"reserved_delta"`
+	StockAfter    int32              `json:"stock_after"`
+	ReservedAfter int32              `json:"reserved_after"`
+	Reason        string             `json:"reason"`
+	OrderID       pgtype.UUID        `json:"order_id"`
+	ReturnID      pgtype.UUID        `json:"return_id"`
+	CreatedBy     pgtype.UUID        `json:"created_by"`
+	Note          pgtype.Text        `json:"note"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	WarehouseID   pgtype.UUID        `json:"warehouse_id"`
+}
+
+type TaxExemption struct {
+	UserID            pgtype.UUID        `json:"user_id"`
+	CertificateNumber pgtype.Text        `json:"certificate_number"`
+	Reason            pgtype.Text        `json:"reason"`
+	ExpiresAt         pgtype.Timestamptz `json:"expires_at"`
+	CreatedBy         pgtype.UUID        `json:"created_by"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+}
+
+type TaxRate struct {
+	ID               pgtype.UUID        `json:"id"`
+	Name             string             `json:"name"`
+	Country          string             `json:"country"`
+	State            string             `json:"state"`
+	PostalCodePrefix string             `json:"postal_code_prefix"`
+	TaxCategory      string             `json:"tax_category"`
+	RateBps          int32              `json:"rate_bps"`
+	IsInclusive      bool               `json:"is_inclusive"`
+	IsActive         bool               `json:"is_active"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
 }
 
 type User struct {
@@ -337,3 +581,70 @@ type UserOtp struct {
 	CreatedAt  pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
 }
+
+type Wallet struct {
+	UserID       pgtype.UUID        `json:"user_id"`
+	BalanceCents int64              `json:"balance_cents"`
+	Currency     string             `json:"currency"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WalletTransaction struct {
+	ID                pgtype.UUID        `json:"id"`
+	UserID            pgtype.UUID        `json:"user_id"`
+	Kind              string             `json:"kind"`
+	AmountCents       int64              `json:"amount_cents"`
+	BalanceAfterCents int64              `json:"balance_after_cents"`
+	Currency          string             `json:"currency"`
+	OrderID           pgtype.UUID        `json:"order_id"`
+	RefundID          pgtype.UUID        `json:"refund_id"`
+	SourceID          pgtype.UUID        `json:"source_id"`
+	Reason            pgtype.Text        `json:"reason"`
+	ExpiresAt         pgtype.Timestamptz `json:"expires_at"`
+	CreatedBy         pgtype.UUID        `json:"created_by"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+}
+
+type Warehouse struct {
+	ID        pgtype.UUID        `json:"id"`
+	Code      string             `json:"code"`
+	Name      string             `json:"name"`
+	Country   string             `json:"country"`
+	Priority  int32              `json:"priority"`
+	IsActive  bool               `json:"is_active"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WarehouseStock struct {
+	WarehouseID pgtype.UUID        `json:"warehouse_id"`
+	ProductID   pgtype.UUID        `json:"product_id"`
+	Stock       int32              `json:"stock"`
+	Reserved    int32              `json:"reserved"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+type WebhookEvent struct {
+	ID                pgtype.UUID        `json:"id"`
+	Provider          string             `json:"provider"`
+	EventID           string             `json:"event_id"`
+	EventType         string             `json:"event_type"`
+	ProviderTxnID     pgtype.Text        `json:"provider_txn_id"`
+	OrderID           pgtype.UUID        `json:"order_id"`
+	PaymentStatus     string             `json:"payment_status"`
+	FailureReason     pgtype.Text        `json:"failure_reason"`
+	OccurredAt        pgtype.Timestamptz `json:"occurred_at"`
+	Payload           []byte             `json:"payload"`
+	Status            string             `json:"status"`
+	Error             pgtype.Text        `json:"error"`
+	Attempts          int32              `json:"attempts"`
+	CreatedAt         pgtype.Timestamptz `json:"created_at"`
+	ProcessedAt       pgtype.Timestamptz `json:"processed_at"`
+	ProviderRefundID  pgtype.Text        `json:"provider_refund_id"`
+	RefundStatus      pgtype.Text        `json:"refund_status"`
+	RefundAmountCents pgtype.Int8        `json:"refund_amount_cents"`
+	RefundReference   pgtype.Text        `json:"refund_reference"`
+	CheckoutSessionID pgtype.Text        `json:"checkout_session_id"`
+}
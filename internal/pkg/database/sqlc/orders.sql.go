@@ -11,12 +11,97 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const addOrderRefundedCents = `-- name: AddOrderRefundedCents :one
+UPDATE orders
+SET
+    refunded_cents = refunded_cents + $1::bigint,
+    updated_at = NOW()
+WHERE id = $2
+  AND refunded_cents + $1::bigint <= final_cents
+RETURNING id, user_id, order_number, subtotal_cents, discount_cents, tax_cents, shipping_cents, total_cents, final_cents, currency, status, shipping_info, notes, created_at, updated_at, paid_at, shipped_at, delivered_at, cancelled_at, refunded_at, refunded_cents, shipping_method_id, shipping_method_name
+`
+
+type AddOrderRefundedCentsParams struct {
+	AmountCents int64       `json:"amount_cents"`
+	ID          pgtype.UUID `json:"id"`
+}
+
+// Adds amount_cents to the order's refunded total. No row is returned if
+// that would refund more than was charged.
+func (q *Queries) AddOrderRefundedCents(ctx context.Context, arg AddOrderRefundedCentsParams) (Order, error) {
+	row := q.db.QueryRow(ctx, addOrderRefundedCents, arg.AmountCents, arg.ID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.OrderNumber,
+		&i.SubtotalCents,
+		&i.DiscountCents,
+		&i.TaxCents,
+		&i.ShippingCents,
+		&i.TotalCents,
+		&i.FinalCents,
+		&i.Currency,
+		&i.Status,
+		&i.ShippingInfo,
+		&i.Notes,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PaidAt,
+		&i.ShippedAt,
+		&i.DeliveredAt,
+		&i.CancelledAt,
+		&i.RefundedAt,
+		&i.RefundedCents,
+		&i.ShippingMethodID,
+		&i.ShippingMethodName,
+	)
+	return i, err
+}
+
 const countOrders = `-- name: CountOrders :one
-SELECT COUNT(*) AS total_count FROM orders
+SELECT COUNT(*) AS total_count
+FROM orders o
+LEFT JOIN users u ON u.id = o.user_id
+WHERE ($1::text[] IS NULL OR o.status = ANY($1::text[]))
+  AND ($2::uuid IS NULL OR o.user_id = $2::uuid)
+  AND ($3::text IS NULL OR LOWER(u.email) = LOWER($3::text))
+  AND ($4::text IS NULL OR starts_with(o.order_number, $4::text))
+  AND ($5::timestamptz IS NULL OR o.created_at >= $5::timestamptz)
+  AND ($6::timestamptz IS NULL OR o.created_at < $6::timestamptz)
+  AND ($7::timestamptz IS NULL OR o.paid_at >= $7::timestamptz)
+  AND ($8::timestamptz IS NULL OR o.paid_at < $8::timestamptz)
+  AND ($9::bigint IS NULL OR o.total_cents >= $9::bigint)
+  AND ($10::bigint IS NULL OR o.total_cents <= $10::bigint)
 `
 
-func (q *Queries) CountOrders(ctx context.Context) (int64, error) {
-	row := q.db.QueryRow(ctx, countOrders)
+type CountOrdersParams struct {
+	Statuses          []string           `json:"statuses"`
+	UserID            pgtype.UUID        `json:"user_id"`
+	Email             pgtype.Text        `json:"email"`
+	OrderNumberPrefix pgtype.Text        `json:"order_number_prefix"`
+	CreatedFrom       pgtype.Timestamptz `json:"created_from"`
+	CreatedTo         pgtype.Timestamptz `json:"created_to"`
+	PaidFrom          pgtype.Timestamptz `json:"paid_from"`
+	PaidTo            pgtype.Timestamptz `json:"paid_to"`
+	MinTotalCents     pgtype.Int8        `json:"min_total_cents"`
+	MaxTotalCents     pgtype.Int8        `json:"max_total_cents"`
+}
+
+// Counts the orders matched by GetOrdersWithItems under the same filters.
+func (q *Queries) CountOrders(ctx context.Context, arg CountOrdersParams) (int64, error) {
+	row := q.db.QueryRow(ctx, countOrders,
+		arg.Statuses,
+		arg.UserID,
+		arg.Email,
+		arg.OrderNumberPrefix,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.PaidFrom,
+		arg.PaidTo,
+		arg.MinTotalCents,
+		arg.MaxTotalCents,
+	)
 	var total_count int64
 	err := row.Scan(&total_count)
 	return total_count, err
@@ -46,24 +131,28 @@ INSERT INTO orders (
     final_cents,
     -- currency,
     shipping_info,
-    notes
+    notes,
+    shipping_method_id,
+    shipping_method_name
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 )
- RETURNING id, user_id, order_number, subtotal_cents, discount_cents, tax_cents, shipping_cents, total_cents, final_cents, currency, status, shipping_info, notes, created_at, updated_at
+ RETURNING id, user_id, order_number, subtotal_cents, discount_cents, tax_cents, shipping_cents, total_cents, final_cents, currency, status, shipping_info, notes, created_at, updated_at, paid_at, shipped_at, delivered_at, cancelled_at, refunded_at, refunded_cents, shipping_method_id, shipping_method_name
 `
 
 type CreateOrderParams struct {
-	UserID        pgtype.UUID `json:"user_id"`
-	OrderNumber   string      `json:"order_number"`
-	SubtotalCents int64       `json:"subtotal_cents"`
-	DiscountCents pgtype.Int8 `json:"discount_cents"`
-	TaxCents      pgtype.Int8 `json:"tax_cents"`
-	ShippingCents pgtype.Int8 `json:"shipping_cents"`
-	TotalCents    int64       `json:"total_cents"`
-	FinalCents    int64       `json:"final_cents"`
-	ShippingInfo  []byte      `json:"shipping_info"`
-	Notes         pgtype.Text `json:"notes"`
+	UserID             pgtype.UUID `json:"user_id"`
+	OrderNumber        string      `json:"order_number"`
+	SubtotalCents      int64       `json:"subtotal_cents"`
+	DiscountCents      pgtype.Int8 `json:"discount_cents"`
+	TaxCents           pgtype.Int8 `json:"tax_cents"`
+	ShippingCents      pgtype.Int8 `json:"shipping_cents"`
+	TotalCents         int64       `json:"total_cents"`
+	FinalCents         int64       `json:"final_cents"`
+	ShippingInfo       []byte      `json:"shipping_info"`
+	Notes              pgtype.Text `json:"notes"`
+	ShippingMethodID   pgtype.UUID `json:"shipping_method_id"`
+	ShippingMethodName pgtype.Text `json:"shipping_method_name"`
 }
 
 func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
@@ -78,6 +167,8 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		arg.FinalCents,
 		arg.ShippingInfo,
 		arg.Notes,
+		arg.ShippingMethodID,
+		arg.ShippingMethodName,
 	)
 	var i Order
 	err := row.Scan(
@@ -96,6 +187,14 @@ func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PaidAt,
+		&i.ShippedAt,
+		&i.DeliveredAt,
+		&i.CancelledAt,
+		&i.RefundedAt,
+		&i.RefundedCents,
+		&i.ShippingMethodID,
+		&i.ShippingMethodName,
 	)
 	return i, err
 }
@@ -115,7 +214,7 @@ const getOrderWithItemsByID = `-- name: GetOrderWithItemsByID :one
 
 
 SELECT 
-    o.id, o.user_id, o.order_number, o.subtotal_cents, o.discount_cents, o.tax_cents, o.shipping_cents, o.total_cents, o.final_cents, o.currency, o.status, o.shipping_info, o.notes, o.created_at, o.updated_at,
+    o.id, o.user_id, o.order_number, o.subtotal_cents, o.discount_cents, o.tax_cents, o.shipping_cents, o.total_cents, o.final_cents, o.currency, o.status, o.shipping_info, o.notes, o.created_at, o.updated_at, o.paid_at, o.shipped_at, o.delivered_at, o.cancelled_at, o.refunded_at, o.refunded_cents, o.shipping_method_id, o.shipping_method_name,
     COALESCE(
         json_agg(to_jsonb(oi)) FILTER (WHERE oi.id IS NOT NULL), '[]'
     ) AS items
@@ -126,22 +225,30 @@ GROUP BY o.id
 `
 
 type GetOrderWithItemsByIDRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	OrderNumber   string             `json:"order_number"`
-	SubtotalCents int64              `json:"subtotal_cents"`
-	DiscountCents pgtype.Int8        `json:"discount_cents"`
-	TaxCents      pgtype.Int8        `json:"tax_cents"`
-	ShippingCents pgtype.Int8        `json:"shipping_cents"`
-	TotalCents    int64              `json:"total_cents"`
-	FinalCents    int64              `json:"final_cents"`
-	Currency      string             `json:"currency"`
-	Status        string             `json:"status"`
-	ShippingInfo  []byte             `json:"shipping_info"`
-	Notes         pgtype.Text        `json:"notes"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	Items         interface{}        `json:"items"`
+	ID                 pgtype.UUID        `json:"id"`
+	UserID             pgtype.UUID        `json:"user_id"`
+	OrderNumber        string             `json:"order_number"`
+	SubtotalCents      int64              `json:"subtotal_cents"`
+	DiscountCents      pgtype.Int8        `json:"discount_cents"`
+	TaxCents           pgtype.Int8        `json:"tax_cents"`
+	ShippingCents      pgtype.Int8        `json:"shipping_cents"`
+	TotalCents         int64              `json:"total_cents"`
+	FinalCents         int64              `json:"final_cents"`
+	Currency           string             `json:"currency"`
+	Status             string             `json:"status"`
+	ShippingInfo       []byte             `json:"shipping_info"`
+	Notes              pgtype.Text        `json:"notes"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	PaidAt             pgtype.Timestamptz `json:"paid_at"`
+	ShippedAt          pgtype.Timestamptz `json:"shipped_at"`
+	DeliveredAt        pgtype.Timestamptz `json:"delivered_at"`
+	CancelledAt        pgtype.Timestamptz `json:"cancelled_at"`
+	RefundedAt         pgtype.Timestamptz `json:"refunded_at"`
+	RefundedCents      int64              `json:"refunded_cents"`
+	ShippingMethodID   pgtype.UUID        `json:"shipping_method_id"`
+	ShippingMethodName pgtype.Text        `json:"shipping_method_name"`
+	Items              interface{}        `json:"items"`
 }
 
 // -- name: GetOrderByID :one
@@ -174,6 +281,14 @@ func (q *Queries) GetOrderWithItemsByID(ctx context.Context, id pgtype.UUID) (Ge
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PaidAt,
+		&i.ShippedAt,
+		&i.DeliveredAt,
+		&i.CancelledAt,
+		&i.RefundedAt,
+		&i.RefundedCents,
+		&i.ShippingMethodID,
+		&i.ShippingMethodName,
 		&i.Items,
 	)
 	return i, err
@@ -181,43 +296,101 @@ func (q *Queries) GetOrderWithItemsByID(ctx context.Context, id pgtype.UUID) (Ge
 
 const getOrdersWithItems = `-- name: GetOrdersWithItems :many
 SELECT 
-    o.id, o.user_id, o.order_number, o.subtotal_cents, o.discount_cents, o.tax_cents, o.shipping_cents, o.total_cents, o.final_cents, o.currency, o.status, o.shipping_info, o.notes, o.created_at, o.updated_at,
+    o.id, o.user_id, o.order_number, o.subtotal_cents, o.discount_cents, o.tax_cents, o.shipping_cents, o.total_cents, o.final_cents, o.currency, o.status, o.shipping_info, o.notes, o.created_at, o.updated_at, o.paid_at, o.shipped_at, o.delivered_at, o.cancelled_at, o.refunded_at, o.refunded_cents, o.shipping_method_id, o.shipping_method_name,
     COALESCE(
         json_agg(to_jsonb(oi)) FILTER (WHERE oi.id IS NOT NULL), '[]'
     ) AS items
 FROM orders o
 LEFT JOIN order_items oi ON o.id = oi.order_id
+LEFT JOIN users u ON u.id = o.user_id
+WHERE ($1::text[] IS NULL OR o.status = ANY($1::text[]))
+  AND ($2::uuid IS NULL OR o.user_id = $2::uuid)
+  AND ($3::text IS NULL OR LOWER(u.email) = LOWER($3::text))
+  AND ($4::text IS NULL OR starts_with(o.order_number, $4::text))
+  AND ($5::timestamptz IS NULL OR o.created_at >= $5::timestamptz)
+  AND ($6::timestamptz IS NULL OR o.created_at < $6::timestamptz)
+  AND ($7::timestamptz IS NULL OR o.paid_at >= $7::timestamptz)
+  AND ($8::timestamptz IS NULL OR o.paid_at < $8::timestamptz)
+  AND ($9::bigint IS NULL OR o.total_cents >= $9::bigint)
+  AND ($10::bigint IS NULL OR o.total_cents <= $10::bigint)
 GROUP BY o.id
-ORDER BY o.created_at DESC
-LIMIT $1 OFFSET $2
+ORDER BY
+    CASE WHEN $11::text = 'created_at' AND $12::text = 'asc' THEN o.created_at END ASC,
+    CASE WHEN $11::text = 'paid_at' AND $12::text = 'asc' THEN o.paid_at END ASC NULLS LAST,
+    CASE WHEN $11::text = 'paid_at' AND $12::text = 'desc' THEN o.paid_at END DESC NULLS LAST,
+    CASE WHEN $11::text = 'total_cents' AND $12::text = 'asc' THEN o.total_cents END ASC,
+    CASE WHEN $11::text = 'total_cents' AND $12::text = 'desc' THEN o.total_cents END DESC,
+    CASE WHEN $11::text = 'order_number' AND $12::text = 'asc' THEN o.order_number END ASC,
+    CASE WHEN $11::text = 'order_number' AND $12::text = 'desc' THEN o.order_number END DESC,
+    o.created_at DESC
+LIMIT $13::int OFFSET $14::int
 `
 
 type GetOrdersWithItemsParams struct {
-	Limit  int32 `json:"limit"`
-	Offset int32 `json:"offset"`
+	Statuses          []string           `json:"statuses"`
+	UserID            pgtype.UUID        `json:"user_id"`
+	Email             pgtype.Text        `json:"email"`
+	OrderNumberPrefix pgtype.Text        `json:"order_number_prefix"`
+	CreatedFrom       pgtype.Timestamptz `json:"created_from"`
+	CreatedTo         pgtype.Timestamptz `json:"created_to"`
+	PaidFrom          pgtype.Timestamptz `json:"paid_from"`
+	PaidTo            pgtype.Timestamptz `json:"paid_to"`
+	MinTotalCents     pgtype.Int8        `json:"min_total_cents"`
+	MaxTotalCents     pgtype.Int8        `json:"max_total_cents"`
+	SortBy            string             `json:"sort_by"`
+	SortOrder         string             `json:"sort_order"`
+	RowLimit          int32              `json:"row_limit"`
+	RowOffset         int32              `json:"row_offset"`
 }
 
 type GetOrdersWithItemsRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	OrderNumber   string             `json:"order_number"`
-	SubtotalCents int64              `json:"subtotal_cents"`
-	DiscountCents pgtype.Int8        `json:"discount_cents"`
-	TaxCents      pgtype.Int8        `json:"tax_cents"`
-	ShippingCents pgtype.Int8        `json:"shipping_cents"`
-	TotalCents    int64              `json:"total_cents"`
-	FinalCents    int64              `json:"final_cents"`
-	Currency      string             `json:"currency"`
-	Status        string             `json:"status"`
-	ShippingInfo  []byte             `json:"shipping_info"`
-	Notes         pgtype.Text        `json:"notes"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	Items         interface{}        `json:"items"`
+	ID                 pgtype.UUID        `json:"id"`
+	UserID             pgtype.UUID        `json:"user_id"`
+	OrderNumber        string             `json:"order_number"`
+	SubtotalCents      int64              `json:"subtotal_cents"`
+	DiscountCents      pgtype.Int8        `json:"discount_cents"`
+	TaxCents           pgtype.Int8        `json:"tax_cents"`
+	ShippingCents      pgtype.Int8        `json:"shipping_cents"`
+	TotalCents         int64              `json:"total_cents"`
+	FinalCents         int64              `json:"final_cents"`
+	Currency           string             `json:"currency"`
+	Status             string             `json:"status"`
+	ShippingInfo       []byte             `json:"shipping_info"`
+	Notes              pgtype.Text        `json:"notes"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	PaidAt             pgtype.Timestamptz `json:"paid_at"`
+	ShippedAt          pgtype.Timestamptz `json:"shipped_at"`
+	DeliveredAt        pgtype.Timestamptz `json:"delivered_at"`
+	CancelledAt        pgtype.Timestamptz `json:"cancelled_at"`
+	RefundedAt         pgtype.Timestamptz `json:"refunded_at"`
+	RefundedCents      int64              `json:"refunded_cents"`
+	ShippingMethodID   pgtype.UUID        `json:"shipping_method_id"`
+	ShippingMethodName pgtype.Text        `json:"shipping_method_name"`
+	Items              interface{}        `json:"items"`
 }
 
+// Admin order search. NULL filters are ignored; sort_by is one of
+// created_at, paid_at, total_cents or order_number and sort_order is asc or
+// desc, with created_at DESC as the tie-breaker. Unpaid orders sort last by
+// paid_at either way.
 func (q *Queries) GetOrdersWithItems(ctx context.Context, arg GetOrdersWithItemsParams) ([]GetOrdersWithItemsRow, error) {
-	rows, err := q.db.Query(ctx, getOrdersWithItems, arg.Limit, arg.Offset)
+	rows, err := q.db.Query(ctx, getOrdersWithItems,
+		arg.Statuses,
+		arg.UserID,
+		arg.Email,
+		arg.OrderNumberPrefix,
+		arg.CreatedFrom,
+		arg.CreatedTo,
+		arg.PaidFrom,
+		arg.PaidTo,
+		arg.MinTotalCents,
+		arg.MaxTotalCents,
+		arg.SortBy,
+		arg.SortOrder,
+		arg.RowLimit,
+		arg.RowOffset,
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -241,6 +414,14 @@ func (q *Queries) GetOrdersWithItems(ctx context.Context, arg GetOrdersWithItems
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PaidAt,
+			&i.ShippedAt,
+			&i.DeliveredAt,
+			&i.CancelledAt,
+			&i.RefundedAt,
+			&i.RefundedCents,
+			&i.ShippingMethodID,
+			&i.ShippingMethodName,
 			&i.Items,
 		); err != nil {
 			return nil, err
@@ -255,7 +436,7 @@ func (q *Queries) GetOrdersWithItems(ctx context.Context, arg GetOrdersWithItems
 
 const getOrdersWithItemsByUserID = `-- name: GetOrdersWithItemsByUserID :many
 SELECT 
-    o.id, o.user_id, o.order_number, o.subtotal_cents, o.discount_cents, o.tax_cents, o.shipping_cents, o.total_cents, o.final_cents, o.currency, o.status, o.shipping_info, o.notes, o.created_at, o.updated_at,
+    o.id, o.user_id, o.order_number, o.subtotal_cents, o.discount_cents, o.tax_cents, o.shipping_cents, o.total_cents, o.final_cents, o.currency, o.status, o.shipping_info, o.notes, o.created_at, o.updated_at, o.paid_at, o.shipped_at, o.delivered_at, o.cancelled_at, o.refunded_at, o.refunded_cents, o.shipping_method_id, o.shipping_method_name,
     COALESCE(
         json_agg(to_jsonb(oi)) FILTER (WHERE oi.id IS NOT NULL), '[]'
     ) AS items
@@ -274,22 +455,30 @@ type GetOrdersWithItemsByUserIDParams struct {
 }
 
 type GetOrdersWithItemsByUserIDRow struct {
-	ID            pgtype.UUID        `json:"id"`
-	UserID        pgtype.UUID        `json:"user_id"`
-	OrderNumber   string             `json:"order_number"`
-	SubtotalCents int64              `json:"subtotal_cents"`
-	DiscountCents pgtype.Int8        `json:"discount_cents"`
-	TaxCents      pgtype.Int8        `json:"tax_cents"`
-	ShippingCents pgtype.Int8        `json:"shipping_cents"`
-	TotalCents    int64              `json:"total_cents"`
-	FinalCents    int64              `json:"final_cents"`
-	Currency      string             `json:"currency"`
-	Status        string             `json:"status"`
-	ShippingInfo  []byte             `json:"shipping_info"`
-	Notes         pgtype.Text        `json:"notes"`
-	CreatedAt     pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
-	Items         interface{}        `json:"items"`
+	ID                 pgtype.UUID        `json:"id"`
+	UserID             pgtype.UUID        `json:"user_id"`
+	OrderNumber        string             `json:"order_number"`
+	SubtotalCents      int64              `json:"subtotal_cents"`
+	DiscountCents      pgtype.Int8        `json:"discount_cents"`
+	TaxCents           pgtype.Int8        `json:"tax_cents"`
+	ShippingCents      pgtype.Int8        `json:"shipping_cents"`
+	TotalCents         int64              `json:"total_cents"`
+	FinalCents         int64              `json:"final_cents"`
+	Currency           string             `json:"currency"`
+	Status             string             `json:"status"`
+	ShippingInfo       []byte             `json:"shipping_info"`
+	Notes              pgtype.Text        `json:"notes"`
+	CreatedAt          pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
+	PaidAt             pgtype.Timestamptz `json:"paid_at"`
+	ShippedAt          pgtype.Timestamptz `json:"shipped_at"`
+	DeliveredAt        pgtype.Timestamptz `json:"delivered_at"`
+	CancelledAt        pgtype.Timestamptz `json:"cancelled_at"`
+	RefundedAt         pgtype.Timestamptz `json:"refunded_at"`
+	RefundedCents      int64              `json:"refunded_cents"`
+	ShippingMethodID   pgtype.UUID        `json:"shipping_method_id"`
+	ShippingMethodName pgtype.Text        `json:"shipping_method_name"`
+	Items              interface{}        `json:"items"`
 }
 
 func (q *Queries) GetOrdersWithItemsByUserID(ctx context.Context, arg GetOrdersWithItemsByUserIDParams) ([]GetOrdersWithItemsByUserIDRow, error) {
@@ -317,6 +506,14 @@ func (q *Queries) GetOrdersWithItemsByUserID(ctx context.Context, arg GetOrdersW
 			&i.Notes,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PaidAt,
+			&i.ShippedAt,
+			&i.DeliveredAt,
+			&i.CancelledAt,
+			&i.RefundedAt,
+			&i.RefundedCents,
+			&i.ShippingMethodID,
+			&i.ShippingMethodName,
 			&i.Items,
 		); err != nil {
 			return nil, err
@@ -329,22 +526,66 @@ func (q *Queries) GetOrdersWithItemsByUserID(ctx context.Context, arg GetOrdersW
 	return items, nil
 }
 
+const setOrderDeliveredAt = `-- name: SetOrderDeliveredAt :exec
+UPDATE orders
+SET delivered_at = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetOrderDeliveredAtParams struct {
+	ID          pgtype.UUID        `json:"id"`
+	DeliveredAt pgtype.Timestamptz `json:"delivered_at"`
+}
+
+// Stamps when the order's latest shipment reached the customer, which
+// starts its return window.
+func (q *Queries) SetOrderDeliveredAt(ctx context.Context, arg SetOrderDeliveredAtParams) error {
+	_, err := q.db.Exec(ctx, setOrderDeliveredAt, arg.ID, arg.DeliveredAt)
+	return err
+}
+
+const setOrderFinalCents = `-- name: SetOrderFinalCents :exec
+UPDATE orders
+SET final_cents = $2, updated_at = NOW()
+WHERE id = $1
+`
+
+type SetOrderFinalCentsParams struct {
+	ID         pgtype.UUID `json:"id"`
+	FinalCents int64       `json:"final_cents"`
+}
+
+// Lowers what is charged for an order once part of it is paid otherwise,
+// e.g. from the customer's wallet.
+func (q *Queries) SetOrderFinalCents(ctx context.Context, arg SetOrderFinalCentsParams) error {
+	_, err := q.db.Exec(ctx, setOrderFinalCents, arg.ID, arg.FinalCents)
+	return err
+}
+
 const updateOrderStatus = `-- name: UpdateOrderStatus :one
 UPDATE orders
 SET 
-    status = $2,
+    status = $1,
+    paid_at = CASE WHEN $1 = 'PAID' THEN NOW() ELSE paid_at END,
+    shipped_at = CASE WHEN $1 = 'SHIPPED' THEN NOW() ELSE shipped_at END,
+    cancelled_at = CASE WHEN $1 = 'CANCELLED' THEN NOW() ELSE cancelled_at END,
+    refunded_at = CASE WHEN $1 = 'REFUNDED' THEN NOW() ELSE refunded_at END,
     updated_at = NOW()
-WHERE id = $1
-RETURNING id, user_id, order_number, subtotal_cents, discount_cents, tax_cents, shipping_cents, total_cents, final_cents, currency, status, shipping_info, notes, created_at, updated_at
+WHERE id = $2
+  AND status = $3
+RETURNING id, user_id, order_number, subtotal_cents, discount_cents, tax_cents, shipping_cents, total_cents, final_cents, currency, status, shipping_info, notes, created_at, updated_at, paid_at, shipped_at, delivered_at, cancelled_at, refunded_at, refunded_cents, shipping_method_id, shipping_method_name
 `
 
 type UpdateOrderStatusParams struct {
-	ID     pgtype.UUID `json:"id"`
-	Status string      `json:"status"`
+	Status     string      `json:"status"`
+	ID         pgtype.UUID `json:"id"`
+	FromStatus string      `json:"from_status"`
 }
 
+// Moves an order from from_status to status and stamps the matching
+// timestamp column. No row is returned if the status changed underneath us.
 func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) (Order, error) {
-	row := q.db.QueryRow(ctx, updateOrderStatus, arg.ID, arg.Status)
+	row := q.db.QueryRow(ctx, updateOrderStatus, arg.Status, arg.ID, arg.FromStatus)
 	var i Order
 	err := row.Scan(
 		&i.ID,
@@ -362,6 +603,14 @@ func (q *Queries) UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusPa
 		&i.Notes,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PaidAt,
+		&i.ShippedAt,
+		&i.DeliveredAt,
+		&i.CancelledAt,
+		&i.RefundedAt,
+		&i.RefundedCents,
+		&i.ShippingMethodID,
+		&i.ShippingMethodName,
 	)
 	return i, err
 }
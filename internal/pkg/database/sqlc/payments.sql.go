@@ -11,6 +11,183 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const applyPaymentEvent = `-- name: ApplyPaymentEvent :one
+UPDATE payments
+SET status = $2,
+    failure_reason = $3,
+    last_event_at = $4,
+    authorized_at = CASE WHEN $2 = 'AUTHORIZED' THEN COALESCE(authorized_at, $4) ELSE authorized_at END,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id
+`
+
+type ApplyPaymentEventParams struct {
+	ID            pgtype.UUID        `json:"id"`
+	Status        string             `json:"status"`
+	FailureReason pgtype.Text        `json:"failure_reason"`
+	LastEventAt   pgtype.Timestamptz `json:"last_event_at"`
+}
+
+func (q *Queries) ApplyPaymentEvent(ctx context.Context, arg ApplyPaymentEventParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, applyPaymentEvent,
+		arg.ID,
+		arg.Status,
+		arg.FailureReason,
+		arg.LastEventAt,
+	)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Provider,
+		&i.ProviderTxnID,
+		&i.AmountCents,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Status,
+		&i.Details,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
+	)
+	return i, err
+}
+
+const attachPaymentIntent = `-- name: AttachPaymentIntent :exec
+UPDATE payments
+SET provider_txn_id = $2, updated_at = NOW()
+WHERE id = $1 AND provider_txn_id IS NULL
+`
+
+type AttachPaymentIntentParams struct {
+	ID            pgtype.UUID `json:"id"`
+	ProviderTxnID pgtype.Text `json:"provider_txn_id"`
+}
+
+// Records the intent a Checkout Session created once a webhook names it.
+func (q *Queries) AttachPaymentIntent(ctx context.Context, arg AttachPaymentIntentParams) error {
+	_, err := q.db.Exec(ctx, attachPaymentIntent, arg.ID, arg.ProviderTxnID)
+	return err
+}
+
+const capturePayment = `-- name: CapturePayment :one
+UPDATE payments
+SET status = 'SUCCESS',
+    authorized_cents = amount_cents,
+    amount_cents = $1::bigint,
+    captured_at = NOW(),
+    updated_at = NOW()
+WHERE id = $2 AND status = 'AUTHORIZED'
+RETURNING id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id
+`
+
+type CapturePaymentParams struct {
+	AmountCents int64       `json:"amount_cents"`
+	ID          pgtype.UUID `json:"id"`
+}
+
+// Settles an authorized payment for amount_cents, keeping the amount that was
+// held. No row is returned unless the payment is still AUTHORIZED.
+func (q *Queries) CapturePayment(ctx context.Context, arg CapturePaymentParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, capturePayment, arg.AmountCents, arg.ID)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Provider,
+		&i.ProviderTxnID,
+		&i.AmountCents,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Status,
+		&i.Details,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
+	)
+	return i, err
+}
+
+const confirmOfflinePayment = `-- name: ConfirmOfflinePayment :one
+UPDATE payments
+SET status = 'SUCCESS',
+    confirmed_by = $1,
+    confirmed_at = NOW(),
+    updated_at = NOW()
+WHERE id = $2 AND status = 'INITIATED'
+RETURNING id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id
+`
+
+type ConfirmOfflinePaymentParams struct {
+	ConfirmedBy pgtype.UUID `json:"confirmed_by"`
+	ID          pgtype.UUID `json:"id"`
+}
+
+// Records that an offline payment was received. No row is returned unless
+// the payment is still waiting to be paid.
+func (q *Queries) ConfirmOfflinePayment(ctx context.Context, arg ConfirmOfflinePaymentParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, confirmOfflinePayment, arg.ConfirmedBy, arg.ID)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Provider,
+		&i.ProviderTxnID,
+		&i.AmountCents,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Status,
+		&i.Details,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
+	)
+	return i, err
+}
+
+const countPaymentsByOrderID = `-- name: CountPaymentsByOrderID :one
+SELECT COUNT(*) FROM payments
+WHERE order_id = $1
+`
+
+func (q *Queries) CountPaymentsByOrderID(ctx context.Context, orderID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countPaymentsByOrderID, orderID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createPayment = `-- name: CreatePayment :one
 INSERT INTO payments (
     order_id,
@@ -20,21 +197,29 @@ INSERT INTO payments (
     currency,
     payment_method,
     status,
-    details
+    details,
+    capture_method,
+    reference,
+    expires_at,
+    checkout_session_id
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
-) RETURNING id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+) RETURNING id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id
 `
 
 type CreatePaymentParams struct {
-	OrderID       pgtype.UUID `json:"order_id"`
-	Provider      string      `json:"provider"`
-	ProviderTxnID pgtype.Text `json:"provider_txn_id"`
-	AmountCents   int64       `json:"amount_cents"`
-	Currency      string      `json:"currency"`
-	PaymentMethod string      `json:"payment_method"`
-	Status        string      `json:"status"`
-	Details       []byte      `json:"details"`
+	OrderID           pgtype.UUID        `json:"order_id"`
+	Provider          string             `json:"provider"`
+	ProviderTxnID     pgtype.Text        `json:"provider_txn_id"`
+	AmountCents       int64              `json:"amount_cents"`
+	Currency          string             `json:"currency"`
+	PaymentMethod     string             `json:"payment_method"`
+	Status            string             `json:"status"`
+	Details           []byte             `json:"details"`
+	CaptureMethod     string             `json:"capture_method"`
+	Reference         pgtype.Text        `json:"reference"`
+	ExpiresAt         pgtype.Timestamptz `json:"expires_at"`
+	CheckoutSessionID pgtype.Text        `json:"checkout_session_id"`
 }
 
 func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (Payment, error) {
@@ -47,7 +232,57 @@ func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (P
 		arg.PaymentMethod,
 		arg.Status,
 		arg.Details,
+		arg.CaptureMethod,
+		arg.Reference,
+		arg.ExpiresAt,
+		arg.CheckoutSessionID,
+	)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Provider,
+		&i.ProviderTxnID,
+		&i.AmountCents,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Status,
+		&i.Details,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
 	)
+	return i, err
+}
+
+const expireOfflinePayment = `-- name: ExpireOfflinePayment :one
+UPDATE payments
+SET status = 'CANCELLED',
+    failure_reason = $2,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'INITIATED'
+RETURNING id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id
+`
+
+type ExpireOfflinePaymentParams struct {
+	ID            pgtype.UUID `json:"id"`
+	FailureReason pgtype.Text `json:"failure_reason"`
+}
+
+// Cancels an offline payment that was never received. No row is returned
+// unless the payment is still waiting to be paid.
+func (q *Queries) ExpireOfflinePayment(ctx context.Context, arg ExpireOfflinePaymentParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, expireOfflinePayment, arg.ID, arg.FailureReason)
 	var i Payment
 	err := row.Scan(
 		&i.ID,
@@ -62,15 +297,104 @@ func (q *Queries) CreatePayment(ctx context.Context, arg CreatePaymentParams) (P
 		&i.FailureReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
+	)
+	return i, err
+}
+
+const getPayment = `-- name: GetPayment :one
+SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id FROM payments
+WHERE id = $1
+`
+
+func (q *Queries) GetPayment(ctx context.Context, id pgtype.UUID) (Payment, error) {
+	row := q.db.QueryRow(ctx, getPayment, id)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Provider,
+		&i.ProviderTxnID,
+		&i.AmountCents,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Status,
+		&i.Details,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
+	)
+	return i, err
+}
+
+const getPaymentByCheckoutSessionID = `-- name: GetPaymentByCheckoutSessionID :one
+SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id FROM payments
+WHERE provider = $1 AND checkout_session_id = $2
+LIMIT 1
+`
+
+type GetPaymentByCheckoutSessionIDParams struct {
+	Provider          string      `json:"provider"`
+	CheckoutSessionID pgtype.Text `json:"checkout_session_id"`
+}
+
+func (q *Queries) GetPaymentByCheckoutSessionID(ctx context.Context, arg GetPaymentByCheckoutSessionIDParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, getPaymentByCheckoutSessionID, arg.Provider, arg.CheckoutSessionID)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Provider,
+		&i.ProviderTxnID,
+		&i.AmountCents,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Status,
+		&i.Details,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
 	)
 	return i, err
 }
 
 const getPaymentByOrderID = `-- name: GetPaymentByOrderID :one
-SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at FROM payments
-WHERE order_id = $1 LIMIT 1
+SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id FROM payments
+WHERE order_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT 1
 `
 
+// An order can have several payment attempts; this is the latest one.
 func (q *Queries) GetPaymentByOrderID(ctx context.Context, orderID pgtype.UUID) (Payment, error) {
 	row := q.db.QueryRow(ctx, getPaymentByOrderID, orderID)
 	var i Payment
@@ -87,15 +411,346 @@ func (q *Queries) GetPaymentByOrderID(ctx context.Context, orderID pgtype.UUID)
 		&i.FailureReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
+	)
+	return i, err
+}
+
+const getPaymentByProviderTxnID = `-- name: GetPaymentByProviderTxnID :one
+SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id FROM payments
+WHERE provider = $1 AND provider_txn_id = $2
+LIMIT 1
+`
+
+type GetPaymentByProviderTxnIDParams struct {
+	Provider      string      `json:"provider"`
+	ProviderTxnID pgtype.Text `json:"provider_txn_id"`
+}
+
+func (q *Queries) GetPaymentByProviderTxnID(ctx context.Context, arg GetPaymentByProviderTxnIDParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, getPaymentByProviderTxnID, arg.Provider, arg.ProviderTxnID)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Provider,
+		&i.ProviderTxnID,
+		&i.AmountCents,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Status,
+		&i.Details,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
+	)
+	return i, err
+}
+
+const listExpiredOfflinePayments = `-- name: ListExpiredOfflinePayments :many
+SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id FROM payments
+WHERE status = 'INITIATED' AND expires_at < $1
+ORDER BY expires_at
+LIMIT $2::int
+`
+
+type ListExpiredOfflinePaymentsParams struct {
+	ExpiredBefore pgtype.Timestamptz `json:"expired_before"`
+	RowLimit      int32              `json:"row_limit"`
+}
+
+// Offline payments still waiting to be paid past their expiry, oldest first.
+func (q *Queries) ListExpiredOfflinePayments(ctx context.Context, arg ListExpiredOfflinePaymentsParams) ([]Payment, error) {
+	rows, err := q.db.Query(ctx, listExpiredOfflinePayments, arg.ExpiredBefore, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payment{}
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.Provider,
+			&i.ProviderTxnID,
+			&i.AmountCents,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Status,
+			&i.Details,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastEventAt,
+			&i.CaptureMethod,
+			&i.AuthorizedAt,
+			&i.AuthorizedCents,
+			&i.CapturedAt,
+			&i.Reference,
+			&i.ExpiresAt,
+			&i.ConfirmedBy,
+			&i.ConfirmedAt,
+			&i.CheckoutSessionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPaymentsByOrderID = `-- name: ListPaymentsByOrderID :many
+SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id FROM payments
+WHERE order_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListPaymentsByOrderIDParams struct {
+	OrderID pgtype.UUID `json:"order_id"`
+	Limit   int32       `json:"limit"`
+	Offset  int32       `json:"offset"`
+}
+
+// Payment attempts for an order, newest first.
+func (q *Queries) ListPaymentsByOrderID(ctx context.Context, arg ListPaymentsByOrderIDParams) ([]Payment, error) {
+	rows, err := q.db.Query(ctx, listPaymentsByOrderID, arg.OrderID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payment{}
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.Provider,
+			&i.ProviderTxnID,
+			&i.AmountCents,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Status,
+			&i.Details,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastEventAt,
+			&i.CaptureMethod,
+			&i.AuthorizedAt,
+			&i.AuthorizedCents,
+			&i.CapturedAt,
+			&i.Reference,
+			&i.ExpiresAt,
+			&i.ConfirmedBy,
+			&i.ConfirmedAt,
+			&i.CheckoutSessionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPaymentsToReconcile = `-- name: ListPaymentsToReconcile :many
+SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id FROM payments
+WHERE provider = $1
+  AND (status IN ('INITIATED', 'AUTHORIZED')
+       OR (status = 'SUCCESS' AND captured_at IS NOT NULL AND (last_event_at IS NULL OR last_event_at < captured_at)))
+  AND updated_at >= $2
+  AND updated_at < $3
+  AND (updated_at, id) > ($4::timestamptz, $5::uuid)
+ORDER BY updated_at, id
+LIMIT $6::int
+`
+
+type ListPaymentsToReconcileParams struct {
+	Provider       string             `json:"provider"`
+	UpdatedAfter   pgtype.Timestamptz `json:"updated_after"`
+	UpdatedBefore  pgtype.Timestamptz `json:"updated_before"`
+	AfterUpdatedAt pgtype.Timestamptz `json:"after_updated_at"`
+	AfterID        pgtype.UUID        `json:"after_id"`
+	RowLimit       int32              `json:"row_limit"`
+}
+
+// Payments still waiting on the provider whose last change falls in
+// [updated_after, updated_before), oldest first: those not yet settled and
+// captures no later event has confirmed. Pass the last row's updated_at and
+// id as after_updated_at and after_id to get the next page.
+func (q *Queries) ListPaymentsToReconcile(ctx context.Context, arg ListPaymentsToReconcileParams) ([]Payment, error) {
+	rows, err := q.db.Query(ctx, listPaymentsToReconcile,
+		arg.Provider,
+		arg.UpdatedAfter,
+		arg.UpdatedBefore,
+		arg.AfterUpdatedAt,
+		arg.AfterID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payment{}
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.Provider,
+			&i.ProviderTxnID,
+			&i.AmountCents,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Status,
+			&i.Details,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastEventAt,
+			&i.CaptureMethod,
+			&i.AuthorizedAt,
+			&i.AuthorizedCents,
+			&i.CapturedAt,
+			&i.Reference,
+			&i.ExpiresAt,
+			&i.ConfirmedBy,
+			&i.ConfirmedAt,
+			&i.CheckoutSessionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listStaleAuthorizations = `-- name: ListStaleAuthorizations :many
+SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id FROM payments
+WHERE status = 'AUTHORIZED' AND authorized_at < $1
+ORDER BY authorized_at
+LIMIT $2::int
+`
+
+type ListStaleAuthorizationsParams struct {
+	AuthorizedBefore pgtype.Timestamptz `json:"authorized_before"`
+	RowLimit         int32              `json:"row_limit"`
+}
+
+// Authorized payments held since before authorized_before, oldest first.
+func (q *Queries) ListStaleAuthorizations(ctx context.Context, arg ListStaleAuthorizationsParams) ([]Payment, error) {
+	rows, err := q.db.Query(ctx, listStaleAuthorizations, arg.AuthorizedBefore, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Payment{}
+	for rows.Next() {
+		var i Payment
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.Provider,
+			&i.ProviderTxnID,
+			&i.AmountCents,
+			&i.Currency,
+			&i.PaymentMethod,
+			&i.Status,
+			&i.Details,
+			&i.FailureReason,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.LastEventAt,
+			&i.CaptureMethod,
+			&i.AuthorizedAt,
+			&i.AuthorizedCents,
+			&i.CapturedAt,
+			&i.Reference,
+			&i.ExpiresAt,
+			&i.ConfirmedBy,
+			&i.ConfirmedAt,
+			&i.CheckoutSessionID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockPayment = `-- name: LockPayment :one
+SELECT id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id FROM payments
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockPayment(ctx context.Context, id pgtype.UUID) (Payment, error) {
+	row := q.db.QueryRow(ctx, lockPayment, id)
+	var i Payment
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.Provider,
+		&i.ProviderTxnID,
+		&i.AmountCents,
+		&i.Currency,
+		&i.PaymentMethod,
+		&i.Status,
+		&i.Details,
+		&i.FailureReason,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
 	)
 	return i, err
 }
 
 const updatePaymentStatus = `-- name: UpdatePaymentStatus :one
 UPDATE payments
-SET status = $2, created_at = NOW()
+SET status = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at
+RETURNING id, order_id, provider, provider_txn_id, amount_cents, currency, payment_method, status, details, failure_reason, created_at, updated_at, last_event_at, capture_method, authorized_at, authorized_cents, captured_at, reference, expires_at, confirmed_by, confirmed_at, checkout_session_id
 `
 
 type UpdatePaymentStatusParams struct {
@@ -119,6 +774,16 @@ func (q *Queries) UpdatePaymentStatus(ctx context.Context, arg UpdatePaymentStat
 		&i.FailureReason,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastEventAt,
+		&i.CaptureMethod,
+		&i.AuthorizedAt,
+		&i.AuthorizedCents,
+		&i.CapturedAt,
+		&i.Reference,
+		&i.ExpiresAt,
+		&i.ConfirmedBy,
+		&i.ConfirmedAt,
+		&i.CheckoutSessionID,
 	)
 	return i, err
 }
@@ -34,10 +34,16 @@ INSERT INTO products (
     main_image_url,
     images,
     discount_percent,
-    discount_valid_until
+    discount_valid_until,
+    tax_category,
+    weight_grams,
+    length_mm,
+    width_mm,
+    height_mm,
+    is_gift_card
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
-) RETURNING id, sku, name, description, category_id, price_cents, currency, attributes, main_image_url, images, discount_percent, discount_valid_until, is_active, is_deleted, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
+) RETURNING id, sku, name, description, category_id, price_cents, currency, attributes, main_image_url, images, discount_percent, discount_valid_until, is_active, is_deleted, created_at, updated_at, tax_category, weight_grams, length_mm, width_mm, height_mm, is_gift_card
 `
 
 type CreateProductParams struct {
@@ -52,6 +58,12 @@ type CreateProductParams struct {
 	Images             []byte             `json:"images"`
 	DiscountPercent    pgtype.Int4        `json:"discount_percent"`
 	DiscountValidUntil pgtype.Timestamptz `json:"discount_valid_until"`
+	TaxCategory        string             `json:"tax_category"`
+	WeightGrams        int32              `json:"weight_grams"`
+	LengthMm           int32              `json:"length_mm"`
+	WidthMm            int32              `json:"width_mm"`
+	HeightMm           int32              `json:"height_mm"`
+	IsGiftCard         bool               `json:"is_gift_card"`
 }
 
 func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (Product, error) {
@@ -67,6 +79,12 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		arg.Images,
 		arg.DiscountPercent,
 		arg.DiscountValidUntil,
+		arg.TaxCategory,
+		arg.WeightGrams,
+		arg.LengthMm,
+		arg.WidthMm,
+		arg.HeightMm,
+		arg.IsGiftCard,
 	)
 	var i Product
 	err := row.Scan(
@@ -86,6 +104,12 @@ func (q *Queries) CreateProduct(ctx context.Context, arg CreateProductParams) (P
 		&i.IsDeleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TaxCategory,
+		&i.WeightGrams,
+		&i.LengthMm,
+		&i.WidthMm,
+		&i.HeightMm,
+		&i.IsGiftCard,
 	)
 	return i, err
 }
@@ -127,6 +151,12 @@ func (q *Queries) GetProductByID(ctx context.Context, id pgtype.UUID) (Product,
 		&i.IsDeleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TaxCategory,
+		&i.WeightGrams,
+		&i.LengthMm,
+		&i.WidthMm,
+		&i.HeightMm,
+		&i.IsGiftCard,
 	)
 	return i, err
 }
@@ -156,6 +186,12 @@ func (q *Queries) GetProductBySKU(ctx context.Context, sku string) (Product, err
 		&i.IsDeleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TaxCategory,
+		&i.WeightGrams,
+		&i.LengthMm,
+		&i.WidthMm,
+		&i.HeightMm,
+		&i.IsGiftCard,
 	)
 	return i, err
 }
@@ -198,6 +234,12 @@ func (q *Queries) ListProducts(ctx context.Context, arg ListProductsParams) ([]P
 			&i.IsDeleted,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.TaxCategory,
+			&i.WeightGrams,
+			&i.LengthMm,
+			&i.WidthMm,
+			&i.HeightMm,
+			&i.IsGiftCard,
 		); err != nil {
 			return nil, err
 		}
@@ -224,7 +266,7 @@ SET
     discount_valid_until = COALESCE($11, discount_valid_until),
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, sku, name, description, category_id, price_cents, currency, attributes, main_image_url, images, discount_percent, discount_valid_until, is_active, is_deleted, created_at, updated_at
+RETURNING id, sku, name, description, category_id, price_cents, currency, attributes, main_image_url, images, discount_percent, discount_valid_until, is_active, is_deleted, created_at, updated_at, tax_category, weight_grams, length_mm, width_mm, height_mm, is_gift_card
 `
 
 type UpdateProductParams struct {
@@ -273,6 +315,12 @@ func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (P
 		&i.IsDeleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TaxCategory,
+		&i.WeightGrams,
+		&i.LengthMm,
+		&i.WidthMm,
+		&i.HeightMm,
+		&i.IsGiftCard,
 	)
 	return i, err
 }
@@ -281,7 +329,7 @@ const updateProductPrice = `-- name: UpdateProductPrice :one
 UPDATE products
 SET price_cents = $2, updated_at = NOW()
 WHERE id = $1
-RETURNING id, sku, name, description, category_id, price_cents, currency, attributes, main_image_url, images, discount_percent, discount_valid_until, is_active, is_deleted, created_at, updated_at
+RETURNING id, sku, name, description, category_id, price_cents, currency, attributes, main_image_url, images, discount_percent, discount_valid_until, is_active, is_deleted, created_at, updated_at, tax_category, weight_grams, length_mm, width_mm, height_mm, is_gift_card
 `
 
 type UpdateProductPriceParams struct {
@@ -309,6 +357,109 @@ func (q *Queries) UpdateProductPrice(ctx context.Context, arg UpdateProductPrice
 		&i.IsDeleted,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.TaxCategory,
+		&i.WeightGrams,
+		&i.LengthMm,
+		&i.WidthMm,
+		&i.HeightMm,
+		&i.IsGiftCard,
+	)
+	return i, err
+}
+
+const updateProductShippingDetails = `-- name: UpdateProductShippingDetails :one
+UPDATE products
+SET weight_grams = $2,
+    length_mm = $3,
+    width_mm = $4,
+    height_mm = $5,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, sku, name, description, category_id, price_cents, currency, attributes, main_image_url, images, discount_percent, discount_valid_until, is_active, is_deleted, created_at, updated_at, tax_category, weight_grams, length_mm, width_mm, height_mm, is_gift_card
+`
+
+type UpdateProductShippingDetailsParams struct {
+	ID          pgtype.UUID `json:"id"`
+	WeightGrams int32       `json:"weight_grams"`
+	LengthMm    int32       `json:"length_mm"`
+	WidthMm     int32       `json:"width_mm"`
+	HeightMm    int32       `json:"height_mm"`
+}
+
+func (q *Queries) UpdateProductShippingDetails(ctx context.Context, arg UpdateProductShippingDetailsParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductShippingDetails,
+		arg.ID,
+		arg.WeightGrams,
+		arg.LengthMm,
+		arg.WidthMm,
+		arg.HeightMm,
+	)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Sku,
+		&i.Name,
+		&i.Description,
+		&i.CategoryID,
+		&i.PriceCents,
+		&i.Currency,
+		&i.Attributes,
+		&i.MainImageUrl,
+		&i.Images,
+		&i.DiscountPercent,
+		&i.DiscountValidUntil,
+		&i.IsActive,
+		&i.IsDeleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TaxCategory,
+		&i.WeightGrams,
+		&i.LengthMm,
+		&i.WidthMm,
+		&i.HeightMm,
+		&i.IsGiftCard,
+	)
+	return i, err
+}
+
+const updateProductTaxCategory = `-- name: UpdateProductTaxCategory :one
+UPDATE products
+SET tax_category = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING id, sku, name, description, category_id, price_cents, currency, attributes, main_image_url, images, discount_percent, discount_valid_until, is_active, is_deleted, created_at, updated_at, tax_category, weight_grams, length_mm, width_mm, height_mm, is_gift_card
+`
+
+type UpdateProductTaxCategoryParams struct {
+	ID          pgtype.UUID `json:"id"`
+	TaxCategory string      `json:"tax_category"`
+}
+
+func (q *Queries) UpdateProductTaxCategory(ctx context.Context, arg UpdateProductTaxCategoryParams) (Product, error) {
+	row := q.db.QueryRow(ctx, updateProductTaxCategory, arg.ID, arg.TaxCategory)
+	var i Product
+	err := row.Scan(
+		&i.ID,
+		&i.Sku,
+		&i.Name,
+		&i.Description,
+		&i.CategoryID,
+		&i.PriceCents,
+		&i.Currency,
+		&i.Attributes,
+		&i.MainImageUrl,
+		&i.Images,
+		&i.DiscountPercent,
+		&i.DiscountValidUntil,
+		&i.IsActive,
+		&i.IsDeleted,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TaxCategory,
+		&i.WeightGrams,
+		&i.LengthMm,
+		&i.WidthMm,
+		&i.HeightMm,
+		&i.IsGiftCard,
 	)
 	return i, err
 }
@@ -13,12 +13,12 @@ import (
 
 const createShipment = `-- name: CreateShipment :one
 INSERT INTO shipments (
-    order_id, carrier, tracking_number, status, shipped_at, delivered_at
+    order_id, carrier, tracking_number, status, shipped_at, delivered_at, warehouse_id
 )
 VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7
 )
-RETURNING id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at
+RETURNING id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at, warehouse_id
 `
 
 type CreateShipmentParams struct {
@@ -28,6 +28,7 @@ type CreateShipmentParams struct {
 	Status         string             `json:"status"`
 	ShippedAt      pgtype.Timestamptz `json:"shipped_at"`
 	DeliveredAt    pgtype.Timestamptz `json:"delivered_at"`
+	WarehouseID    pgtype.UUID        `json:"warehouse_id"`
 }
 
 func (q *Queries) CreateShipment(ctx context.Context, arg CreateShipmentParams) (Shipment, error) {
@@ -38,6 +39,7 @@ func (q *Queries) CreateShipment(ctx context.Context, arg CreateShipmentParams)
 		arg.Status,
 		arg.ShippedAt,
 		arg.DeliveredAt,
+		arg.WarehouseID,
 	)
 	var i Shipment
 	err := row.Scan(
@@ -50,6 +52,7 @@ func (q *Queries) CreateShipment(ctx context.Context, arg CreateShipmentParams)
 		&i.DeliveredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WarehouseID,
 	)
 	return i, err
 }
@@ -65,7 +68,7 @@ func (q *Queries) DeleteShipment(ctx context.Context, id pgtype.UUID) error {
 }
 
 const getShipment = `-- name: GetShipment :one
-SELECT id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at FROM shipments
+SELECT id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at, warehouse_id FROM shipments
 WHERE id = $1
 `
 
@@ -82,12 +85,13 @@ func (q *Queries) GetShipment(ctx context.Context, id pgtype.UUID) (Shipment, er
 		&i.DeliveredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WarehouseID,
 	)
 	return i, err
 }
 
 const listShipmentsByOrder = `-- name: ListShipmentsByOrder :many
-SELECT id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at FROM shipments
+SELECT id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at, warehouse_id FROM shipments
 WHERE order_id = $1
 ORDER BY created_at DESC
 `
@@ -111,6 +115,7 @@ func (q *Queries) ListShipmentsByOrder(ctx context.Context, orderID pgtype.UUID)
 			&i.DeliveredAt,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.WarehouseID,
 		); err != nil {
 			return nil, err
 		}
@@ -129,7 +134,7 @@ SET status = $2,
     delivered_at = $4,
     updated_at = NOW()
 WHERE id = $1
-RETURNING id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at
+RETURNING id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at, warehouse_id
 `
 
 type UpdateShipmentStatusParams struct {
@@ -157,6 +162,7 @@ func (q *Queries) UpdateShipmentStatus(ctx context.Context, arg UpdateShipmentSt
 		&i.DeliveredAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.WarehouseID,
 	)
 	return i, err
 }
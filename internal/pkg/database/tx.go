@@ -0,0 +1,74 @@
+package database
+
+import (
+	"context"
+	"ecommerce-app/internal/pkg/database/sqlc"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type txCtxKey struct{}
+
+type afterCommitCtxKey struct{}
+
+// Transactor starts database transactions (satisfied by *pgxpool.Pool).
+type Transactor interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// WithTx runs fn inside a transaction that travels on the context.
+// Repositories pick it up through Queries, so calls made across domains
+// with the returned ctx commit or roll back together. Nested calls join
+// the outer transaction instead of opening a new one. Functions queued with
+// AfterCommit run once the outermost transaction has committed.
+func WithTx(ctx context.Context, db Transactor, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txCtxKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var hooks []func(ctx context.Context)
+	txCtx := context.WithValue(ctx, txCtxKey{}, tx)
+	txCtx = context.WithValue(txCtx, afterCommitCtxKey{}, &hooks)
+
+	if err := fn(txCtx); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return err
+	}
+
+	for _, hook := range hooks {
+		hook(ctx)
+	}
+
+	return nil
+}
+
+// AfterCommit queues fn to run once the transaction carried by ctx has
+// committed, or runs it at once when ctx carries none. Calls to outside
+// services that cannot be rolled back, such as the payment provider, go
+// through it so a rollback never leaves them behind. fn runs outside any
+// transaction, in the order it was queued, and handles its own errors.
+func AfterCommit(ctx context.Context, fn func(ctx context.Context)) {
+	if hooks, ok := ctx.Value(afterCommitCtxKey{}).(*[]func(ctx context.Context)); ok {
+		*hooks = append(*hooks, fn)
+		return
+	}
+	fn(ctx)
+}
+
+// Queries returns q bound to the transaction carried by ctx, or q itself
+// when no transaction is active.
+func Queries(ctx context.Context, q *sqlc.Queries) *sqlc.Queries {
+	if tx, ok := ctx.Value(txCtxKey{}).(pgx.Tx); ok {
+		return q.WithTx(tx)
+	}
+	return q
+}
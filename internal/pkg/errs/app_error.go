@@ -16,6 +16,7 @@ var (
 	ErrBadRequest      = &AppError{Code: http.StatusBadRequest, Message: "Invalid request"}
 	ErrInternal        = &AppError{Code: http.StatusInternalServerError, Message: "Internal server error"}
 	ErrUnauthorized    = &AppError{Code: http.StatusUnauthorized, Message: "Unauthorized"}
+	ErrForbidden       = &AppError{Code: http.StatusForbidden, Message: "Forbidden"}
 )
 
 
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+
+	"ecommerce-app/internal/pkg/logger"
+	"ecommerce-app/internal/pkg/response"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+)
+
+// IdempotencyRecord is the stored state of a previously seen Idempotency-Key.
+type IdempotencyRecord struct {
+	RequestHash string
+	StatusCode  int
+	Body        []byte
+	Completed   bool
+}
+
+// IdempotencyStore persists Idempotency-Key records per user.
+type IdempotencyStore interface {
+	// Claim marks key as in flight for userID. When the key is already taken
+	// it returns claimed=false along with the existing record.
+	Claim(ctx context.Context, userID, key, method, path, requestHash string) (existing IdempotencyRecord, claimed bool, err error)
+	// Complete stores the response to replay for key.
+	Complete(ctx context.Context, userID, key string, statusCode int, body []byte) error
+	// Release forgets key so the request can be retried.
+	Release(ctx context.Context, userID, key string) error
+}
+
+// Idempotency makes a route safe to retry when the client sends an
+// Idempotency-Key header. The first request with a key runs normally and its
+// response is stored; a retry with the same key and body gets that response
+// replayed, the same key with a different body is rejected with 422, and a
+// retry that arrives while the first request is still running gets 409.
+// 5xx responses are not stored so the client can retry them.
+//
+// It is opt-in per route and must run after RoleMiddleware, since keys are
+// scoped to the authenticated user. Requests without the header pass through.
+func Idempotency(store IdempotencyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				response.BadRequest(w, "Idempotency-Key must be at most 255 characters")
+				return
+			}
+
+			userID, ok := r.Context().Value(UserIDKey).(string)
+			if !ok || userID == "" {
+				response.Unauthorized(w, "authentication required for idempotent requests")
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				response.BadRequest(w, "failed to read request body")
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			hash := requestHash(r.Method, r.URL.Path, body)
+
+			existing, claimed, err := store.Claim(r.Context(), userID, key, r.Method, r.URL.Path, hash)
+			if err != nil {
+				logger.Error("Idempotency: failed to claim key %s: %v", key, err)
+				response.InternalServerError(w, "failed to process Idempotency-Key")
+				return
+			}
+
+			if !claimed {
+				switch {
+				case existing.RequestHash != hash:
+					response.Error(w, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request")
+				case !existing.Completed:
+					response.Error(w, http.StatusConflict, "A request with this Idempotency-Key is still being processed")
+				default:
+					w.Header().Set("Content-Type", "application/json")
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(existing.StatusCode)
+					w.Write(existing.Body)
+				}
+				return
+			}
+
+			rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rec, r)
+
+			// The client may have gone away; the outcome must still be saved
+			ctx := context.WithoutCancel(r.Context())
+			if rec.status >= http.StatusInternalServerError {
+				if err := store.Release(ctx, userID, key); err != nil {
+					logger.Error("Idempotency: failed to release key %s: %v", key, err)
+				}
+				return
+			}
+
+			if err := store.Complete(ctx, userID, key, rec.status, rec.body.Bytes()); err != nil {
+				logger.Error("Idempotency: failed to store response for key %s: %v", key, err)
+			}
+		})
+	}
+}
+
+func requestHash(method, path string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{0})
+	h.Write([]byte(path))
+	h.Write([]byte{0})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// responseRecorder passes the response through while keeping a copy of the
+// status and body.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	wroteHeader bool
+}
+
+func (rr *responseRecorder) WriteHeader(code int) {
+	if !rr.wroteHeader {
+		rr.status = code
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(code)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	rr.body.Write(b)
+	return rr.ResponseWriter.Write(b)
+}
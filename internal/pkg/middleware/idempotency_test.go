@@ -0,0 +1,206 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// memoryIdempotencyStore keeps records in memory, keyed by user and key
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]IdempotencyRecord
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{records: map[string]IdempotencyRecord{}}
+}
+
+func (s *memoryIdempotencyStore) Claim(ctx context.Context, userID, key, method, path, requestHash string) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[userID+"/"+key]; ok {
+		return existing, false, nil
+	}
+	s.records[userID+"/"+key] = IdempotencyRecord{RequestHash: requestHash}
+	return IdempotencyRecord{}, true, nil
+}
+
+func (s *memoryIdempotencyStore) Complete(ctx context.Context, userID, key string, statusCode int, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	rec := s.records[userID+"/"+key]
+	rec.StatusCode, rec.Body, rec.Completed = statusCode, body, true
+	s.records[userID+"/"+key] = rec
+	return nil
+}
+
+func (s *memoryIdempotencyStore) Release(ctx context.Context, userID, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, userID+"/"+key)
+	return nil
+}
+
+type idempotentRequest struct {
+	user string
+	key  string
+	body string
+}
+
+func TestIdempotency(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		first        idempotentRequest
+		retry        idempotentRequest
+		wantStatus   int
+		wantReplayed bool
+		wantCalls    int
+	}{
+		{
+			name:         "retry replays the stored response",
+			status:       http.StatusCreated,
+			first:        idempotentRequest{user: "u1", key: "k1", body: `{"qty":1}`},
+			retry:        idempotentRequest{user: "u1", key: "k1", body: `{"qty":1}`},
+			wantStatus:   http.StatusCreated,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name:         "stored client error is replayed too",
+			status:       http.StatusBadRequest,
+			first:        idempotentRequest{user: "u1", key: "k1", body: `{}`},
+			retry:        idempotentRequest{user: "u1", key: "k1", body: `{}`},
+			wantStatus:   http.StatusBadRequest,
+			wantReplayed: true,
+			wantCalls:    1,
+		},
+		{
+			name:       "same key with a different body",
+			status:     http.StatusCreated,
+			first:      idempotentRequest{user: "u1", key: "k1", body: `{"qty":1}`},
+			retry:      idempotentRequest{user: "u1", key: "k1", body: `{"qty":2}`},
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCalls:  1,
+		},
+		{
+			name:       "server errors are released for a retry",
+			status:     http.StatusInternalServerError,
+			first:      idempotentRequest{user: "u1", key: "k1", body: `{}`},
+			retry:      idempotentRequest{user: "u1", key: "k1", body: `{}`},
+			wantStatus: http.StatusInternalServerError,
+			wantCalls:  2,
+		},
+		{
+			name:       "keys are scoped to the user",
+			status:     http.StatusCreated,
+			first:      idempotentRequest{user: "u1", key: "k1", body: `{}`},
+			retry:      idempotentRequest{user: "u2", key: "k1", body: `{}`},
+			wantStatus: http.StatusCreated,
+			wantCalls:  2,
+		},
+		{
+			name:       "requests without a key pass through",
+			status:     http.StatusCreated,
+			first:      idempotentRequest{user: "u1", body: `{}`},
+			retry:      idempotentRequest{user: "u1", body: `{}`},
+			wantStatus: http.StatusCreated,
+			wantCalls:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			handler := Idempotency(newMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tt.status)
+				w.Write([]byte(`{"call":1}`))
+			}))
+
+			serve(handler, tt.first)
+			rec := serve(handler, tt.retry)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("retry got %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if replayed := rec.Header().Get(IdempotentReplayedHeader) == "true"; replayed != tt.wantReplayed {
+				t.Errorf("replayed = %v, want %v", replayed, tt.wantReplayed)
+			}
+			if tt.wantReplayed && rec.Body.String() != `{"call":1}` {
+				t.Errorf("replayed body %q", rec.Body.String())
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("handler ran %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	started, finish := make(chan struct{}), make(chan struct{})
+	handler := Idempotency(newMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-finish
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	req := idempotentRequest{user: "u1", key: "k1", body: `{}`}
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serve(handler, req) }()
+	<-started
+
+	if rec := serve(handler, req); rec.Code != http.StatusConflict {
+		t.Errorf("retry while in flight got %d, want %d", rec.Code, http.StatusConflict)
+	}
+
+	close(finish)
+	if rec := <-done; rec.Code != http.StatusCreated {
+		t.Errorf("first request got %d, want %d", rec.Code, http.StatusCreated)
+	}
+	if rec := serve(handler, req); rec.Code != http.StatusCreated || rec.Header().Get(IdempotentReplayedHeader) != "true" {
+		t.Errorf("retry after completion got %d, replayed %q", rec.Code, rec.Header().Get(IdempotentReplayedHeader))
+	}
+}
+
+func TestIdempotencyRejectsBadRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		user       string
+		key        string
+		wantStatus int
+	}{
+		{name: "key too long", user: "u1", key: strings.Repeat("k", maxIdempotencyKeyLength+1), wantStatus: http.StatusBadRequest},
+		{name: "not authenticated", key: "k1", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Idempotency(newMemoryIdempotencyStore())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				t.Error("handler ran")
+			}))
+
+			if rec := serve(handler, idempotentRequest{user: tt.user, key: tt.key}); rec.Code != tt.wantStatus {
+				t.Errorf("got %d, want %d", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func serve(handler http.Handler, in idempotentRequest) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(in.body))
+	if in.key != "" {
+		req.Header.Set(IdempotencyKeyHeader, in.key)
+	}
+	if in.user != "" {
+		req = req.WithContext(context.WithValue(req.Context(), UserIDKey, in.user))
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
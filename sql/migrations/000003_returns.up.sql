@@ -0,0 +1,34 @@
+-- Running total refunded against an order across cancellations and partial returns
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded_cents BIGINT NOT NULL DEFAULT 0;
+
+-- Returns (RMA) table
+CREATE TABLE IF NOT EXISTS returns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    status TEXT NOT NULL CHECK (status IN ('REQUESTED', 'APPROVED', 'REJECTED', 'REFUNDED')) DEFAULT 'REQUESTED',
+    reason TEXT NOT NULL,
+    resolution_note TEXT,
+    reviewed_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    shipment_id UUID REFERENCES shipments(id) ON DELETE SET NULL, -- RETURNED shipment recorded on receipt
+    refund_cents BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+    reviewed_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ
+);
+
+-- Return Items table
+CREATE TABLE IF NOT EXISTS return_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    return_id UUID NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    qty INT NOT NULL CHECK (qty > 0),
+    refund_cents BIGINT NOT NULL DEFAULT 0, -- line total less its pro-rated share of the order discount
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_returns_order ON returns(order_id);
+CREATE INDEX IF NOT EXISTS idx_returns_user ON returns(user_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_return ON return_items(return_id);
+CREATE INDEX IF NOT EXISTS idx_return_items_order_item ON return_items(order_item_id);
@@ -0,0 +1,17 @@
+-- Idempotency Keys table: one row per (user, Idempotency-Key) holding the
+-- response to replay when a client retries the same request
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    idempotency_key TEXT NOT NULL,
+    method TEXT NOT NULL,
+    path TEXT NOT NULL,
+    request_hash TEXT NOT NULL, -- sha256 of method, path and body
+    response_status INT,
+    response_body BYTEA,
+    locked_at TIMESTAMPTZ NOT NULL DEFAULT NOW(), -- when the in-flight request claimed the key
+    completed_at TIMESTAMPTZ, -- NULL while the request is in flight
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT unique_user_idempotency_key UNIQUE (user_id, idempotency_key)
+);
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS order_tax_lines;
+DROP TABLE IF EXISTS tax_exemptions;
+DROP TABLE IF EXISTS tax_rates;
+
+ALTER TABLE products DROP COLUMN IF EXISTS tax_category;
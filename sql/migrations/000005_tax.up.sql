@@ -0,0 +1,49 @@
+-- Tax category drives which rate applies to a product
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_category TEXT NOT NULL DEFAULT 'standard';
+
+-- Tax Rates table. A rate applies to a country, optionally narrowed to a
+-- state and a postal code prefix ('' matches any); the most specific match
+-- wins per tax category.
+CREATE TABLE IF NOT EXISTS tax_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    country CHAR(2) NOT NULL,
+    state TEXT NOT NULL DEFAULT '',
+    postal_code_prefix TEXT NOT NULL DEFAULT '',
+    tax_category TEXT NOT NULL DEFAULT 'standard',
+    rate_bps INT NOT NULL CHECK (rate_bps >= 0 AND rate_bps <= 10000), -- basis points, 825 = 8.25%
+    is_inclusive BOOLEAN NOT NULL DEFAULT FALSE, -- prices in this jurisdiction already include the tax
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT unique_tax_rate_jurisdiction UNIQUE (country, state, postal_code_prefix, tax_category)
+);
+
+-- Tax Exemptions table: customers who are not charged tax
+CREATE TABLE IF NOT EXISTS tax_exemptions (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    certificate_number TEXT,
+    reason TEXT,
+    expires_at TIMESTAMPTZ,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Order Tax Lines table: per-line tax breakdown persisted with the order
+CREATE TABLE IF NOT EXISTS order_tax_lines (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id UUID REFERENCES order_items(id) ON DELETE CASCADE,
+    tax_rate_id UUID REFERENCES tax_rates(id) ON DELETE SET NULL,
+    jurisdiction TEXT NOT NULL,
+    tax_category TEXT NOT NULL,
+    rate_bps INT NOT NULL,
+    is_inclusive BOOLEAN NOT NULL,
+    taxable_cents BIGINT NOT NULL,
+    tax_cents BIGINT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_rates_country ON tax_rates(country);
+CREATE INDEX IF NOT EXISTS idx_order_tax_lines_order ON order_tax_lines(order_id);
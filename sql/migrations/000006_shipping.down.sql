@@ -0,0 +1,11 @@
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_method_name;
+ALTER TABLE orders DROP COLUMN IF EXISTS shipping_method_id;
+
+DROP TABLE IF EXISTS shipping_methods;
+DROP TABLE IF EXISTS shipping_zone_regions;
+DROP TABLE IF EXISTS shipping_zones;
+
+ALTER TABLE products DROP COLUMN IF EXISTS height_mm;
+ALTER TABLE products DROP COLUMN IF EXISTS width_mm;
+ALTER TABLE products DROP COLUMN IF EXISTS length_mm;
+ALTER TABLE products DROP COLUMN IF EXISTS weight_grams;
@@ -0,0 +1,51 @@
+-- Physical details used to price shipping
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight_grams INT NOT NULL DEFAULT 0 CHECK (weight_grams >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS length_mm INT NOT NULL DEFAULT 0 CHECK (length_mm >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS width_mm INT NOT NULL DEFAULT 0 CHECK (width_mm >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS height_mm INT NOT NULL DEFAULT 0 CHECK (height_mm >= 0);
+
+-- Shipping Zones table: a named set of countries/states sharing shipping methods
+CREATE TABLE IF NOT EXISTS shipping_zones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL UNIQUE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Shipping Zone Regions table. An empty state covers the whole country; a
+-- state-level region wins over a country-wide one.
+CREATE TABLE IF NOT EXISTS shipping_zone_regions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    zone_id UUID NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+    country CHAR(2) NOT NULL,
+    state TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT unique_shipping_region UNIQUE (country, state)
+);
+
+-- Shipping Methods table. FLAT charges flat_cents; WEIGHT_TIERED and
+-- PRICE_TIERED pick the first tier whose up_to covers the cart weight (grams)
+-- or subtotal (cents). Carts at or above free_over_cents ship free.
+CREATE TABLE IF NOT EXISTS shipping_methods (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    zone_id UUID NOT NULL REFERENCES shipping_zones(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    rate_type TEXT NOT NULL CHECK (rate_type IN ('FLAT', 'WEIGHT_TIERED', 'PRICE_TIERED')),
+    flat_cents BIGINT NOT NULL DEFAULT 0 CHECK (flat_cents >= 0),
+    tiers JSONB NOT NULL DEFAULT '[]'::jsonb, -- [{"up_to": 1000, "cents": 500}, {"up_to": null, "cents": 900}]
+    free_over_cents BIGINT CHECK (free_over_cents >= 0),
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT unique_shipping_method_name UNIQUE (zone_id, name)
+);
+
+-- The shipping method chosen at checkout, snapshotted on the order
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_method_id UUID REFERENCES shipping_methods(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS shipping_method_name TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_shipping_zone_regions_zone ON shipping_zone_regions(zone_id);
+CREATE INDEX IF NOT EXISTS idx_shipping_methods_zone ON shipping_methods(zone_id);
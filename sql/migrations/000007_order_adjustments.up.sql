@@ -0,0 +1,15 @@
+-- Order Adjustments table: itemized explanation of how an order was priced,
+-- in the order the pricing pipeline applied them. Discounts are negative.
+CREATE TABLE IF NOT EXISTS order_adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id UUID REFERENCES order_items(id) ON DELETE CASCADE, -- NULL for order-level adjustments such as shipping
+    kind TEXT NOT NULL CHECK (kind IN ('PRODUCT_DISCOUNT', 'COUPON', 'SHIPPING', 'TAX')),
+    code TEXT NOT NULL DEFAULT '', -- coupon code, tax jurisdiction or shipping method name
+    description TEXT NOT NULL DEFAULT '',
+    amount_cents BIGINT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_adjustments_order ON order_adjustments(order_id);
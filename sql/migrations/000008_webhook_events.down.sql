@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS webhook_events;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS last_event_at;
@@ -0,0 +1,27 @@
+-- Provider timestamp of the newest webhook event applied to the payment;
+-- older events arriving late are ignored
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS last_event_at TIMESTAMPTZ;
+
+-- Webhook Events table: durable log of every verified provider webhook,
+-- keyed by the provider's event ID so retried deliveries are applied once
+CREATE TABLE IF NOT EXISTS webhook_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider TEXT NOT NULL,
+    event_id TEXT NOT NULL,
+    event_type TEXT NOT NULL,
+    provider_txn_id TEXT,
+    order_id UUID,
+    payment_status TEXT NOT NULL, -- payment status the event maps to
+    failure_reason TEXT,
+    occurred_at TIMESTAMPTZ NOT NULL, -- when the provider created the event
+    payload JSONB NOT NULL,
+    status TEXT NOT NULL DEFAULT 'RECEIVED' CHECK (status IN ('RECEIVED', 'PROCESSED', 'IGNORED', 'FAILED')),
+    error TEXT, -- why processing failed or the event was ignored
+    attempts INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    processed_at TIMESTAMPTZ,
+
+    CONSTRAINT unique_webhook_event UNIQUE (provider, event_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_events_status ON webhook_events(status, created_at);
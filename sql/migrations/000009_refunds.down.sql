@@ -0,0 +1,6 @@
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS refund_reference;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS refund_amount_cents;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS refund_status;
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS provider_refund_id;
+
+DROP TABLE IF EXISTS refunds;
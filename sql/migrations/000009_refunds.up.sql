@@ -0,0 +1,28 @@
+-- Refunds table: ledger of every refund issued against a payment, whether
+-- started here or at the provider
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    provider_refund_id TEXT, -- set once the provider accepts the refund
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    reason TEXT,
+    status TEXT NOT NULL CHECK (status IN ('PENDING', 'SUCCEEDED', 'FAILED', 'CANCELLED')) DEFAULT 'PENDING',
+    failure_reason TEXT,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL for refunds made at the provider
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT unique_provider_refund UNIQUE (provider, provider_refund_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_payment_id ON refunds(payment_id);
+
+-- Refund webhooks: the refund they report on, or for charge-level events the
+-- provider's running refunded total in refund_amount_cents
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS provider_refund_id TEXT;
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS refund_status TEXT;
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS refund_amount_cents BIGINT;
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS refund_reference TEXT; -- our refunds.id, when we started the refund
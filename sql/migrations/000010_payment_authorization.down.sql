@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_payments_authorized;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS captured_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS authorized_cents;
+ALTER TABLE payments DROP COLUMN IF EXISTS authorized_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS capture_method;
+
+UPDATE payments SET status = 'CANCELLED' WHERE status = 'AUTHORIZED';
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('INITIATED', 'PROCESSING', 'SUCCESS', 'FAILED', 'CANCELLED', 'REFUNDED'));
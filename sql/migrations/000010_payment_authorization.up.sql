@@ -0,0 +1,12 @@
+-- Manual capture: payments can be AUTHORIZED at checkout and captured when
+-- the order ships
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_status_check;
+ALTER TABLE payments ADD CONSTRAINT payments_status_check
+    CHECK (status IN ('INITIATED', 'PROCESSING', 'AUTHORIZED', 'SUCCESS', 'FAILED', 'CANCELLED', 'REFUNDED'));
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS capture_method TEXT NOT NULL DEFAULT 'AUTOMATIC' CHECK (capture_method IN ('AUTOMATIC', 'MANUAL'));
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS authorized_at TIMESTAMPTZ;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS authorized_cents BIGINT; -- amount held before a partial capture
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS captured_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_payments_authorized ON payments(authorized_at) WHERE status = 'AUTHORIZED';
@@ -0,0 +1,39 @@
+-- Wallets: a customer's store-credit balance. balance_cents is kept in step
+-- with wallet_transactions and can never go negative.
+CREATE TABLE IF NOT EXISTS wallets (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    balance_cents BIGINT NOT NULL DEFAULT 0 CHECK (balance_cents >= 0),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Wallet transactions: append-only ledger of every balance change. Credits
+-- and refunds add to the balance, debits and expiries take from it.
+CREATE TABLE IF NOT EXISTS wallet_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES wallets(user_id) ON DELETE CASCADE,
+    kind TEXT NOT NULL CHECK (kind IN ('CREDIT', 'DEBIT', 'REFUND', 'EXPIRY')),
+    amount_cents BIGINT NOT NULL, -- signed: positive adds to the balance
+    balance_after_cents BIGINT NOT NULL CHECK (balance_after_cents >= 0),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    refund_id UUID REFERENCES refunds(id) ON DELETE SET NULL, -- set when a card refund was paid out as credit
+    source_id UUID REFERENCES wallet_transactions(id), -- the credit an EXPIRY row lapses
+    reason TEXT,
+    expires_at TIMESTAMPTZ, -- credits only; NULL never expires
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL for system entries
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT wallet_transactions_sign_check CHECK (
+        (kind IN ('CREDIT', 'REFUND') AND amount_cents > 0) OR
+        (kind = 'DEBIT' AND amount_cents < 0) OR
+        -- an expiry lapses whatever is left of a credit, which may be nothing
+        (kind = 'EXPIRY' AND amount_cents <= 0 AND source_id IS NOT NULL)
+    )
+);
+
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_user ON wallet_transactions(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_order ON wallet_transactions(order_id) WHERE order_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_wallet_transactions_expiring ON wallet_transactions(expires_at) WHERE expires_at IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_wallet_transactions_expiry_source ON wallet_transactions(source_id) WHERE kind = 'EXPIRY';
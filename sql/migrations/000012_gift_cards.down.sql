@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS gift_card_redemptions;
+DROP TABLE IF EXISTS gift_cards;
+ALTER TABLE products DROP COLUMN IF EXISTS is_gift_card;
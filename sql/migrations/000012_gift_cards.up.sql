@@ -0,0 +1,39 @@
+-- Gift-card products issue a gift card per unit once the order is paid
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_gift_card BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Gift cards: a secret code holding a balance that pays for orders. Cards
+-- bought in an order are voided if that order is cancelled or refunded.
+CREATE TABLE IF NOT EXISTS gift_cards (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,
+    initial_cents BIGINT NOT NULL CHECK (initial_cents > 0),
+    balance_cents BIGINT NOT NULL CHECK (balance_cents >= 0 AND balance_cents <= initial_cents),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    status TEXT NOT NULL DEFAULT 'ACTIVE' CHECK (status IN ('ACTIVE', 'VOIDED')),
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL, -- the order it was bought in
+    order_item_id UUID REFERENCES order_items(id) ON DELETE SET NULL,
+    purchased_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    issued_by UUID REFERENCES users(id) ON DELETE SET NULL, -- set for cards issued by an admin
+    expires_at TIMESTAMPTZ, -- NULL never expires
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_cards_order ON gift_cards(order_id) WHERE order_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_gift_cards_purchased_by ON gift_cards(purchased_by, created_at) WHERE purchased_by IS NOT NULL;
+
+-- Gift card redemptions: what each card paid towards an order. Cancelling
+-- the order reverses them and puts the money back on the cards.
+CREATE TABLE IF NOT EXISTS gift_card_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    gift_card_id UUID NOT NULL REFERENCES gift_cards(id) ON DELETE CASCADE,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    amount_cents BIGINT NOT NULL CHECK (amount_cents > 0),
+    status TEXT NOT NULL DEFAULT 'APPLIED' CHECK (status IN ('APPLIED', 'REVERSED')),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    reversed_at TIMESTAMPTZ,
+
+    CONSTRAINT unique_gift_card_redemption UNIQUE (gift_card_id, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_card_redemptions_order ON gift_card_redemptions(order_id);
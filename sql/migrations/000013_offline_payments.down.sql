@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS idx_payments_expires_at;
+
+ALTER TABLE payments DROP COLUMN IF EXISTS confirmed_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS confirmed_by;
+ALTER TABLE payments DROP COLUMN IF EXISTS expires_at;
+ALTER TABLE payments DROP COLUMN IF EXISTS reference;
+
+UPDATE payments SET payment_method = 'BANK_TRANSFER' WHERE payment_method = 'CASH_ON_DELIVERY';
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_payment_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_payment_method_check
+    CHECK (payment_method IN ('CREDIT_CARD', 'PAYPAL', 'BANK_TRANSFER', 'STRIPE', 'APPLE_PAY', 'GOOGLE_PAY'));
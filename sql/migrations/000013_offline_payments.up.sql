@@ -0,0 +1,13 @@
+-- Offline payments: bank transfers and cash on delivery skip the gateway.
+-- The customer quotes the payment's reference when paying, and an admin or
+-- the courier confirms the money arrived.
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS payments_payment_method_check;
+ALTER TABLE payments ADD CONSTRAINT payments_payment_method_check
+    CHECK (payment_method IN ('CREDIT_CARD', 'PAYPAL', 'BANK_TRANSFER', 'STRIPE', 'APPLE_PAY', 'GOOGLE_PAY', 'CASH_ON_DELIVERY'));
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS reference TEXT UNIQUE;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ; -- offline payments not received by then are cancelled
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS confirmed_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS confirmed_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_payments_expires_at ON payments(expires_at) WHERE status = 'INITIATED';
@@ -0,0 +1,4 @@
+ALTER TABLE webhook_events DROP COLUMN IF EXISTS checkout_session_id;
+
+DROP INDEX IF EXISTS idx_payments_checkout_session;
+ALTER TABLE payments DROP COLUMN IF EXISTS checkout_session_id;
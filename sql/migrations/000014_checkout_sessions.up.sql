@@ -0,0 +1,7 @@
+-- Hosted checkout: a payment can be started as a provider Checkout Session
+-- instead of a client-side intent. The session creates its intent only once
+-- the customer pays, so provider_txn_id stays NULL until a webhook names it.
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS checkout_session_id TEXT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payments_checkout_session ON payments(provider, checkout_session_id) WHERE checkout_session_id IS NOT NULL;
+
+ALTER TABLE webhook_events ADD COLUMN IF NOT EXISTS checkout_session_id TEXT;
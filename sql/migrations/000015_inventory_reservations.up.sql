@@ -0,0 +1,19 @@
+-- Inventory reservations: the units each order line holds. A HELD
+-- reservation counts towards inventory.reserved; paying the order COMMITS
+-- it, taking the units out of stock, and cancelling or abandoning the order
+-- RELEASES it. Products without an inventory row are not stock-tracked and
+-- get no reservation.
+CREATE TABLE IF NOT EXISTS inventory_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL UNIQUE REFERENCES order_items(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    qty INT NOT NULL CHECK (qty > 0),
+    status TEXT NOT NULL DEFAULT 'HELD' CHECK (status IN ('HELD', 'COMMITTED', 'RELEASED')),
+    expires_at TIMESTAMPTZ, -- HELD only; NULL holds until the order is paid or cancelled
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_order ON inventory_reservations(order_id);
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_expiring ON inventory_reservations(expires_at) WHERE status = 'HELD';
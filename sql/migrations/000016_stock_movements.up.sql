@@ -0,0 +1,30 @@
+-- Stock movements: append-only ledger of every change to an inventory row.
+-- delta moves stock and reserved_delta moves reserved, so for every product
+-- inventory.stock = SUM(delta) and inventory.reserved = SUM(reserved_delta).
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    delta INT NOT NULL,
+    reserved_delta INT NOT NULL DEFAULT 0,
+    stock_after INT NOT NULL,
+    reserved_after INT NOT NULL,
+    reason TEXT NOT NULL CHECK (reason IN (
+        'RECEIVED', 'SOLD', 'RETURNED', 'ADJUSTMENT', 'DAMAGED', 'RESERVED', 'RELEASED', 'CANCELLED'
+    )),
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    return_id UUID REFERENCES returns(id) ON DELETE SET NULL,
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL, -- NULL for system movements
+    note TEXT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+
+    CONSTRAINT stock_movements_nonzero_check CHECK (delta <> 0 OR reserved_delta <> 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product ON stock_movements(product_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_order ON stock_movements(order_id) WHERE order_id IS NOT NULL;
+
+-- Open the ledger with what is on hand today
+INSERT INTO stock_movements (product_id, delta, reserved_delta, stock_after, reserved_after, reason, note)
+SELECT product_id, stock, reserved, stock, reserved, 'ADJUSTMENT', 'Opening balance'
+FROM inventory
+WHERE stock <> 0 OR reserved <> 0;
@@ -0,0 +1,15 @@
+ALTER TABLE shipments DROP COLUMN IF EXISTS warehouse_id;
+
+DROP INDEX IF EXISTS idx_stock_movements_warehouse;
+ALTER TABLE stock_movements DROP COLUMN IF EXISTS warehouse_id;
+
+-- Split allocations cannot be merged back; keep one reservation per line
+DELETE FROM inventory_reservations r
+USING inventory_reservations o
+WHERE r.order_item_id = o.order_item_id AND r.id > o.id;
+ALTER TABLE inventory_reservations DROP CONSTRAINT IF EXISTS unique_inventory_reservation;
+ALTER TABLE inventory_reservations DROP COLUMN IF EXISTS warehouse_id;
+ALTER TABLE inventory_reservations ADD CONSTRAINT inventory_reservations_order_item_id_key UNIQUE (order_item_id);
+
+DROP TABLE IF EXISTS warehouse_stock;
+DROP TABLE IF EXISTS warehouses;
@@ -0,0 +1,58 @@
+-- Warehouses: the locations stock is held and shipped from. Lower
+-- priority values are allocated first.
+CREATE TABLE IF NOT EXISTS warehouses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    country CHAR(2) NOT NULL,
+    priority INT NOT NULL DEFAULT 100,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Warehouse stock: a product's stock and reserved units at one warehouse.
+-- inventory keeps the product's totals across all of them.
+CREATE TABLE IF NOT EXISTS warehouse_stock (
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id) ON DELETE RESTRICT,
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    stock INT NOT NULL DEFAULT 0 CHECK (stock >= 0),
+    reserved INT NOT NULL DEFAULT 0 CHECK (reserved >= 0 AND reserved <= stock),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW(),
+
+    PRIMARY KEY (warehouse_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_warehouse_stock_product ON warehouse_stock(product_id);
+
+-- Everything stocked so far sits in one warehouse; set its country and
+-- add the others once they exist
+INSERT INTO warehouses (code, name, country, priority)
+VALUES ('MAIN', 'Main warehouse', 'US', 0)
+ON CONFLICT (code) DO NOTHING;
+
+INSERT INTO warehouse_stock (warehouse_id, product_id, stock, reserved)
+SELECT w.id, i.product_id, i.stock, i.reserved
+FROM inventory i
+CROSS JOIN warehouses w
+WHERE w.code = 'MAIN'
+ON CONFLICT DO NOTHING;
+
+-- Reservations become allocations: an order line may be split across
+-- warehouses, one reservation per warehouse
+ALTER TABLE inventory_reservations ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses(id) ON DELETE RESTRICT;
+UPDATE inventory_reservations SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'MAIN') WHERE warehouse_id IS NULL;
+ALTER TABLE inventory_reservations ALTER COLUMN warehouse_id SET NOT NULL;
+ALTER TABLE inventory_reservations DROP CONSTRAINT IF EXISTS inventory_reservations_order_item_id_key;
+ALTER TABLE inventory_reservations ADD CONSTRAINT unique_inventory_reservation UNIQUE (order_item_id, warehouse_id);
+
+-- Every movement happens at a warehouse
+ALTER TABLE stock_movements ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses(id) ON DELETE RESTRICT;
+UPDATE stock_movements SET warehouse_id = (SELECT id FROM warehouses WHERE code = 'MAIN') WHERE warehouse_id IS NULL;
+ALTER TABLE stock_movements ALTER COLUMN warehouse_id SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_warehouse ON stock_movements(warehouse_id, product_id);
+
+-- The warehouse a shipment leaves from
+ALTER TABLE shipments ADD COLUMN IF NOT EXISTS warehouse_id UUID REFERENCES warehouses(id) ON DELETE SET NULL;
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS low_stock_alerts;
+DROP TABLE IF EXISTS category_stock_thresholds;
+
+ALTER TABLE inventory
+    DROP COLUMN IF EXISTS reorder_qty,
+    DROP COLUMN IF EXISTS low_stock_threshold;
@@ -0,0 +1,30 @@
+-- Low-stock thresholds: a product is low on stock once its available stock
+-- (stock - reserved) is at or below its threshold. A product without its
+-- own threshold or reorder quantity uses its category's; products with
+-- neither are not watched.
+ALTER TABLE inventory
+    ADD COLUMN IF NOT EXISTS low_stock_threshold INT CHECK (low_stock_threshold >= 0),
+    ADD COLUMN IF NOT EXISTS reorder_qty INT CHECK (reorder_qty > 0);
+
+CREATE TABLE IF NOT EXISTS category_stock_thresholds (
+    category_id UUID PRIMARY KEY REFERENCES categories(id) ON DELETE CASCADE,
+    low_stock_threshold INT NOT NULL CHECK (low_stock_threshold >= 0),
+    reorder_qty INT CHECK (reorder_qty > 0),
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    updated_at TIMESTAMPTZ DEFAULT NOW()
+);
+
+-- Low-stock alerts: one row each time a product's available stock drops
+-- to its threshold, written in the same transaction as the movement that
+-- crossed it. delivered_at is set once the admins were notified.
+CREATE TABLE IF NOT EXISTS low_stock_alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    available INT NOT NULL,
+    low_stock_threshold INT NOT NULL,
+    reorder_qty INT,
+    created_at TIMESTAMPTZ DEFAULT NOW(),
+    delivered_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_low_stock_alerts_undelivered ON low_stock_alerts(created_at) WHERE delivered_at IS NULL;
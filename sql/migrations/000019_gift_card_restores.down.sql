@@ -0,0 +1,3 @@
+ALTER TABLE gift_card_redemptions
+    DROP CONSTRAINT IF EXISTS gift_card_redemptions_restored_cents_check,
+    DROP COLUMN IF EXISTS restored_cents;
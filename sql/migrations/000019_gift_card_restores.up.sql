@@ -0,0 +1,6 @@
+-- A return can put part of what an order took from a gift card back on it
+-- before the order is ever reversed; restored_cents keeps track of it so a
+-- later reversal gives back only the rest.
+ALTER TABLE gift_card_redemptions
+    ADD COLUMN IF NOT EXISTS restored_cents BIGINT NOT NULL DEFAULT 0,
+    ADD CONSTRAINT gift_card_redemptions_restored_cents_check CHECK (restored_cents >= 0 AND restored_cents <= amount_cents);
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_inventory_reservations_oversold;
+
+UPDATE inventory_reservations SET status = 'RELEASED' WHERE status = 'OVERSOLD';
+ALTER TABLE inventory_reservations DROP CONSTRAINT IF EXISTS inventory_reservations_status_check;
+ALTER TABLE inventory_reservations ADD CONSTRAINT inventory_reservations_status_check
+    CHECK (status IN ('HELD', 'COMMITTED', 'RELEASED'));
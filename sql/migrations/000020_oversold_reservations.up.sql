@@ -0,0 +1,8 @@
+-- An order paid after its reservation was released, whose units have been
+-- sold in the meantime, is OVERSOLD: it took nothing from stock and waits
+-- for an admin to restock or refund it.
+ALTER TABLE inventory_reservations DROP CONSTRAINT IF EXISTS inventory_reservations_status_check;
+ALTER TABLE inventory_reservations ADD CONSTRAINT inventory_reservations_status_check
+    CHECK (status IN ('HELD', 'COMMITTED', 'RELEASED', 'OVERSOLD'));
+
+CREATE INDEX IF NOT EXISTS idx_inventory_reservations_oversold ON inventory_reservations(updated_at) WHERE status = 'OVERSOLD';
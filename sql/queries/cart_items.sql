@@ -47,6 +47,7 @@ SELECT ci.*
 FROM cart_items ci
 JOIN carts c ON ci.cart_id = c.id
 WHERE c.user_id = $1
+  AND (c.expires_at IS NULL OR c.expires_at > NOW())
 ORDER BY ci.created_at ASC;
 
 -- name: ListCartItems :many
@@ -70,3 +71,13 @@ WHERE id = $1;
 -- name: DeleteCartItemsByCart :exec
 DELETE FROM cart_items
 WHERE cart_id = $1;
+
+-- name: DeleteOrderedCartItems :exec
+-- Removes the cart items a checkout ordered. Items added, or whose quantity
+-- changed, after the checkout read the cart are left in it.
+DELETE FROM cart_items ci
+USING (
+    SELECT unnest(sqlc.arg(ids)::uuid[]) AS id,
+           unnest(sqlc.arg(quantities)::int[]) AS quantity
+) AS o(id, quantity)
+WHERE ci.id = o.id AND ci.quantity = o.quantity;
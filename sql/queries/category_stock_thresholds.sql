@@ -0,0 +1,22 @@
+-- name: UpsertCategoryStockThreshold :one
+INSERT INTO category_stock_thresholds (
+    category_id,
+    low_stock_threshold,
+    reorder_qty
+) VALUES (
+    $1, $2, $3
+)
+ON CONFLICT (category_id) DO UPDATE
+SET
+    low_stock_threshold = EXCLUDED.low_stock_threshold,
+    reorder_qty = EXCLUDED.reorder_qty,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: GetCategoryStockThreshold :one
+SELECT * FROM category_stock_thresholds
+WHERE category_id = $1 LIMIT 1;
+
+-- name: DeleteCategoryStockThreshold :execrows
+DELETE FROM category_stock_thresholds
+WHERE category_id = $1;
@@ -29,6 +29,31 @@ SET used_count = used_count + 1,
 WHERE code = $1
 RETURNING *;
 
+-- name: RedeemCoupon :one
+-- Counts one use of the coupon, only while it is still redeemable, so
+-- concurrent orders cannot push it past max_uses. A max_uses of NULL or 0
+-- means unlimited, as in Coupon.Redeemable.
+UPDATE coupons
+SET used_count = COALESCE(used_count, 0) + 1,
+    updated_at = NOW()
+WHERE code = $1
+  AND is_active IS TRUE
+  AND is_deleted IS NOT TRUE
+  AND valid_from <= NOW()
+  AND valid_until > NOW()
+  AND (max_uses IS NULL OR max_uses <= 0 OR COALESCE(used_count, 0) < max_uses)
+RETURNING *;
+
+-- name: ReleaseCoupon :one
+-- Gives back one use of the coupon, e.g. when the order that redeemed it is
+-- cancelled. Returns no row when no use is counted.
+UPDATE coupons
+SET used_count = used_count - 1,
+    updated_at = NOW()
+WHERE code = $1
+  AND used_count > 0
+RETURNING *;
+
 -- name: UpdateCoupon :one
 UPDATE coupons
 SET code = COALESCE($2, code),
@@ -0,0 +1,76 @@
+-- name: CreateGiftCard :one
+INSERT INTO gift_cards (
+    code, initial_cents, balance_cents, currency, order_id, order_item_id, purchased_by, issued_by, expires_at
+)
+VALUES (
+    $1, $2, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetGiftCardByCode :one
+SELECT * FROM gift_cards
+WHERE code = $1;
+
+-- name: LockGiftCard :one
+SELECT * FROM gift_cards
+WHERE id = $1
+FOR UPDATE;
+
+-- name: UpdateGiftCardBalance :one
+UPDATE gift_cards
+SET balance_cents = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListGiftCardsByPurchaser :many
+SELECT * FROM gift_cards
+WHERE purchased_by = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountGiftCardsByPurchaser :one
+SELECT COUNT(*) FROM gift_cards
+WHERE purchased_by = $1;
+
+-- name: LockOrderGiftCards :many
+-- The gift cards bought in an order, locked until the transaction ends.
+SELECT * FROM gift_cards
+WHERE order_id = $1
+ORDER BY id
+FOR UPDATE;
+
+-- name: VoidOrderGiftCards :many
+-- Voids the gift cards bought in an order.
+UPDATE gift_cards
+SET status = 'VOIDED',
+    updated_at = NOW()
+WHERE order_id = $1 AND status = 'ACTIVE'
+RETURNING *;
+
+-- name: CreateGiftCardRedemption :one
+INSERT INTO gift_card_redemptions (gift_card_id, order_id, amount_cents)
+VALUES ($1, $2, $3)
+RETURNING *;
+
+-- name: ListAppliedGiftCardRedemptions :many
+-- Redemptions an order has not reversed, in the order their cards are
+-- locked in.
+SELECT * FROM gift_card_redemptions
+WHERE order_id = $1 AND status = 'APPLIED'
+ORDER BY gift_card_id;
+
+-- name: RestoreGiftCardRedemption :one
+-- Puts part of an applied redemption back on its card; the reversal gives
+-- back only what is left.
+UPDATE gift_card_redemptions
+SET restored_cents = restored_cents + $2
+WHERE id = $1 AND status = 'APPLIED' AND restored_cents + $2 <= amount_cents
+RETURNING *;
+
+-- name: ReverseGiftCardRedemption :one
+UPDATE gift_card_redemptions
+SET status = 'REVERSED',
+    reversed_at = NOW()
+WHERE id = $1 AND status = 'APPLIED'
+RETURNING *;
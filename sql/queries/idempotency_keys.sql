@@ -0,0 +1,38 @@
+-- name: ClaimIdempotencyKey :one
+-- Claims a key for an in-flight request. A key whose request never
+-- completed and whose lock has gone stale is taken over; otherwise no row
+-- is returned when the key is already in use.
+INSERT INTO idempotency_keys (
+    user_id,
+    idempotency_key,
+    method,
+    path,
+    request_hash
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id, idempotency_key) DO UPDATE
+SET
+    method = EXCLUDED.method,
+    path = EXCLUDED.path,
+    request_hash = EXCLUDED.request_hash,
+    locked_at = NOW()
+WHERE idempotency_keys.completed_at IS NULL
+  AND idempotency_keys.locked_at < NOW() - INTERVAL '5 minutes'
+RETURNING *;
+
+-- name: GetIdempotencyKey :one
+SELECT * FROM idempotency_keys
+WHERE user_id = $1 AND idempotency_key = $2 LIMIT 1;
+
+-- name: CompleteIdempotencyKey :exec
+UPDATE idempotency_keys
+SET
+    response_status = $3,
+    response_body = $4,
+    completed_at = NOW()
+WHERE user_id = $1 AND idempotency_key = $2;
+
+-- name: DeleteIdempotencyKey :exec
+DELETE FROM idempotency_keys
+WHERE user_id = $1 AND idempotency_key = $2;
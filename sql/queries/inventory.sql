@@ -11,14 +11,81 @@ INSERT INTO inventory (
 SELECT * FROM inventory
 WHERE product_id = $1 LIMIT 1;
 
--- name: UpdateInventoryStock :one
+-- name: CountInventory :one
+SELECT COUNT(*) FROM inventory;
+
+-- name: MoveInventory :one
+-- Moves the product's totals by a warehouse movement's deltas; the table's
+-- CHECK constraints reject moves that would leave stock below zero or below
+-- what is reserved.
 UPDATE inventory
-SET 
-    stock = $2,
+SET
+    stock = stock + sqlc.arg(delta)::int,
+    reserved = reserved + sqlc.arg(reserved_delta)::int,
     updated_at = NOW()
-WHERE product_id = $1
+WHERE product_id = sqlc.arg(product_id)
 RETURNING *;
 
 -- name: DeleteInventory :exec
 DELETE FROM inventory
-WHERE product_id = $1;
\ No newline at end of file
+WHERE product_id = $1;
+
+-- name: SetInventoryThresholds :one
+-- NULL clears the product's own setting, so its category's applies.
+UPDATE inventory
+SET
+    low_stock_threshold = sqlc.narg(low_stock_threshold),
+    reorder_qty = sqlc.narg(reorder_qty),
+    updated_at = NOW()
+WHERE product_id = sqlc.arg(product_id)
+RETURNING *;
+
+-- name: GetEffectiveStockThreshold :one
+-- The low-stock threshold and reorder quantity that apply to a product:
+-- its own, else its category's.
+SELECT
+    COALESCE(i.low_stock_threshold, c.low_stock_threshold) AS low_stock_threshold,
+    COALESCE(i.reorder_qty, c.reorder_qty) AS reorder_qty
+FROM inventory i
+JOIN products p ON p.id = i.product_id
+LEFT JOIN category_stock_thresholds c ON c.category_id = p.category_id
+WHERE i.product_id = $1;
+
+-- name: ListLowStockInventory :many
+-- Products whose available stock is at or below the threshold that applies
+-- to them, with the units sold by orders paid since sold_since, lowest
+-- available first.
+WITH sales AS (
+    SELECT oi.product_id, SUM(oi.qty)::bigint AS units_sold
+    FROM order_items oi
+    JOIN orders o ON o.id = oi.order_id
+    WHERE o.paid_at >= sqlc.arg(sold_since)
+      AND o.status NOT IN ('PENDING', 'CANCELLED')
+    GROUP BY oi.product_id
+)
+SELECT
+    i.product_id,
+    p.sku,
+    p.name,
+    i.stock,
+    i.reserved,
+    COALESCE(i.low_stock_threshold, c.low_stock_threshold) AS low_stock_threshold,
+    COALESCE(i.reorder_qty, c.reorder_qty) AS reorder_qty,
+    COALESCE(s.units_sold, 0)::bigint AS units_sold
+FROM inventory i
+JOIN products p ON p.id = i.product_id
+LEFT JOIN category_stock_thresholds c ON c.category_id = p.category_id
+LEFT JOIN sales s ON s.product_id = i.product_id
+WHERE i.stock - i.reserved <= COALESCE(i.low_stock_threshold, c.low_stock_threshold)
+ORDER BY i.stock - i.reserved, p.sku;
+
+-- name: ListProductsBySku :many
+-- The products with the given SKUs; tracked tells whether they have an
+-- inventory row.
+SELECT
+    p.id,
+    p.sku,
+    (i.product_id IS NOT NULL)::bool AS tracked
+FROM products p
+LEFT JOIN inventory i ON i.product_id = p.id
+WHERE p.sku = ANY(sqlc.arg(skus)::text[]);
@@ -0,0 +1,51 @@
+-- name: CreateInventoryReservation :one
+INSERT INTO inventory_reservations (
+    order_id,
+    order_item_id,
+    product_id,
+    warehouse_id,
+    qty,
+    expires_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: LockOrderInventoryReservations :many
+-- Locks the order's reservations until the transaction ends, ordered by
+-- product and warehouse so stock rows are always locked in the same order.
+SELECT * FROM inventory_reservations
+WHERE order_id = $1
+ORDER BY product_id, warehouse_id, id
+FOR UPDATE;
+
+-- name: UpdateInventoryReservationStatus :one
+UPDATE inventory_reservations
+SET status = $2, expires_at = NULL, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: ListOversoldReservations :many
+SELECT * FROM inventory_reservations
+WHERE status = 'OVERSOLD'
+ORDER BY updated_at, id;
+
+-- name: ListOrdersWithExpiredReservations :many
+SELECT DISTINCT order_id FROM inventory_reservations
+WHERE status = 'HELD' AND expires_at < sqlc.arg(expired_before)
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListOrderInventoryAllocations :many
+-- The warehouses an order's lines are served from; released and oversold
+-- reservations ship from nowhere.
+SELECT
+    r.id,
+    r.order_item_id,
+    r.product_id,
+    r.warehouse_id,
+    w.code AS warehouse_code,
+    r.qty,
+    r.status
+FROM inventory_reservations r
+JOIN warehouses w ON w.id = r.warehouse_id
+WHERE r.order_id = $1 AND r.status IN ('HELD', 'COMMITTED')
+ORDER BY w.priority, w.code, r.order_item_id;
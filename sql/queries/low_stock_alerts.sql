@@ -0,0 +1,32 @@
+-- name: CreateLowStockAlert :one
+INSERT INTO low_stock_alerts (
+    product_id,
+    available,
+    low_stock_threshold,
+    reorder_qty
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: ListUndeliveredLowStockAlerts :many
+-- Oldest first; SKIP LOCKED lets concurrent deliveries split the batch.
+SELECT
+    a.id,
+    a.product_id,
+    p.sku,
+    p.name,
+    a.available,
+    a.low_stock_threshold,
+    a.reorder_qty,
+    a.created_at
+FROM low_stock_alerts a
+JOIN products p ON p.id = a.product_id
+WHERE a.delivered_at IS NULL
+ORDER BY a.created_at, a.id
+LIMIT sqlc.arg(row_limit)
+FOR UPDATE OF a SKIP LOCKED;
+
+-- name: MarkLowStockAlertDelivered :exec
+UPDATE low_stock_alerts
+SET delivered_at = NOW()
+WHERE id = $1;
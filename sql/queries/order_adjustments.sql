@@ -0,0 +1,13 @@
+-- name: CreateOrderAdjustment :one
+INSERT INTO order_adjustments (
+    order_id, order_item_id, kind, code, description, amount_cents, position
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: ListOrderAdjustments :many
+SELECT * FROM order_adjustments
+WHERE order_id = $1
+ORDER BY position, id;
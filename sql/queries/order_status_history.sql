@@ -0,0 +1,15 @@
+-- name: CreateOrderStatusHistory :one
+INSERT INTO order_status_history (
+    order_id,
+    from_status,
+    to_status,
+    changed_by,
+    reason
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: ListOrderStatusHistory :many
+SELECT * FROM order_status_history
+WHERE order_id = $1
+ORDER BY created_at ASC;
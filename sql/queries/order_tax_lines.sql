@@ -0,0 +1,13 @@
+-- name: CreateOrderTaxLine :one
+INSERT INTO order_tax_lines (
+    order_id, order_item_id, tax_rate_id, jurisdiction, tax_category, rate_bps, is_inclusive, taxable_cents, tax_cents
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: ListOrderTaxLines :many
+SELECT * FROM order_tax_lines
+WHERE order_id = $1
+ORDER BY created_at, id;
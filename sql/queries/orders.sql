@@ -10,9 +10,11 @@ INSERT INTO orders (
     final_cents,
     -- currency,
     shipping_info,
-    notes
+    notes,
+    shipping_method_id,
+    shipping_method_name
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 )
  RETURNING *;
 
@@ -55,6 +57,10 @@ ORDER BY o.created_at DESC
 LIMIT $2 OFFSET $3;
 
 -- name: GetOrdersWithItems :many
+-- Admin order search. NULL filters are ignored; sort_by is one of
+-- created_at, paid_at, total_cents or order_number and sort_order is asc or
+-- desc, with created_at DESC as the tie-breaker. Unpaid orders sort last by
+-- paid_at either way.
 SELECT 
     o.*,
     COALESCE(
@@ -62,26 +68,90 @@ SELECT
     ) AS items
 FROM orders o
 LEFT JOIN order_items oi ON o.id = oi.order_id
+LEFT JOIN users u ON u.id = o.user_id
+WHERE (sqlc.narg(statuses)::text[] IS NULL OR o.status = ANY(sqlc.narg(statuses)::text[]))
+  AND (sqlc.narg(user_id)::uuid IS NULL OR o.user_id = sqlc.narg(user_id)::uuid)
+  AND (sqlc.narg(email)::text IS NULL OR LOWER(u.email) = LOWER(sqlc.narg(email)::text))
+  AND (sqlc.narg(order_number_prefix)::text IS NULL OR starts_with(o.order_number, sqlc.narg(order_number_prefix)::text))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR o.created_at >= sqlc.narg(created_from)::timestamptz)
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR o.created_at < sqlc.narg(created_to)::timestamptz)
+  AND (sqlc.narg(paid_from)::timestamptz IS NULL OR o.paid_at >= sqlc.narg(paid_from)::timestamptz)
+  AND (sqlc.narg(paid_to)::timestamptz IS NULL OR o.paid_at < sqlc.narg(paid_to)::timestamptz)
+  AND (sqlc.narg(min_total_cents)::bigint IS NULL OR o.total_cents >= sqlc.narg(min_total_cents)::bigint)
+  AND (sqlc.narg(max_total_cents)::bigint IS NULL OR o.total_cents <= sqlc.narg(max_total_cents)::bigint)
 GROUP BY o.id
-ORDER BY o.created_at DESC
-LIMIT $1 OFFSET $2;
+ORDER BY
+    CASE WHEN sqlc.arg(sort_by)::text = 'created_at' AND sqlc.arg(sort_order)::text = 'asc' THEN o.created_at END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'paid_at' AND sqlc.arg(sort_order)::text = 'asc' THEN o.paid_at END ASC NULLS LAST,
+    CASE WHEN sqlc.arg(sort_by)::text = 'paid_at' AND sqlc.arg(sort_order)::text = 'desc' THEN o.paid_at END DESC NULLS LAST,
+    CASE WHEN sqlc.arg(sort_by)::text = 'total_cents' AND sqlc.arg(sort_order)::text = 'asc' THEN o.total_cents END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'total_cents' AND sqlc.arg(sort_order)::text = 'desc' THEN o.total_cents END DESC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'order_number' AND sqlc.arg(sort_order)::text = 'asc' THEN o.order_number END ASC,
+    CASE WHEN sqlc.arg(sort_by)::text = 'order_number' AND sqlc.arg(sort_order)::text = 'desc' THEN o.order_number END DESC,
+    o.created_at DESC
+LIMIT sqlc.arg(row_limit)::int OFFSET sqlc.arg(row_offset)::int;
 
 
 -- name: CountOrders :one
-SELECT COUNT(*) AS total_count FROM orders;
+-- Counts the orders matched by GetOrdersWithItems under the same filters.
+SELECT COUNT(*) AS total_count
+FROM orders o
+LEFT JOIN users u ON u.id = o.user_id
+WHERE (sqlc.narg(statuses)::text[] IS NULL OR o.status = ANY(sqlc.narg(statuses)::text[]))
+  AND (sqlc.narg(user_id)::uuid IS NULL OR o.user_id = sqlc.narg(user_id)::uuid)
+  AND (sqlc.narg(email)::text IS NULL OR LOWER(u.email) = LOWER(sqlc.narg(email)::text))
+  AND (sqlc.narg(order_number_prefix)::text IS NULL OR starts_with(o.order_number, sqlc.narg(order_number_prefix)::text))
+  AND (sqlc.narg(created_from)::timestamptz IS NULL OR o.created_at >= sqlc.narg(created_from)::timestamptz)
+  AND (sqlc.narg(created_to)::timestamptz IS NULL OR o.created_at < sqlc.narg(created_to)::timestamptz)
+  AND (sqlc.narg(paid_from)::timestamptz IS NULL OR o.paid_at >= sqlc.narg(paid_from)::timestamptz)
+  AND (sqlc.narg(paid_to)::timestamptz IS NULL OR o.paid_at < sqlc.narg(paid_to)::timestamptz)
+  AND (sqlc.narg(min_total_cents)::bigint IS NULL OR o.total_cents >= sqlc.narg(min_total_cents)::bigint)
+  AND (sqlc.narg(max_total_cents)::bigint IS NULL OR o.total_cents <= sqlc.narg(max_total_cents)::bigint);
 
 -- name: CountOrdersByUser :one
 SELECT COUNT(*) AS total_count FROM orders
 WHERE user_id = $1;
 
 -- name: UpdateOrderStatus :one
+-- Moves an order from from_status to status and stamps the matching
+-- timestamp column. No row is returned if the status changed underneath us.
 UPDATE orders
 SET 
-    status = $2,
+    status = sqlc.arg(status),
+    paid_at = CASE WHEN sqlc.arg(status) = 'PAID' THEN NOW() ELSE paid_at END,
+    shipped_at = CASE WHEN sqlc.arg(status) = 'SHIPPED' THEN NOW() ELSE shipped_at END,
+    cancelled_at = CASE WHEN sqlc.arg(status) = 'CANCELLED' THEN NOW() ELSE cancelled_at END,
+    refunded_at = CASE WHEN sqlc.arg(status) = 'REFUNDED' THEN NOW() ELSE refunded_at END,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND status = sqlc.arg(from_status)
+RETURNING *;
+
+-- name: AddOrderRefundedCents :one
+-- Adds amount_cents to the order's refunded total. No row is returned if
+-- that would refund more than was charged.
+UPDATE orders
+SET
+    refunded_cents = refunded_cents + sqlc.arg(amount_cents)::bigint,
     updated_at = NOW()
-WHERE id = $1
+WHERE id = sqlc.arg(id)
+  AND refunded_cents + sqlc.arg(amount_cents)::bigint <= final_cents
 RETURNING *;
 
+-- name: SetOrderFinalCents :exec
+-- Lowers what is charged for an order once part of it is paid otherwise,
+-- e.g. from the customer's wallet.
+UPDATE orders
+SET final_cents = $2, updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetOrderDeliveredAt :exec
+-- Stamps when the order's latest shipment reached the customer, which
+-- starts its return window.
+UPDATE orders
+SET delivered_at = $2, updated_at = NOW()
+WHERE id = $1;
+
 -- name: DeleteOrder :exec
 DELETE FROM orders
 WHERE id = $1;
\ No newline at end of file
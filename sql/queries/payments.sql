@@ -1,3 +1,13 @@
+-- name: ApplyPaymentEvent :one
+UPDATE payments
+SET status = $2,
+    failure_reason = $3,
+    last_event_at = $4,
+    authorized_at = CASE WHEN $2 = 'AUTHORIZED' THEN COALESCE(authorized_at, $4) ELSE authorized_at END,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
 -- name: CreatePayment :one
 INSERT INTO payments (
     order_id,
@@ -7,17 +17,122 @@ INSERT INTO payments (
     currency,
     payment_method,
     status,
-    details
+    details,
+    capture_method,
+    reference,
+    expires_at,
+    checkout_session_id
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
 ) RETURNING *;
 
+-- name: AttachPaymentIntent :exec
+-- Records the intent a Checkout Session created once a webhook names it.
+UPDATE payments
+SET provider_txn_id = $2, updated_at = NOW()
+WHERE id = $1 AND provider_txn_id IS NULL;
+
+-- name: CapturePayment :one
+-- Settles an authorized payment for amount_cents, keeping the amount that was
+-- held. No row is returned unless the payment is still AUTHORIZED.
+UPDATE payments
+SET status = 'SUCCESS',
+    authorized_cents = amount_cents,
+    amount_cents = sqlc.arg(amount_cents)::bigint,
+    captured_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND status = 'AUTHORIZED'
+RETURNING *;
+
+-- name: ConfirmOfflinePayment :one
+-- Records that an offline payment was received. No row is returned unless
+-- the payment is still waiting to be paid.
+UPDATE payments
+SET status = 'SUCCESS',
+    confirmed_by = sqlc.narg(confirmed_by),
+    confirmed_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id) AND status = 'INITIATED'
+RETURNING *;
+
+-- name: ExpireOfflinePayment :one
+-- Cancels an offline payment that was never received. No row is returned
+-- unless the payment is still waiting to be paid.
+UPDATE payments
+SET status = 'CANCELLED',
+    failure_reason = $2,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'INITIATED'
+RETURNING *;
+
+-- name: CountPaymentsByOrderID :one
+SELECT COUNT(*) FROM payments
+WHERE order_id = $1;
+
+-- name: GetPayment :one
+SELECT * FROM payments
+WHERE id = $1;
+
 -- name: GetPaymentByOrderID :one
+-- An order can have several payment attempts; this is the latest one.
+SELECT * FROM payments
+WHERE order_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT 1;
+
+-- name: GetPaymentByProviderTxnID :one
+SELECT * FROM payments
+WHERE provider = $1 AND provider_txn_id = $2
+LIMIT 1;
+
+-- name: GetPaymentByCheckoutSessionID :one
+SELECT * FROM payments
+WHERE provider = $1 AND checkout_session_id = $2
+LIMIT 1;
+
+-- name: LockPayment :one
+SELECT * FROM payments
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListStaleAuthorizations :many
+-- Authorized payments held since before authorized_before, oldest first.
+SELECT * FROM payments
+WHERE status = 'AUTHORIZED' AND authorized_at < sqlc.arg(authorized_before)
+ORDER BY authorized_at
+LIMIT sqlc.arg(row_limit)::int;
+
+-- name: ListExpiredOfflinePayments :many
+-- Offline payments still waiting to be paid past their expiry, oldest first.
+SELECT * FROM payments
+WHERE status = 'INITIATED' AND expires_at < sqlc.arg(expired_before)
+ORDER BY expires_at
+LIMIT sqlc.arg(row_limit)::int;
+
+-- name: ListPaymentsToReconcile :many
+-- Payments still waiting on the provider whose last change falls in
+-- [updated_after, updated_before), oldest first: those not yet settled and
+-- captures no later event has confirmed. Pass the last row's updated_at and
+-- id as after_updated_at and after_id to get the next page.
+SELECT * FROM payments
+WHERE provider = sqlc.arg(provider)
+  AND (status IN ('INITIATED', 'AUTHORIZED')
+       OR (status = 'SUCCESS' AND captured_at IS NOT NULL AND (last_event_at IS NULL OR last_event_at < captured_at)))
+  AND updated_at >= sqlc.arg(updated_after)
+  AND updated_at < sqlc.arg(updated_before)
+  AND (updated_at, id) > (sqlc.arg(after_updated_at)::timestamptz, sqlc.arg(after_id)::uuid)
+ORDER BY updated_at, id
+LIMIT sqlc.arg(row_limit)::int;
+
+-- name: ListPaymentsByOrderID :many
+-- Payment attempts for an order, newest first.
 SELECT * FROM payments
-WHERE order_id = $1 LIMIT 1;
+WHERE order_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
 
 -- name: UpdatePaymentStatus :one
 UPDATE payments
-SET status = $2, created_at = NOW()
+SET status = $2, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
\ No newline at end of file
@@ -10,9 +10,15 @@ INSERT INTO products (
     main_image_url,
     images,
     discount_percent,
-    discount_valid_until
+    discount_valid_until,
+    tax_category,
+    weight_grams,
+    length_mm,
+    width_mm,
+    height_mm,
+    is_gift_card
 ) VALUES (
-    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17
 ) RETURNING *;
 
 -- name: GetProductByID :one
@@ -38,6 +44,22 @@ SET price_cents = $2, updated_at = NOW()
 WHERE id = $1
 RETURNING *;
 
+-- name: UpdateProductTaxCategory :one
+UPDATE products
+SET tax_category = $2, updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: UpdateProductShippingDetails :one
+UPDATE products
+SET weight_grams = $2,
+    length_mm = $3,
+    width_mm = $4,
+    height_mm = $5,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
 -- name: UpdateProduct :one
 UPDATE products
 SET
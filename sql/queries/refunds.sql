@@ -0,0 +1,43 @@
+-- name: CreateRefund :one
+INSERT INTO refunds (
+    payment_id, order_id, provider, provider_refund_id, amount_cents, currency, reason, status, created_by
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+)
+RETURNING *;
+
+-- name: GetRefund :one
+SELECT * FROM refunds
+WHERE id = $1;
+
+-- name: GetRefundByProviderRefundID :one
+SELECT * FROM refunds
+WHERE provider = $1 AND provider_refund_id = $2;
+
+-- name: ListRefundsByPaymentID :many
+SELECT * FROM refunds
+WHERE payment_id = $1
+ORDER BY created_at, id;
+
+-- name: GetPaymentRefundTotals :one
+-- committed_cents counts refunds still in flight as well, so it bounds what
+-- is left to refund. wallet_cents is the part paid out as store credit,
+-- which the provider knows nothing about.
+SELECT
+    COALESCE(SUM(amount_cents) FILTER (WHERE status = 'SUCCEEDED'), 0)::bigint AS succeeded_cents,
+    COALESCE(SUM(amount_cents) FILTER (WHERE status IN ('PENDING', 'SUCCEEDED')), 0)::bigint AS committed_cents,
+    COALESCE(SUM(amount_cents) FILTER (WHERE status = 'SUCCEEDED' AND provider = 'WALLET'), 0)::bigint AS wallet_cents
+FROM refunds
+WHERE payment_id = $1;
+
+-- name: UpdateRefundResult :one
+-- Records the provider's answer to a refund request. Returns no row once the
+-- refund has been settled, so the answer and its webhook apply it only once.
+UPDATE refunds
+SET provider_refund_id = $2,
+    status = $3,
+    failure_reason = $4,
+    updated_at = NOW()
+WHERE id = $1 AND status = 'PENDING'
+RETURNING *;
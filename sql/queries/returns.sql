@@ -0,0 +1,83 @@
+-- name: CreateReturn :one
+INSERT INTO returns (
+    order_id,
+    user_id,
+    reason,
+    refund_cents
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: CreateReturnItem :one
+INSERT INTO return_items (
+    return_id,
+    order_item_id,
+    qty,
+    refund_cents
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: GetReturn :one
+SELECT * FROM returns
+WHERE id = $1 LIMIT 1;
+
+-- name: ListReturnsByOrder :many
+SELECT * FROM returns
+WHERE order_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListReturnsByUser :many
+SELECT * FROM returns
+WHERE user_id = $1
+ORDER BY created_at DESC;
+
+-- name: ListReturnItems :many
+SELECT * FROM return_items
+WHERE return_id = $1
+ORDER BY created_at ASC;
+
+-- name: LockReturnOrder :one
+-- Locks the order until the transaction ends, so returns on the same order
+-- are claimed and refunded one at a time.
+SELECT id FROM orders
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListReturnedQtyByOrder :many
+-- Quantity of each order item already claimed by returns that have not
+-- been rejected.
+SELECT
+    ri.order_item_id,
+    SUM(ri.qty)::int AS returned_qty
+FROM return_items ri
+JOIN returns r ON r.id = ri.return_id
+WHERE r.order_id = $1
+  AND r.status <> 'REJECTED'
+GROUP BY ri.order_item_id;
+
+-- name: ReviewReturn :one
+-- Approves or rejects a return that is still awaiting review.
+UPDATE returns
+SET
+    status = sqlc.arg(status),
+    resolution_note = sqlc.arg(resolution_note),
+    reviewed_by = sqlc.arg(reviewed_by),
+    reviewed_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND status = 'REQUESTED'
+RETURNING *;
+
+-- name: CompleteReturn :one
+-- Marks an approved return as received and refunded.
+UPDATE returns
+SET
+    status = 'REFUNDED',
+    shipment_id = sqlc.arg(shipment_id),
+    refund_cents = sqlc.arg(refund_cents),
+    received_at = NOW(),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND status = 'APPROVED'
+RETURNING *;
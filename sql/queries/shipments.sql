@@ -1,9 +1,9 @@
 -- name: CreateShipment :one
 INSERT INTO shipments (
-    order_id, carrier, tracking_number, status, shipped_at, delivered_at
+    order_id, carrier, tracking_number, status, shipped_at, delivered_at, warehouse_id
 )
 VALUES (
-    $1, $2, $3, $4, $5, $6
+    $1, $2, $3, $4, $5, $6, $7
 )
 RETURNING *;
 
@@ -0,0 +1,39 @@
+-- name: CreateShippingMethod :one
+INSERT INTO shipping_methods (
+    zone_id, name, rate_type, flat_cents, tiers, free_over_cents, is_active
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+)
+RETURNING *;
+
+-- name: GetShippingMethod :one
+SELECT * FROM shipping_methods
+WHERE id = $1 LIMIT 1;
+
+-- name: ListShippingMethodsByZone :many
+SELECT * FROM shipping_methods
+WHERE zone_id = $1
+ORDER BY name;
+
+-- name: ListActiveShippingMethodsByZone :many
+SELECT * FROM shipping_methods
+WHERE zone_id = $1
+  AND is_active = TRUE
+ORDER BY name;
+
+-- name: UpdateShippingMethod :one
+UPDATE shipping_methods
+SET name = $2,
+    rate_type = $3,
+    flat_cents = $4,
+    tiers = $5,
+    free_over_cents = $6,
+    is_active = $7,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteShippingMethod :exec
+DELETE FROM shipping_methods
+WHERE id = $1;
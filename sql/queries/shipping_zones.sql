@@ -0,0 +1,57 @@
+-- name: CreateShippingZone :one
+INSERT INTO shipping_zones (
+    name, is_active
+)
+VALUES (
+    $1, $2
+)
+RETURNING *;
+
+-- name: GetShippingZone :one
+SELECT * FROM shipping_zones
+WHERE id = $1 LIMIT 1;
+
+-- name: ListShippingZones :many
+SELECT * FROM shipping_zones
+ORDER BY name;
+
+-- name: UpdateShippingZone :one
+UPDATE shipping_zones
+SET name = $2,
+    is_active = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteShippingZone :exec
+DELETE FROM shipping_zones
+WHERE id = $1;
+
+-- name: FindShippingZoneForAddress :one
+-- The active zone covering an address; a zone listing the state wins over
+-- one covering the whole country.
+SELECT z.* FROM shipping_zones z
+JOIN shipping_zone_regions r ON r.zone_id = z.id
+WHERE z.is_active = TRUE
+  AND r.country = sqlc.arg(country)
+  AND (r.state = '' OR r.state = sqlc.arg(state))
+ORDER BY LENGTH(r.state) DESC
+LIMIT 1;
+
+-- name: CreateShippingZoneRegion :one
+INSERT INTO shipping_zone_regions (
+    zone_id, country, state
+)
+VALUES (
+    $1, $2, $3
+)
+RETURNING *;
+
+-- name: ListShippingZoneRegions :many
+SELECT * FROM shipping_zone_regions
+WHERE zone_id = $1
+ORDER BY country, state;
+
+-- name: DeleteShippingZoneRegions :exec
+DELETE FROM shipping_zone_regions
+WHERE zone_id = $1;
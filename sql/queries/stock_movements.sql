@@ -0,0 +1,43 @@
+-- name: CreateStockMovement :one
+INSERT INTO stock_movements (
+    product_id,
+    warehouse_id,
+    delta,
+    reserved_delta,
+    stock_after,
+    reserved_after,
+    reason,
+    order_id,
+    return_id,
+    created_by,
+    note
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING *;
+
+-- name: ListStockMovements :many
+SELECT * FROM stock_movements
+WHERE product_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountStockMovements :one
+SELECT COUNT(*) FROM stock_movements
+WHERE product_id = $1;
+
+-- name: ListStockLedgerMismatches :many
+-- Warehouse stock rows whose stock or reserved count differs from the sum
+-- of their movements.
+SELECT
+    ws.product_id,
+    ws.warehouse_id,
+    ws.stock,
+    ws.reserved,
+    COALESCE(SUM(m.delta), 0)::bigint AS ledger_stock,
+    COALESCE(SUM(m.reserved_delta), 0)::bigint AS ledger_reserved
+FROM warehouse_stock ws
+LEFT JOIN stock_movements m ON m.product_id = ws.product_id AND m.warehouse_id = ws.warehouse_id
+GROUP BY ws.product_id, ws.warehouse_id, ws.stock, ws.reserved
+HAVING ws.stock <> COALESCE(SUM(m.delta), 0)
+    OR ws.reserved <> COALESCE(SUM(m.reserved_delta), 0)
+ORDER BY ws.product_id, ws.warehouse_id;
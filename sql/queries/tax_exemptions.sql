@@ -0,0 +1,25 @@
+-- name: UpsertTaxExemption :one
+INSERT INTO tax_exemptions (
+    user_id, certificate_number, reason, expires_at, created_by
+)
+VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (user_id) DO UPDATE
+SET certificate_number = EXCLUDED.certificate_number,
+    reason = EXCLUDED.reason,
+    expires_at = EXCLUDED.expires_at,
+    created_by = EXCLUDED.created_by
+RETURNING *;
+
+-- name: GetTaxExemption :one
+SELECT * FROM tax_exemptions
+WHERE user_id = $1 LIMIT 1;
+
+-- name: ListTaxExemptions :many
+SELECT * FROM tax_exemptions
+ORDER BY created_at DESC;
+
+-- name: DeleteTaxExemption :exec
+DELETE FROM tax_exemptions
+WHERE user_id = $1;
@@ -0,0 +1,50 @@
+-- name: CreateTaxRate :one
+INSERT INTO tax_rates (
+    name, country, state, postal_code_prefix, tax_category, rate_bps, is_inclusive, is_active
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+RETURNING *;
+
+-- name: GetTaxRate :one
+SELECT * FROM tax_rates
+WHERE id = $1 LIMIT 1;
+
+-- name: ListTaxRates :many
+SELECT * FROM tax_rates
+ORDER BY country, state, postal_code_prefix, tax_category
+LIMIT $1 OFFSET $2;
+
+-- name: CountTaxRates :one
+SELECT COUNT(*) FROM tax_rates;
+
+-- name: ListApplicableTaxRates :many
+-- Active rates matching an address for the given categories, most specific
+-- first within each category: longest postal code prefix, then state-level
+-- over country-wide.
+SELECT * FROM tax_rates
+WHERE is_active = TRUE
+  AND country = sqlc.arg(country)
+  AND (state = '' OR state = sqlc.arg(state))
+  AND (postal_code_prefix = '' OR sqlc.arg(postal_code)::text LIKE postal_code_prefix || '%')
+  AND tax_category = ANY(sqlc.arg(tax_categories)::text[])
+ORDER BY tax_category, LENGTH(postal_code_prefix) DESC, LENGTH(state) DESC;
+
+-- name: UpdateTaxRate :one
+UPDATE tax_rates
+SET name = $2,
+    country = $3,
+    state = $4,
+    postal_code_prefix = $5,
+    tax_category = $6,
+    rate_bps = $7,
+    is_inclusive = $8,
+    is_active = $9,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteTaxRate :exec
+DELETE FROM tax_rates
+WHERE id = $1;
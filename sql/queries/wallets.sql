@@ -0,0 +1,69 @@
+-- name: EnsureWallet :exec
+-- Opens an empty wallet for the user unless one exists.
+INSERT INTO wallets (user_id, currency)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO NOTHING;
+
+-- name: GetWallet :one
+SELECT * FROM wallets
+WHERE user_id = $1;
+
+-- name: LockWallet :one
+SELECT * FROM wallets
+WHERE user_id = $1
+FOR UPDATE;
+
+-- name: UpdateWalletBalance :one
+UPDATE wallets
+SET balance_cents = $2,
+    updated_at = NOW()
+WHERE user_id = $1
+RETURNING *;
+
+-- name: CreateWalletTransaction :one
+INSERT INTO wallet_transactions (
+    user_id, kind, amount_cents, balance_after_cents, currency, order_id, refund_id, source_id, reason, expires_at, created_by
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+)
+RETURNING *;
+
+-- name: ListWalletTransactions :many
+-- A user's wallet history, newest first.
+SELECT * FROM wallet_transactions
+WHERE user_id = $1
+ORDER BY created_at DESC, id DESC
+LIMIT $2 OFFSET $3;
+
+-- name: CountWalletTransactions :one
+SELECT COUNT(*) FROM wallet_transactions
+WHERE user_id = $1;
+
+-- name: ListAllWalletTransactions :many
+-- A user's whole ledger in the order it was written, for replaying it.
+SELECT * FROM wallet_transactions
+WHERE user_id = $1
+ORDER BY created_at, id;
+
+-- name: GetOrderWalletDebit :one
+-- What an order took from the wallet and has not been given back yet.
+-- Card refunds paid out as credit (refund_id set) do not count.
+SELECT (
+    COALESCE(-SUM(amount_cents) FILTER (WHERE kind = 'DEBIT'), 0) -
+    COALESCE(SUM(amount_cents) FILTER (WHERE kind = 'REFUND' AND refund_id IS NULL), 0)
+)::bigint AS outstanding_cents
+FROM wallet_transactions
+WHERE order_id = $1;
+
+-- name: ListUsersWithExpiredCredits :many
+-- Users holding credits that expired before expired_before and have not
+-- been lapsed yet.
+SELECT DISTINCT c.user_id FROM wallet_transactions c
+WHERE c.kind = 'CREDIT'
+  AND c.expires_at < sqlc.arg(expired_before)
+  AND NOT EXISTS (
+      SELECT 1 FROM wallet_transactions e
+      WHERE e.kind = 'EXPIRY' AND e.source_id = c.id
+  )
+LIMIT sqlc.arg(row_limit)::int;
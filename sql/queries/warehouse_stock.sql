@@ -0,0 +1,98 @@
+-- name: EnsureWarehouseStock :exec
+-- Starts tracking a product at a warehouse with nothing in stock.
+INSERT INTO warehouse_stock (warehouse_id, product_id)
+VALUES ($1, $2)
+ON CONFLICT (warehouse_id, product_id) DO NOTHING;
+
+-- name: LockWarehouseStock :one
+-- Reads the product's stock at one warehouse and locks it until the
+-- transaction ends.
+SELECT * FROM warehouse_stock
+WHERE warehouse_id = $1 AND product_id = $2
+FOR UPDATE;
+
+-- name: LockProductWarehouseStock :many
+-- Locks the product's stock at every warehouse until the transaction ends,
+-- in warehouse order so concurrent allocations cannot deadlock. same_zone
+-- tells whether the warehouse's country shares a shipping zone with the
+-- destination country.
+SELECT
+    ws.warehouse_id,
+    ws.product_id,
+    ws.stock,
+    ws.reserved,
+    ws.updated_at,
+    w.code AS warehouse_code,
+    w.name AS warehouse_name,
+    w.country,
+    w.priority,
+    w.is_active,
+    EXISTS (
+        SELECT 1 FROM shipping_zone_regions a
+        JOIN shipping_zone_regions b ON b.zone_id = a.zone_id
+        WHERE a.country = w.country AND b.country = sqlc.arg(country)::text
+    ) AS same_zone
+FROM warehouse_stock ws
+JOIN warehouses w ON w.id = ws.warehouse_id
+WHERE ws.product_id = sqlc.arg(product_id)
+ORDER BY ws.warehouse_id
+FOR UPDATE OF ws;
+
+-- name: ListProductWarehouseStock :many
+SELECT
+    ws.warehouse_id,
+    ws.product_id,
+    ws.stock,
+    ws.reserved,
+    ws.updated_at,
+    w.code AS warehouse_code,
+    w.name AS warehouse_name
+FROM warehouse_stock ws
+JOIN warehouses w ON w.id = ws.warehouse_id
+WHERE ws.product_id = $1
+ORDER BY w.priority, w.code;
+
+-- name: MoveWarehouseStock :one
+-- Moves the product's stock and reserved units at a warehouse; the table's
+-- CHECK constraints reject moves that would leave stock below zero or below
+-- what is reserved.
+UPDATE warehouse_stock
+SET
+    stock = stock + sqlc.arg(delta)::int,
+    reserved = reserved + sqlc.arg(reserved_delta)::int,
+    updated_at = NOW()
+WHERE warehouse_id = sqlc.arg(warehouse_id) AND product_id = sqlc.arg(product_id)
+RETURNING *;
+
+-- name: DeleteProductWarehouseStock :exec
+DELETE FROM warehouse_stock
+WHERE product_id = $1;
+
+-- name: ListInventoryTotalMismatches :many
+-- Products whose inventory totals differ from the sum of their stock at
+-- each warehouse.
+SELECT
+    i.product_id,
+    i.stock,
+    i.reserved,
+    COALESCE(SUM(ws.stock), 0)::bigint AS warehouse_stock,
+    COALESCE(SUM(ws.reserved), 0)::bigint AS warehouse_reserved
+FROM inventory i
+LEFT JOIN warehouse_stock ws ON ws.product_id = i.product_id
+GROUP BY i.product_id, i.stock, i.reserved
+HAVING i.stock <> COALESCE(SUM(ws.stock), 0)
+    OR i.reserved <> COALESCE(SUM(ws.reserved), 0)
+ORDER BY i.product_id;
+
+-- name: ListStockLevels :many
+-- Every product's stock at every warehouse that tracks it, for export.
+SELECT
+    p.sku,
+    p.name,
+    w.code AS warehouse_code,
+    ws.stock,
+    ws.reserved
+FROM warehouse_stock ws
+JOIN products p ON p.id = ws.product_id
+JOIN warehouses w ON w.id = ws.warehouse_id
+ORDER BY p.sku, w.priority, w.code;
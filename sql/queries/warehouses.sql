@@ -0,0 +1,37 @@
+-- name: CreateWarehouse :one
+INSERT INTO warehouses (
+    code,
+    name,
+    country,
+    priority,
+    is_active
+) VALUES (
+    $1, $2, $3, $4, $5
+) RETURNING *;
+
+-- name: GetWarehouse :one
+SELECT * FROM warehouses
+WHERE id = $1;
+
+-- name: GetDefaultWarehouse :one
+-- The active warehouse allocated first, where stock goes when no
+-- warehouse is named.
+SELECT * FROM warehouses
+WHERE is_active = TRUE
+ORDER BY priority, code
+LIMIT 1;
+
+-- name: ListWarehouses :many
+SELECT * FROM warehouses
+ORDER BY priority, code;
+
+-- name: UpdateWarehouse :one
+UPDATE warehouses
+SET
+    name = $2,
+    country = $3,
+    priority = $4,
+    is_active = $5,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
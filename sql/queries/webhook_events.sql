@@ -0,0 +1,43 @@
+-- name: CreateWebhookEvent :one
+-- Returns no row when the event was already recorded.
+INSERT INTO webhook_events (
+    provider, event_id, event_type, provider_txn_id, order_id, payment_status, failure_reason, occurred_at, payload,
+    provider_refund_id, refund_status, refund_amount_cents, refund_reference, checkout_session_id
+)
+VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+)
+ON CONFLICT (provider, event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetWebhookEvent :one
+SELECT * FROM webhook_events
+WHERE id = $1;
+
+-- name: GetWebhookEventByEventID :one
+SELECT * FROM webhook_events
+WHERE provider = $1 AND event_id = $2;
+
+-- name: LockWebhookEvent :one
+SELECT * FROM webhook_events
+WHERE id = $1
+FOR UPDATE;
+
+-- name: ListWebhookEvents :many
+SELECT * FROM webhook_events
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text)
+ORDER BY created_at DESC, id
+LIMIT sqlc.arg(row_limit)::int OFFSET sqlc.arg(row_offset)::int;
+
+-- name: CountWebhookEvents :one
+SELECT COUNT(*) FROM webhook_events
+WHERE (sqlc.narg(status)::text IS NULL OR status = sqlc.narg(status)::text);
+
+-- name: UpdateWebhookEventStatus :one
+UPDATE webhook_events
+SET status = $2,
+    error = $3,
+    attempts = attempts + 1,
+    processed_at = NOW()
+WHERE id = $1
+RETURNING *;